@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/melkior/nodestatus/internal/sim"
 	"github.com/spf13/cobra"
@@ -14,7 +15,8 @@ import (
 )
 
 var (
-	logger *zap.Logger
+	logger        *zap.Logger
+	deterministic bool
 )
 
 func main() {
@@ -38,11 +40,17 @@ func run() error {
 		Long:  "A CLI tool for simulating node operations against the gRPC backend",
 	}
 
+	rootCmd.PersistentFlags().BoolVar(&deterministic, "deterministic", false,
+		"Derive all randomness from SIM_SEED and use a logical clock so seed/run are reproducible")
+
 	rootCmd.AddCommand(
 		seedCmd(),
 		runCmd(),
+		loadtestCmd(),
 		cleanupCmd(),
+		incidentCmd(),
 		statsCmd(),
+		histogramCmd(),
 	)
 
 	return rootCmd.Execute()
@@ -55,13 +63,19 @@ func seedCmd() *cobra.Command {
 		pctVM         float64
 		pctContainer  float64
 		labels        []string
+		statusWeights string
+		manifest      string
+		namesPool     string
+		uniqueNames   bool
+		labelSchema   string
+		shutdown      time.Duration
 	)
 
 	cmd := &cobra.Command{
 		Use:   "seed",
 		Short: "Create initial dataset of nodes",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := sim.LoadConfig()
+			cfg, err := loadConfig()
 			if err != nil {
 				return err
 			}
@@ -70,18 +84,38 @@ func seedCmd() *cobra.Command {
 				return fmt.Errorf("percentages must sum to 1.0 (got %.2f)", pctBaremetal+pctVM+pctContainer)
 			}
 
+			weights, err := parseStatusWeights(statusWeights, cfg)
+			if err != nil {
+				return err
+			}
+
+			var schema *sim.LabelSchema
+			if labelSchema != "" {
+				schema, err = sim.LoadLabelSchema(labelSchema)
+				if err != nil {
+					return err
+				}
+			}
+
 			seeder := sim.NewSeeder(cfg, logger)
 
 			ctx, cancel := setupSignalHandler()
 			defer cancel()
 
-			return seeder.Seed(ctx, sim.SeedOptions{
-				Total:        total,
-				PctBaremetal: pctBaremetal,
-				PctVM:        pctVM,
-				PctContainer: pctContainer,
-				Labels:       labels,
+			_, err = seeder.Seed(ctx, sim.SeedOptions{
+				Total:           total,
+				PctBaremetal:    pctBaremetal,
+				PctVM:           pctVM,
+				PctContainer:    pctContainer,
+				Labels:          labels,
+				StatusWeights:   weights,
+				Manifest:        manifest,
+				NamesPool:       namesPool,
+				UniqueNames:     uniqueNames,
+				LabelSchema:     schema,
+				ShutdownTimeout: shutdown,
 			})
+			return err
 		},
 	}
 
@@ -90,6 +124,12 @@ func seedCmd() *cobra.Command {
 	cmd.Flags().Float64Var(&pctVM, "pct-vm", 0.50, "Percentage of VM nodes")
 	cmd.Flags().Float64Var(&pctContainer, "pct-container", 0.40, "Percentage of container nodes")
 	cmd.Flags().StringSliceVar(&labels, "labels", []string{}, "Additional labels (key=value)")
+	cmd.Flags().StringVar(&statusWeights, "status-weights", "", "Initial status mix, e.g. up=0.7,degraded=0.1,down=0.1,unknown=0.1")
+	cmd.Flags().StringVar(&manifest, "manifest", "", "Record every created node (id, name, type) in this file as it is created")
+	cmd.Flags().StringVar(&namesPool, "names-pool", "", "Path to file with candidate names")
+	cmd.Flags().BoolVar(&uniqueNames, "unique-names", false, "Use each --names-pool name at most once, failing when the pool runs out")
+	cmd.Flags().StringVar(&labelSchema, "label-schema", "", "YAML/JSON file describing the labels to generate, replacing env/datacenter/service")
+	cmd.Flags().DurationVar(&shutdown, "shutdown-timeout", sim.DefaultShutdownTimeout, "On interrupt, how long to wait for in-flight creates before exiting")
 
 	return cmd
 }
@@ -98,6 +138,9 @@ func runCmd() *cobra.Command {
 	var (
 		duration              string
 		updateQPS             float64
+		ramp                  time.Duration
+		metricsAddr           string
+		qpsAlertThreshold     float64
 		maxConcurrency        int
 		probStatusFlip        float64
 		probLabelChange       float64
@@ -106,13 +149,47 @@ func runCmd() *cobra.Command {
 		jitter                bool
 		batchSize             int
 		namesPool             string
+		uniqueNames           bool
+		statusWeights         string
+		opTypeWeights         string
+		scenarioFile          string
+		shutdown              time.Duration
+		targetLabels          []string
+		force                 bool
+		verify                bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "run",
 		Short: "Start continuous simulation",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := sim.LoadConfig()
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			weights, err := parseStatusWeights(statusWeights, cfg)
+			if err != nil {
+				return err
+			}
+
+			var typeWeights *sim.TypeWeights
+			if opTypeWeights != "" {
+				typeWeights, err = sim.ParseTypeWeights(opTypeWeights)
+				if err != nil {
+					return err
+				}
+			}
+
+			var scenario *sim.Scenario
+			if scenarioFile != "" {
+				scenario, err = sim.LoadScenario(scenarioFile)
+				if err != nil {
+					return err
+				}
+			}
+
+			target, err := sim.ParseLabelSelector(targetLabels)
 			if err != nil {
 				return err
 			}
@@ -125,6 +202,9 @@ func runCmd() *cobra.Command {
 			return runner.Run(ctx, sim.RunOptions{
 				Duration:              duration,
 				UpdateQPS:             updateQPS,
+				Ramp:                  ramp,
+				MetricsAddr:           metricsAddr,
+				QPSAlertThreshold:     qpsAlertThreshold,
 				MaxConcurrency:        maxConcurrency,
 				ProbStatusFlip:        probStatusFlip,
 				ProbLabelChange:       probLabelChange,
@@ -133,58 +213,235 @@ func runCmd() *cobra.Command {
 				Jitter:                jitter,
 				BatchSize:             batchSize,
 				NamesPool:             namesPool,
+				UniqueNames:           uniqueNames,
+				StatusWeights:         weights,
+				TypeWeights:           typeWeights,
+				Scenario:              scenario,
+				ShutdownTimeout:       shutdown,
+				TargetLabels:          target,
+				Confirmed:             force,
+				Verify:                verify,
 			})
 		},
 	}
 
 	cmd.Flags().StringVar(&duration, "duration", "0", "Duration to run (e.g., 6h, 0=infinite)")
 	cmd.Flags().Float64Var(&updateQPS, "update-qps", 15.0, "Approximate updates per second")
+	cmd.Flags().DurationVar(&ramp, "ramp", 0, "Linearly ramp from near zero to --update-qps over this window (e.g. 2m)")
+	cmd.Flags().Float64Var(&qpsAlertThreshold, "qps-alert-threshold", 0.5, "Warn when achieved QPS drops below this fraction of the target (0 disables)")
+	cmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Serve run stats as Prometheus metrics on this address (e.g. :9100)")
 	cmd.Flags().IntVar(&maxConcurrency, "max-concurrency", 32, "Maximum concurrent goroutines")
 	cmd.Flags().Float64Var(&probStatusFlip, "prob-status-flip", 0.25, "Probability of status change")
 	cmd.Flags().Float64Var(&probLabelChange, "prob-label-change", 0.15, "Probability of label change")
 	cmd.Flags().Float64Var(&probMetadataChange, "prob-metadata-change", 0.20, "Probability of metadata change")
 	cmd.Flags().Float64Var(&probDeleteAndRecreate, "prob-delete-and-recreate", 0.02, "Probability of delete and recreate")
 	cmd.Flags().BoolVar(&jitter, "jitter", true, "Add ±20% jitter to sleep intervals")
-	cmd.Flags().IntVar(&batchSize, "batch-size", 50, "Number of nodes per update tick")
+	cmd.Flags().IntVar(&batchSize, "batch-size", 0, "Most operations started per one-second tick; 0 lets --update-qps alone set the rate")
 	cmd.Flags().StringVar(&namesPool, "names-pool", "", "Path to file with candidate names")
+	cmd.Flags().BoolVar(&uniqueNames, "unique-names", false, "Use each --names-pool name at most once; recreates fail once the pool runs out")
+	cmd.Flags().StringVar(&statusWeights, "status-weights", "", "Status flip targets, e.g. down=0.4,up=0.4,degraded=0.1,unknown=0.1")
+	cmd.Flags().StringVar(&opTypeWeights, "op-type-weights", "", "Share of operations per node type, e.g. container=0.8,vm=0.2 (default: every node equally)")
+	cmd.Flags().StringVar(&scenarioFile, "scenario", "", "YAML/JSON file of timed phases overriding these flags")
+	cmd.Flags().DurationVar(&shutdown, "shutdown-timeout", sim.DefaultShutdownTimeout, "On interrupt, how long to wait for in-flight operations before exiting")
+	cmd.Flags().StringSliceVar(&targetLabels, "target-all-labels", []string{}, "Operate on every node with this label (key=value, repeatable), not only simulator nodes; never deletes them")
+	cmd.Flags().BoolVar(&force, "force", false, "Skip the --target-all-labels confirmation prompt")
+	cmd.Flags().BoolVar(&verify, "verify", false, "Read back every status flip and delete to check it took effect (doubles their RPCs)")
+
+	return cmd
+}
+
+func loadtestCmd() *cobra.Command {
+	var (
+		startQPS        float64
+		stepQPS         float64
+		steps           int
+		stepDuration    time.Duration
+		saturationRatio float64
+		maxErrorRate    float64
+		maxConcurrency  int
+		probStatusFlip  float64
+		probLabelChange float64
+		statusWeights   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "loadtest",
+		Short: "Run the workload at increasing QPS steps and report the saturation point",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			weights, err := parseStatusWeights(statusWeights, cfg)
+			if err != nil {
+				return err
+			}
+
+			tester := sim.NewLoadTester(cfg, logger)
+
+			ctx, cancel := setupSignalHandler()
+			defer cancel()
+
+			_, err = tester.Run(ctx, sim.LoadTestOptions{
+				Run: sim.RunOptions{
+					MaxConcurrency:  maxConcurrency,
+					ProbStatusFlip:  probStatusFlip,
+					ProbLabelChange: probLabelChange,
+					StatusWeights:   weights,
+				},
+				StartQPS:        startQPS,
+				StepQPS:         stepQPS,
+				Steps:           steps,
+				StepDuration:    stepDuration,
+				SaturationRatio: saturationRatio,
+				MaxErrorRate:    maxErrorRate,
+			})
+			return err
+		},
+	}
+
+	cmd.Flags().Float64Var(&startQPS, "start-qps", 10, "Target QPS of the first step")
+	cmd.Flags().Float64Var(&stepQPS, "step-qps", 10, "QPS added at each following step")
+	cmd.Flags().IntVar(&steps, "steps", 5, "Number of steps")
+	cmd.Flags().DurationVar(&stepDuration, "step-duration", 30*time.Second, "Duration of each step")
+	cmd.Flags().Float64Var(&saturationRatio, "saturation-ratio", 0.9, "A step is saturated below this fraction of its target QPS")
+	cmd.Flags().Float64Var(&maxErrorRate, "max-error-rate", 0.05, "A step is saturated above this error rate")
+	cmd.Flags().IntVar(&maxConcurrency, "max-concurrency", 32, "Maximum concurrent goroutines")
+	cmd.Flags().Float64Var(&probStatusFlip, "prob-status-flip", 0.7, "Probability of status change")
+	cmd.Flags().Float64Var(&probLabelChange, "prob-label-change", 0.3, "Probability of label change")
+	cmd.Flags().StringVar(&statusWeights, "status-weights", "", "Status flip targets, e.g. down=0.4,up=0.4,degraded=0.1,unknown=0.1")
 
 	return cmd
 }
 
 func cleanupCmd() *cobra.Command {
-	var force bool
+	var (
+		force    bool
+		dryRun   bool
+		labels   []string
+		manifest string
+		shutdown time.Duration
+	)
 
 	cmd := &cobra.Command{
 		Use:   "cleanup",
 		Short: "Remove all nodes created by the simulator",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := sim.LoadConfig()
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			selector, err := sim.ParseLabelSelector(labels)
 			if err != nil {
 				return err
 			}
+			if manifest != "" && len(selector) > 0 {
+				return fmt.Errorf("--label cannot be combined with --manifest")
+			}
 
 			cleaner := sim.NewCleaner(cfg, logger)
 
 			ctx, cancel := setupSignalHandler()
 			defer cancel()
 
-			return cleaner.Cleanup(ctx, force)
+			return cleaner.Cleanup(ctx, sim.CleanupOptions{
+				Force:           force,
+				DryRun:          dryRun,
+				Selector:        selector,
+				Manifest:        manifest,
+				ShutdownTimeout: shutdown,
+			})
 		},
 	}
 
 	cmd.Flags().BoolVar(&force, "force", false, "Skip confirmation prompt")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the nodes that would be deleted and exit")
+	cmd.Flags().StringSliceVar(&labels, "label", []string{}, "Only delete simulator nodes with this label (key=value, repeatable)")
+	cmd.Flags().StringVar(&manifest, "manifest", "", "Delete exactly the nodes listed in a seed manifest")
+	cmd.Flags().DurationVar(&shutdown, "shutdown-timeout", sim.DefaultShutdownTimeout, "On interrupt, how long to wait for in-flight deletes before exiting")
+
+	return cmd
+}
+
+func incidentCmd() *cobra.Command {
+	var (
+		pct           float64
+		hold          time.Duration
+		recoverWindow time.Duration
+		statusWeights string
+		labels        []string
+		shutdown      time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "incident",
+		Short: "Take a share of simulator nodes down, hold, then restore them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			selector, err := sim.ParseLabelSelector(labels)
+			if err != nil {
+				return err
+			}
+
+			var weights *sim.StatusWeights
+			if statusWeights != "" {
+				weights, err = sim.ParseStatusWeights(statusWeights)
+				if err != nil {
+					return err
+				}
+			}
+
+			incident := sim.NewIncident(cfg, logger)
+
+			ctx, cancel := setupSignalHandler()
+			defer cancel()
+
+			_, err = incident.Run(ctx, sim.IncidentOptions{
+				Pct:             pct,
+				Hold:            hold,
+				Recover:         recoverWindow,
+				StatusWeights:   weights,
+				Selector:        selector,
+				ShutdownTimeout: shutdown,
+			})
+			return err
+		},
+	}
+
+	cmd.Flags().Float64Var(&pct, "pct", 0.3, "Share of simulator nodes to affect, in (0, 1]")
+	cmd.Flags().DurationVar(&hold, "hold", 2*time.Minute, "How long affected nodes stay down")
+	cmd.Flags().DurationVar(&recoverWindow, "recover", time.Minute, "Spread the restores evenly over this window; 0 restores all at once")
+	cmd.Flags().StringVar(&statusWeights, "status-weights", "", "Incident statuses, e.g. down=0.5,degraded=0.5 (default down=0.7,degraded=0.3)")
+	cmd.Flags().StringSliceVar(&labels, "label", []string{}, "Only affect simulator nodes with this label (key=value, repeatable)")
+	cmd.Flags().DurationVar(&shutdown, "shutdown-timeout", sim.DefaultShutdownTimeout, "On interrupt, how long to keep restoring nodes before exiting")
 
 	return cmd
 }
 
 func statsCmd() *cobra.Command {
-	var jsonOutput bool
+	var (
+		jsonOutput bool
+		pageSize   int
+		watch      bool
+		interval   time.Duration
+		out        string
+	)
 
 	cmd := &cobra.Command{
 		Use:   "stats",
 		Short: "Print current counts by type & status",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := sim.LoadConfig()
+			if out != "" && !watch {
+				return fmt.Errorf("--out requires --watch")
+			}
+
+			cfg, err := loadConfig()
 			if err != nil {
 				return err
 			}
@@ -194,15 +451,90 @@ func statsCmd() *cobra.Command {
 			ctx, cancel := setupSignalHandler()
 			defer cancel()
 
-			return stats.Print(ctx, jsonOutput)
+			return stats.Print(ctx, sim.StatsOptions{
+				JSON:     jsonOutput,
+				PageSize: pageSize,
+				Watch:    watch,
+				Interval: interval,
+				Out:      out,
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
+	cmd.Flags().IntVar(&pageSize, "page-size", 500, "Nodes fetched per ListNodes page")
+	cmd.Flags().BoolVar(&watch, "watch", false, "Keep counts live from WatchEvents and redraw on changes")
+	cmd.Flags().DurationVar(&interval, "interval", time.Second, "Minimum time between redraws in watch mode, or between rows with --out")
+	cmd.Flags().StringVar(&out, "out", "", "With --watch, append a row of counts every interval to this file (CSV, or JSON lines for .jsonl)")
+
+	return cmd
+}
+
+func histogramCmd() *cobra.Command {
+	var (
+		field      string
+		buckets    []float64
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "histogram",
+		Short: "Print the distribution of a numeric metadata field",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			histogram := sim.NewHistogram(cfg, logger)
+
+			ctx, cancel := setupSignalHandler()
+			defer cancel()
+
+			return histogram.Print(ctx, sim.HistogramOptions{
+				Field:   field,
+				Buckets: buckets,
+				JSON:    jsonOutput,
+			})
 		},
 	}
 
+	cmd.Flags().StringVar(&field, "field", "ram_gb", "Numeric metadata field to bucket")
+	cmd.Flags().Float64SliceVar(&buckets, "buckets", []float64{8, 16, 32, 64, 128, 256}, "Ascending bucket boundaries")
 	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
 
 	return cmd
 }
 
+func loadConfig() (*sim.Config, error) {
+	cfg, err := sim.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if deterministic {
+		if !cfg.SeedFromEnv {
+			return nil, fmt.Errorf("--deterministic requires an explicit SIM_SEED")
+		}
+		cfg.Deterministic = true
+	}
+
+	return cfg, nil
+}
+
+// parseStatusWeights reads --status-weights, falling back to SIM_STATUS_WEIGHTS.
+// It returns nil when neither is set so the command keeps its default mix.
+func parseStatusWeights(flagValue string, cfg *sim.Config) (*sim.StatusWeights, error) {
+	spec := flagValue
+	if spec == "" {
+		spec = cfg.StatusWeights
+	}
+	if spec == "" {
+		return nil, nil
+	}
+	return sim.ParseStatusWeights(spec)
+}
+
 func setupLogger() (*zap.Logger, error) {
 	config := zap.NewDevelopmentConfig()
 	config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
@@ -6,24 +6,50 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 var mutatingMethods = map[string]bool{
-	"/node.v1.NodeService/CreateNode":   true,
-	"/node.v1.NodeService/UpdateNode":   true,
-	"/node.v1.NodeService/UpdateStatus": true,
-	"/node.v1.NodeService/DeleteNode":   true,
+	"/node.v1.NodeService/CreateNode":        true,
+	"/node.v1.NodeService/UpdateNode":        true,
+	"/node.v1.NodeService/UpdateStatus":      true,
+	"/node.v1.NodeService/BatchUpdateStatus": true,
+	"/node.v1.NodeService/DeleteNode":        true,
+}
+
+// publicMethods never need a token, so probes that can't send one (Envoy,
+// Kubernetes gRPC probes) work even when reads require auth
+var publicMethods = map[string]bool{
+	healthpb.Health_Check_FullMethodName: true,
+	healthpb.Health_List_FullMethodName:  true,
+	healthpb.Health_Watch_FullMethodName: true,
+}
+
+// Options controls how read methods are authorized. The zero value keeps
+// reads open to everyone.
+type Options struct {
+	// RequireAuthForReads rejects read methods without a valid token
+	RequireAuthForReads bool
+	// ReaderToken is accepted for read methods in addition to the admin
+	// token. It never grants access to mutating methods.
+	ReaderToken string
 }
 
 func UnaryAuthInterceptor(adminToken string) grpc.UnaryServerInterceptor {
-	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		if !mutatingMethods[info.FullMethod] {
-			return handler(ctx, req)
-		}
+	return UnaryAuthInterceptorWithOptions(adminToken, Options{})
+}
 
-		if err := validateToken(ctx, adminToken); err != nil {
+func StreamAuthInterceptor(adminToken string) grpc.StreamServerInterceptor {
+	return StreamAuthInterceptorWithOptions(adminToken, Options{})
+}
+
+// UnaryAuthInterceptorWithOptions is UnaryAuthInterceptor with a configurable
+// policy for read methods
+func UnaryAuthInterceptorWithOptions(adminToken string, opts Options) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := authorize(ctx, info.FullMethod, adminToken, opts); err != nil {
 			return nil, err
 		}
 
@@ -31,13 +57,11 @@ func UnaryAuthInterceptor(adminToken string) grpc.UnaryServerInterceptor {
 	}
 }
 
-func StreamAuthInterceptor(adminToken string) grpc.StreamServerInterceptor {
+// StreamAuthInterceptorWithOptions is StreamAuthInterceptor with a
+// configurable policy for read methods
+func StreamAuthInterceptorWithOptions(adminToken string, opts Options) grpc.StreamServerInterceptor {
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		if !mutatingMethods[info.FullMethod] {
-			return handler(srv, ss)
-		}
-
-		if err := validateToken(ss.Context(), adminToken); err != nil {
+		if err := authorize(ss.Context(), info.FullMethod, adminToken, opts); err != nil {
 			return err
 		}
 
@@ -45,7 +69,27 @@ func StreamAuthInterceptor(adminToken string) grpc.StreamServerInterceptor {
 	}
 }
 
-func validateToken(ctx context.Context, expectedToken string) error {
+// authorize checks the caller's token against the policy for method
+func authorize(ctx context.Context, method, adminToken string, opts Options) error {
+	if mutatingMethods[method] {
+		return validateToken(ctx, adminToken)
+	}
+
+	if publicMethods[method] {
+		return nil
+	}
+
+	if !opts.RequireAuthForReads {
+		return nil
+	}
+
+	if opts.ReaderToken == "" {
+		return validateToken(ctx, adminToken)
+	}
+	return validateToken(ctx, adminToken, opts.ReaderToken)
+}
+
+func validateToken(ctx context.Context, expectedTokens ...string) error {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		return status.Errorf(codes.Unauthenticated, "missing metadata")
@@ -62,9 +106,32 @@ func validateToken(ctx context.Context, expectedToken string) error {
 	}
 
 	token := strings.TrimPrefix(authHeader, "Bearer ")
-	if token != expectedToken {
-		return status.Errorf(codes.PermissionDenied, "invalid token")
+	for _, expected := range expectedTokens {
+		if token == expected {
+			return nil
+		}
 	}
 
-	return nil
+	return status.Errorf(codes.PermissionDenied, "invalid token")
+}
+
+// Token names reported by TokenName
+const (
+	TokenAdmin     = "admin"
+	TokenReader    = "reader"
+	TokenAnonymous = "anonymous"
+)
+
+// TokenName names the configured token the caller presented: TokenAdmin,
+// TokenReader, or TokenAnonymous for a missing or unknown token. It is meant
+// for keying per-caller policies such as rate limits, not for authorization.
+func TokenName(ctx context.Context, adminToken string, opts Options) string {
+	switch {
+	case adminToken != "" && validateToken(ctx, adminToken) == nil:
+		return TokenAdmin
+	case opts.ReaderToken != "" && validateToken(ctx, opts.ReaderToken) == nil:
+		return TokenReader
+	default:
+		return TokenAnonymous
+	}
 }
\ No newline at end of file
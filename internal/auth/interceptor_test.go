@@ -146,4 +146,179 @@ func TestValidateToken(t *testing.T) {
 			}
 		})
 	}
+}
+
+func TestUnaryAuthInterceptorRequireReadAuth(t *testing.T) {
+	interceptor := UnaryAuthInterceptorWithOptions("admin-token", Options{
+		RequireAuthForReads: true,
+		ReaderToken:         "reader-token",
+	})
+
+	tests := []struct {
+		name      string
+		method    string
+		metadata  metadata.MD
+		wantError bool
+		wantCode  codes.Code
+	}{
+		{
+			name:      "read without metadata is rejected",
+			method:    "/node.v1.NodeService/GetNode",
+			metadata:  nil,
+			wantError: true,
+			wantCode:  codes.Unauthenticated,
+		},
+		{
+			name:      "read without authorization header is rejected",
+			method:    "/node.v1.NodeService/ListNodes",
+			metadata:  metadata.Pairs(),
+			wantError: true,
+			wantCode:  codes.Unauthenticated,
+		},
+		{
+			name:      "read with wrong token is rejected",
+			method:    "/node.v1.NodeService/GetNode",
+			metadata:  metadata.Pairs("authorization", "Bearer wrong-token"),
+			wantError: true,
+			wantCode:  codes.PermissionDenied,
+		},
+		{
+			name:      "read with reader token passes",
+			method:    "/node.v1.NodeService/GetNode",
+			metadata:  metadata.Pairs("authorization", "Bearer reader-token"),
+			wantError: false,
+		},
+		{
+			name:      "read with admin token passes",
+			method:    "/node.v1.NodeService/ListNodes",
+			metadata:  metadata.Pairs("authorization", "Bearer admin-token"),
+			wantError: false,
+		},
+		{
+			name:      "mutation with reader token is rejected",
+			method:    "/node.v1.NodeService/DeleteNode",
+			metadata:  metadata.Pairs("authorization", "Bearer reader-token"),
+			wantError: true,
+			wantCode:  codes.PermissionDenied,
+		},
+		{
+			name:      "mutation with admin token passes",
+			method:    "/node.v1.NodeService/DeleteNode",
+			metadata:  metadata.Pairs("authorization", "Bearer admin-token"),
+			wantError: false,
+		},
+		{
+			name:      "health check without metadata passes",
+			method:    "/grpc.health.v1.Health/Check",
+			metadata:  nil,
+			wantError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.metadata != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.metadata)
+			}
+
+			info := &grpc.UnaryServerInfo{
+				FullMethod: tt.method,
+			}
+
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return "ok", nil
+			}
+
+			result, err := interceptor(ctx, nil, info, handler)
+
+			if tt.wantError {
+				assert.Error(t, err)
+				st, ok := status.FromError(err)
+				assert.True(t, ok)
+				assert.Equal(t, tt.wantCode, st.Code())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "ok", result)
+			}
+		})
+	}
+}
+
+func TestUnaryAuthInterceptorRequireReadAuthWithoutReaderToken(t *testing.T) {
+	interceptor := UnaryAuthInterceptorWithOptions("admin-token", Options{RequireAuthForReads: true})
+	info := &grpc.UnaryServerInfo{FullMethod: "/node.v1.NodeService/GetNode"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	// An empty bearer must not match the unset reader token
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "))
+	_, err := interceptor(ctx, nil, info, handler)
+	st, _ := status.FromError(err)
+	assert.Equal(t, codes.PermissionDenied, st.Code())
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer admin-token"))
+	result, err := interceptor(ctx, nil, info, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", result)
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamAuthInterceptorRequireReadAuth(t *testing.T) {
+	info := &grpc.StreamServerInfo{FullMethod: "/node.v1.NodeService/WatchEvents", IsServerStream: true}
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		return nil
+	}
+
+	// Reads stay open by default
+	open := StreamAuthInterceptor("admin-token")
+	assert.NoError(t, open(nil, &fakeServerStream{ctx: context.Background()}, info, handler))
+
+	locked := StreamAuthInterceptorWithOptions("admin-token", Options{
+		RequireAuthForReads: true,
+		ReaderToken:         "reader-token",
+	})
+
+	err := locked(nil, &fakeServerStream{ctx: context.Background()}, info, handler)
+	st, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.Unauthenticated, st.Code())
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer reader-token"))
+	assert.NoError(t, locked(nil, &fakeServerStream{ctx: ctx}, info, handler))
+}
+
+func TestTokenName(t *testing.T) {
+	opts := Options{ReaderToken: "reader-token"}
+
+	tests := []struct {
+		name     string
+		metadata metadata.MD
+		want     string
+	}{
+		{"admin token", metadata.Pairs("authorization", "Bearer admin-token"), TokenAdmin},
+		{"reader token", metadata.Pairs("authorization", "Bearer reader-token"), TokenReader},
+		{"unknown token", metadata.Pairs("authorization", "Bearer other"), TokenAnonymous},
+		{"empty token", metadata.Pairs("authorization", "Bearer "), TokenAnonymous},
+		{"no metadata", nil, TokenAnonymous},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.metadata != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.metadata)
+			}
+			assert.Equal(t, tt.want, TokenName(ctx, "admin-token", opts))
+		})
+	}
 }
\ No newline at end of file
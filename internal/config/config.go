@@ -3,6 +3,11 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
 )
 
 type Config struct {
@@ -13,18 +18,94 @@ type Config struct {
 	HTTPAddr      string
 	AdminToken    string
 	LogLevel      string
+
+	// RedisClusterAddrs selects Redis Cluster through these seed nodes
+	RedisClusterAddrs []string
+	// RedisSentinelMaster selects Sentinel failover for this master name,
+	// discovered through RedisSentinelAddrs
+	RedisSentinelMaster string
+	RedisSentinelAddrs  []string
+
+	// SoftDeleteGrace keeps deleted nodes restorable for this long; zero
+	// deletes them outright
+	SoftDeleteGrace time.Duration
+
+	// MaxMetadataBytes limits the size of a node's metadata_json; zero
+	// removes the limit
+	MaxMetadataBytes int
+
+	// WatchHeartbeatInterval is how long a WatchEvents stream may stay
+	// silent before a heartbeat is sent; zero disables heartbeats
+	WatchHeartbeatInterval time.Duration
+	// WatchSlowTimeout ends a WatchEvents stream whose client hasn't read
+	// an event for this long; zero never ends it
+	WatchSlowTimeout time.Duration
+
+	// RequireAuthForReads makes read methods require a token too
+	RequireAuthForReads bool
+	// ReaderToken is an optional token that only grants read access
+	ReaderToken string
+	// RateLimits caps requests per second by token name (admin, reader or
+	// anonymous); names without an entry are not limited
+	RateLimits map[string]RateLimit
+
+	// StaleAfter marks nodes without a LastSeen update for this long as
+	// StaleStatus; zero disables the reaper
+	StaleAfter         time.Duration
+	StaleCheckInterval time.Duration
+	StaleStatus        nodev1.NodeStatus
+	// StaleExcludeLabels exempts nodes with any of these labels, by default
+	// the simulator's demo nodes
+	StaleExcludeLabels map[string]string
 }
 
+// RateLimit is a sustained rate with a burst allowance. A zero QPS means no
+// limit, and a zero Burst allows one second's worth of QPS.
+type RateLimit struct {
+	QPS   float64
+	Burst int
+}
+
+// envKeys lists every environment variable Load reads, which are also the
+// keys allowed in a config file
+var envKeys = []string{
+	"REDIS_ADDR", "REDIS_DB", "REDIS_PASSWORD", "REDIS_CLUSTER_ADDRS",
+	"REDIS_SENTINEL_MASTER", "REDIS_SENTINEL_ADDRS", "GRPC_ADDR", "HTTP_ADDR", "PORT",
+	"ADMIN_TOKEN", "LOG_LEVEL", "REQUIRE_READ_AUTH", "READER_TOKEN", "RATE_LIMITS",
+	"STALE_AFTER", "STALE_CHECK_INTERVAL", "STALE_STATUS", "STALE_EXCLUDE_LABELS",
+	"SOFT_DELETE_GRACE", "MAX_METADATA_BYTES", "WATCH_HEARTBEAT_INTERVAL",
+	"WATCH_SLOW_TIMEOUT",
+}
+
+// Load reads the configuration from the environment, or from the file named by
+// CONFIG_FILE with environment variables taking precedence.
 func Load() (*Config, error) {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		return LoadFromFile(path)
+	}
+	return load(Source{})
+}
+
+// LoadFromFile reads the configuration from a YAML file keyed by lowercase
+// environment variable names. Environment variables override the file.
+func LoadFromFile(path string) (*Config, error) {
+	src, err := NewFileSource(path, envKeys)
+	if err != nil {
+		return nil, err
+	}
+	return load(src)
+}
+
+func load(src Source) (*Config, error) {
 	cfg := &Config{
-		RedisAddr:  getEnvOrDefault("REDIS_ADDR", "localhost:6379"),
-		RedisDB:    0,
-		HTTPAddr:   getHTTPAddr(),
-		GRPCAddr:   getEnvOrDefault("GRPC_ADDR", ":50051"),
-		LogLevel:   getEnvOrDefault("LOG_LEVEL", "info"),
+		RedisAddr: src.GetOrDefault("REDIS_ADDR", "localhost:6379"),
+		RedisDB:   0,
+		HTTPAddr:  getHTTPAddr(src),
+		GRPCAddr:  src.GetOrDefault("GRPC_ADDR", ":50051"),
+		LogLevel:  src.GetOrDefault("LOG_LEVEL", "info"),
 	}
 
-	redisDB := os.Getenv("REDIS_DB")
+	redisDB := src.Get("REDIS_DB")
 	if redisDB != "" {
 		var db int
 		if _, err := fmt.Sscanf(redisDB, "%d", &db); err == nil {
@@ -32,28 +113,177 @@ func Load() (*Config, error) {
 		}
 	}
 
-	cfg.RedisPassword = os.Getenv("REDIS_PASSWORD")
+	cfg.RedisPassword = src.Get("REDIS_PASSWORD")
+	cfg.RedisClusterAddrs = splitList(src.Get("REDIS_CLUSTER_ADDRS"))
+	cfg.RedisSentinelMaster = src.Get("REDIS_SENTINEL_MASTER")
+	cfg.RedisSentinelAddrs = splitList(src.Get("REDIS_SENTINEL_ADDRS"))
+
+	cfg.AdminToken = src.Get("ADMIN_TOKEN")
+
+	if requireReadAuth := src.Get("REQUIRE_READ_AUTH"); requireReadAuth != "" {
+		v, err := strconv.ParseBool(requireReadAuth)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REQUIRE_READ_AUTH value %q: %w", requireReadAuth, err)
+		}
+		cfg.RequireAuthForReads = v
+	}
+	cfg.ReaderToken = src.Get("READER_TOKEN")
+
+	rateLimits, err := parseRateLimits(src.Get("RATE_LIMITS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMITS value: %w", err)
+	}
+	cfg.RateLimits = rateLimits
+
+	if value := src.Get("SOFT_DELETE_GRACE"); value != "" {
+		v, err := time.ParseDuration(value)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid SOFT_DELETE_GRACE value %q", value)
+		}
+		cfg.SoftDeleteGrace = v
+	}
+
+	cfg.MaxMetadataBytes = 64 << 10
+	if value := src.Get("MAX_METADATA_BYTES"); value != "" {
+		v, err := strconv.Atoi(value)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid MAX_METADATA_BYTES value %q", value)
+		}
+		cfg.MaxMetadataBytes = v
+	}
+
+	cfg.WatchHeartbeatInterval = 30 * time.Second
+	if value := src.Get("WATCH_HEARTBEAT_INTERVAL"); value != "" {
+		v, err := time.ParseDuration(value)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid WATCH_HEARTBEAT_INTERVAL value %q", value)
+		}
+		cfg.WatchHeartbeatInterval = v
+	}
+
+	cfg.WatchSlowTimeout = time.Minute
+	if value := src.Get("WATCH_SLOW_TIMEOUT"); value != "" {
+		v, err := time.ParseDuration(value)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid WATCH_SLOW_TIMEOUT value %q", value)
+		}
+		cfg.WatchSlowTimeout = v
+	}
+
+	if err := loadStaleConfig(cfg, src); err != nil {
+		return nil, err
+	}
 
-	cfg.AdminToken = os.Getenv("ADMIN_TOKEN")
-	if cfg.AdminToken == "" {
-		return nil, fmt.Errorf("ADMIN_TOKEN environment variable is required")
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 
 	return cfg, nil
 }
 
-func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+func loadStaleConfig(cfg *Config, src Source) error {
+	cfg.StaleCheckInterval = 30 * time.Second
+	cfg.StaleStatus = nodev1.NodeStatus_UNKNOWN
+	cfg.StaleExcludeLabels = map[string]string{"demo": "true"}
+
+	for _, d := range []struct {
+		env string
+		dst *time.Duration
+	}{
+		{"STALE_AFTER", &cfg.StaleAfter},
+		{"STALE_CHECK_INTERVAL", &cfg.StaleCheckInterval},
+	} {
+		value := src.Get(d.env)
+		if value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(value)
+		if err != nil || v < 0 {
+			return fmt.Errorf("invalid %s value %q", d.env, value)
+		}
+		*d.dst = v
+	}
+
+	if value := src.Get("STALE_STATUS"); value != "" {
+		switch strings.ToUpper(value) {
+		case "UNKNOWN":
+			cfg.StaleStatus = nodev1.NodeStatus_UNKNOWN
+		case "DOWN":
+			cfg.StaleStatus = nodev1.NodeStatus_DOWN
+		default:
+			return fmt.Errorf("invalid STALE_STATUS value %q: must be UNKNOWN or DOWN", value)
+		}
+	}
+
+	// Set but empty means no exclusions
+	if value, ok := src.Lookup("STALE_EXCLUDE_LABELS"); ok {
+		cfg.StaleExcludeLabels = map[string]string{}
+		for _, pair := range strings.Split(value, ",") {
+			pair = strings.TrimSpace(pair)
+			if pair == "" {
+				continue
+			}
+			k, v, found := strings.Cut(pair, "=")
+			if !found || k == "" {
+				return fmt.Errorf("invalid STALE_EXCLUDE_LABELS entry %q: want key=value", pair)
+			}
+			cfg.StaleExcludeLabels[k] = v
+		}
+	}
+
+	return nil
+}
+
+// parseRateLimits reads a comma separated list of name=qps or
+// name=qps:burst, e.g. "admin=500,reader=100:200"
+func parseRateLimits(value string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, spec, found := strings.Cut(entry, "=")
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid rate limit %q: want name=qps[:burst]", entry)
+		}
+		qpsStr, burstStr, hasBurst := strings.Cut(spec, ":")
+
+		var limit RateLimit
+		qps, err := strconv.ParseFloat(qpsStr, 64)
+		if err != nil || qps < 0 {
+			return nil, fmt.Errorf("invalid rate limit %q: qps must be a non-negative number", entry)
+		}
+		limit.QPS = qps
+		if hasBurst {
+			burst, err := strconv.Atoi(burstStr)
+			if err != nil || burst < 1 {
+				return nil, fmt.Errorf("invalid rate limit %q: burst must be a positive integer", entry)
+			}
+			limit.Burst = burst
+		}
+		limits[name] = limit
+	}
+	return limits, nil
+}
+
+// splitList parses a comma separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
-	return defaultValue
+	return items
 }
 
-func getHTTPAddr() string {
+func getHTTPAddr(src Source) string {
 	// Check PORT env var first (common in cloud environments)
-	if port := os.Getenv("PORT"); port != "" {
+	if port := src.Get("PORT"); port != "" {
 		return ":" + port
 	}
 	// Fall back to HTTP_ADDR
-	return getEnvOrDefault("HTTP_ADDR", ":8080")
+	return src.GetOrDefault("HTTP_ADDR", ":8080")
 }
\ No newline at end of file
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRateLimits(t *testing.T) {
+	limits, err := parseRateLimits(" admin=500, reader=2.5:10 ,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]RateLimit{
+		"admin":  {QPS: 500},
+		"reader": {QPS: 2.5, Burst: 10},
+	}, limits)
+
+	limits, err = parseRateLimits("")
+	require.NoError(t, err)
+	assert.Empty(t, limits)
+
+	for _, bad := range []string{"admin", "=5", "admin=fast", "admin=-1", "admin=5:0", "admin=5:x"} {
+		_, err := parseRateLimits(bad)
+		assert.Error(t, err, bad)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Source resolves settings by environment variable name. The zero Source
+// reads the environment only; one from NewFileSource falls back to a file.
+type Source struct {
+	file map[string]string
+}
+
+// NewFileSource reads a YAML file of settings keyed by the lowercase names of
+// the environment variables in known, e.g. "redis_addr: localhost:6379".
+// Unknown keys are rejected so a typo doesn't silently fall back to a default.
+func NewFileSource(path string, known []string) (Source, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var values map[string]string
+	if err := yaml.NewDecoder(bytes.NewReader(raw)).Decode(&values); err != nil && !errors.Is(err, io.EOF) {
+		return Source{}, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	allowed := make(map[string]bool, len(known))
+	for _, key := range known {
+		allowed[key] = true
+	}
+
+	file := make(map[string]string, len(values))
+	var unknown []string
+	for key, value := range values {
+		envKey := strings.ToUpper(key)
+		if !allowed[envKey] {
+			unknown = append(unknown, key)
+			continue
+		}
+		file[envKey] = value
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return Source{}, fmt.Errorf("invalid config file %s: unknown keys %s", path, strings.Join(unknown, ", "))
+	}
+
+	return Source{file: file}, nil
+}
+
+// Lookup returns the value for key and whether it was set. A non-empty
+// environment variable wins over the file, so an exported but empty variable
+// doesn't blank out a file value.
+func (s Source) Lookup(key string) (string, bool) {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value, true
+	}
+	if value, ok := s.file[key]; ok {
+		return value, true
+	}
+	return os.LookupEnv(key)
+}
+
+// Get returns the value for key, or "" when it isn't set
+func (s Source) Get(key string) string {
+	value, _ := s.Lookup(key)
+	return value
+}
+
+// GetOrDefault returns the value for key, or defaultValue when it is empty
+func (s Source) GetOrDefault(key, defaultValue string) string {
+	if value := s.Get(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
\ No newline at end of file
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "dev.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadFromFile(t *testing.T) {
+	path := writeConfigFile(t, `
+admin_token: file-secret-token
+grpc_addr: ":6000"
+redis_db: 2
+require_read_auth: true
+stale_after: 5m
+stale_status: down
+stale_exclude_labels: ""
+soft_delete_grace: 10m
+max_metadata_bytes: 1024
+watch_heartbeat_interval: 0s
+rate_limits: "anonymous=5,reader=50:100"
+`)
+	t.Setenv("ADMIN_TOKEN", "")
+	t.Setenv("GRPC_ADDR", ":7000")
+
+	cfg, err := LoadFromFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "file-secret-token", cfg.AdminToken, "empty env var falls back to the file")
+	assert.Equal(t, ":7000", cfg.GRPCAddr, "env wins over the file")
+	assert.Equal(t, 2, cfg.RedisDB)
+	assert.True(t, cfg.RequireAuthForReads)
+	assert.Equal(t, 5*time.Minute, cfg.StaleAfter)
+	assert.Equal(t, nodev1.NodeStatus_DOWN, cfg.StaleStatus)
+	assert.Empty(t, cfg.StaleExcludeLabels)
+	assert.Equal(t, 10*time.Minute, cfg.SoftDeleteGrace)
+	assert.Equal(t, 1024, cfg.MaxMetadataBytes)
+	assert.Zero(t, cfg.WatchHeartbeatInterval)
+	assert.Equal(t, map[string]RateLimit{
+		"anonymous": {QPS: 5},
+		"reader":    {QPS: 50, Burst: 100},
+	}, cfg.RateLimits)
+	assert.Equal(t, "localhost:6379", cfg.RedisAddr, "unset keys keep their default")
+}
+
+func TestLoadRedisSentinel(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "dev-secret-token")
+	t.Setenv("REDIS_SENTINEL_MASTER", "mymaster")
+	t.Setenv("REDIS_SENTINEL_ADDRS", "sentinel-0:26379, sentinel-1:26379,")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "mymaster", cfg.RedisSentinelMaster)
+	assert.Equal(t, []string{"sentinel-0:26379", "sentinel-1:26379"}, cfg.RedisSentinelAddrs)
+	assert.Empty(t, cfg.RedisClusterAddrs)
+}
+
+func TestLoadUsesConfigFileEnv(t *testing.T) {
+	path := writeConfigFile(t, "admin_token: file-secret-token\n")
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("ADMIN_TOKEN", "")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "file-secret-token", cfg.AdminToken)
+}
+
+func TestLoadFromFileRejectsUnknownKeys(t *testing.T) {
+	path := writeConfigFile(t, "admin_token: file-secret-token\nredis_adr: localhost:6379\n")
+
+	_, err := LoadFromFile(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "redis_adr")
+}
\ No newline at end of file
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MinAdminTokenLength is the shortest ADMIN_TOKEN Validate accepts
+const MinAdminTokenLength = 8
+
+// rateLimitNames are the token names the server keys RATE_LIMITS by
+var rateLimitNames = map[string]bool{"admin": true, "reader": true, "anonymous": true}
+
+// FieldError is one invalid setting, named by its environment variable
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+// ValidationError lists every invalid setting found by Validate
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return "invalid configuration: " + strings.Join(msgs, "; ")
+}
+
+var validLogLevels = map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
+
+// Validate checks every setting and returns a *ValidationError listing all
+// problems, or nil when the configuration is usable
+func (c *Config) Validate() error {
+	var errs []*FieldError
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, &FieldError{Field: field, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	if err := validateHostPort(c.GRPCAddr); err != nil {
+		add("GRPC_ADDR", "%v", err)
+	}
+	if err := validateHostPort(c.HTTPAddr); err != nil {
+		add("HTTP_ADDR", "%v", err)
+	}
+	if c.RedisAddr == "" {
+		add("REDIS_ADDR", "is required")
+	}
+	if c.RedisDB < 0 {
+		add("REDIS_DB", "must not be negative, got %d", c.RedisDB)
+	}
+	for _, addr := range c.RedisClusterAddrs {
+		if err := validateHostPort(addr); err != nil {
+			add("REDIS_CLUSTER_ADDRS", "%v", err)
+		}
+	}
+	for _, addr := range c.RedisSentinelAddrs {
+		if err := validateHostPort(addr); err != nil {
+			add("REDIS_SENTINEL_ADDRS", "%v", err)
+		}
+	}
+	switch {
+	case len(c.RedisClusterAddrs) > 0 && c.RedisSentinelMaster != "":
+		add("REDIS_CLUSTER_ADDRS", "cannot be combined with REDIS_SENTINEL_MASTER")
+	case len(c.RedisClusterAddrs) > 0 && c.RedisDB != 0:
+		add("REDIS_DB", "must be 0 with REDIS_CLUSTER_ADDRS, got %d", c.RedisDB)
+	case c.RedisSentinelMaster != "" && len(c.RedisSentinelAddrs) == 0:
+		add("REDIS_SENTINEL_ADDRS", "is required with REDIS_SENTINEL_MASTER")
+	case c.RedisSentinelMaster == "" && len(c.RedisSentinelAddrs) > 0:
+		add("REDIS_SENTINEL_MASTER", "is required with REDIS_SENTINEL_ADDRS")
+	}
+	if !validLogLevels[strings.ToLower(c.LogLevel)] {
+		add("LOG_LEVEL", "must be one of debug, info, warn, error, got %q", c.LogLevel)
+	}
+
+	switch {
+	case c.AdminToken == "":
+		add("ADMIN_TOKEN", "is required")
+	case len(c.AdminToken) < MinAdminTokenLength:
+		add("ADMIN_TOKEN", "must be at least %d characters", MinAdminTokenLength)
+	}
+
+	names := make([]string, 0, len(c.RateLimits))
+	for name := range c.RateLimits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !rateLimitNames[name] {
+			add("RATE_LIMITS", "unknown token name %q, want admin, reader or anonymous", name)
+		}
+	}
+
+	if c.StaleAfter > 0 && c.StaleCheckInterval <= 0 {
+		add("STALE_CHECK_INTERVAL", "must be positive when STALE_AFTER is set")
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// validateHostPort accepts host:port addresses with an optional host
+func validateHostPort(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("must be host:port, got %q", addr)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
\ No newline at end of file
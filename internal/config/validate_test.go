@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validConfig() *Config {
+	return &Config{
+		RedisAddr:  "localhost:6379",
+		GRPCAddr:   ":50051",
+		HTTPAddr:   ":8080",
+		AdminToken: "dev-secret-token",
+		LogLevel:   "info",
+	}
+}
+
+func TestValidateAcceptsDefaults(t *testing.T) {
+	assert.NoError(t, validConfig().Validate())
+}
+
+func TestValidateListsAllProblems(t *testing.T) {
+	cfg := validConfig()
+	cfg.GRPCAddr = "50051"
+	cfg.HTTPAddr = ":http-port"
+	cfg.RedisDB = -1
+	cfg.LogLevel = "verbose"
+	cfg.AdminToken = "short"
+	cfg.RateLimits = map[string]RateLimit{"simulator": {QPS: 10}}
+	cfg.StaleAfter = time.Minute
+
+	err := cfg.Validate()
+	require.Error(t, err)
+
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+
+	var fields []string
+	for _, fe := range verr.Errors {
+		fields = append(fields, fe.Field)
+	}
+	assert.Equal(t, []string{"GRPC_ADDR", "HTTP_ADDR", "REDIS_DB", "LOG_LEVEL", "ADMIN_TOKEN", "RATE_LIMITS", "STALE_CHECK_INTERVAL"}, fields)
+	assert.Contains(t, err.Error(), "ADMIN_TOKEN: must be at least 8 characters")
+}
+
+func TestLoadRequiresAdminToken(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "")
+
+	_, err := Load()
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	assert.Contains(t, err.Error(), "ADMIN_TOKEN: is required")
+}
+
+func TestValidateRedisTopology(t *testing.T) {
+	cfg := validConfig()
+	cfg.RedisClusterAddrs = []string{"redis-0:6379", "redis-1:6379"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.RedisDB = 1
+	assert.ErrorContains(t, cfg.Validate(), "REDIS_DB: must be 0 with REDIS_CLUSTER_ADDRS")
+
+	cfg = validConfig()
+	cfg.RedisSentinelMaster = "mymaster"
+	assert.ErrorContains(t, cfg.Validate(), "REDIS_SENTINEL_ADDRS: is required")
+
+	cfg.RedisSentinelAddrs = []string{"sentinel-0:26379", "sentinel-1"}
+	assert.ErrorContains(t, cfg.Validate(), `REDIS_SENTINEL_ADDRS: must be host:port, got "sentinel-1"`)
+
+	cfg.RedisSentinelAddrs = []string{"sentinel-0:26379"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.RedisClusterAddrs = []string{"redis-0:6379"}
+	assert.ErrorContains(t, cfg.Validate(), "cannot be combined with REDIS_SENTINEL_MASTER")
+}
\ No newline at end of file
@@ -12,24 +12,45 @@ import (
 // Aggregator maintains rolling metrics and time-series data
 type Aggregator struct {
 	mu             sync.RWMutex
-	windowSecs     int
+	windowSize     int           // Samples kept in each ring buffer
+	sampleInterval time.Duration // Time between samples
 	nodes          map[string]*Node
 	statusCounts   map[nodev1.NodeStatus]int
 	typeCounts     map[nodev1.NodeType]int
 
-	// Time series ring buffers (one per status)
-	statusTimeSeries map[nodev1.NodeStatus]*RingBuffer
-	eventBuffer      *RingBuffer
-	mutationBuffer   *RingBuffer
+	// Time series ring buffers (one per status and one per type)
+	statusTimeSeries map[nodev1.NodeStatus]*RingBuffer[int]
+	typeTimeSeries   map[nodev1.NodeType]*RingBuffer[int]
+	eventBuffer      *RingBuffer[int]
+	mutationBuffer   *RingBuffer[int]
+
+	// Label keys whose value distributions are included in snapshots
+	trackedLabels []string
+
+	// Last recentEventsCap events, oldest first, for event feeds
+	recentEvents []*Event
+
+	// Watcher count last reported by the server
+	connectedWatchers int
+
+	// generation counts changes to the node set and snapshotGeneration
+	// changes to snapshots, which also change with each sample, so callers
+	// can skip work when nothing changed
+	generation         uint64
+	snapshotGeneration uint64
 
 	// Event counters
 	totalEvents    int64
-	eventsLastSec  int
-	mutationsLastSec int
+	eventsThisInterval    int
+	mutationsThisInterval int
 
-	// Subscribers for push updates
-	subscribers    map[chan MetricsSnapshot]struct{}
+	// Subscribers for push updates. mu and subscribersMu are never held
+	// together: snapshots are built under mu and delivered under
+	// subscribersMu afterwards, so neither lock can wait on the other.
+	subscribers    map[uint64]chan MetricsSnapshot
 	subscribersMu  sync.RWMutex
+	nextSubID      uint64
+	closed         bool // Set by Close; later subscriptions start closed
 
 	// Ticker for sampling
 	ticker         *time.Ticker
@@ -37,20 +58,41 @@ type Aggregator struct {
 	cancel         context.CancelFunc
 }
 
-// NewAggregator creates a new metrics aggregator
+// recentEventsCap bounds the events kept for RecentEvents
+const recentEventsCap = 200
+
+// DefaultSampleInterval is the sample interval used by NewAggregator
+const DefaultSampleInterval = time.Second
+
+// NewAggregator creates a new metrics aggregator that samples once per second
+// and keeps windowSecs samples
 func NewAggregator(windowSecs int) *Aggregator {
+	return NewAggregatorWithInterval(windowSecs, DefaultSampleInterval)
+}
+
+// NewAggregatorWithInterval creates a metrics aggregator that samples every
+// sampleInterval and keeps windowSize samples, so the history covers
+// windowSize*sampleInterval. Rates in snapshots are normalized to per-second
+// values whatever the interval. A non-positive interval falls back to
+// DefaultSampleInterval.
+func NewAggregatorWithInterval(windowSize int, sampleInterval time.Duration) *Aggregator {
+	if sampleInterval <= 0 {
+		sampleInterval = DefaultSampleInterval
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 
 	agg := &Aggregator{
-		windowSecs:       windowSecs,
+		windowSize:       windowSize,
+		sampleInterval:   sampleInterval,
 		nodes:            make(map[string]*Node),
 		statusCounts:     make(map[nodev1.NodeStatus]int),
 		typeCounts:       make(map[nodev1.NodeType]int),
-		statusTimeSeries: make(map[nodev1.NodeStatus]*RingBuffer),
-		eventBuffer:      NewRingBuffer(windowSecs),
-		mutationBuffer:   NewRingBuffer(windowSecs),
-		subscribers:      make(map[chan MetricsSnapshot]struct{}),
-		ticker:           time.NewTicker(1 * time.Second),
+		statusTimeSeries: make(map[nodev1.NodeStatus]*RingBuffer[int]),
+		typeTimeSeries:   make(map[nodev1.NodeType]*RingBuffer[int]),
+		eventBuffer:      NewRingBuffer[int](windowSize),
+		mutationBuffer:   NewRingBuffer[int](windowSize),
+		subscribers:      make(map[uint64]chan MetricsSnapshot),
+		ticker:           time.NewTicker(sampleInterval),
 		ctx:              ctx,
 		cancel:           cancel,
 	}
@@ -62,7 +104,16 @@ func NewAggregator(windowSecs int) *Aggregator {
 		nodev1.NodeStatus_DOWN,
 		nodev1.NodeStatus_DEGRADED,
 	} {
-		agg.statusTimeSeries[status] = NewRingBuffer(windowSecs)
+		agg.statusTimeSeries[status] = NewRingBuffer[int](windowSize)
+	}
+
+	// Initialize time series buffers for each type
+	for _, nodeType := range []nodev1.NodeType{
+		nodev1.NodeType_BAREMETAL,
+		nodev1.NodeType_VM,
+		nodev1.NodeType_CONTAINER,
+	} {
+		agg.typeTimeSeries[nodeType] = NewRingBuffer[int](windowSize)
 	}
 
 	// Start the sampling loop
@@ -77,52 +128,141 @@ func (agg *Aggregator) Close() {
 	agg.ticker.Stop()
 
 	agg.subscribersMu.Lock()
-	for ch := range agg.subscribers {
+	for _, ch := range agg.subscribers {
 		close(ch)
 	}
-	agg.subscribers = make(map[chan MetricsSnapshot]struct{})
+	agg.subscribers = make(map[uint64]chan MetricsSnapshot)
+	agg.closed = true
 	agg.subscribersMu.Unlock()
 }
 
-// HandleEvent processes an incoming event
+// HandleEvent processes an incoming event. The aggregator keeps its own copy
+// of the node, so callers may keep using or mutating event.Node afterwards.
 func (agg *Aggregator) HandleEvent(event *Event) {
+	if event.Node == nil {
+		return
+	}
+	node := event.Node.Clone()
+
 	agg.mu.Lock()
 	defer agg.mu.Unlock()
 
 	agg.totalEvents++
-	agg.eventsLastSec++
+	agg.eventsThisInterval++
+	agg.generation++
+	agg.snapshotGeneration++
+
+	// Diff against the stored copy with the same rules as the server, and
+	// fill in the changed fields for events that don't carry them
+	var changed []string
+	existing, known := agg.nodes[node.ID]
+	if event.Type == nodev1.EventType_UPDATED && known {
+		changed = DiffNodes(existing, node)
+		if len(event.ChangedFields) == 0 && len(changed) > 0 {
+			filled := *event
+			filled.ChangedFields = changed
+			event = &filled
+		}
+	}
+	agg.recordRecent(event, node)
 
 	switch event.Type {
 	case nodev1.EventType_CREATED:
-		agg.nodes[event.Node.ID] = event.Node
-		agg.statusCounts[event.Node.Status]++
-		agg.typeCounts[event.Node.Type]++
-		agg.mutationsLastSec++
+		agg.nodes[node.ID] = node
+		agg.statusCounts[node.Status]++
+		agg.typeCounts[node.Type]++
+		agg.mutationsThisInterval++
 
 	case nodev1.EventType_UPDATED:
-		if existing, ok := agg.nodes[event.Node.ID]; ok {
-			// Update status counts if status changed
-			if existing.Status != event.Node.Status {
+		for _, field := range changed {
+			switch field {
+			case "status":
 				agg.statusCounts[existing.Status]--
-				agg.statusCounts[event.Node.Status]++
-			}
-			// Update type counts if type changed (unlikely but possible)
-			if existing.Type != event.Node.Type {
+				agg.statusCounts[node.Status]++
+			case "type":
+				// Unlikely but possible
 				agg.typeCounts[existing.Type]--
-				agg.typeCounts[event.Node.Type]++
+				agg.typeCounts[node.Type]++
 			}
 		}
-		agg.nodes[event.Node.ID] = event.Node
-		agg.mutationsLastSec++
+		agg.nodes[node.ID] = node
+		agg.mutationsThisInterval++
 
 	case nodev1.EventType_DELETED:
-		if existing, ok := agg.nodes[event.Node.ID]; ok {
+		if known {
 			agg.statusCounts[existing.Status]--
 			agg.typeCounts[existing.Type]--
-			delete(agg.nodes, event.Node.ID)
+			delete(agg.nodes, node.ID)
+		}
+		agg.mutationsThisInterval++
+	}
+}
+
+// recordRecent keeps a copy of event, dropping the oldest past the cap
+func (agg *Aggregator) recordRecent(event *Event, node *Node) {
+	recent := *event
+	recent.Node = node.Clone()
+	recent.ChangedFields = append([]string(nil), event.ChangedFields...)
+	recent.Changes = append([]FieldChange(nil), event.Changes...)
+
+	if len(agg.recentEvents) == recentEventsCap {
+		copy(agg.recentEvents, agg.recentEvents[1:])
+		agg.recentEvents = agg.recentEvents[:recentEventsCap-1]
+	}
+	agg.recentEvents = append(agg.recentEvents, &recent)
+}
+
+// RecentEvents returns copies of up to the last n events handled, oldest
+// first. At most 200 events are kept.
+func (agg *Aggregator) RecentEvents(n int) []*Event {
+	agg.mu.RLock()
+	defer agg.mu.RUnlock()
+
+	if n <= 0 {
+		return nil
+	}
+	if n > len(agg.recentEvents) {
+		n = len(agg.recentEvents)
+	}
+	events := make([]*Event, 0, n)
+	for _, e := range agg.recentEvents[len(agg.recentEvents)-n:] {
+		c := *e
+		c.Node = e.Node.Clone()
+		c.ChangedFields = append([]string(nil), e.ChangedFields...)
+		c.Changes = append([]FieldChange(nil), e.Changes...)
+		events = append(events, &c)
+	}
+	return events
+}
+
+// SetTrackedLabels sets the label keys whose value distributions are
+// included in every snapshot
+func (agg *Aggregator) SetTrackedLabels(keys []string) {
+	agg.mu.Lock()
+	defer agg.mu.Unlock()
+
+	agg.trackedLabels = append([]string(nil), keys...)
+}
+
+// LabelDistribution counts nodes per value of a label key. Nodes without the
+// label are not counted. It scans all nodes, which is fine for the modest
+// label cardinality we see.
+func (agg *Aggregator) LabelDistribution(key string) map[string]int {
+	agg.mu.RLock()
+	defer agg.mu.RUnlock()
+
+	return agg.labelDistributionUnlocked(key)
+}
+
+// labelDistributionUnlocked counts label values (caller must hold lock)
+func (agg *Aggregator) labelDistributionUnlocked(key string) map[string]int {
+	dist := make(map[string]int)
+	for _, node := range agg.nodes {
+		if value, ok := node.Labels[key]; ok {
+			dist[value]++
 		}
-		agg.mutationsLastSec++
 	}
+	return dist
 }
 
 // SetNodes initializes the node set (for initial load)
@@ -142,27 +282,76 @@ func (agg *Aggregator) SetNodes(nodes []*Node) {
 
 	// Populate from nodes
 	for _, node := range nodes {
-		agg.nodes[node.ID] = node
+		agg.nodes[node.ID] = node.Clone()
 		agg.statusCounts[node.Status]++
 		agg.typeCounts[node.Type]++
 	}
+	agg.generation++
+	agg.snapshotGeneration++
 }
 
-// GetNodes returns a copy of current nodes
+// GetNodes returns deep copies of the current nodes
 func (agg *Aggregator) GetNodes() []*Node {
 	logging.Debug("Aggregator.GetNodes: Acquiring RLock...")
 	agg.mu.RLock()
 	defer agg.mu.RUnlock()
 	logging.Debug("Aggregator.GetNodes: RLock acquired, %d nodes", len(agg.nodes))
 
+	return agg.getNodesUnlocked()
+}
+
+// GetNodesIfChanged returns the nodes and the current generation, unless the
+// node set is unchanged since lastGen, in which case it copies nothing and
+// reports false. A lastGen of 0 always gets the nodes.
+func (agg *Aggregator) GetNodesIfChanged(lastGen uint64) ([]*Node, uint64, bool) {
+	agg.mu.RLock()
+	defer agg.mu.RUnlock()
+
+	if lastGen != 0 && lastGen == agg.generation {
+		return nil, agg.generation, false
+	}
+	return agg.getNodesUnlocked(), agg.generation, true
+}
+
+// getNodesUnlocked copies the nodes (caller must hold lock)
+func (agg *Aggregator) getNodesUnlocked() []*Node {
 	nodes := make([]*Node, 0, len(agg.nodes))
 	for _, node := range agg.nodes {
-		nodeCopy := *node
-		nodes = append(nodes, &nodeCopy)
+		nodes = append(nodes, node.Clone())
 	}
 	return nodes
 }
 
+// Generation returns the node set generation, which changes with every
+// handled event and SetNodes
+func (agg *Aggregator) Generation() uint64 {
+	agg.mu.RLock()
+	defer agg.mu.RUnlock()
+	return agg.generation
+}
+
+// SetConnectedWatchers records the watcher count reported by the server
+func (agg *Aggregator) SetConnectedWatchers(n int) {
+	agg.mu.Lock()
+	defer agg.mu.Unlock()
+	if agg.connectedWatchers != n {
+		agg.connectedWatchers = n
+		agg.snapshotGeneration++
+	}
+}
+
+// GetNode returns a deep copy of the node with the given ID
+func (agg *Aggregator) GetNode(id string) (*Node, bool) {
+	agg.mu.RLock()
+	defer agg.mu.RUnlock()
+
+	node, ok := agg.nodes[id]
+	if !ok {
+		return nil, false
+	}
+	return node.Clone(), true
+}
+
 // Snapshot returns current metrics snapshot
 func (agg *Aggregator) Snapshot() MetricsSnapshot {
 	agg.mu.RLock()
@@ -171,6 +360,18 @@ func (agg *Aggregator) Snapshot() MetricsSnapshot {
 	return agg.snapshotUnlocked()
 }
 
+// SnapshotIfChanged is like GetNodesIfChanged for snapshots: it returns a
+// snapshot only if an event, sample or watcher count changed it since lastGen
+func (agg *Aggregator) SnapshotIfChanged(lastGen uint64) (MetricsSnapshot, uint64, bool) {
+	agg.mu.RLock()
+	defer agg.mu.RUnlock()
+
+	if lastGen != 0 && lastGen == agg.snapshotGeneration {
+		return MetricsSnapshot{}, agg.snapshotGeneration, false
+	}
+	return agg.snapshotUnlocked(), agg.snapshotGeneration, true
+}
+
 // snapshotUnlocked returns a snapshot without locking (caller must hold lock)
 func (agg *Aggregator) snapshotUnlocked() MetricsSnapshot {
 	snap := MetricsSnapshot{
@@ -180,8 +381,10 @@ func (agg *Aggregator) snapshotUnlocked() MetricsSnapshot {
 		TypeCounts:   make(map[nodev1.NodeType]int),
 		TypeRatios:   make(map[nodev1.NodeType]float64),
 		StatusTimeSeries: make(map[nodev1.NodeStatus][]int),
+		TypeTimeSeries:   make(map[nodev1.NodeType][]int),
 		TotalNodes:   len(agg.nodes),
 		TotalEvents:  agg.totalEvents,
+		ConnectedWatchers: agg.connectedWatchers,
 	}
 
 	// Copy status counts and calculate ratios
@@ -201,63 +404,89 @@ func (agg *Aggregator) snapshotUnlocked() MetricsSnapshot {
 		snap.TypeRatios[nodeType] = float64(count) / totalNodes
 	}
 
+	// Distributions of the tracked label keys
+	if len(agg.trackedLabels) > 0 {
+		snap.LabelDistributions = make(map[string]map[string]int, len(agg.trackedLabels))
+		for _, key := range agg.trackedLabels {
+			snap.LabelDistributions[key] = agg.labelDistributionUnlocked(key)
+		}
+	}
+
 	// Copy time series data
 	for status, buffer := range agg.statusTimeSeries {
 		snap.StatusTimeSeries[status] = buffer.GetAll()
 	}
+	for nodeType, buffer := range agg.typeTimeSeries {
+		snap.TypeTimeSeries[nodeType] = buffer.GetAll()
+	}
+
+	// Calculate rates, normalizing per-interval counts to per-second
+	intervalSecs := agg.sampleInterval.Seconds()
+	snap.SampleInterval = agg.sampleInterval
 
-	// Calculate rates
 	eventHistory := agg.eventBuffer.GetAll()
+	snap.EventRateSeries = eventHistory
 	if len(eventHistory) > 0 {
-		snap.EventsPerSecond = agg.eventBuffer.Average()
+		snap.EventsPerSecond = agg.eventBuffer.Average() / intervalSecs
+		snap.EventsPerSecondP95 = agg.eventBuffer.Percentile(95) / intervalSecs
+		snap.EventsPerSecondMax = float64(agg.eventBuffer.Max()) / intervalSecs
 	}
 
 	mutationHistory := agg.mutationBuffer.GetAll()
+	snap.MutationRateSeries = mutationHistory
 	if len(mutationHistory) > 0 {
-		snap.MutationRate = agg.mutationBuffer.Average()
+		snap.MutationRate = agg.mutationBuffer.Average() / intervalSecs
+		snap.MutationRateMax = float64(agg.mutationBuffer.Max()) / intervalSecs
 	}
 
-	// Generate time labels (last N seconds)
-	now := time.Now()
-	labels := make([]string, 0, agg.windowSecs)
-	for i := agg.windowSecs - 1; i >= 0; i-- {
-		t := now.Add(-time.Duration(i) * time.Second)
-		labels = append(labels, t.Format("15:04:05"))
-	}
-	snap.TimeSeriesLabels = labels
+	// Generate time labels, one per sample
+	snap.TimeSeriesLabels = timeSeriesLabels(time.Now(), agg.windowSize, agg.sampleInterval)
 
 	return snap
 }
 
-// Subscribe creates a channel for receiving push updates
-func (agg *Aggregator) Subscribe() <-chan MetricsSnapshot {
-	ch := make(chan MetricsSnapshot, 1)
+// Subscription is a registration for push updates. C receives a snapshot
+// after every sample and is closed by Unsubscribe or Close.
+type Subscription struct {
+	C  <-chan MetricsSnapshot
+	id uint64
+}
 
-	agg.subscribersMu.Lock()
-	agg.subscribers[ch] = struct{}{}
-	agg.subscribersMu.Unlock()
+// Subscribe registers for push updates, starting with the current snapshot
+func (agg *Aggregator) Subscribe() *Subscription {
+	ch := make(chan MetricsSnapshot, 1)
 
-	// Send initial snapshot
+	// Queue the initial snapshot before registering the channel. Once it is
+	// registered, sample may fill the one-slot buffer first, and this send
+	// would block forever since nobody reads the channel yet.
 	ch <- agg.Snapshot()
 
-	return ch
+	agg.subscribersMu.Lock()
+	defer agg.subscribersMu.Unlock()
+
+	agg.nextSubID++
+	sub := &Subscription{C: ch, id: agg.nextSubID}
+	if agg.closed {
+		close(ch)
+		return sub
+	}
+	agg.subscribers[sub.id] = ch
+	return sub
 }
 
-// Unsubscribe removes a subscriber
-func (agg *Aggregator) Unsubscribe(ch <-chan MetricsSnapshot) {
+// Unsubscribe removes a subscription and closes its channel. It is a no-op
+// for a subscription already removed by Unsubscribe or Close.
+func (agg *Aggregator) Unsubscribe(sub *Subscription) {
 	agg.subscribersMu.Lock()
-	// We need to match the actual channel
-	for subCh := range agg.subscribers {
-		if subCh == ch {
-			delete(agg.subscribers, subCh)
-			close(subCh)
-			break
-		}
+	defer agg.subscribersMu.Unlock()
+
+	if ch, ok := agg.subscribers[sub.id]; ok {
+		delete(agg.subscribers, sub.id)
+		close(ch)
 	}
-	agg.subscribersMu.Unlock()
 }
 
-// sampleLoop runs every second to update time series
+// sampleLoop runs every sample interval to update time series
 func (agg *Aggregator) sampleLoop() {
 	for {
 		select {
@@ -279,16 +508,22 @@ func (agg *Aggregator) sample() {
 		buffer.Push(agg.statusCounts[status])
 	}
 
+	// Push current type counts to time series
+	for nodeType, buffer := range agg.typeTimeSeries {
+		buffer.Push(agg.typeCounts[nodeType])
+	}
+
 	// Push event and mutation rates
-	agg.eventBuffer.Push(agg.eventsLastSec)
-	agg.mutationBuffer.Push(agg.mutationsLastSec)
+	agg.eventBuffer.Push(agg.eventsThisInterval)
+	agg.mutationBuffer.Push(agg.mutationsThisInterval)
 
-	// Reset per-second counters
-	agg.eventsLastSec = 0
-	agg.mutationsLastSec = 0
+	// Reset per-interval counters
+	agg.eventsThisInterval = 0
+	agg.mutationsThisInterval = 0
+	agg.snapshotGeneration++
 
-	// Create snapshot for subscribers - must be done WITHOUT lock held to avoid deadlock
-	// First collect the data we need while holding the lock
+	// Build the snapshot under mu, but deliver it after releasing mu so the
+	// two locks are never held together
 	snap := agg.snapshotUnlocked()
 
 	logging.Debug("Aggregator.sample: Releasing Lock")
@@ -296,7 +531,7 @@ func (agg *Aggregator) sample() {
 
 	// Notify subscribers (non-blocking)
 	agg.subscribersMu.RLock()
-	for ch := range agg.subscribers {
+	for _, ch := range agg.subscribers {
 		select {
 		case ch <- snap:
 		default:
@@ -304,4 +539,20 @@ func (agg *Aggregator) sample() {
 		}
 	}
 	agg.subscribersMu.RUnlock()
+}
+
+// timeSeriesLabels returns one clock label per sample, oldest first, ending at
+// now. Sub-second intervals get millisecond labels so they stay distinct.
+func timeSeriesLabels(now time.Time, samples int, interval time.Duration) []string {
+	layout := "15:04:05"
+	if interval%time.Second != 0 {
+		layout = "15:04:05.000"
+	}
+
+	labels := make([]string, 0, samples)
+	for i := samples - 1; i >= 0; i-- {
+		t := now.Add(-time.Duration(i) * interval)
+		labels = append(labels, t.Format(layout))
+	}
+	return labels
 }
\ No newline at end of file
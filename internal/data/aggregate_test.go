@@ -0,0 +1,406 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+)
+
+func TestSampleRecordsTypeTimeSeries(t *testing.T) {
+	agg := NewAggregator(10)
+	// Stop the background sampler so the test drives sample() itself
+	agg.Close()
+
+	agg.SetNodes([]*Node{
+		{ID: "a", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP},
+		{ID: "b", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP},
+		{ID: "c", Type: nodev1.NodeType_BAREMETAL, Status: nodev1.NodeStatus_DOWN},
+	})
+	agg.sample()
+
+	agg.HandleEvent(&Event{
+		Type: nodev1.EventType_CREATED,
+		Node: &Node{ID: "d", Type: nodev1.NodeType_CONTAINER, Status: nodev1.NodeStatus_UP},
+	})
+	agg.HandleEvent(&Event{
+		Type: nodev1.EventType_DELETED,
+		Node: &Node{ID: "a"},
+	})
+	agg.sample()
+
+	snap := agg.Snapshot()
+	want := map[nodev1.NodeType][]int{
+		nodev1.NodeType_BAREMETAL: {1, 1},
+		nodev1.NodeType_VM:        {2, 1},
+		nodev1.NodeType_CONTAINER: {0, 1},
+	}
+	if len(snap.TypeTimeSeries) != len(want) {
+		t.Fatalf("expected %d type series, got %d", len(want), len(snap.TypeTimeSeries))
+	}
+	for nodeType, series := range want {
+		assertSeries(t, nodeType.String(), series, snap.TypeTimeSeries[nodeType])
+	}
+}
+
+func TestSnapshotExposesEventRateSeries(t *testing.T) {
+	agg := NewAggregator(10)
+	agg.Close()
+
+	agg.SetNodes([]*Node{{ID: "a", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP}})
+
+	// Second 1: three status updates. Second 2: nothing. Second 3: one create.
+	for i := 0; i < 3; i++ {
+		agg.HandleEvent(&Event{
+			Type: nodev1.EventType_UPDATED,
+			Node: &Node{ID: "a", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_DOWN},
+		})
+	}
+	agg.sample()
+	agg.sample()
+	agg.HandleEvent(&Event{
+		Type: nodev1.EventType_CREATED,
+		Node: &Node{ID: "b", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP},
+	})
+	agg.sample()
+
+	snap := agg.Snapshot()
+	assertSeries(t, "events", []int{3, 0, 1}, snap.EventRateSeries)
+	assertSeries(t, "mutations", []int{3, 0, 1}, snap.MutationRateSeries)
+}
+
+func assertSeries(t *testing.T, name string, want, got []int) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: expected %v, got %v", name, want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
+
+func TestAggregatorCopiesAreIndependent(t *testing.T) {
+	agg := NewAggregator(10)
+	agg.Close()
+
+	node := &Node{ID: "a", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP, Labels: map[string]string{"env": "prod"}}
+	agg.HandleEvent(&Event{Type: nodev1.EventType_CREATED, Node: node})
+
+	// Mutating the caller's node or a returned copy must not leak into the aggregator
+	node.Labels["env"] = "dev"
+	node.Status = nodev1.NodeStatus_DOWN
+	got := agg.GetNodes()[0]
+	got.Labels["env"] = "staging"
+
+	stored := agg.GetNodes()[0]
+	if stored.Labels["env"] != "prod" || stored.Status != nodev1.NodeStatus_UP {
+		t.Errorf("aggregator node was mutated through a shared pointer: %+v", stored)
+	}
+}
+
+func TestGenerationSkipsUnchangedData(t *testing.T) {
+	agg := NewAggregator(10)
+	agg.Close()
+
+	agg.SetNodes([]*Node{{ID: "a", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP}})
+	nodes, gen, changed := agg.GetNodesIfChanged(0)
+	if !changed || len(nodes) != 1 || gen != agg.Generation() {
+		t.Fatalf("expected the loaded node at generation %d, got %d nodes at %d (changed=%v)", agg.Generation(), len(nodes), gen, changed)
+	}
+	_, snapGen, _ := agg.SnapshotIfChanged(0)
+
+	if nodes, _, changed := agg.GetNodesIfChanged(gen); changed || nodes != nil {
+		t.Errorf("expected no copy while unchanged, got %d nodes", len(nodes))
+	}
+	if _, _, changed := agg.SnapshotIfChanged(snapGen); changed {
+		t.Error("expected no snapshot while unchanged")
+	}
+
+	// A sample moves the time series but not the node set
+	agg.sample()
+	if _, _, changed := agg.GetNodesIfChanged(gen); changed {
+		t.Error("a sample must not change the node generation")
+	}
+	_, snapGen, changed = agg.SnapshotIfChanged(snapGen)
+	if !changed {
+		t.Error("expected a new snapshot after a sample")
+	}
+
+	agg.HandleEvent(&Event{Type: nodev1.EventType_UPDATED, Node: &Node{ID: "a", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_DOWN}})
+	nodes, _, changed = agg.GetNodesIfChanged(gen)
+	if !changed || nodes[0].Status != nodev1.NodeStatus_DOWN {
+		t.Errorf("expected the updated node after an event, got %v (changed=%v)", nodes, changed)
+	}
+	if _, _, changed := agg.SnapshotIfChanged(snapGen); !changed {
+		t.Error("expected a new snapshot after an event")
+	}
+}
+
+// Run with -race: the mock consumer mutates nodes obtained from GetNodes while
+// readers iterate labels, as the list view does
+func TestAggregatorDrivenByMockConsumerIsRaceFree(t *testing.T) {
+	agg := NewAggregator(10)
+	defer agg.Close()
+
+	consumer := NewMockStreamConsumer(agg)
+	consumer.ticker.Reset(time.Millisecond)
+	if err := consumer.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case event := <-consumer.Events():
+				agg.HandleEvent(event)
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				for _, node := range agg.GetNodes() {
+					for k, v := range node.Labels {
+						_ = k + v
+					}
+					node.Labels["seen"] = "true"
+				}
+			}
+		}()
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+	consumer.Stop()
+
+	if agg.Snapshot().TotalEvents == 0 {
+		t.Fatal("expected the mock consumer to produce events")
+	}
+}
+
+func TestLabelDistribution(t *testing.T) {
+	agg := NewAggregator(10)
+	agg.Close()
+
+	agg.SetNodes([]*Node{
+		{ID: "a", Labels: map[string]string{"env": "prod", "datacenter": "us-east-1"}},
+		{ID: "b", Labels: map[string]string{"env": "prod", "datacenter": "eu-central-1"}},
+		{ID: "c", Labels: map[string]string{"env": "dev"}},
+		{ID: "d"},
+	})
+
+	env := agg.LabelDistribution("env")
+	if len(env) != 2 || env["prod"] != 2 || env["dev"] != 1 {
+		t.Errorf("unexpected env distribution: %v", env)
+	}
+	if dist := agg.LabelDistribution("missing"); len(dist) != 0 {
+		t.Errorf("expected empty distribution, got %v", dist)
+	}
+
+	// Only tracked keys are included in snapshots
+	if snap := agg.Snapshot(); snap.LabelDistributions != nil {
+		t.Errorf("expected no distributions before tracking, got %v", snap.LabelDistributions)
+	}
+	agg.SetTrackedLabels([]string{"datacenter"})
+	snap := agg.Snapshot()
+	if len(snap.LabelDistributions) != 1 {
+		t.Fatalf("expected one tracked distribution, got %v", snap.LabelDistributions)
+	}
+	dc := snap.LabelDistributions["datacenter"]
+	if len(dc) != 2 || dc["us-east-1"] != 1 || dc["eu-central-1"] != 1 {
+		t.Errorf("unexpected datacenter distribution: %v", dc)
+	}
+}
+
+func TestAggregatorNormalizesRatesToSampleInterval(t *testing.T) {
+	agg := NewAggregatorWithInterval(8, 250*time.Millisecond)
+	agg.Close()
+
+	agg.SetNodes([]*Node{{ID: "a", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP}})
+
+	// Two events per 250ms sample is eight events per second
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 2; j++ {
+			agg.HandleEvent(&Event{
+				Type: nodev1.EventType_UPDATED,
+				Node: &Node{ID: "a", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP},
+			})
+		}
+		agg.sample()
+	}
+
+	snap := agg.Snapshot()
+	assertSeries(t, "events", []int{2, 2, 2, 2}, snap.EventRateSeries)
+	if snap.SampleInterval != 250*time.Millisecond {
+		t.Errorf("expected sample interval 250ms, got %v", snap.SampleInterval)
+	}
+	if snap.EventsPerSecond != 8 {
+		t.Errorf("expected 8 events/sec, got %v", snap.EventsPerSecond)
+	}
+	if snap.MutationRate != 8 {
+		t.Errorf("expected 8 mutations/sec, got %v", snap.MutationRate)
+	}
+	if len(snap.TimeSeriesLabels) != 8 {
+		t.Errorf("expected one label per sample, got %d", len(snap.TimeSeriesLabels))
+	}
+}
+
+func TestTimeSeriesLabelsFollowInterval(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 10, 0, time.UTC)
+
+	labels := timeSeriesLabels(now, 3, 5*time.Second)
+	want := []string{"12:00:00", "12:00:05", "12:00:10"}
+	for i := range want {
+		if labels[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, labels)
+		}
+	}
+
+	labels = timeSeriesLabels(now, 2, 250*time.Millisecond)
+	want = []string{"12:00:09.750", "12:00:10.000"}
+	for i := range want {
+		if labels[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, labels)
+		}
+	}
+}
+
+func TestNewAggregatorDefaultsToOneSecond(t *testing.T) {
+	agg := NewAggregator(5)
+	agg.Close()
+
+	if snap := agg.Snapshot(); snap.SampleInterval != time.Second {
+		t.Errorf("expected 1s default interval, got %v", snap.SampleInterval)
+	}
+}
+
+func TestRecentEventsKeepsNewestCopies(t *testing.T) {
+	agg := NewAggregator(10)
+	defer agg.Close()
+
+	for i := 0; i < recentEventsCap+5; i++ {
+		agg.HandleEvent(&Event{
+			Type: nodev1.EventType_CREATED,
+			Node: &Node{ID: fmt.Sprintf("node-%d", i), Labels: map[string]string{"env": "prod"}},
+		})
+	}
+
+	recent := agg.RecentEvents(3)
+	if len(recent) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(recent))
+	}
+	for i, e := range recent {
+		if want := fmt.Sprintf("node-%d", recentEventsCap+2+i); e.Node.ID != want {
+			t.Errorf("event %d: expected %s, got %s", i, want, e.Node.ID)
+		}
+	}
+
+	if got := len(agg.RecentEvents(1000)); got != recentEventsCap {
+		t.Errorf("expected the feed capped at %d events, got %d", recentEventsCap, got)
+	}
+
+	recent[0].Node.Labels["env"] = "dev"
+	if agg.RecentEvents(3)[0].Node.Labels["env"] != "prod" {
+		t.Error("RecentEvents should return copies")
+	}
+}
+
+func TestSubscribeUnderLoadDoesNotHang(t *testing.T) {
+	agg := NewAggregatorWithInterval(10, time.Millisecond)
+	defer agg.Close()
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			agg.HandleEvent(&Event{
+				Type: nodev1.EventType_UPDATED,
+				Node: &Node{ID: fmt.Sprintf("node-%d", i%50), Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP},
+			})
+		}
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var subs sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			subs.Add(1)
+			go func() {
+				defer subs.Done()
+				for i := 0; i < 200; i++ {
+					sub := agg.Subscribe()
+					<-sub.C
+					agg.Unsubscribe(sub)
+				}
+			}()
+		}
+		subs.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Subscribe/Unsubscribe hung against a busy aggregator")
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestUnsubscribeIsIdempotent(t *testing.T) {
+	agg := NewAggregatorWithInterval(10, time.Hour)
+
+	first := agg.Subscribe()
+	second := agg.Subscribe()
+	<-first.C
+	<-second.C
+
+	agg.Unsubscribe(first)
+	agg.Unsubscribe(first)
+	if _, ok := <-first.C; ok {
+		t.Fatal("expected the unsubscribed channel to be closed")
+	}
+
+	// Close already closed the channel, so a later Unsubscribe must not
+	agg.Close()
+	agg.Unsubscribe(second)
+	if _, ok := <-second.C; ok {
+		t.Fatal("expected Close to close the channel")
+	}
+
+	late := agg.Subscribe()
+	<-late.C
+	if _, ok := <-late.C; ok {
+		t.Fatal("expected a subscription after Close to start closed")
+	}
+	agg.Unsubscribe(late)
+}
\ No newline at end of file
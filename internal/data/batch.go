@@ -0,0 +1,42 @@
+package data
+
+// BatchItemResult is the outcome of one node in a batch operation
+type BatchItemResult struct {
+	NodeID   string
+	NodeName string // May be empty when the node could not be resolved
+	Error    string // Empty on success
+}
+
+// Succeeded reports whether the item was applied
+func (r BatchItemResult) Succeeded() bool {
+	return r.Error == ""
+}
+
+// BatchResult is the per-node outcome of a batch operation such as a
+// multi-node status update or bulk relabel
+type BatchResult struct {
+	Operation string
+	Items     []BatchItemResult
+}
+
+// Succeeded returns the items that were applied
+func (r *BatchResult) Succeeded() []BatchItemResult {
+	var items []BatchItemResult
+	for _, item := range r.Items {
+		if item.Succeeded() {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Failed returns the items that were rejected, with their reasons
+func (r *BatchResult) Failed() []BatchItemResult {
+	var items []BatchItemResult
+	for _, item := range r.Items {
+		if !item.Succeeded() {
+			items = append(items, item)
+		}
+	}
+	return items
+}
\ No newline at end of file
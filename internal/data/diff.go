@@ -0,0 +1,49 @@
+package data
+
+import (
+	"maps"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+)
+
+// DiffNodes returns the names of the fields that differ between old and new,
+// as the server reports them in an event's changed_fields: name, type,
+// status, status_reason, desired_status, labels and metadata_json, in that
+// order. Labels are
+// compared by content, so nil and empty label sets are equal. ID and LastSeen
+// are not compared. It returns nil when either node is nil.
+func DiffNodes(old, new *Node) []string {
+	if old == nil || new == nil {
+		return nil
+	}
+
+	var fields []string
+	if old.Name != new.Name {
+		fields = append(fields, "name")
+	}
+	if old.Type != new.Type {
+		fields = append(fields, "type")
+	}
+	if old.Status != new.Status {
+		fields = append(fields, "status")
+	}
+	if old.Reason != new.Reason {
+		fields = append(fields, "status_reason")
+	}
+	if old.Desired != new.Desired {
+		fields = append(fields, "desired_status")
+	}
+	if !maps.Equal(old.Labels, new.Labels) {
+		fields = append(fields, "labels")
+	}
+	if old.Metadata != new.Metadata {
+		fields = append(fields, "metadata_json")
+	}
+	return fields
+}
+
+// DiffProtoNodes is DiffNodes for API nodes, so the store and the clients
+// agree on what an update changed
+func DiffProtoNodes(old, new *nodev1.Node) []string {
+	return DiffNodes(convertNode(old), convertNode(new))
+}
\ No newline at end of file
@@ -0,0 +1,92 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestDiffNodes(t *testing.T) {
+	base := &Node{
+		ID:       "n1",
+		Name:     "web-01",
+		Type:     nodev1.NodeType_VM,
+		Status:   nodev1.NodeStatus_UP,
+		Labels:   map[string]string{"env": "prod"},
+		Metadata: `{"cpu":4}`,
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(n *Node)
+		want   []string
+	}{
+		{"identical", func(n *Node) {}, nil},
+		{"last seen only", func(n *Node) { n.LastSeen = n.LastSeen.Add(1) }, nil},
+		{"status", func(n *Node) { n.Status = nodev1.NodeStatus_DOWN }, []string{"status"}},
+		{"status and reason", func(n *Node) {
+			n.Status = nodev1.NodeStatus_DOWN
+			n.Reason = "disk full"
+		}, []string{"status", "status_reason"}},
+		{"desired", func(n *Node) { n.Desired = nodev1.NodeStatus_UP }, []string{"desired_status"}},
+		{"label value", func(n *Node) { n.Labels["env"] = "dev" }, []string{"labels"}},
+		{"label removed", func(n *Node) { delete(n.Labels, "env") }, []string{"labels"}},
+		{"in field order", func(n *Node) {
+			n.Metadata = "{}"
+			n.Name = "web-02"
+			n.Type = nodev1.NodeType_CONTAINER
+		}, []string{"name", "type", "metadata_json"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := base.Clone()
+			tt.mutate(next)
+			if got := DiffNodes(base, next); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffNodes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := DiffNodes(nil, base); got != nil {
+		t.Errorf("DiffNodes with a nil node = %v, want nil", got)
+	}
+}
+
+func TestDiffProtoNodesTreatsNilAndEmptyLabelsAlike(t *testing.T) {
+	old := &nodev1.Node{Id: "n1", Name: "db-01", Status: nodev1.NodeStatus_UP, LastSeen: timestamppb.Now()}
+	next := &nodev1.Node{Id: "n1", Name: "db-01", Status: nodev1.NodeStatus_DEGRADED, Labels: map[string]string{}}
+
+	if got := DiffProtoNodes(old, next); !reflect.DeepEqual(got, []string{"status"}) {
+		t.Errorf("DiffProtoNodes = %v, want [status]", got)
+	}
+}
+
+func TestAggregatorFillsMissingChangedFields(t *testing.T) {
+	agg := NewAggregator(10)
+	defer agg.Close()
+
+	agg.HandleEvent(&Event{Type: nodev1.EventType_CREATED, Node: &Node{ID: "n1", Status: nodev1.NodeStatus_UP, Type: nodev1.NodeType_VM}})
+	agg.HandleEvent(&Event{Type: nodev1.EventType_UPDATED, Node: &Node{ID: "n1", Status: nodev1.NodeStatus_DOWN, Type: nodev1.NodeType_VM}})
+	// Fields sent by the server are kept as they are
+	agg.HandleEvent(&Event{
+		Type:          nodev1.EventType_UPDATED,
+		Node:          &Node{ID: "n1", Status: nodev1.NodeStatus_DOWN, Type: nodev1.NodeType_VM, Labels: map[string]string{"a": "b"}},
+		ChangedFields: []string{"labels"},
+	})
+
+	recent := agg.RecentEvents(2)
+	if got := recent[0].ChangedFields; !reflect.DeepEqual(got, []string{"status"}) {
+		t.Errorf("filled changed fields = %v, want [status]", got)
+	}
+	if got := recent[1].ChangedFields; !reflect.DeepEqual(got, []string{"labels"}) {
+		t.Errorf("server changed fields = %v, want [labels]", got)
+	}
+
+	snap := agg.Snapshot()
+	if snap.StatusCounts[nodev1.NodeStatus_UP] != 0 || snap.StatusCounts[nodev1.NodeStatus_DOWN] != 1 {
+		t.Errorf("status counts = %v, want the node moved to DOWN", snap.StatusCounts)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,52 @@
+package data
+
+import (
+	"math"
+	"sync"
+)
+
+// EventSampler picks an evenly spaced fraction of events. It is used to thin
+// out the events shown in the logs view under extreme load while metrics
+// still count every event.
+type EventSampler struct {
+	mu        sync.Mutex
+	rate      float64
+	seen      int64
+	forwarded int64
+}
+
+// NewEventSampler creates a sampler forwarding the given fraction of events.
+// Rates outside (0, 1) forward every event.
+func NewEventSampler(rate float64) *EventSampler {
+	if rate <= 0 || rate > 1 {
+		rate = 1
+	}
+	return &EventSampler{rate: rate}
+}
+
+// Rate returns the fraction of events forwarded
+func (s *EventSampler) Rate() float64 {
+	return s.rate
+}
+
+// Sample records an event and reports whether it should be forwarded. The
+// first event is always forwarded.
+func (s *EventSampler) Sample() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seen++
+	// The epsilon keeps float error (e.g. 30*0.1) from forwarding one early
+	if s.forwarded < int64(math.Ceil(float64(s.seen)*s.rate-1e-9)) {
+		s.forwarded++
+		return true
+	}
+	return false
+}
+
+// Counts returns how many events were seen and forwarded
+func (s *EventSampler) Counts() (seen, forwarded int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seen, s.forwarded
+}
\ No newline at end of file
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"math/rand"
+	"sync/atomic"
 	"time"
 
 	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
@@ -13,32 +15,100 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-// StreamConsumer consumes events from gRPC stream
+// ReconnectStatus reports the consumer's connection state while it retries
+type ReconnectStatus struct {
+	Connected   bool
+	Attempt     int           // Reconnect attempt number, 0 once connected
+	MaxAttempts int           // 0 when retries are unlimited
+	Backoff     time.Duration // Delay before the attempt is made
+	NextRetry   time.Time
+	// PollInterval is set while nodes are refreshed by polling ListNodes
+	// because the event stream is unavailable
+	PollInterval time.Duration
+}
+
+// ConnState is the connection state of a StreamConsumer
+type ConnState int32
+
+const (
+	// StateDisconnected is the state before Start and after the consumer
+	// gave up on the backend
+	StateDisconnected ConnState = iota
+	StateConnected
+	// StateReconnecting is set while the stream is retried with backoff,
+	// whether or not nodes are refreshed by polling meanwhile
+	StateReconnecting
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case StateConnected:
+		return "Connected"
+	case StateReconnecting:
+		return "Reconnecting"
+	default:
+		return "Disconnected"
+	}
+}
+
+// DefaultEventQueueSize is how many received events may wait to be applied
+const DefaultEventQueueSize = 1000
+
+// StreamConsumer consumes events from gRPC stream. Received events go
+// through a bounded queue to a single worker that applies them in order;
+// when the worker falls behind and the queue is full, events are dropped and
+// counted rather than blocking the stream or piling up goroutines.
 type StreamConsumer struct {
-	client       nodev1.NodeServiceClient
-	aggregator   *Aggregator
-	eventChan    chan *Event
-	errorChan    chan error
-	ctx          context.Context
-	cancel       context.CancelFunc
-	maxRetries   int
-	baseDelay    time.Duration
-	maxDelay     time.Duration
+	client     nodev1.NodeServiceClient
+	aggregator *Aggregator
+	queue      chan *Event
+	handler    func(*Event)
+	dropped    atomic.Uint64
+	eventChan  chan *Event
+	errorChan  chan error
+	statusChan chan ReconnectStatus
+	ctx        context.Context
+	cancel     context.CancelFunc
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+
+	loopDone    chan struct{} // Closed when the consume loop exits
+	state       atomic.Int32  // ConnState, see State
+	lastEventID string        // Last event received, owned by the consume loop
+	workerDone  chan struct{} // Closed when the event worker exits
+
+	// Polling fallback, owned by the consume loop
+	pollInterval time.Duration
+	pollCancel   context.CancelFunc
+	pollDone     chan struct{}
+
+	// Server stats polling for the watcher count
+	statsInterval time.Duration
+	statsDone     chan struct{}
 }
 
+// DefaultStatsInterval is how often the consumer asks the server for its stats
+const DefaultStatsInterval = 5 * time.Second
+
 // NewStreamConsumer creates a new stream consumer
 func NewStreamConsumer(client nodev1.NodeServiceClient, aggregator *Aggregator) *StreamConsumer {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &StreamConsumer{
 		client:     client,
 		aggregator: aggregator,
+		queue:      make(chan *Event, DefaultEventQueueSize),
+		handler:    aggregator.HandleEvent,
 		eventChan:  make(chan *Event, 100),
 		errorChan:  make(chan error, 10),
+		statusChan: make(chan ReconnectStatus, 10),
 		ctx:        ctx,
 		cancel:     cancel,
 		maxRetries: 10,
 		baseDelay:  100 * time.Millisecond,
 		maxDelay:   30 * time.Second,
+
+		statsInterval: DefaultStatsInterval,
 	}
 }
 
@@ -54,26 +124,84 @@ func (sc *StreamConsumer) Start(ctx context.Context) error {
 	}
 	logging.Debug("Initial state loaded successfully")
 
+	sc.setState(StateConnected)
+
 	// Start the stream consumer
 	logging.Debug("Starting consume loop goroutine...")
+	sc.loopDone = make(chan struct{})
 	go sc.consumeLoop(ctx)
 
 	// Start the event processor
 	logging.Debug("Starting event processor goroutine...")
-	go sc.processEvents()
+	sc.startWorker()
+
+	if sc.statsInterval > 0 {
+		sc.statsDone = make(chan struct{})
+		go sc.statsLoop()
+	}
 
 	logging.Debug("StreamConsumer started successfully")
 	return nil
 }
 
-// Stop stops the stream consumer
+// Stop stops the stream consumer. It waits for the consume loop to exit so
+// nothing is sent on the channels after they are closed.
 func (sc *StreamConsumer) Stop() {
 	sc.cancel()
+	if sc.loopDone != nil {
+		<-sc.loopDone
+	}
+	if sc.workerDone != nil {
+		<-sc.workerDone
+	}
+	if sc.statsDone != nil {
+		<-sc.statsDone
+	}
 	close(sc.eventChan)
 	close(sc.errorChan)
+	close(sc.statusChan)
+}
+
+// SetMaxRetries sets how many reconnect attempts are made before giving up
+func (sc *StreamConsumer) SetMaxRetries(n int) {
+	sc.maxRetries = n
 }
 
-// Events returns the event channel
+// SetPollInterval enables the polling fallback: while WatchEvents is
+// unavailable, nodes are refreshed with ListNodes at this interval and the
+// stream is retried without limit. 0 disables the fallback.
+func (sc *StreamConsumer) SetPollInterval(d time.Duration) {
+	sc.pollInterval = d
+}
+
+// SetStatsInterval sets how often GetServerStats is polled for the watcher
+// count shown in snapshots. 0 disables it. It must be called before Start.
+func (sc *StreamConsumer) SetStatsInterval(d time.Duration) {
+	sc.statsInterval = d
+}
+
+// SetQueueSize sets how many events may wait for the worker before new ones
+// are dropped. It must be called before Start.
+func (sc *StreamConsumer) SetQueueSize(n int) {
+	if n > 0 {
+		sc.queue = make(chan *Event, n)
+	}
+}
+
+// SetEventHandler replaces the function the worker applies each event with,
+// by default the aggregator's HandleEvent. It must be called before Start.
+func (sc *StreamConsumer) SetEventHandler(h func(*Event)) {
+	sc.handler = h
+}
+
+// Dropped returns how many events were discarded because the queue was full
+func (sc *StreamConsumer) Dropped() uint64 {
+	return sc.dropped.Load()
+}
+
+// Events returns the event channel. Events are sent on it after they are
+// applied; a reader that falls behind misses events instead of stalling the
+// worker.
 func (sc *StreamConsumer) Events() <-chan *Event {
 	return sc.eventChan
 }
@@ -83,31 +211,137 @@ func (sc *StreamConsumer) Errors() <-chan error {
 	return sc.errorChan
 }
 
+// Status returns the reconnect status channel
+func (sc *StreamConsumer) Status() <-chan ReconnectStatus {
+	return sc.statusChan
+}
+
+// State returns the connection state. It is Connected once the initial state
+// is loaded and again only after a reconnect has resynced what the outage
+// missed.
+func (sc *StreamConsumer) State() ConnState {
+	return ConnState(sc.state.Load())
+}
+
+func (sc *StreamConsumer) setState(s ConnState) {
+	sc.state.Store(int32(s))
+}
+
+// reportStatus publishes a reconnect status, dropping it if nobody is listening
+func (sc *StreamConsumer) reportStatus(s ReconnectStatus) {
+	select {
+	case sc.statusChan <- s:
+	default:
+	}
+}
+
 // loadInitialState loads all current nodes
 func (sc *StreamConsumer) loadInitialState(ctx context.Context) error {
 	logging.Debug("Calling ListNodes to load initial state...")
-	resp, err := sc.client.ListNodes(ctx, &nodev1.ListNodesRequest{
-		PageSize: 1000,
-	})
+	nodes, err := sc.listAllNodes(ctx)
 	if err != nil {
 		logging.Error("ListNodes failed: %v", err)
 		return err
 	}
-	logging.Debug("ListNodes returned %d nodes", len(resp.Nodes))
-
-	nodes := make([]*Node, 0, len(resp.Nodes))
-	for _, n := range resp.Nodes {
-		nodes = append(nodes, convertNode(n))
-	}
 
 	logging.Debug("Setting %d nodes in aggregator", len(nodes))
 	sc.aggregator.SetNodes(nodes)
 	return nil
 }
 
+// listAllNodes pages through ListNodes
+func (sc *StreamConsumer) listAllNodes(ctx context.Context) ([]*Node, error) {
+	var nodes []*Node
+	pageToken := ""
+	for {
+		resp, err := sc.client.ListNodes(ctx, &nodev1.ListNodesRequest{
+			PageSize:  1000,
+			PageToken: pageToken,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range resp.Nodes {
+			nodes = append(nodes, convertNode(n))
+		}
+
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+	logging.Debug("ListNodes returned %d nodes", len(nodes))
+	return nodes, nil
+}
+
+// startPolling refreshes the aggregator from ListNodes until stopPolling is
+// called. It is a no-op when the fallback is disabled or already running.
+func (sc *StreamConsumer) startPolling() {
+	if sc.pollInterval <= 0 || sc.pollCancel != nil {
+		return
+	}
+	logging.Info("Event stream unavailable, polling ListNodes every %v", sc.pollInterval)
+
+	ctx, cancel := context.WithCancel(sc.ctx)
+	done := make(chan struct{})
+	sc.pollCancel = cancel
+	sc.pollDone = done
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(sc.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			if nodes, err := sc.listAllNodes(ctx); err != nil {
+				logging.Error("Polling ListNodes failed: %v", err)
+			} else if ctx.Err() == nil {
+				sc.aggregator.SetNodes(nodes)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// stopPolling stops the polling fallback and waits for an in-flight refresh,
+// so it cannot overwrite state loaded after the stream recovered
+func (sc *StreamConsumer) stopPolling() {
+	if sc.pollCancel == nil {
+		return
+	}
+	sc.pollCancel()
+	<-sc.pollDone
+	sc.pollCancel = nil
+	sc.pollDone = nil
+}
+
+// polling reports the poll interval while the fallback is running
+func (sc *StreamConsumer) polling() time.Duration {
+	if sc.pollCancel == nil {
+		return 0
+	}
+	return sc.pollInterval
+}
+
 // consumeLoop continuously consumes events with reconnection
 func (sc *StreamConsumer) consumeLoop(ctx context.Context) {
 	logging.Debug("ConsumeLoop goroutine started")
+	defer close(sc.loopDone)
+	defer sc.stopPolling()
+	defer sc.setState(StateDisconnected)
+
+	// Stop must also interrupt calls made with the caller's context
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stopAfter := context.AfterFunc(sc.ctx, cancel)
+	defer stopAfter()
+
 	retries := 0
 
 	for {
@@ -120,15 +354,33 @@ func (sc *StreamConsumer) consumeLoop(ctx context.Context) {
 
 		// Try to establish stream
 		logging.Debug("ConsumeLoop: Attempting to establish WatchEvents stream...")
-		stream, err := sc.client.WatchEvents(ctx, &nodev1.WatchEventsRequest{})
+		// Resume after the last event so nothing from the outage is lost
+		stream, err := sc.client.WatchEvents(ctx, &nodev1.WatchEventsRequest{FromEventId: sc.lastEventID})
 		if err != nil {
 			logging.Error("ConsumeLoop: Failed to establish stream: %v", err)
-			sc.handleStreamError(err, &retries)
+			if !sc.handleStreamError(err, &retries) {
+				logging.Error("ConsumeLoop: Giving up on the event stream")
+				return
+			}
 			continue
 		}
 		logging.Debug("ConsumeLoop: Stream established successfully")
 
-		// Reset retries on successful connection
+		// Leave the polling fallback and resync what it may have missed
+		if sc.polling() > 0 {
+			logging.Info("Event stream recovered, stopped polling")
+			sc.stopPolling()
+			if err := sc.loadInitialState(ctx); err != nil {
+				logging.Error("ConsumeLoop: Failed to resync after polling: %v", err)
+			}
+		}
+
+		// Reset retries on successful connection. Resuming from lastEventID
+		// replays what the outage missed, so the data is current again.
+		sc.setState(StateConnected)
+		if retries > 0 {
+			sc.reportStatus(ReconnectStatus{Connected: true})
+		}
 		retries = 0
 		eventCount := 0
 
@@ -152,41 +404,108 @@ func (sc *StreamConsumer) consumeLoop(ctx context.Context) {
 				break
 			}
 
+			// Heartbeats only keep an idle stream open
+			if resp.Heartbeat {
+				continue
+			}
+
+			// The server is shutting down and ends the stream next; the
+			// EOF that follows reconnects from its position
+			if resp.ServerClosing {
+				logging.Info("ConsumeLoop: Server closing the stream, will reconnect")
+				if resp.EventId != "" {
+					sc.lastEventID = resp.EventId
+				}
+				continue
+			}
+
 			eventCount++
 			if eventCount == 1 {
 				logging.Debug("ConsumeLoop: First event received")
-			} else if eventCount % 10 == 0 {
+			} else if eventCount%10 == 0 {
 				logging.Debug("ConsumeLoop: Received %d events", eventCount)
 			}
 
 			// Convert and send event
 			event := &Event{
+				ID:            resp.EventId,
 				Type:          resp.EventType,
 				Node:          convertNode(resp.Node),
 				ChangedFields: resp.ChangedFields,
+				Changes:       convertChanges(resp.Changes),
 				Timestamp:     time.Now(),
 			}
 
-			select {
-			case sc.eventChan <- event:
-			case <-sc.ctx.Done():
-				logging.Info("ConsumeLoop: Context done while sending event, exiting")
-				return
+			sc.enqueue(event)
+			if resp.EventId != "" {
+				sc.lastEventID = resp.EventId
 			}
 		}
 	}
 }
 
-// processEvents processes events from the channel
+// statsLoop feeds the server's watcher count to the aggregator until Stop. A
+// server without GetServerStats ends the loop.
+func (sc *StreamConsumer) statsLoop() {
+	defer close(sc.statsDone)
+
+	ticker := time.NewTicker(sc.statsInterval)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(sc.ctx, sc.statsInterval)
+		stats, err := sc.client.GetServerStats(ctx, &nodev1.GetServerStatsRequest{})
+		cancel()
+		switch {
+		case status.Code(err) == codes.Unimplemented:
+			logging.Info("Server does not report stats, watcher count disabled")
+			return
+		case err != nil:
+			logging.Debug("GetServerStats failed: %v", err)
+		default:
+			sc.aggregator.SetConnectedWatchers(int(stats.ConnectedWatchers))
+		}
+
+		select {
+		case <-ticker.C:
+		case <-sc.ctx.Done():
+			return
+		}
+	}
+}
+
+// enqueue hands an event to the worker, dropping it if the queue is full
+func (sc *StreamConsumer) enqueue(event *Event) {
+	select {
+	case sc.queue <- event:
+	default:
+		if n := sc.dropped.Add(1); n == 1 || n%1000 == 0 {
+			logging.Warn("Event queue full, dropped %d events so far", n)
+		}
+	}
+}
+
+// startWorker starts the single goroutine applying queued events
+func (sc *StreamConsumer) startWorker() {
+	sc.workerDone = make(chan struct{})
+	go sc.processEvents()
+}
+
+// processEvents applies queued events in order and passes them on to Events
 func (sc *StreamConsumer) processEvents() {
+	defer close(sc.workerDone)
 	for {
 		select {
-		case event, ok := <-sc.eventChan:
-			if !ok {
-				return
+		case event := <-sc.queue:
+			if event == nil {
+				continue
 			}
-			if event != nil {
-				sc.aggregator.HandleEvent(event)
+			if sc.handler != nil {
+				sc.handler(event)
+			}
+			select {
+			case sc.eventChan <- event:
+			default:
 			}
 		case <-sc.ctx.Done():
 			return
@@ -194,8 +513,16 @@ func (sc *StreamConsumer) processEvents() {
 	}
 }
 
-// handleStreamError handles stream errors with exponential backoff
+// handleStreamError handles stream errors with exponential backoff. It
+// reports whether the stream should be retried.
 func (sc *StreamConsumer) handleStreamError(err error, retries *int) bool {
+	// With the polling fallback any error is survivable: keep the data
+	// fresh through ListNodes and retry the stream until it comes back
+	if sc.pollInterval > 0 {
+		sc.startPolling()
+		return sc.waitForRetry(retries, 0)
+	}
+
 	st, ok := status.FromError(err)
 	if !ok {
 		// Not a gRPC error
@@ -218,16 +545,7 @@ func (sc *StreamConsumer) handleStreamError(err error, retries *int) bool {
 			return false
 		}
 
-		// Calculate backoff with jitter
-		delay := sc.calculateBackoff(*retries)
-		*retries++
-
-		select {
-		case <-time.After(delay):
-		case <-sc.ctx.Done():
-			return false
-		}
-		return true
+		return sc.waitForRetry(retries, sc.maxRetries)
 
 	default:
 		// Non-retryable error
@@ -239,11 +557,36 @@ func (sc *StreamConsumer) handleStreamError(err error, retries *int) bool {
 	}
 }
 
+// waitForRetry reports the next attempt and sleeps through its backoff. It
+// returns false if the consumer is stopped meanwhile.
+func (sc *StreamConsumer) waitForRetry(retries *int, maxAttempts int) bool {
+	// Calculate backoff with jitter
+	delay := sc.calculateBackoff(*retries)
+	*retries++
+	sc.setState(StateReconnecting)
+	sc.reportStatus(ReconnectStatus{
+		Attempt:      *retries,
+		MaxAttempts:  maxAttempts,
+		Backoff:      delay,
+		NextRetry:    time.Now().Add(delay),
+		PollInterval: sc.polling(),
+	})
+
+	select {
+	case <-time.After(delay):
+	case <-sc.ctx.Done():
+		return false
+	}
+	return true
+}
+
 // calculateBackoff calculates exponential backoff with jitter
 func (sc *StreamConsumer) calculateBackoff(retry int) time.Duration {
-	delay := sc.baseDelay * (1 << uint(retry))
-	if delay > sc.maxDelay {
-		delay = sc.maxDelay
+	// Shifting past ~30 would overflow, which matters once retries are
+	// unlimited under the polling fallback
+	delay := sc.maxDelay
+	if retry < 30 && sc.baseDelay<<uint(retry) < delay {
+		delay = sc.baseDelay << uint(retry)
 	}
 
 	// Add jitter (±25%)
@@ -273,33 +616,98 @@ func convertNode(n *nodev1.Node) *Node {
 		Name:     n.Name,
 		Type:     n.Type,
 		Status:   n.Status,
-		Labels:   n.Labels,
+		Reason:   n.StatusReason,
+		Desired:  n.DesiredStatus,
+		Labels:   copyLabels(n.Labels),
 		Metadata: n.MetadataJson,
 		LastSeen: lastSeen,
 	}
 }
 
+// convertChanges converts the before and after values of an event
+func convertChanges(changes []*nodev1.FieldChange) []FieldChange {
+	if len(changes) == 0 {
+		return nil
+	}
+	out := make([]FieldChange, len(changes))
+	for i, c := range changes {
+		out[i] = FieldChange{Field: c.Field, Old: c.OldValue, New: c.NewValue}
+	}
+	return out
+}
+
+// MockOptions shapes the load a MockStreamConsumer generates. One event is
+// generated per tick; the probabilities are relative weights of its type.
+type MockOptions struct {
+	InitialNodes int
+	TickInterval time.Duration
+	CreateProb   float64
+	UpdateProb   float64
+	DeleteProb   float64
+}
+
+// DefaultMockOptions returns the load of NewMockStreamConsumer: 10 nodes and
+// an event every 500ms, mostly updates
+func DefaultMockOptions() MockOptions {
+	return MockOptions{
+		InitialNodes: 10,
+		TickInterval: 500 * time.Millisecond,
+		CreateProb:   0.2,
+		UpdateProb:   0.6,
+		DeleteProb:   0.2,
+	}
+}
+
 // MockStreamConsumer for testing without gRPC connection
 type MockStreamConsumer struct {
 	*StreamConsumer
-	ticker *time.Ticker
+	opts    MockOptions
+	ticker  *time.Ticker
+	started bool
+	done    chan struct{} // Closed when the event generator exits
 }
 
 // NewMockStreamConsumer creates a mock stream consumer for testing
 func NewMockStreamConsumer(aggregator *Aggregator) *MockStreamConsumer {
+	return NewMockStreamConsumerWithOptions(aggregator, DefaultMockOptions())
+}
+
+// NewMockStreamConsumerWithOptions creates a mock stream consumer generating
+// the load described by opts. A non-positive TickInterval uses the default,
+// and so does an event mix whose weights are all zero.
+func NewMockStreamConsumerWithOptions(aggregator *Aggregator, opts MockOptions) *MockStreamConsumer {
 	logging.Debug("Creating MockStreamConsumer")
+	defaults := DefaultMockOptions()
+	if opts.TickInterval <= 0 {
+		opts.TickInterval = defaults.TickInterval
+	}
+	if opts.InitialNodes < 0 {
+		opts.InitialNodes = 0
+	}
+	opts.CreateProb = math.Max(opts.CreateProb, 0)
+	opts.UpdateProb = math.Max(opts.UpdateProb, 0)
+	opts.DeleteProb = math.Max(opts.DeleteProb, 0)
+	if opts.CreateProb+opts.UpdateProb+opts.DeleteProb == 0 {
+		opts.CreateProb, opts.UpdateProb, opts.DeleteProb = defaults.CreateProb, defaults.UpdateProb, defaults.DeleteProb
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	sc := &StreamConsumer{
 		aggregator: aggregator,
+		queue:      make(chan *Event, DefaultEventQueueSize),
+		handler:    aggregator.HandleEvent,
 		eventChan:  make(chan *Event, 100),
 		errorChan:  make(chan error, 10),
+		statusChan: make(chan ReconnectStatus, 10),
 		ctx:        ctx,
 		cancel:     cancel,
 	}
 
 	return &MockStreamConsumer{
 		StreamConsumer: sc,
-		ticker:         time.NewTicker(500 * time.Millisecond),
+		opts:           opts,
+		ticker:         time.NewTicker(opts.TickInterval),
+		done:           make(chan struct{}),
 	}
 }
 
@@ -308,8 +716,8 @@ func (msc *MockStreamConsumer) Start(ctx context.Context) error {
 	logging.Debug("MockStreamConsumer.Start called")
 
 	// Generate initial nodes
-	nodes := make([]*Node, 0, 10)
-	for i := 0; i < 10; i++ {
+	nodes := make([]*Node, 0, msc.opts.InitialNodes)
+	for i := 0; i < msc.opts.InitialNodes; i++ {
 		nodes = append(nodes, &Node{
 			ID:       fmt.Sprintf("node-%d", i),
 			Name:     fmt.Sprintf("test-node-%d", i),
@@ -323,8 +731,11 @@ func (msc *MockStreamConsumer) Start(ctx context.Context) error {
 	logging.Debug("MockStreamConsumer: Setting %d initial nodes", len(nodes))
 	msc.aggregator.SetNodes(nodes)
 
-	// Start generating events (DO NOT call processEvents for mock, let app.go handle it)
+	// Generated events go through the same queue and worker as real ones
 	logging.Debug("MockStreamConsumer: Starting event generator goroutine")
+	msc.started = true
+	msc.setState(StateConnected)
+	msc.startWorker()
 	go msc.generateEvents()
 
 	logging.Debug("MockStreamConsumer.Start completed")
@@ -334,16 +745,9 @@ func (msc *MockStreamConsumer) Start(ctx context.Context) error {
 // generateEvents generates mock events
 func (msc *MockStreamConsumer) generateEvents() {
 	logging.Debug("MockStreamConsumer.generateEvents goroutine started")
+	defer close(msc.done)
 
-	eventTypes := []nodev1.EventType{
-		nodev1.EventType_CREATED,
-		nodev1.EventType_UPDATED,
-		nodev1.EventType_UPDATED,
-		nodev1.EventType_UPDATED,
-		nodev1.EventType_DELETED,
-	}
-
-	nodeID := 10
+	nodeID := msc.opts.InitialNodes
 	eventCount := 0
 
 	for {
@@ -352,7 +756,7 @@ func (msc *MockStreamConsumer) generateEvents() {
 			eventCount++
 			logging.Debug("MockStreamConsumer: Ticker fired, generating event #%d", eventCount)
 			// Generate random event
-			eventType := eventTypes[rand.Intn(len(eventTypes))]
+			eventType := msc.nextEventType()
 			logging.Debug("MockStreamConsumer: Event type will be %v", eventType)
 
 			var event *Event
@@ -379,14 +783,19 @@ func (msc *MockStreamConsumer) generateEvents() {
 				logging.Debug("MockStreamConsumer: Got %d nodes", len(nodes))
 				if len(nodes) > 0 {
 					node := nodes[rand.Intn(len(nodes))]
+					before := node.Clone()
+					oldStatus := node.Status
 					node.Status = nodev1.NodeStatus(rand.Intn(4) + 1)
 					node.LastSeen = time.Now()
 					event = &Event{
 						Type:          eventType,
 						Node:          node,
-						ChangedFields: []string{"status", "last_seen"},
+						ChangedFields: DiffNodes(before, node),
 						Timestamp:     time.Now(),
 					}
+					if node.Status != oldStatus {
+						event.Changes = []FieldChange{{Field: "status", Old: oldStatus.String(), New: node.Status.String()}}
+					}
 				}
 
 			case nodev1.EventType_DELETED:
@@ -405,15 +814,8 @@ func (msc *MockStreamConsumer) generateEvents() {
 			}
 
 			if event != nil {
-				logging.Debug("MockStreamConsumer: Sending event type=%v to channel", event.Type)
-				select {
-				case msc.eventChan <- event:
-					// Do NOT call HandleEvent here - let app.go's processEventsBackground do it
-					logging.Debug("MockStreamConsumer: Event sent to channel")
-				case <-msc.ctx.Done():
-					logging.Info("MockStreamConsumer: Context cancelled, exiting generateEvents")
-					return
-				}
+				logging.Debug("MockStreamConsumer: Queueing event type=%v", event.Type)
+				msc.enqueue(event)
 			}
 
 		case <-msc.ctx.Done():
@@ -423,8 +825,27 @@ func (msc *MockStreamConsumer) generateEvents() {
 	}
 }
 
+// nextEventType draws an event type from the configured mix
+func (msc *MockStreamConsumer) nextEventType() nodev1.EventType {
+	o := msc.opts
+	r := rand.Float64() * (o.CreateProb + o.UpdateProb + o.DeleteProb)
+	switch {
+	case r < o.CreateProb:
+		return nodev1.EventType_CREATED
+	case r < o.CreateProb+o.UpdateProb:
+		return nodev1.EventType_UPDATED
+	default:
+		return nodev1.EventType_DELETED
+	}
+}
+
 // Stop stops the mock stream consumer
 func (msc *MockStreamConsumer) Stop() {
 	msc.ticker.Stop()
+	// Wait for the generator before closing the channels it sends on
+	msc.cancel()
+	if msc.started {
+		<-msc.done
+	}
 	msc.StreamConsumer.Stop()
 }
\ No newline at end of file
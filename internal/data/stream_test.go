@@ -0,0 +1,205 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStreamConsumerDropsWhenQueueFull(t *testing.T) {
+	agg := NewAggregator(10)
+	defer agg.Close()
+
+	sc := NewStreamConsumer(nil, agg)
+	sc.SetQueueSize(3)
+
+	var applied []string
+	done := make(chan struct{})
+	sc.SetEventHandler(func(e *Event) {
+		applied = append(applied, e.Node.ID)
+		if len(applied) == 3 {
+			close(done)
+		}
+	})
+
+	// Nothing drains the queue until the worker starts
+	for i := 0; i < 5; i++ {
+		sc.enqueue(&Event{
+			Type: nodev1.EventType_CREATED,
+			Node: &Node{ID: fmt.Sprintf("node-%d", i)},
+		})
+	}
+	if got := sc.Dropped(); got != 2 {
+		t.Fatalf("expected 2 dropped events, got %d", got)
+	}
+
+	sc.startWorker()
+	defer sc.Stop()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not apply the queued events")
+	}
+	want := []string{"node-0", "node-1", "node-2"}
+	for i, id := range want {
+		if applied[i] != id {
+			t.Fatalf("events applied out of order: %v", applied)
+		}
+	}
+
+	// Applied events are passed on to Events
+	for _, id := range want {
+		select {
+		case e := <-sc.Events():
+			if e.Node.ID != id {
+				t.Fatalf("expected %s on Events, got %s", id, e.Node.ID)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected %s on Events", id)
+		}
+	}
+}
+
+func TestMockStreamConsumerWithoutDeletesKeepsNodeSet(t *testing.T) {
+	agg := NewAggregator(10)
+	defer agg.Close()
+
+	consumer := NewMockStreamConsumerWithOptions(agg, MockOptions{
+		InitialNodes: 5,
+		TickInterval: time.Millisecond,
+		UpdateProb:   1,
+	})
+	if err := consumer.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	consumer.Stop()
+
+	snap := agg.Snapshot()
+	if snap.TotalEvents == 0 {
+		t.Fatal("expected the mock consumer to produce events")
+	}
+	if got := len(agg.GetNodes()); got != 5 {
+		t.Errorf("updates only should keep the 5 initial nodes, got %d", got)
+	}
+}
+
+func TestMockOptionsDefaults(t *testing.T) {
+	agg := NewAggregator(10)
+	defer agg.Close()
+
+	consumer := NewMockStreamConsumerWithOptions(agg, MockOptions{InitialNodes: -1})
+	defer consumer.Stop()
+
+	want := DefaultMockOptions()
+	want.InitialNodes = 0
+	if consumer.opts != want {
+		t.Errorf("expected %+v, got %+v", want, consumer.opts)
+	}
+
+	consumer.opts = MockOptions{CreateProb: 1}
+	for i := 0; i < 100; i++ {
+		if got := consumer.nextEventType(); got != nodev1.EventType_CREATED {
+			t.Fatalf("creates only mix produced %v", got)
+		}
+	}
+}
+
+// statsClient serves GetServerStats; other methods are not used
+type statsClient struct {
+	nodev1.NodeServiceClient
+	watchers int32
+}
+
+func (c *statsClient) GetServerStats(ctx context.Context, in *nodev1.GetServerStatsRequest, opts ...grpc.CallOption) (*nodev1.GetServerStatsResponse, error) {
+	return &nodev1.GetServerStatsResponse{ConnectedWatchers: c.watchers}, nil
+}
+
+func TestStreamConsumerReportsConnectedWatchers(t *testing.T) {
+	agg := NewAggregator(10)
+	defer agg.Close()
+
+	sc := NewStreamConsumer(&statsClient{watchers: 3}, agg)
+	sc.SetStatsInterval(10 * time.Millisecond)
+	sc.statsDone = make(chan struct{})
+	go sc.statsLoop()
+
+	deadline := time.Now().Add(time.Second)
+	for agg.Snapshot().ConnectedWatchers != 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 3 watchers in the snapshot, got %d", agg.Snapshot().ConnectedWatchers)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	sc.Stop()
+}
+
+// flakyWatchClient fails WatchEvents with Unavailable until up is set, then
+// serves a stream that stays open until the call is cancelled
+type flakyWatchClient struct {
+	nodev1.NodeServiceClient
+	up atomic.Bool
+}
+
+func (c *flakyWatchClient) WatchEvents(ctx context.Context, in *nodev1.WatchEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[nodev1.WatchEventsResponse], error) {
+	if !c.up.Load() {
+		return nil, status.Error(codes.Unavailable, "backend restarting")
+	}
+	return &idleStream{ctx: ctx}, nil
+}
+
+type idleStream struct {
+	grpc.ClientStream
+	ctx context.Context
+}
+
+func (s *idleStream) Recv() (*nodev1.WatchEventsResponse, error) {
+	<-s.ctx.Done()
+	return nil, status.FromContextError(s.ctx.Err()).Err()
+}
+
+func TestStreamConsumerStateFollowsReconnects(t *testing.T) {
+	agg := NewAggregator(10)
+	defer agg.Close()
+
+	client := &flakyWatchClient{}
+	sc := NewStreamConsumer(client, agg)
+	sc.baseDelay = time.Millisecond
+	sc.maxDelay = 5 * time.Millisecond
+	sc.maxRetries = 1000
+	if got := sc.State(); got != StateDisconnected {
+		t.Fatalf("expected Disconnected before Start, got %v", got)
+	}
+
+	waitForState := func(want ConnState) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for sc.State() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %v, got %v", want, sc.State())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	sc.loopDone = make(chan struct{})
+	go sc.consumeLoop(context.Background())
+	waitForState(StateReconnecting)
+
+	client.up.Store(true)
+	waitForState(StateConnected)
+
+	sc.Stop()
+	if got := sc.State(); got != StateDisconnected {
+		t.Errorf("expected Disconnected after Stop, got %v", got)
+	}
+}
\ No newline at end of file
@@ -1,6 +1,8 @@
 package data
 
 import (
+	"math"
+	"slices"
 	"sync"
 	"time"
 
@@ -19,13 +21,26 @@ type MetricsSnapshot struct {
 	TypeCounts map[nodev1.NodeType]int
 	TypeRatios map[nodev1.NodeType]float64
 
-	// Time series (per-second buckets)
-	StatusTimeSeries map[nodev1.NodeStatus][]int // Last N seconds
-	TimeSeriesLabels []string                     // Time labels
+	// Node counts per label value for each tracked label key, e.g.
+	// LabelDistributions["env"]["prod"]
+	LabelDistributions map[string]map[string]int
+
+	// Time series (one bucket per sample interval)
+	StatusTimeSeries map[nodev1.NodeStatus][]int // Last N samples
+	TypeTimeSeries   map[nodev1.NodeType][]int   // Last N samples
+	TimeSeriesLabels []string                     // Time labels, one per sample
+
+	// Event and mutation counts per sample interval over the window
+	EventRateSeries    []int
+	MutationRateSeries []int
+	SampleInterval     time.Duration // Time covered by each series bucket
 
 	// Rates
-	EventsPerSecond float64
-	MutationRate    float64 // Creates + Updates + Deletes per second
+	EventsPerSecond    float64
+	EventsPerSecondP95 float64 // 95th percentile of per-second event counts
+	EventsPerSecondMax float64 // Highest event rate over the window, for scaling gauges
+	MutationRate       float64 // Creates + Updates + Deletes per second
+	MutationRateMax    float64 // Highest mutation rate over the window
 
 	// Totals
 	TotalNodes       int
@@ -39,38 +54,99 @@ type Node struct {
 	Name     string
 	Type     nodev1.NodeType
 	Status   nodev1.NodeStatus
+	Reason   string            // Why the node has its status, empty when none was given
+	Desired  nodev1.NodeStatus // Desired status, unspecified when the node has none
 	Labels   map[string]string
 	Metadata string
 	LastSeen time.Time
 }
 
+// Clone returns a deep copy of the node, so the copy can be read while the
+// original is mutated on another goroutine
+func (n *Node) Clone() *Node {
+	clone := *n
+	clone.Labels = copyLabels(n.Labels)
+	return &clone
+}
+
+// copyLabels copies a label map, preserving nil
+func copyLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(labels))
+	for k, v := range labels {
+		copied[k] = v
+	}
+	return copied
+}
+
+// Drifted reports whether the node has a desired status it is not currently in
+func (n *Node) Drifted() bool {
+	return StatusDrifted(n.Status, n.Desired)
+}
+
+// StatusDrifted reports whether a node in status has drifted from desired. A
+// node without a desired status never drifts. It is the one definition of
+// drift, shared by the store's GetDrift and the dashboard.
+func StatusDrifted(status, desired nodev1.NodeStatus) bool {
+	return desired != nodev1.NodeStatus_NODE_STATUS_UNSPECIFIED && desired != status
+}
+
 // Event represents a change event
 type Event struct {
+	ID            string // Position in the server's event log, empty for mock events
 	Type          nodev1.EventType
 	Node          *Node
 	ChangedFields []string
+	Changes       []FieldChange // Old and new values, when the server sends them
 	Timestamp     time.Time
 }
 
+// FieldChange is the value of one field before and after an update. Enums
+// are given by name and labels per key, as labels.<key>.
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// Change returns the change of field, if the event carries one
+func (e *Event) Change(field string) (FieldChange, bool) {
+	for _, c := range e.Changes {
+		if c.Field == field {
+			return c, true
+		}
+	}
+	return FieldChange{}, false
+}
+
+// Number is the set of value types a RingBuffer can hold
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
 // RingBuffer is a circular buffer for storing time-series data
-type RingBuffer struct {
+type RingBuffer[T Number] struct {
 	mu       sync.RWMutex
-	data     []int
+	data     []T
 	capacity int
 	head     int
 	size     int
 }
 
 // NewRingBuffer creates a new ring buffer
-func NewRingBuffer(capacity int) *RingBuffer {
-	return &RingBuffer{
-		data:     make([]int, capacity),
+func NewRingBuffer[T Number](capacity int) *RingBuffer[T] {
+	return &RingBuffer[T]{
+		data:     make([]T, capacity),
 		capacity: capacity,
 	}
 }
 
 // Push adds a value to the ring buffer
-func (rb *RingBuffer) Push(value int) {
+func (rb *RingBuffer[T]) Push(value T) {
 	rb.mu.Lock()
 	defer rb.mu.Unlock()
 
@@ -82,15 +158,15 @@ func (rb *RingBuffer) Push(value int) {
 }
 
 // GetAll returns all values in chronological order
-func (rb *RingBuffer) GetAll() []int {
+func (rb *RingBuffer[T]) GetAll() []T {
 	rb.mu.RLock()
 	defer rb.mu.RUnlock()
 
 	if rb.size == 0 {
-		return []int{}
+		return []T{}
 	}
 
-	result := make([]int, rb.size)
+	result := make([]T, rb.size)
 	if rb.size < rb.capacity {
 		// Buffer not full yet
 		copy(result, rb.data[:rb.size])
@@ -104,11 +180,16 @@ func (rb *RingBuffer) GetAll() []int {
 }
 
 // Sum returns the sum of all values
-func (rb *RingBuffer) Sum() int {
+func (rb *RingBuffer[T]) Sum() T {
 	rb.mu.RLock()
 	defer rb.mu.RUnlock()
 
-	sum := 0
+	return rb.sumUnlocked()
+}
+
+// sumUnlocked sums the valid region. The caller must hold rb.mu.
+func (rb *RingBuffer[T]) sumUnlocked() T {
+	var sum T
 	for i := 0; i < rb.size; i++ {
 		sum += rb.data[i]
 	}
@@ -116,14 +197,64 @@ func (rb *RingBuffer) Sum() int {
 }
 
 // Average returns the average of all values
-func (rb *RingBuffer) Average() float64 {
+func (rb *RingBuffer[T]) Average() float64 {
 	rb.mu.RLock()
 	defer rb.mu.RUnlock()
 
 	if rb.size == 0 {
 		return 0
 	}
-	return float64(rb.Sum()) / float64(rb.size)
+	return float64(rb.sumUnlocked()) / float64(rb.size)
+}
+
+// Min returns the smallest value, or 0 when the buffer is empty
+func (rb *RingBuffer[T]) Min() T {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+
+	if rb.size == 0 {
+		return 0
+	}
+	return slices.Min(rb.data[:rb.size])
+}
+
+// Max returns the largest value, or 0 when the buffer is empty
+func (rb *RingBuffer[T]) Max() T {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+
+	if rb.size == 0 {
+		return 0
+	}
+	return slices.Max(rb.data[:rb.size])
+}
+
+// Percentile returns the p-th percentile (0-100) of the stored values,
+// interpolating linearly between the closest ranks. Only the filled part of
+// the buffer is considered. Returns 0 when the buffer is empty.
+func (rb *RingBuffer[T]) Percentile(p float64) float64 {
+	rb.mu.RLock()
+	sorted := slices.Clone(rb.data[:rb.size])
+	rb.mu.RUnlock()
+
+	if len(sorted) == 0 {
+		return 0
+	}
+	slices.Sort(sorted)
+
+	if p <= 0 {
+		return float64(sorted[0])
+	}
+	if p >= 100 {
+		return float64(sorted[len(sorted)-1])
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	frac := rank - float64(lower)
+	// Interpolated in float64 so unsigned values can't wrap around
+	return float64(sorted[lower]) + frac*(float64(sorted[upper])-float64(sorted[lower]))
 }
 
 // SnapshotProvider provides metrics snapshots
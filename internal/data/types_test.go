@@ -0,0 +1,124 @@
+package data
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRingBufferMinMaxEmpty(t *testing.T) {
+	rb := NewRingBuffer[int](5)
+	if rb.Min() != 0 || rb.Max() != 0 {
+		t.Fatalf("expected 0/0 for empty buffer, got %d/%d", rb.Min(), rb.Max())
+	}
+	if p := rb.Percentile(95); p != 0 {
+		t.Fatalf("expected p95 0 for empty buffer, got %v", p)
+	}
+}
+
+func TestRingBufferPercentilePartiallyFilled(t *testing.T) {
+	rb := NewRingBuffer[int](10)
+	for _, v := range []int{5, 1, 3} {
+		rb.Push(v)
+	}
+
+	// The unused slots hold zeros and must not pull the stats down
+	if rb.Min() != 1 {
+		t.Errorf("expected min 1, got %d", rb.Min())
+	}
+	if rb.Max() != 5 {
+		t.Errorf("expected max 5, got %d", rb.Max())
+	}
+
+	tests := []struct {
+		p    float64
+		want float64
+	}{
+		{0, 1},
+		{50, 3},
+		{75, 4},
+		{95, 4.8},
+		{100, 5},
+	}
+	for _, tt := range tests {
+		if got := rb.Percentile(tt.p); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("p%v: expected %v, got %v", tt.p, tt.want, got)
+		}
+	}
+}
+
+func TestRingBufferStatsAfterWrap(t *testing.T) {
+	rb := NewRingBuffer[int](4)
+	for _, v := range []int{100, 0, 2, 4, 6, 8} {
+		rb.Push(v)
+	}
+
+	// Only the last four values remain: 2, 4, 6, 8
+	if rb.Min() != 2 {
+		t.Errorf("expected min 2, got %d", rb.Min())
+	}
+	if rb.Max() != 8 {
+		t.Errorf("expected max 8, got %d", rb.Max())
+	}
+	if got := rb.Percentile(50); got != 5 {
+		t.Errorf("expected p50 5, got %v", got)
+	}
+	assertSeries(t, "buffer", []int{2, 4, 6, 8}, rb.GetAll())
+}
+
+func TestSnapshotIncludesEventRateP95(t *testing.T) {
+	agg := NewAggregator(10)
+	agg.Close()
+
+	agg.SetNodes([]*Node{{ID: "a"}})
+
+	// The quiet seconds drag the average down; the single burst
+	// shows up in p95
+	for _, burst := range []int{0, 0, 0, 20} {
+		for i := 0; i < burst; i++ {
+			agg.HandleEvent(&Event{Node: &Node{ID: "a"}})
+		}
+		agg.sample()
+	}
+
+	snap := agg.Snapshot()
+	if snap.EventsPerSecond != 5 {
+		t.Errorf("expected average 5, got %v", snap.EventsPerSecond)
+	}
+	if math.Abs(snap.EventsPerSecondP95-17) > 1e-9 {
+		t.Errorf("expected p95 17, got %v", snap.EventsPerSecondP95)
+	}
+	if snap.EventsPerSecondMax != 20 {
+		t.Errorf("expected peak 20, got %v", snap.EventsPerSecondMax)
+	}
+}
+
+func TestRingBufferFloat(t *testing.T) {
+	rb := NewRingBuffer[float64](3)
+	for _, v := range []float64{0.5, 2.25, 1.75, 0.25} {
+		rb.Push(v)
+	}
+
+	// Fractions survive: 2.25, 1.75 and 0.25 remain
+	if got := rb.Sum(); got != 4.25 {
+		t.Errorf("expected sum 4.25, got %v", got)
+	}
+	if got := rb.Min(); got != 0.25 {
+		t.Errorf("expected min 0.25, got %v", got)
+	}
+	if got := rb.Max(); got != 2.25 {
+		t.Errorf("expected max 2.25, got %v", got)
+	}
+	if got := rb.Percentile(50); got != 1.75 {
+		t.Errorf("expected p50 1.75, got %v", got)
+	}
+}
+
+func TestRingBufferUnsignedPercentile(t *testing.T) {
+	rb := NewRingBuffer[uint](4)
+	for _, v := range []uint{10, 2} {
+		rb.Push(v)
+	}
+	if got := rb.Percentile(50); got != 6 {
+		t.Errorf("expected p50 6, got %v", got)
+	}
+}
\ No newline at end of file
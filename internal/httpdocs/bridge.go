@@ -0,0 +1,145 @@
+package httpdocs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// The bridge lets clients behind proxies that block HTTP/2 call the unary
+// NodeService methods as plain HTTP/1.1 JSON:
+//
+//	POST /rpc/node.v1.NodeService/GetNode
+//	{"id": "..."}
+//
+// Requests and responses use the protobuf JSON mapping. Calls go through the
+// generated gRPC handlers and the same unary interceptors as the gRPC server,
+// so mutations need the same "Authorization: Bearer <token>" header and
+// callers share the same rate limits.
+const bridgePrefix = "/rpc/node.v1.NodeService"
+
+// httpStatusByCode maps gRPC codes to the closest HTTP status
+var httpStatusByCode = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           499,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusPreconditionFailed,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+}
+
+// EnableRPCBridge serves the unary NodeService methods as JSON over HTTP/1.1.
+// Streaming methods such as WatchEvents are not bridged. Pass the gRPC
+// server's unary interceptors, e.g. auth then rate limiting, so bridged calls
+// follow the same policy; they run in the order given.
+func (s *Server) EnableRPCBridge(svc nodev1.NodeServiceServer, interceptors ...grpc.UnaryServerInterceptor) {
+	methods := make(map[string]grpc.MethodDesc)
+	for _, m := range nodev1.NodeService_ServiceDesc.Methods {
+		methods[m.MethodName] = m
+	}
+	interceptor := chainUnary(interceptors)
+
+	s.engine.POST(bridgePrefix+"/:method", func(c *gin.Context) {
+		desc, ok := methods[c.Param("method")]
+		if !ok {
+			writeBridgeError(c, status.Errorf(codes.Unimplemented, "unknown method %q", c.Param("method")))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			writeBridgeError(c, status.Errorf(codes.InvalidArgument, "failed to read body: %v", err))
+			return
+		}
+		if len(strings.TrimSpace(string(body))) == 0 {
+			body = []byte("{}")
+		}
+		dec := func(req interface{}) error {
+			if err := protojson.Unmarshal(body, req.(proto.Message)); err != nil {
+				return status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+			}
+			return nil
+		}
+
+		resp, err := desc.Handler(svc, bridgeContext(c), dec, interceptor)
+		if err != nil {
+			writeBridgeError(c, err)
+			return
+		}
+
+		out, err := protojson.Marshal(resp.(proto.Message))
+		if err != nil {
+			writeBridgeError(c, status.Errorf(codes.Internal, "failed to encode response: %v", err))
+			return
+		}
+		c.Data(http.StatusOK, "application/json", out)
+	})
+}
+
+// chainUnary combines interceptors into one, the first being the outermost,
+// the same way grpc.ChainUnaryInterceptor does for the gRPC server
+func chainUnary(interceptors []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		next := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], next
+			next = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, inner)
+			}
+		}
+		return next(ctx, req)
+	}
+}
+
+// bridgeContext carries the HTTP Authorization header as incoming gRPC
+// metadata, which is where the auth interceptor looks for it
+func bridgeContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	if authz := c.GetHeader("Authorization"); authz != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", authz))
+	}
+	return ctx
+}
+
+// writeBridgeError renders a gRPC error as {"code": "...", "message": "..."}
+func writeBridgeError(c *gin.Context, err error) {
+	st := status.Convert(err)
+	httpStatus, ok := httpStatusByCode[st.Code()]
+	if !ok {
+		httpStatus = http.StatusInternalServerError
+	}
+	c.JSON(httpStatus, gin.H{
+		"code":    codeName(st.Code()),
+		"message": st.Message(),
+	})
+}
+
+// codeName renders a gRPC code in snake case, e.g. NotFound as "not_found"
+func codeName(code codes.Code) string {
+	var b strings.Builder
+	for i, r := range code.String() {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToLower(b.String())
+}
\ No newline at end of file
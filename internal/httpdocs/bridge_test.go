@@ -0,0 +1,152 @@
+package httpdocs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/melkior/nodestatus/internal/auth"
+	"github.com/melkior/nodestatus/internal/ratelimit"
+	"github.com/melkior/nodestatus/internal/redisstore"
+	"github.com/melkior/nodestatus/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+const testToken = "test-token"
+
+// newBridgeServer bridges a NodeService behind the auth interceptor, followed
+// by any extra interceptors
+func newBridgeServer(t *testing.T, extra ...grpc.UnaryServerInterceptor) *Server {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	store, err := redisstore.New(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	s := NewServer(store)
+	interceptors := append([]grpc.UnaryServerInterceptor{
+		auth.UnaryAuthInterceptorWithOptions(testToken, auth.Options{}),
+	}, extra...)
+	s.EnableRPCBridge(service.NewNodeService(store, zap.NewNop()), interceptors...)
+	return s
+}
+
+// call POSTs a JSON body to a bridged method and decodes the JSON response
+func call(t *testing.T, s *Server, method, token, body string) (int, map[string]interface{}) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/rpc/node.v1.NodeService/"+method, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.engine.ServeHTTP(rec, req)
+
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out), rec.Body.String())
+	return rec.Code, out
+}
+
+func TestBridgeCreateGetDelete(t *testing.T) {
+	s := newBridgeServer(t)
+
+	code, out := call(t, s, "CreateNode", testToken,
+		`{"node": {"name": "web-01", "type": "VM", "status": "UP", "labels": {"env": "prod"}}}`)
+	require.Equal(t, http.StatusOK, code, out)
+	node := out["node"].(map[string]interface{})
+	id := node["id"].(string)
+	assert.NotEmpty(t, id)
+	assert.Equal(t, "web-01", node["name"])
+
+	code, out = call(t, s, "GetNode", "", `{"id": "`+id+`"}`)
+	require.Equal(t, http.StatusOK, code, out)
+	node = out["node"].(map[string]interface{})
+	assert.Equal(t, "VM", node["type"])
+	assert.Equal(t, "UP", node["status"])
+	assert.Equal(t, map[string]interface{}{"env": "prod"}, node["labels"])
+
+	code, out = call(t, s, "DeleteNode", testToken, `{"id": "`+id+`"}`)
+	require.Equal(t, http.StatusOK, code, out)
+
+	code, out = call(t, s, "GetNode", "", `{"id": "`+id+`"}`)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "not_found", out["code"])
+}
+
+func TestBridgeReusesAuth(t *testing.T) {
+	s := newBridgeServer(t)
+	body := `{"node": {"name": "web-01", "type": "VM"}}`
+
+	code, out := call(t, s, "CreateNode", "", body)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, "unauthenticated", out["code"])
+
+	code, out = call(t, s, "CreateNode", "wrong", body)
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, "permission_denied", out["code"])
+
+	// Reads stay open, as on the gRPC server
+	code, _ = call(t, s, "ListNodes", "", "")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestBridgeAppliesRateLimits(t *testing.T) {
+	limiter := ratelimit.NewLimiter(map[string]ratelimit.Limit{
+		auth.TokenAnonymous: {QPS: 0.001, Burst: 1},
+	})
+	keyFn := func(ctx context.Context) string {
+		return auth.TokenName(ctx, testToken, auth.Options{})
+	}
+	s := newBridgeServer(t, ratelimit.UnaryServerInterceptor(limiter, keyFn))
+
+	code, out := call(t, s, "ListNodes", "", "")
+	require.Equal(t, http.StatusOK, code, out)
+
+	code, out = call(t, s, "ListNodes", "", "")
+	assert.Equal(t, http.StatusTooManyRequests, code)
+	assert.Equal(t, "resource_exhausted", out["code"])
+
+	// The admin token is not limited
+	code, out = call(t, s, "ListNodes", testToken, "")
+	assert.Equal(t, http.StatusOK, code, out)
+}
+
+func TestBridgeRejectsBadRequests(t *testing.T) {
+	s := newBridgeServer(t)
+
+	code, out := call(t, s, "GetNode", "", `{"id": `)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "invalid_argument", out["code"])
+
+	code, out = call(t, s, "WatchEvents", "", "{}")
+	assert.Equal(t, http.StatusNotImplemented, code)
+	assert.Equal(t, "unimplemented", out["code"])
+}
+
+func TestReadinessReportsPoolStats(t *testing.T) {
+	s := newBridgeServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	rec := httptest.NewRecorder()
+	s.engine.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	assert.Equal(t, "ready", out["status"])
+	pool, ok := out["redis_pool"].(map[string]interface{})
+	require.True(t, ok, "missing redis_pool in %s", rec.Body.String())
+	assert.Contains(t, pool, "total_conns")
+}
\ No newline at end of file
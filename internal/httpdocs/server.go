@@ -1,8 +1,10 @@
 package httpdocs
 
 import (
+	"context"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/melkior/nodestatus/internal/redisstore"
@@ -10,6 +12,10 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
+// readinessTimeout bounds the Redis checks behind /readyz, so a stalled Redis
+// fails the probe instead of hanging it
+const readinessTimeout = 2 * time.Second
+
 type Server struct {
 	engine *gin.Engine
 	store  *redisstore.Store
@@ -47,18 +53,47 @@ func (s *Server) healthHandler(c *gin.Context) {
 	})
 }
 
+// readinessHandler reports ready when Redis answers a PING. With ?deep=true it
+// also runs a one-node list query, which tells "Redis up but slow or broken"
+// apart from "Redis down".
 func (s *Server) readinessHandler(c *gin.Context) {
-	ctx := c.Request.Context()
-	if _, err := s.store.ListNodes(ctx, 0, 0, 0, 1); err != nil {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	if err := s.store.Ping(ctx); err != nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
 			"status": "not ready",
+			"check":  "ping",
 			"error":  err.Error(),
 		})
 		return
 	}
 
+	check := "ping"
+	if c.Query("deep") == "true" {
+		check = "deep"
+		if _, err := s.store.ListNodes(ctx, 0, 0, 0, 1); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status": "not ready",
+				"check":  "list",
+				"error":  err.Error(),
+			})
+			return
+		}
+	}
+
+	stats := s.store.PoolStats()
 	c.JSON(http.StatusOK, gin.H{
 		"status": "ready",
+		"check":  check,
+		"redis_pool": gin.H{
+			"hits":        stats.Hits,
+			"misses":      stats.Misses,
+			"timeouts":    stats.Timeouts,
+			"total_conns": stats.TotalConns,
+			"idle_conns":  stats.IdleConns,
+			"stale_conns": stats.StaleConns,
+		},
 	})
 }
 
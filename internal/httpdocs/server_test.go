@@ -0,0 +1,51 @@
+package httpdocs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/melkior/nodestatus/internal/redisstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readyz fetches target and decodes the JSON response
+func readyz(t *testing.T, s *Server, target string) (int, map[string]interface{}) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	s.engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	return rec.Code, out
+}
+
+func TestReadinessChecks(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	store, err := redisstore.New(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	s := NewServer(store)
+
+	code, out := readyz(t, s, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ping", out["check"])
+
+	code, out = readyz(t, s, "/readyz?deep=true")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "deep", out["check"])
+
+	mr.SetError("LOADING Redis is loading the dataset in memory")
+	code, out = readyz(t, s, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not ready", out["status"])
+	assert.Equal(t, "ping", out["check"])
+	assert.Contains(t, out["error"], "LOADING")
+}
\ No newline at end of file
@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// Writer returns an io.Writer that logs each line written to it at level
+// through the default logger, for libraries that only take a writer, e.g.
+//
+//	grpclog.SetLoggerV2(grpclog.NewLoggerV2(logging.Writer(logging.INFO),
+//		logging.Writer(logging.WARN), logging.Writer(logging.ERROR)))
+//
+// Writes before Init are discarded.
+func Writer(level LogLevel) io.Writer {
+	return &levelWriter{level: level}
+}
+
+type levelWriter struct {
+	level LogLevel
+}
+
+func (w *levelWriter) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		if len(line) > 0 && defaultLogger != nil {
+			defaultLogger.log(w.level, "%s", line)
+		}
+	}
+	return len(p), nil
+}
+
+// SlogHandler returns a slog.Handler that writes through the default logger,
+// so libraries logging with log/slog end up in the same file and console
+// output. Attributes follow the message as key=value pairs.
+func SlogHandler() slog.Handler {
+	return &slogHandler{}
+}
+
+type slogHandler struct {
+	attrs  string // Preformatted attributes from WithAttrs
+	prefix string // Group names from WithGroup, each followed by a dot
+}
+
+// slogLevel maps a slog level to the nearest LogLevel
+func slogLevel(level slog.Level) LogLevel {
+	switch {
+	case level >= slog.LevelError:
+		return ERROR
+	case level >= slog.LevelWarn:
+		return WARN
+	case level >= slog.LevelInfo:
+		return INFO
+	default:
+		return DEBUG
+	}
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return defaultLogger != nil && slogLevel(level) >= defaultLogger.level
+}
+
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	if defaultLogger == nil {
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString(r.Message)
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(&b, h.prefix, a)
+		return true
+	})
+	defaultLogger.log(slogLevel(r.Level), "%s", b.String())
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	b.WriteString(h.attrs)
+	for _, a := range attrs {
+		appendAttr(&b, h.prefix, a)
+	}
+	return &slogHandler{attrs: b.String(), prefix: h.prefix}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+// appendAttr writes a as " key=value", flattening groups into dotted keys
+func appendAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			appendAttr(b, prefix, ga)
+		}
+		return
+	}
+
+	value := a.Value.String()
+	if strings.ContainsAny(value, " \t\n\"=") {
+		value = strconv.Quote(value)
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefix, a.Key, value)
+}
\ No newline at end of file
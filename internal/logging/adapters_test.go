@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// captureLogs points the default logger at a buffer for the test
+func captureLogs(t *testing.T, level LogLevel) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	prev := defaultLogger
+	defaultLogger = &Logger{logger: log.New(&buf, "", 0), level: level}
+	t.Cleanup(func() { defaultLogger = prev })
+	return &buf
+}
+
+func TestWriterLogsEachLine(t *testing.T) {
+	buf := captureLogs(t, DEBUG)
+
+	w := Writer(WARN)
+	w.Write([]byte("first line\nsecond %d\n"))
+
+	want := "[WARN] first line\n[WARN] second %d\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSlogHandler(t *testing.T) {
+	buf := captureLogs(t, INFO)
+
+	logger := slog.New(SlogHandler()).With("component", "grpc")
+	logger.Debug("hidden below the logger level")
+	logger.WithGroup("conn").Warn("transport closed", "addr", "127.0.0.1:50051", "reason", "server shutdown")
+	logger.Error("failed", slog.Group("req", "id", 7))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		`[WARN] transport closed component=grpc conn.addr=127.0.0.1:50051 conn.reason="server shutdown"`,
+		`[ERROR] failed component=grpc req.id=7`,
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d lines, got:\n%s", len(want), buf.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d: got %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
\ No newline at end of file
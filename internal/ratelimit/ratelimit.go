@@ -0,0 +1,134 @@
+// Package ratelimit enforces per-caller request rates on the gRPC server.
+// Each key, typically the name of the token the caller presented, gets its
+// own token bucket; calls over the limit fail with codes.ResourceExhausted,
+// which clients treat as retryable and back off from.
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Limit is a sustained rate with a burst allowance. A zero QPS means no limit.
+type Limit struct {
+	QPS   float64
+	Burst int // Bucket capacity; zero uses one second's worth of QPS
+}
+
+func (l Limit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	if l.QPS < 1 {
+		return 1
+	}
+	return l.QPS
+}
+
+// bucket is a non-blocking token bucket: unlike the simulator's TokenBucket,
+// callers over the limit are rejected instead of waiting
+type bucket struct {
+	rate       float64
+	capacity   float64
+	tokens     float64
+	lastRefill time.Time
+}
+
+func (b *bucket) allow(now time.Time) bool {
+	elapsed := now.Sub(b.lastRefill).Seconds()
+	if elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+		b.lastRefill = now
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// KeyFunc names the caller a request is accounted to
+type KeyFunc func(ctx context.Context) string
+
+// Limiter holds one bucket per key. It is safe for concurrent use.
+type Limiter struct {
+	mu      sync.Mutex
+	limits  map[string]Limit
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// NewLimiter limits each key in limits to its Limit. Keys without an entry
+// are not limited.
+func NewLimiter(limits map[string]Limit) *Limiter {
+	return &Limiter{
+		limits:  limits,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes one token from key's bucket and reports whether the call may
+// proceed
+func (l *Limiter) Allow(key string) bool {
+	limit, ok := l.limits[key]
+	if !ok || limit.QPS <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{
+			rate:       limit.QPS,
+			capacity:   limit.capacity(),
+			tokens:     limit.capacity(),
+			lastRefill: now,
+		}
+		l.buckets[key] = b
+	}
+	return b.allow(now)
+}
+
+func (l *Limiter) check(ctx context.Context, keyFn KeyFunc) error {
+	key := keyFn(ctx)
+	if l.Allow(key) {
+		return nil
+	}
+	return status.Errorf(codes.ResourceExhausted, "rate limit exceeded for %s (%g requests/s)", key, l.limits[key].QPS)
+}
+
+// UnaryServerInterceptor rejects unary calls over the caller's limit
+func UnaryServerInterceptor(l *Limiter, keyFn KeyFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := l.check(ctx, keyFn); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor rejects opening a stream over the caller's limit.
+// Messages on an established stream are not counted.
+func StreamServerInterceptor(l *Limiter, keyFn KeyFunc) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.check(ss.Context(), keyFn); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/melkior/nodestatus/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeClock lets tests move time forward by hand
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func newTestLimiter(limits map[string]Limit) (*Limiter, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	l := NewLimiter(limits)
+	l.now = clock.Now
+	return l, clock
+}
+
+func TestLimiterBurstThenRefill(t *testing.T) {
+	l, clock := newTestLimiter(map[string]Limit{
+		"reader": {QPS: 2, Burst: 5},
+	})
+
+	// The full burst is allowed at once, then calls are denied
+	for i := 0; i < 5; i++ {
+		assert.True(t, l.Allow("reader"), "call %d within burst", i)
+	}
+	assert.False(t, l.Allow("reader"))
+
+	// Half a second refills one token at 2 QPS
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	assert.True(t, l.Allow("reader"))
+	assert.False(t, l.Allow("reader"))
+
+	// A long pause refills no more than the burst
+	clock.now = clock.now.Add(time.Minute)
+	for i := 0; i < 5; i++ {
+		assert.True(t, l.Allow("reader"))
+	}
+	assert.False(t, l.Allow("reader"))
+}
+
+func TestLimiterKeysAreIndependent(t *testing.T) {
+	l, _ := newTestLimiter(map[string]Limit{
+		"anonymous": {QPS: 1},
+		"reader":    {QPS: 0},
+	})
+
+	assert.True(t, l.Allow("anonymous"))
+	assert.False(t, l.Allow("anonymous"))
+
+	// Zero QPS and unlisted keys are not limited
+	for i := 0; i < 100; i++ {
+		require.True(t, l.Allow("reader"))
+		require.True(t, l.Allow("admin"))
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	l, _ := newTestLimiter(map[string]Limit{
+		auth.TokenAnonymous: {QPS: 1, Burst: 2},
+	})
+	keyFn := func(ctx context.Context) string {
+		return auth.TokenName(ctx, "admin-token", auth.Options{})
+	}
+	interceptor := UnaryServerInterceptor(l, keyFn)
+	info := &grpc.UnaryServerInfo{FullMethod: "/node.v1.NodeService/ListNodes"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	anonymous := context.Background()
+	admin := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer admin-token"))
+
+	for i := 0; i < 2; i++ {
+		resp, err := interceptor(anonymous, nil, info, handler)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", resp)
+	}
+
+	_, err := interceptor(anonymous, nil, info, handler)
+	require.Error(t, err)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Contains(t, err.Error(), "anonymous")
+
+	// The admin token has no limit configured
+	_, err = interceptor(admin, nil, info, handler)
+	assert.NoError(t, err)
+}
\ No newline at end of file
@@ -0,0 +1,154 @@
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SchemaVersion is the layout of the node hashes this store writes, recorded
+// in each hash's schema_version field. Version 0 is every hash written before
+// the field existed: status_reason, desired_status, labels_json or
+// metadata_json may be missing, and the node may be absent from the name,
+// type and status indexes. Version 1 nodes may be absent from the sorted ID
+// index that ListNodesAfter pages through.
+const SchemaVersion = 2
+
+// nodeMigrations[v] upgrades the fields of a version v hash to version v+1.
+// Migrate then rewrites the node and its indexes from the result, so an
+// entry only has to bring the fields to the next layout.
+var nodeMigrations = []func(data map[string]string){
+	migrateNodeV0,
+	migrateNodeV1,
+}
+
+// migrateNodeV0 backfills the fields added since the first layout
+func migrateNodeV0(data map[string]string) {
+	defaults := map[string]string{
+		"status_reason":  "",
+		"desired_status": "0",
+		"labels_json":    "{}",
+		"metadata_json":  "",
+	}
+	for field, value := range defaults {
+		if _, ok := data[field]; !ok {
+			data[field] = value
+		}
+	}
+}
+
+// migrateNodeV1 changes no field: rewriting the node adds it to the sorted ID
+// index
+func migrateNodeV1(data map[string]string) {}
+
+// ErrSchemaTooNew is returned by Migrate for a node written by a newer build
+var ErrSchemaTooNew = errors.New("node schema is newer than this build supports")
+
+// Migrate upgrades every node hash below SchemaVersion in place and rebuilds
+// its name, type, status and sorted ID indexes, returning how many nodes it upgraded.
+// Nodes are found through the nodes:all set, which every layout has kept.
+// Each node is upgraded in its own WATCH transaction, so Migrate is safe to
+// run against a live store and to run again after an interruption; current
+// nodes are left untouched. Soft-deleted tombstones are not migrated and are
+// read as they are by nodeFromHash.
+func (s *Store) Migrate(ctx context.Context) (int, error) {
+	ids, err := s.client.SMembers(ctx, s.allNodesKey()).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	migrated := 0
+	for _, id := range ids {
+		ok, err := s.migrateNode(ctx, id)
+		if err != nil {
+			return migrated, err
+		}
+		if ok {
+			migrated++
+		}
+	}
+
+	return migrated, nil
+}
+
+// migrateNode upgrades one node hash, reporting whether it needed it
+func (s *Store) migrateNode(ctx context.Context, id string) (bool, error) {
+	nodeKey := s.nodeKey(id)
+
+	var migrated bool
+	upgrade := func(tx *redis.Tx) error {
+		migrated = false
+		data, err := tx.HGetAll(ctx, nodeKey).Result()
+		if err != nil {
+			return fmt.Errorf("failed to get node: %w", err)
+		}
+		// Deleted since it was listed
+		if len(data) == 0 {
+			return nil
+		}
+
+		version, err := hashSchemaVersion(data)
+		if err != nil {
+			return fmt.Errorf("node %s: %w", id, err)
+		}
+		if version > SchemaVersion {
+			return fmt.Errorf("node %s has schema version %d, this build supports %d: %w", id, version, SchemaVersion, ErrSchemaTooNew)
+		}
+		if version == SchemaVersion {
+			return nil
+		}
+
+		for v := version; v < SchemaVersion; v++ {
+			nodeMigrations[v](data)
+		}
+		if data["id"] == "" {
+			data["id"] = id
+		}
+		node, err := s.nodeFromHash(data)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			s.queueSaveNode(ctx, pipe, node)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		migrated = true
+		return nil
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := s.client.Watch(ctx, upgrade, nodeKey)
+		if err == nil {
+			return migrated, nil
+		}
+		if !errors.Is(err, redis.TxFailedErr) {
+			return false, err
+		}
+		if attempt+1 >= maxUpdateAttempts {
+			return false, fmt.Errorf("failed to migrate node %s: concurrent updates, giving up after %d attempts", id, maxUpdateAttempts)
+		}
+		time.Sleep(retryJitter(attempt))
+	}
+}
+
+// hashSchemaVersion reads a node hash's schema_version; hashes without one
+// are version 0
+func hashSchemaVersion(data map[string]string) (int, error) {
+	raw, ok := data["schema_version"]
+	if !ok {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(raw)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid schema_version %q", raw)
+	}
+	return version, nil
+}
\ No newline at end of file
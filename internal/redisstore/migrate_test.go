@@ -0,0 +1,118 @@
+package redisstore
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateV0Node(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+
+	ctx := context.Background()
+
+	// A node as the first layout wrote it: no schema_version, status_reason,
+	// desired_status or metadata_json, and only in the nodes:all index
+	mr.HSet("node:legacy-1",
+		"id", "legacy-1",
+		"type", "2",
+		"name", "old-vm",
+		"status", "2",
+		"last_seen", "2024-01-15T10:00:00Z",
+		"labels_json", `{"env":"prod"}`,
+	)
+	mr.SAdd("nodes:all", "legacy-1")
+
+	// Readable before the migration
+	node, err := store.GetNode(ctx, "legacy-1")
+	require.NoError(t, err)
+	assert.Equal(t, "old-vm", node.Name)
+	assert.Equal(t, nodev1.NodeStatus_NODE_STATUS_UNSPECIFIED, node.DesiredStatus)
+	assert.Empty(t, node.StatusReason)
+
+	current, err := store.CreateNode(ctx, &nodev1.Node{Name: "new-vm", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP})
+	require.NoError(t, err)
+
+	migrated, err := store.Migrate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, migrated, "only the v0 node needs upgrading")
+
+	assert.Equal(t, strconv.Itoa(SchemaVersion), mr.HGet("node:legacy-1", "schema_version"))
+	assert.Equal(t, "0", mr.HGet("node:legacy-1", "desired_status"))
+	assert.True(t, mr.Exists("node:legacy-1"))
+
+	// The indexes the old layout lacked were rebuilt
+	byName, err := store.GetNodeByName(ctx, nodev1.NodeType_VM, "old-vm")
+	require.NoError(t, err)
+	assert.Equal(t, "legacy-1", byName.Id)
+	assert.Equal(t, map[string]string{"env": "prod"}, byName.Labels)
+	assert.Equal(t, "2024-01-15T10:00:00Z", byName.LastSeen.AsTime().Format(time.RFC3339))
+
+	count, err := store.CountNodes(ctx, nodev1.NodeType_VM, nodev1.NodeStatus_UP)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+
+	// Running again has nothing left to do
+	migrated, err = store.Migrate(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, migrated)
+	assert.Equal(t, strconv.Itoa(SchemaVersion), mr.HGet("node:"+current.Id, "schema_version"))
+}
+
+func TestMigrateV1NodeJoinsSortedIndex(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+
+	ctx := context.Background()
+
+	// A version 1 node, written before the sorted ID index existed
+	mr.HSet("node:v1-1",
+		"id", "v1-1",
+		"type", "2",
+		"name", "v1-vm",
+		"status", "2",
+		"status_reason", "",
+		"desired_status", "0",
+		"last_seen", "2024-01-15T10:00:00Z",
+		"labels_json", "{}",
+		"metadata_json", "",
+		"schema_version", "1",
+	)
+	mr.SAdd("nodes:all", "v1-1")
+	mr.SAdd("nodes:type:2", "v1-1")
+	mr.SAdd("nodes:status:2", "v1-1")
+
+	nodes, _, err := store.ListNodesAfter(ctx, 0, 0, "", 0)
+	require.NoError(t, err)
+	assert.Empty(t, nodes, "not in the sorted index yet")
+
+	migrated, err := store.Migrate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, migrated)
+
+	nodes, _, err = store.ListNodesAfter(ctx, 0, 0, "", 0)
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, "v1-1", nodes[0].Id)
+}
+
+func TestMigrateRejectsNewerSchema(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+
+	mr.HSet("node:future-1", "id", "future-1", "name", "future", "schema_version", "99")
+	mr.SAdd("nodes:all", "future-1")
+
+	_, err := store.Migrate(context.Background())
+	assert.ErrorIs(t, err, ErrSchemaTooNew)
+	assert.Equal(t, "99", mr.HGet("node:future-1", "schema_version"))
+}
\ No newline at end of file
@@ -3,41 +3,196 @@ package redisstore
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	nodedata "github.com/melkior/nodestatus/internal/data"
 	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type Store struct {
-	client *redis.Client
+	client redis.UniversalClient
+	// hashTag prefixes every key. In cluster mode it is "{node}", so all keys
+	// share one slot and the multi-key transactions and SINTER stay valid.
+	hashTag string
+	// softDeleteGrace keeps deleted nodes as tombstones for this long; zero
+	// deletes them outright
+	softDeleteGrace time.Duration
+}
+
+// clusterHashTag is the hash tag used for every key on a Redis Cluster
+const clusterHashTag = "{node}"
+
+// Options tunes the Redis connection pool. Zero values keep the go-redis
+// defaults: 10 connections per CPU, no idle minimum, 5s dial timeout, 3s
+// read and write timeouts and 3 retries. MaxRetries -1 disables retries.
+type Options struct {
+	PoolSize     int
+	MinIdleConns int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	MaxRetries   int
 }
 
 func New(addr string, password string, db int) (*Store, error) {
+	return NewWithOptions(addr, password, db, Options{})
+}
+
+// NewWithOptions connects to a single Redis server with a tuned pool
+func NewWithOptions(addr string, password string, db int, opts Options) (*Store, error) {
 	client := redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: password,
-		DB:       db,
+		Addr:         addr,
+		Password:     password,
+		DB:           db,
+		PoolSize:     opts.PoolSize,
+		MinIdleConns: opts.MinIdleConns,
+		DialTimeout:  opts.DialTimeout,
+		ReadTimeout:  opts.ReadTimeout,
+		WriteTimeout: opts.WriteTimeout,
+		MaxRetries:   opts.MaxRetries,
 	})
+	return newStore(client, "")
+}
 
+// NewFailover connects through Redis Sentinel to the master named masterName,
+// following failovers to the promoted replica
+func NewFailover(masterName string, sentinelAddrs []string, password string, db int, opts Options) (*Store, error) {
+	client := redis.NewFailoverClient(&redis.FailoverOptions{
+		MasterName:    masterName,
+		SentinelAddrs: sentinelAddrs,
+		Password:      password,
+		DB:            db,
+		PoolSize:      opts.PoolSize,
+		MinIdleConns:  opts.MinIdleConns,
+		DialTimeout:   opts.DialTimeout,
+		ReadTimeout:   opts.ReadTimeout,
+		WriteTimeout:  opts.WriteTimeout,
+		MaxRetries:    opts.MaxRetries,
+	})
+	return newStore(client, "")
+}
+
+// NewCluster connects to a Redis Cluster through the seed addresses in addrs.
+// Keys carry the {node} hash tag and therefore live in a single slot: the
+// cluster provides failover, not sharding of the node set. The pool options
+// apply to each cluster node.
+func NewCluster(addrs []string, password string, opts Options) (*Store, error) {
+	client := redis.NewClusterClient(&redis.ClusterOptions{
+		Addrs:        addrs,
+		Password:     password,
+		PoolSize:     opts.PoolSize,
+		MinIdleConns: opts.MinIdleConns,
+		DialTimeout:  opts.DialTimeout,
+		ReadTimeout:  opts.ReadTimeout,
+		WriteTimeout: opts.WriteTimeout,
+		MaxRetries:   opts.MaxRetries,
+	})
+	return newStore(client, clusterHashTag)
+}
+
+// newStore checks that client can reach Redis and wraps it
+func newStore(client redis.UniversalClient, hashTag string) (*Store, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	
+
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to ping Redis: %w", err)
 	}
 
-	return &Store{client: client}, nil
+	return &Store{client: client, hashTag: hashTag}, nil
+}
+
+// Key names. Every key goes through one of these so the cluster hash tag is
+// never forgotten.
+
+func (s *Store) nodeKey(id string) string {
+	return s.hashTag + "node:" + id
+}
+
+func (s *Store) nameKey(nodeType nodev1.NodeType, name string) string {
+	return fmt.Sprintf("%snode:byname:%d:%s", s.hashTag, nodeType, name)
+}
+
+func (s *Store) allNodesKey() string {
+	return s.hashTag + "nodes:all"
+}
+
+// sortedNodesKey is a sorted set of every node ID, all with score 0, so
+// members are ordered by ID and ListNodesAfter can page with ZRANGEBYLEX
+func (s *Store) sortedNodesKey() string {
+	return s.hashTag + "nodes:all:sorted"
+}
+
+func (s *Store) typeKey(nodeType nodev1.NodeType) string {
+	return fmt.Sprintf("%snodes:type:%d", s.hashTag, nodeType)
+}
+
+func (s *Store) statusKey(status nodev1.NodeStatus) string {
+	return fmt.Sprintf("%snodes:status:%d", s.hashTag, status)
+}
+
+func (s *Store) deletedNodeKey(id string) string {
+	return s.hashTag + "node:deleted:" + id
+}
+
+func (s *Store) eventsKey() string {
+	return s.hashTag + "nodes:events"
+}
+
+// SetSoftDeleteGrace makes DeleteNode keep deleted nodes as tombstones for
+// grace, readable with GetDeletedNode and recoverable with RestoreNode. Redis
+// expires the tombstones afterwards. Zero, the default, deletes outright.
+func (s *Store) SetSoftDeleteGrace(grace time.Duration) {
+	s.softDeleteGrace = grace
+}
+
+// Ping checks that Redis answers, without touching any keys
+func (s *Store) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// PoolStats reports connection pool usage, summed over all nodes of a cluster
+func (s *Store) PoolStats() *redis.PoolStats {
+	return s.client.PoolStats()
 }
 
 func (s *Store) Close() error {
 	return s.client.Close()
 }
 
+// ErrNodeExists is returned by CreateNode when a node with the same name and
+// type is already stored
+var ErrNodeExists = errors.New("node already exists")
+
+// CreateNode stores a new node. The name index is checked and written in one
+// WATCH transaction, so concurrent creates of the same name and type can't
+// both succeed.
 func (s *Store) CreateNode(ctx context.Context, node *nodev1.Node) (*nodev1.Node, error) {
+	created, _, err := s.createNode(ctx, node, false, nil)
+	return created, err
+}
+
+// UpsertNode creates node, or returns the node already stored under its name
+// and type. For an existing node the masked fields are copied from node as in
+// UpdateNode; an empty mask returns it unchanged. The returned bool reports
+// whether the node was created.
+func (s *Store) UpsertNode(ctx context.Context, node *nodev1.Node, mask []string) (*nodev1.Node, bool, error) {
+	return s.createNode(ctx, node, true, mask)
+}
+
+// createNode implements CreateNode and UpsertNode
+func (s *Store) createNode(ctx context.Context, node *nodev1.Node, upsert bool, mask []string) (*nodev1.Node, bool, error) {
 	if node.Id == "" {
 		node.Id = uuid.New().String()
 	}
@@ -46,139 +201,792 @@ func (s *Store) CreateNode(ctx context.Context, node *nodev1.Node) (*nodev1.Node
 		node.LastSeen = timestamppb.Now()
 	}
 
-	existingID, err := s.client.Get(ctx, fmt.Sprintf("node:byname:%d:%s", node.Type, node.Name)).Result()
-	if err == nil && existingID != "" {
-		return nil, fmt.Errorf("node with name %s of type %s already exists", node.Name, node.Type.String())
+	nameKey := s.nameKey(node.Type, node.Name)
+
+	var result *nodev1.Node
+	var created bool
+	create := func(tx *redis.Tx) error {
+		existingID, err := tx.Get(ctx, nameKey).Result()
+		if errors.Is(err, redis.Nil) {
+			result, created = node, true
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				s.queueSaveNode(ctx, pipe, node)
+				s.queueAppendEvent(ctx, pipe, nodev1.EventType_CREATED, node.Id, nil, nil)
+				return nil
+			})
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("failed to check node name: %w", err)
+		}
+		if !upsert {
+			return fmt.Errorf("%w: %s of type %s", ErrNodeExists, node.Name, node.Type.String())
+		}
+
+		// Watch the existing node too, so a concurrent update retries the upsert
+		nodeKey := s.nodeKey(existingID)
+		if err := tx.Watch(ctx, nodeKey).Err(); err != nil {
+			return err
+		}
+		data, err := tx.HGetAll(ctx, nodeKey).Result()
+		if err != nil {
+			return fmt.Errorf("failed to get node: %w", err)
+		}
+		if len(data) == 0 {
+			return ErrNodeNotFound
+		}
+		existing, err := s.nodeFromHash(data)
+		if err != nil {
+			return err
+		}
+
+		result, created = existing, false
+		if len(mask) == 0 {
+			return nil
+		}
+
+		updated, err := applyUpdateMask(existing, node, mask)
+		if err != nil {
+			return err
+		}
+		updated.LastSeen = timestamppb.Now()
+		changedFields := nodedata.DiffProtoNodes(existing, updated)
+		result = updated
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			s.queueDeleteIndexes(ctx, pipe, existing)
+			s.queueSaveNode(ctx, pipe, updated)
+			if len(changedFields) > 0 {
+				s.queueAppendEvent(ctx, pipe, nodev1.EventType_UPDATED, updated.Id, changedFields, fieldChanges(existing, updated))
+			}
+			return nil
+		})
+		return err
 	}
 
-	if err := s.saveNode(ctx, node); err != nil {
-		return nil, err
+	for attempt := 0; ; attempt++ {
+		err := s.client.Watch(ctx, create, nameKey)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, redis.TxFailedErr) {
+			return nil, false, err
+		}
+		if attempt+1 >= maxUpdateAttempts {
+			return nil, false, fmt.Errorf("failed to create node %s: concurrent writes, giving up after %d attempts", node.Name, maxUpdateAttempts)
+		}
+		time.Sleep(retryJitter(attempt))
 	}
 
-	if err := s.appendEvent(ctx, nodev1.EventType_CREATED, node.Id, nil); err != nil {
-		return nil, err
+	return result, created, nil
+}
+
+// ErrNodeNotFound is returned when no node matches the requested ID or name
+var ErrNodeNotFound = errors.New("node not found")
+
+// ErrInvalidUpdateMask is returned when an update mask names a field that
+// does not exist or cannot be updated
+var ErrInvalidUpdateMask = errors.New("invalid update mask")
+
+// maxUpdateAttempts bounds the optimistic retries of UpdateNode when another
+// writer changes the node between the read and the write
+const maxUpdateAttempts = 5
+
+// retryJitter is the random pause before retrying a failed WATCH transaction.
+// Without it two writers looping on one node fall into lockstep: the loser
+// restarts one round trip behind the winner and fails again every time.
+func retryJitter(attempt int) time.Duration {
+	return time.Duration(rand.Int63n(int64(attempt+1) * int64(time.Millisecond)))
+}
+
+// UpdateNode updates a stored node. With an empty mask the node is replaced by
+// node, otherwise only the masked fields are copied from node onto the stored
+// one. Supported paths are name, type, status, desired_status,
+// status_reason, labels, labels.<key> and metadata_json. The read and write run in a WATCH
+// transaction, so concurrent partial updates don't clobber each other, and
+// the UPDATED event is written in the same transaction as the node.
+func (s *Store) UpdateNode(ctx context.Context, node *nodev1.Node, mask []string) (*nodev1.Node, error) {
+	nodeKey := s.nodeKey(node.Id)
+
+	var updated *nodev1.Node
+	update := func(tx *redis.Tx) error {
+		data, err := tx.HGetAll(ctx, nodeKey).Result()
+		if err != nil {
+			return fmt.Errorf("failed to get node: %w", err)
+		}
+		if len(data) == 0 {
+			return ErrNodeNotFound
+		}
+		oldNode, err := s.nodeFromHash(data)
+		if err != nil {
+			return err
+		}
+
+		updated, err = applyUpdateMask(oldNode, node, mask)
+		if err != nil {
+			return err
+		}
+		updated.LastSeen = timestamppb.Now()
+		changedFields := nodedata.DiffProtoNodes(oldNode, updated)
+		changes := fieldChanges(oldNode, updated)
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			s.queueDeleteIndexes(ctx, pipe, oldNode)
+			s.queueSaveNode(ctx, pipe, updated)
+			s.queueAppendEvent(ctx, pipe, nodev1.EventType_UPDATED, updated.Id, changedFields, changes)
+			return nil
+		})
+		return err
 	}
 
-	return node, nil
+	for attempt := 0; ; attempt++ {
+		err := s.client.Watch(ctx, update, nodeKey)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, redis.TxFailedErr) {
+			return nil, err
+		}
+		if attempt+1 >= maxUpdateAttempts {
+			return nil, fmt.Errorf("failed to update node %s: concurrent updates, giving up after %d attempts", node.Id, maxUpdateAttempts)
+		}
+		time.Sleep(retryJitter(attempt))
+	}
+
+	return updated, nil
+}
+
+// applyUpdateMask returns stored with the masked fields taken from patch, or a
+// copy of patch when the mask is empty
+func applyUpdateMask(stored, patch *nodev1.Node, mask []string) (*nodev1.Node, error) {
+	if len(mask) == 0 {
+		merged := proto.Clone(patch).(*nodev1.Node)
+		merged.Id = stored.Id
+		return merged, nil
+	}
+
+	merged := proto.Clone(stored).(*nodev1.Node)
+	for _, path := range mask {
+		switch path {
+		case "name":
+			merged.Name = patch.Name
+		case "type":
+			merged.Type = patch.Type
+		case "status":
+			merged.Status = patch.Status
+		case "desired_status":
+			merged.DesiredStatus = patch.DesiredStatus
+		case "status_reason":
+			merged.StatusReason = patch.StatusReason
+		case "metadata_json":
+			merged.MetadataJson = patch.MetadataJson
+		case "labels":
+			merged.Labels = make(map[string]string, len(patch.Labels))
+			for k, v := range patch.Labels {
+				merged.Labels[k] = v
+			}
+		default:
+			key, ok := strings.CutPrefix(path, "labels.")
+			if !ok || key == "" {
+				return nil, fmt.Errorf("%w: unsupported path %q", ErrInvalidUpdateMask, path)
+			}
+			// A masked key missing from the patch is removed
+			if v, present := patch.Labels[key]; present {
+				if merged.Labels == nil {
+					merged.Labels = make(map[string]string)
+				}
+				merged.Labels[key] = v
+			} else {
+				delete(merged.Labels, key)
+			}
+		}
+	}
+	return merged, nil
 }
 
-func (s *Store) UpdateNode(ctx context.Context, node *nodev1.Node) (*nodev1.Node, error) {
-	oldNode, err := s.GetNode(ctx, node.Id)
+// UpdateStatus sets a node's status, status reason and LastSeen. Only the
+// status and status_reason fields are read, under WATCH, and the transaction
+// touches just those fields, last_seen and the two status sets, so it never
+// rewrites the rest of the node or races with another writer. changed lists
+// the fields the update changed; an update that changes nothing writes
+// nothing, emits no event and returns nil changed.
+func (s *Store) UpdateStatus(ctx context.Context, id string, status nodev1.NodeStatus, reason string) (node *nodev1.Node, changed []string, err error) {
+	nodeKey := s.nodeKey(id)
+
+	var data map[string]string
+	update := func(tx *redis.Tx) error {
+		changed = nil
+		fields, err := tx.HMGet(ctx, nodeKey, "status", "status_reason").Result()
+		if err != nil {
+			return fmt.Errorf("failed to get node status: %w", err)
+		}
+		if fields[0] == nil {
+			return ErrNodeNotFound
+		}
+		current, err := strconv.Atoi(fields[0].(string))
+		if err != nil {
+			return fmt.Errorf("failed to get node status: %w", err)
+		}
+		oldStatus := nodev1.NodeStatus(current)
+		oldReason, _ := fields[1].(string)
+
+		newReason, changes := statusUpdateChanges(oldStatus, oldReason, status, reason)
+		if len(changes) == 0 {
+			data, err = tx.HGetAll(ctx, nodeKey).Result()
+			if err != nil {
+				return fmt.Errorf("failed to get node: %w", err)
+			}
+			return nil
+		}
+		for _, c := range changes {
+			changed = append(changed, c.Field)
+		}
+
+		var read *redis.MapStringStringCmd
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, nodeKey,
+				"status", int32(status),
+				"status_reason", newReason,
+				"last_seen", time.Now().Format(time.RFC3339),
+			)
+			if oldStatus != status {
+				pipe.SRem(ctx, s.statusKey(oldStatus), id)
+				pipe.SAdd(ctx, s.statusKey(status), id)
+			}
+			s.queueAppendEvent(ctx, pipe, nodev1.EventType_UPDATED, id, changed, changes)
+			read = pipe.HGetAll(ctx, nodeKey)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		data = read.Val()
+		return nil
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := s.client.Watch(ctx, update, nodeKey)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, redis.TxFailedErr) {
+			return nil, nil, err
+		}
+		if attempt+1 >= maxUpdateAttempts {
+			return nil, nil, fmt.Errorf("failed to update status of node %s: concurrent updates, giving up after %d attempts", id, maxUpdateAttempts)
+		}
+		time.Sleep(retryJitter(attempt))
+	}
+
+	if len(data) == 0 {
+		return nil, nil, ErrNodeNotFound
+	}
+	node, err = s.nodeFromHash(data)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	return node, changed, nil
+}
+
+// statusUpdateChanges returns the status reason a status update leaves on a
+// node and the fields it changes. A new status replaces the reason, even with
+// an empty one, since the old reason explained the old status; resending the
+// current status only replaces the reason when one is given.
+func statusUpdateChanges(oldStatus nodev1.NodeStatus, oldReason string, status nodev1.NodeStatus, reason string) (string, []FieldChange) {
+	var changes []FieldChange
+	if oldStatus != status {
+		changes = append(changes, FieldChange{Field: "status", Old: oldStatus.String(), New: status.String()})
+	} else if reason == "" {
+		return oldReason, nil
+	}
+	if reason != oldReason {
+		changes = append(changes, FieldChange{Field: "status_reason", Old: oldReason, New: reason})
 	}
+	return reason, changes
+}
 
-	node.LastSeen = timestamppb.Now()
+// StatusUpdate is one item of UpdateStatusBatch
+type StatusUpdate struct {
+	ID     string
+	Status nodev1.NodeStatus
+	Reason string // Optional, see UpdateStatus
+}
 
-	changedFields := s.getChangedFields(oldNode, node)
+// StatusUpdateResult is the outcome of one StatusUpdate
+type StatusUpdateResult struct {
+	Node          *nodev1.Node // The node after the update, nil on error
+	Changed       bool         // False when the update changed nothing
+	ChangedFields []string     // status and/or status_reason when Changed
+	Err           error
+}
 
-	if err := s.deleteIndexes(ctx, oldNode); err != nil {
-		return nil, err
+// UpdateStatusBatch applies many status updates. One pipelined read finds
+// the items that change anything; each node they touch is then updated in its
+// own WATCH transaction that, like UpdateStatus, re-reads only status and
+// status_reason and writes only those fields, last_seen and the status sets,
+// so concurrent edits to the rest of the node are never overwritten. Items
+// that change neither the status nor its reason are not written and emit no
+// event. Results are in the order of updates; an item's error doesn't affect
+// other nodes. Repeated IDs apply in order within their node's transaction.
+func (s *Store) UpdateStatusBatch(ctx context.Context, updates []StatusUpdate) ([]StatusUpdateResult, error) {
+	results := make([]StatusUpdateResult, len(updates))
+	if len(updates) == 0 {
+		return results, nil
 	}
 
-	if err := s.saveNode(ctx, node); err != nil {
-		return nil, err
+	readPipe := s.client.Pipeline()
+	reads := make([]*redis.MapStringStringCmd, len(updates))
+	for i, u := range updates {
+		reads[i] = readPipe.HGetAll(ctx, s.nodeKey(u.ID))
+	}
+	if _, err := readPipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to get nodes: %w", err)
 	}
 
-	if err := s.appendEvent(ctx, nodev1.EventType_UPDATED, node.Id, changedFields); err != nil {
-		return nil, err
+	// Item indexes per ID, in the order IDs first appear
+	var ids []string
+	items := make(map[string][]int)
+	for i, u := range updates {
+		if _, ok := items[u.ID]; !ok {
+			ids = append(ids, u.ID)
+		}
+		items[u.ID] = append(items[u.ID], i)
 	}
 
-	return node, nil
+	for _, id := range ids {
+		idx := items[id]
+		data, err := reads[idx[0]].Result()
+		if err == nil && len(data) == 0 {
+			err = ErrNodeNotFound
+		}
+		var node *nodev1.Node
+		if err == nil {
+			node, err = s.nodeFromHash(data)
+		}
+		if err != nil {
+			for _, i := range idx {
+				results[i].Err = err
+			}
+			continue
+		}
+
+		if steps := statusBatchSteps(node.Status, node.StatusReason, updates, idx); !steps.changed() {
+			for _, i := range idx {
+				results[i] = StatusUpdateResult{Node: proto.Clone(node).(*nodev1.Node)}
+			}
+			continue
+		}
+
+		if err := s.updateStatusItems(ctx, id, updates, idx, results); err != nil {
+			for _, i := range idx {
+				results[i] = StatusUpdateResult{Err: err}
+			}
+		}
+	}
+
+	return results, nil
 }
 
-func (s *Store) UpdateStatus(ctx context.Context, id string, status nodev1.NodeStatus) (*nodev1.Node, error) {
-	node, err := s.GetNode(ctx, id)
+// statusStep is the effect of one batch item on its node
+type statusStep struct {
+	status  nodev1.NodeStatus
+	reason  string
+	changes []FieldChange
+}
+
+type statusSteps []statusStep
+
+// statusBatchSteps applies the items idx of updates in order to a node in
+// status with reason
+func statusBatchSteps(status nodev1.NodeStatus, reason string, updates []StatusUpdate, idx []int) statusSteps {
+	steps := make(statusSteps, len(idx))
+	for j, i := range idx {
+		u := updates[i]
+		newReason, changes := statusUpdateChanges(status, reason, u.Status, u.Reason)
+		if len(changes) > 0 {
+			status = u.Status
+		}
+		reason = newReason
+		steps[j] = statusStep{status: status, reason: reason, changes: changes}
+	}
+	return steps
+}
+
+func (steps statusSteps) changed() bool {
+	for _, step := range steps {
+		if len(step.changes) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// updateStatusItems applies the items idx of updates, which all target id, in
+// one WATCH transaction and fills in their results
+func (s *Store) updateStatusItems(ctx context.Context, id string, updates []StatusUpdate, idx []int, results []StatusUpdateResult) error {
+	nodeKey := s.nodeKey(id)
+
+	var steps statusSteps
+	var data map[string]string
+	update := func(tx *redis.Tx) error {
+		fields, err := tx.HMGet(ctx, nodeKey, "status", "status_reason").Result()
+		if err != nil {
+			return fmt.Errorf("failed to get node status: %w", err)
+		}
+		if fields[0] == nil {
+			return ErrNodeNotFound
+		}
+		current, err := strconv.Atoi(fields[0].(string))
+		if err != nil {
+			return fmt.Errorf("failed to get node status: %w", err)
+		}
+		oldStatus := nodev1.NodeStatus(current)
+		oldReason, _ := fields[1].(string)
+
+		steps = statusBatchSteps(oldStatus, oldReason, updates, idx)
+		if !steps.changed() {
+			data, err = tx.HGetAll(ctx, nodeKey).Result()
+			if err != nil {
+				return fmt.Errorf("failed to get node: %w", err)
+			}
+			return nil
+		}
+		final := steps[len(steps)-1]
+
+		var read *redis.MapStringStringCmd
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, nodeKey,
+				"status", int32(final.status),
+				"status_reason", final.reason,
+				"last_seen", time.Now().Format(time.RFC3339),
+			)
+			if oldStatus != final.status {
+				pipe.SRem(ctx, s.statusKey(oldStatus), id)
+				pipe.SAdd(ctx, s.statusKey(final.status), id)
+			}
+			for _, step := range steps {
+				if len(step.changes) > 0 {
+					s.queueAppendEvent(ctx, pipe, nodev1.EventType_UPDATED, id, changedFieldNames(step.changes), step.changes)
+				}
+			}
+			read = pipe.HGetAll(ctx, nodeKey)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		data = read.Val()
+		return nil
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := s.client.Watch(ctx, update, nodeKey)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+		if attempt+1 >= maxUpdateAttempts {
+			return fmt.Errorf("failed to update status of node %s: concurrent updates, giving up after %d attempts", id, maxUpdateAttempts)
+		}
+		time.Sleep(retryJitter(attempt))
+	}
+
+	if len(data) == 0 {
+		return ErrNodeNotFound
+	}
+	node, err := s.nodeFromHash(data)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	// Each result shows the node as its own item left it
+	for j, i := range idx {
+		step := steps[j]
+		itemNode := proto.Clone(node).(*nodev1.Node)
+		itemNode.Status = step.status
+		itemNode.StatusReason = step.reason
+		results[i] = StatusUpdateResult{Node: itemNode, Changed: len(step.changes) > 0}
+		if results[i].Changed {
+			results[i].ChangedFields = changedFieldNames(step.changes)
+		}
+	}
+	return nil
+}
+
+// changedFieldNames lists the fields of changes
+func changedFieldNames(changes []FieldChange) []string {
+	fields := make([]string, len(changes))
+	for i, c := range changes {
+		fields[i] = c.Field
 	}
+	return fields
+}
 
-	oldStatus := node.Status
-	node.Status = status
-	node.LastSeen = timestamppb.Now()
+// MarkStaleNodes sets staleStatus on every node whose LastSeen is before
+// cutoff, skipping nodes already in that status and nodes carrying any of the
+// exclude labels. LastSeen is kept, so the node still shows when it was last
+// heard from. Each node is rechecked inside a transaction, and a node that is
+// updated meanwhile is left alone since it is no longer stale.
+func (s *Store) MarkStaleNodes(ctx context.Context, cutoff time.Time, staleStatus nodev1.NodeStatus, exclude map[string]string) ([]*nodev1.Node, error) {
+	ids, err := s.listMemberIDs(ctx, nodev1.NodeType_NODE_TYPE_UNSPECIFIED, nodev1.NodeStatus_NODE_STATUS_UNSPECIFIED)
+	if err != nil {
+		return nil, err
+	}
 
-	if oldStatus != status {
-		if err := s.deleteIndexes(ctx, node); err != nil {
-			return nil, err
+	isStale := func(node *nodev1.Node) bool {
+		if node.Status == staleStatus || node.LastSeen == nil || !node.LastSeen.AsTime().Before(cutoff) {
+			return false
 		}
+		for k, v := range exclude {
+			if node.Labels[k] == v {
+				return false
+			}
+		}
+		return true
+	}
 
-		node.Status = status
+	readPipe := s.client.Pipeline()
+	reads := make([]*redis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		reads[i] = readPipe.HGetAll(ctx, s.nodeKey(id))
+	}
+	if len(ids) > 0 {
+		if _, err := readPipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+			return nil, fmt.Errorf("failed to get nodes: %w", err)
+		}
+	}
 
-		if err := s.saveNode(ctx, node); err != nil {
-			return nil, err
+	var marked []*nodev1.Node
+	for i, id := range ids {
+		data, err := reads[i].Result()
+		if err != nil || len(data) == 0 {
+			continue
+		}
+		node, err := s.nodeFromHash(data)
+		if err != nil || !isStale(node) {
+			continue
 		}
 
-		if err := s.appendEvent(ctx, nodev1.EventType_UPDATED, node.Id, []string{"status"}); err != nil {
-			return nil, err
+		nodeKey := s.nodeKey(id)
+		var updated *nodev1.Node
+		err = s.client.Watch(ctx, func(tx *redis.Tx) error {
+			updated = nil
+			data, err := tx.HGetAll(ctx, nodeKey).Result()
+			if err != nil {
+				return fmt.Errorf("failed to get node: %w", err)
+			}
+			if len(data) == 0 {
+				return nil
+			}
+			current, err := s.nodeFromHash(data)
+			if err != nil || !isStale(current) {
+				return err
+			}
+
+			updated = proto.Clone(current).(*nodev1.Node)
+			updated.Status = staleStatus
+			updated.StatusReason = "not seen since " + current.LastSeen.AsTime().Format(time.RFC3339)
+			changes := fieldChanges(current, updated)
+			changedFields := make([]string, len(changes))
+			for j, c := range changes {
+				changedFields[j] = c.Field
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				s.queueDeleteIndexes(ctx, pipe, current)
+				s.queueSaveNode(ctx, pipe, updated)
+				s.queueAppendEvent(ctx, pipe, nodev1.EventType_UPDATED, updated.Id, changedFields, changes)
+				return nil
+			})
+			return err
+		}, nodeKey)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return marked, fmt.Errorf("failed to mark node %s stale: %w", id, err)
+		}
+		if updated != nil {
+			marked = append(marked, updated)
 		}
 	}
 
-	return node, nil
+	return marked, nil
 }
 
+// DeleteNode removes a node and its indexes and appends a DELETED event, all
+// in one transaction. With a soft delete grace the node hash is kept as a
+// tombstone with a deleted_at field until the grace period expires.
 func (s *Store) DeleteNode(ctx context.Context, id string) error {
 	node, err := s.GetNode(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	if err := s.deleteIndexes(ctx, node); err != nil {
-		return err
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		s.queueDeleteIndexes(ctx, pipe, node)
+		pipe.SRem(ctx, s.allNodesKey(), id)
+		pipe.ZRem(ctx, s.sortedNodesKey(), id)
+		if s.softDeleteGrace > 0 {
+			tombstone := s.deletedNodeKey(id)
+			pipe.Rename(ctx, s.nodeKey(id), tombstone)
+			pipe.HSet(ctx, tombstone, "deleted_at", time.Now().Format(time.RFC3339))
+			pipe.Expire(ctx, tombstone, s.softDeleteGrace)
+		} else {
+			pipe.Del(ctx, s.nodeKey(id))
+		}
+		s.queueAppendEvent(ctx, pipe, nodev1.EventType_DELETED, node.Id, nil, nil)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete node: %w", err)
 	}
 
-	pipe := s.client.Pipeline()
-	pipe.Del(ctx, fmt.Sprintf("node:%s", id))
-	pipe.SRem(ctx, "nodes:all", id)
+	return nil
+}
 
-	if _, err := pipe.Exec(ctx); err != nil {
-		return fmt.Errorf("failed to delete node: %w", err)
+// GetDeletedNode returns a soft-deleted node and when it was deleted, while
+// its tombstone is within the grace period
+func (s *Store) GetDeletedNode(ctx context.Context, id string) (*nodev1.Node, time.Time, error) {
+	data, err := s.client.HGetAll(ctx, s.deletedNodeKey(id)).Result()
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to get deleted node: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, time.Time{}, ErrNodeNotFound
 	}
 
-	if err := s.appendEvent(ctx, nodev1.EventType_DELETED, node.Id, nil); err != nil {
+	node, err := s.nodeFromHash(data)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	deletedAt, _ := time.Parse(time.RFC3339, data["deleted_at"])
+	return node, deletedAt, nil
+}
+
+// RestoreNode brings a soft-deleted node back with its ID, indexes and fields
+// and appends a CREATED event. It fails with ErrNodeNotFound once the
+// tombstone expired and with ErrNodeExists when its name was taken meanwhile.
+func (s *Store) RestoreNode(ctx context.Context, id string) (*nodev1.Node, error) {
+	tombstone := s.deletedNodeKey(id)
+
+	var restored *nodev1.Node
+	restore := func(tx *redis.Tx) error {
+		data, err := tx.HGetAll(ctx, tombstone).Result()
+		if err != nil {
+			return fmt.Errorf("failed to get deleted node: %w", err)
+		}
+		if len(data) == 0 {
+			return ErrNodeNotFound
+		}
+		node, err := s.nodeFromHash(data)
+		if err != nil {
+			return err
+		}
+
+		nameKey := s.nameKey(node.Type, node.Name)
+		if err := tx.Watch(ctx, nameKey).Err(); err != nil {
+			return err
+		}
+		if n, err := tx.Exists(ctx, nameKey).Result(); err != nil {
+			return fmt.Errorf("failed to check node name: %w", err)
+		} else if n > 0 {
+			return fmt.Errorf("%w: %s of type %s", ErrNodeExists, node.Name, node.Type.String())
+		}
+
+		restored = node
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, tombstone)
+			s.queueSaveNode(ctx, pipe, node)
+			s.queueAppendEvent(ctx, pipe, nodev1.EventType_CREATED, node.Id, nil, nil)
+			return nil
+		})
 		return err
 	}
 
-	return nil
+	for attempt := 0; ; attempt++ {
+		err := s.client.Watch(ctx, restore, tombstone)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, redis.TxFailedErr) {
+			return nil, err
+		}
+		if attempt+1 >= maxUpdateAttempts {
+			return nil, fmt.Errorf("failed to restore node %s: concurrent writes, giving up after %d attempts", id, maxUpdateAttempts)
+		}
+		time.Sleep(retryJitter(attempt))
+	}
+
+	return restored, nil
 }
 
 func (s *Store) GetNode(ctx context.Context, id string) (*nodev1.Node, error) {
-	data, err := s.client.HGetAll(ctx, fmt.Sprintf("node:%s", id)).Result()
+	data, err := s.client.HGetAll(ctx, s.nodeKey(id)).Result()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get node: %w", err)
 	}
 
 	if len(data) == 0 {
-		return nil, fmt.Errorf("node not found")
+		return nil, ErrNodeNotFound
 	}
 
 	return s.nodeFromHash(data)
 }
 
+// GetNodeByName resolves a node through the node:byname index
+func (s *Store) GetNodeByName(ctx context.Context, nodeType nodev1.NodeType, name string) (*nodev1.Node, error) {
+	id, err := s.client.Get(ctx, s.nameKey(nodeType, name)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNodeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+
+	return s.GetNode(ctx, id)
+}
+
+// CountNodes returns the number of stored nodes matching the filters, which
+// are ignored when unspecified as in ListNodes. It reads set cardinalities
+// rather than the nodes.
+func (s *Store) CountNodes(ctx context.Context, typeFilter nodev1.NodeType, statusFilter nodev1.NodeStatus) (int64, error) {
+	var n int64
+	var err error
+
+	switch {
+	case typeFilter != nodev1.NodeType_NODE_TYPE_UNSPECIFIED && statusFilter != nodev1.NodeStatus_NODE_STATUS_UNSPECIFIED:
+		n, err = s.client.SInterCard(ctx, 0, s.typeKey(typeFilter), s.statusKey(statusFilter)).Result()
+	case typeFilter != nodev1.NodeType_NODE_TYPE_UNSPECIFIED:
+		n, err = s.client.SCard(ctx, s.typeKey(typeFilter)).Result()
+	case statusFilter != nodev1.NodeStatus_NODE_STATUS_UNSPECIFIED:
+		n, err = s.client.SCard(ctx, s.statusKey(statusFilter)).Result()
+	default:
+		n, err = s.client.SCard(ctx, s.allNodesKey()).Result()
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to count nodes: %w", err)
+	}
+	return n, nil
+}
+
+// CountEvents returns the number of events in the event log
+func (s *Store) CountEvents(ctx context.Context) (int64, error) {
+	n, err := s.client.XLen(ctx, s.eventsKey()).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count events: %w", err)
+	}
+	return n, nil
+}
+
+// ListNodes returns nodes ordered by ID, skipping the first offset. A limit
+// of 0 returns everything after the offset.
 func (s *Store) ListNodes(ctx context.Context, typeFilter nodev1.NodeType, statusFilter nodev1.NodeStatus, offset, limit int) ([]*nodev1.Node, error) {
-	var setKey string
-
-	if typeFilter != nodev1.NodeType_NODE_TYPE_UNSPECIFIED && statusFilter != nodev1.NodeStatus_NODE_STATUS_UNSPECIFIED {
-		typeSet := fmt.Sprintf("nodes:type:%d", typeFilter)
-		statusSet := fmt.Sprintf("nodes:status:%d", statusFilter)
-		setKey = fmt.Sprintf("temp:list:%d", time.Now().UnixNano())
-		
-		if err := s.client.ZInterStore(ctx, setKey, &redis.ZStore{
-			Keys: []string{typeSet, statusSet},
-		}).Err(); err != nil {
-			return nil, fmt.Errorf("failed to intersect sets: %w", err)
-		}
-		defer s.client.Del(ctx, setKey)
-	} else if typeFilter != nodev1.NodeType_NODE_TYPE_UNSPECIFIED {
-		setKey = fmt.Sprintf("nodes:type:%d", typeFilter)
-	} else if statusFilter != nodev1.NodeStatus_NODE_STATUS_UNSPECIFIED {
-		setKey = fmt.Sprintf("nodes:status:%d", statusFilter)
-	} else {
-		setKey = "nodes:all"
-	}
-
-	members, err := s.client.SMembers(ctx, setKey).Result()
+	members, err := s.listMemberIDs(ctx, typeFilter, statusFilter)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list nodes: %w", err)
+		return nil, err
 	}
 
 	start := offset
@@ -204,9 +1012,155 @@ func (s *Store) ListNodes(ctx context.Context, typeFilter nodev1.NodeType, statu
 	return nodes, nil
 }
 
+// listScanBatch is how many IDs ListNodesAfter reads at a time when it has no
+// limit to size the read by
+const listScanBatch = 500
+
+// ListNodesAfter returns up to limit nodes whose IDs sort after afterID, in
+// ID order, so paging stays stable while nodes are added or removed. An empty
+// afterID starts from the beginning and a limit of 0 returns everything. The
+// returned cursor is the last ID examined, or empty once the listing is
+// exhausted. IDs are read from the sorted ID index with ZRANGEBYLEX and the
+// filters are checked on the nodes, so a page costs its own size and not the
+// size of the index.
+func (s *Store) ListNodesAfter(ctx context.Context, typeFilter nodev1.NodeType, statusFilter nodev1.NodeStatus, afterID string, limit int) ([]*nodev1.Node, string, error) {
+	matches := func(node *nodev1.Node) bool {
+		return (typeFilter == nodev1.NodeType_NODE_TYPE_UNSPECIFIED || node.Type == typeFilter) &&
+			(statusFilter == nodev1.NodeStatus_NODE_STATUS_UNSPECIFIED || node.Status == statusFilter)
+	}
+
+	nodes := make([]*nodev1.Node, 0, limit)
+	last := afterID
+	for limit == 0 || len(nodes) < limit {
+		count := listScanBatch
+		if limit > 0 {
+			count = limit - len(nodes)
+		}
+		ids, err := s.client.ZRangeByLex(ctx, s.sortedNodesKey(), &redis.ZRangeBy{
+			Min:   lexAfter(last),
+			Max:   "+",
+			Count: int64(count),
+		}).Result()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list nodes: %w", err)
+		}
+		if len(ids) == 0 {
+			return nodes, "", nil
+		}
+
+		page, err := s.getNodes(ctx, ids)
+		if err != nil {
+			return nil, "", err
+		}
+		// Nodes deleted since the IDs were read are skipped without ending
+		// the page
+		for _, node := range page {
+			if node != nil && matches(node) {
+				nodes = append(nodes, node)
+			}
+		}
+		last = ids[len(ids)-1]
+	}
+
+	more, err := s.client.ZLexCount(ctx, s.sortedNodesKey(), lexAfter(last), "+").Result()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list nodes: %w", err)
+	}
+	if more == 0 {
+		return nodes, "", nil
+	}
+	return nodes, last, nil
+}
+
+// lexAfter is the ZRANGEBYLEX bound just after id, or the start for ""
+func lexAfter(id string) string {
+	if id == "" {
+		return "-"
+	}
+	return "(" + id
+}
+
+// getNodes reads the nodes with the given IDs in one pipeline. Missing nodes
+// are nil in the result.
+func (s *Store) getNodes(ctx context.Context, ids []string) ([]*nodev1.Node, error) {
+	pipe := s.client.Pipeline()
+	reads := make([]*redis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		reads[i] = pipe.HGetAll(ctx, s.nodeKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to get nodes: %w", err)
+	}
+
+	nodes := make([]*nodev1.Node, len(ids))
+	for i := range ids {
+		data, err := reads[i].Result()
+		if err != nil || len(data) == 0 {
+			continue
+		}
+		if nodes[i], err = s.nodeFromHash(data); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
+
+// listMemberIDs returns the sorted IDs of the nodes matching the filters
+func (s *Store) listMemberIDs(ctx context.Context, typeFilter nodev1.NodeType, statusFilter nodev1.NodeStatus) ([]string, error) {
+	var members []string
+	var err error
+
+	switch {
+	case typeFilter != nodev1.NodeType_NODE_TYPE_UNSPECIFIED && statusFilter != nodev1.NodeStatus_NODE_STATUS_UNSPECIFIED:
+		members, err = s.client.SInter(ctx,
+			s.typeKey(typeFilter),
+			s.statusKey(statusFilter)).Result()
+	case typeFilter != nodev1.NodeType_NODE_TYPE_UNSPECIFIED:
+		members, err = s.client.SMembers(ctx, s.typeKey(typeFilter)).Result()
+	case statusFilter != nodev1.NodeStatus_NODE_STATUS_UNSPECIFIED:
+		members, err = s.client.SMembers(ctx, s.statusKey(statusFilter)).Result()
+	default:
+		members, err = s.client.SMembers(ctx, s.allNodesKey()).Result()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	// Set members come back in an unspecified order that changes as the
+	// set grows, so sort them to make pages deterministic
+	sort.Strings(members)
+	return members, nil
+}
+
+// GetDrift returns every node whose status differs from its desired status.
+// Nodes without a desired status are never reported.
+func (s *Store) GetDrift(ctx context.Context) ([]*nodev1.Node, error) {
+	members, err := s.client.SMembers(ctx, s.allNodesKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	drifted := []*nodev1.Node{}
+	for _, id := range members {
+		node, err := s.GetNode(ctx, id)
+		if err != nil {
+			continue
+		}
+		if nodedata.StatusDrifted(node.Status, node.DesiredStatus) {
+			drifted = append(drifted, node)
+		}
+	}
+
+	sort.Slice(drifted, func(i, j int) bool {
+		return drifted[i].Name < drifted[j].Name
+	})
+
+	return drifted, nil
+}
+
 func (s *Store) GetEventStream(ctx context.Context, lastID string) ([]*Event, error) {
 	args := &redis.XReadArgs{
-		Streams: []string{"nodes:events", lastID},
+		Streams: []string{s.eventsKey(), lastID},
 		Count:   100,
 		Block:   0,
 	}
@@ -234,35 +1188,98 @@ func (s *Store) GetEventStream(ctx context.Context, lastID string) ([]*Event, er
 	return events, nil
 }
 
+// ErrInvalidEventID is returned when an event ID is not a stream ID of the
+// form <ms> or <ms>-<seq>
+var ErrInvalidEventID = errors.New("invalid event id")
+
+// LastEventID returns the ID of the newest event, or "0-0" when there is none.
+// Reading from it yields only events appended afterwards.
+func (s *Store) LastEventID(ctx context.Context) (string, error) {
+	return s.RecentEventID(ctx, 0)
+}
+
+// RecentEventID returns the ID to read from to get the newest n events, or
+// "0-0" when the log holds n or fewer
+func (s *Store) RecentEventID(ctx context.Context, n int) (string, error) {
+	msgs, err := s.client.XRevRangeN(ctx, s.eventsKey(), "+", "-", int64(n)+1).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to read last event: %w", err)
+	}
+	if len(msgs) <= n {
+		return "0-0", nil
+	}
+	return msgs[n].ID, nil
+}
+
+// ReadEvents returns up to 100 events appended after afterID, waiting up to
+// block for one to arrive; as with XREAD, 0 waits indefinitely. It returns no
+// events and no error on timeout.
+func (s *Store) ReadEvents(ctx context.Context, afterID string, block time.Duration) ([]*Event, error) {
+	if !validEventID(afterID) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidEventID, afterID)
+	}
+
+	result, err := s.client.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{s.eventsKey(), afterID},
+		Count:   100,
+		Block:   block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+
+	var events []*Event
+	for _, stream := range result {
+		for _, msg := range stream.Messages {
+			event, err := s.eventFromStreamMessage(msg)
+			if err != nil {
+				continue
+			}
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func validEventID(id string) bool {
+	ms, seq, hasSeq := strings.Cut(id, "-")
+	return isDigits(ms) && (!hasSeq || isDigits(seq))
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 type Event struct {
 	ID            string
 	Type          nodev1.EventType
 	NodeID        string
 	ChangedFields []string
+	Changes       []FieldChange // Before and after values, see fieldChanges
 	Timestamp     time.Time
 }
 
-func (s *Store) saveNode(ctx context.Context, node *nodev1.Node) error {
-	labelsJSON, _ := json.Marshal(node.Labels)
+// FieldChange is the value of one field before and after an update
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
 
+func (s *Store) saveNode(ctx context.Context, node *nodev1.Node) error {
 	pipe := s.client.Pipeline()
-
-	nodeKey := fmt.Sprintf("node:%s", node.Id)
-	pipe.HSet(ctx, nodeKey, map[string]interface{}{
-		"id":            node.Id,
-		"type":          int32(node.Type),
-		"name":          node.Name,
-		"status":        int32(node.Status),
-		"last_seen":     node.LastSeen.AsTime().Format(time.RFC3339),
-		"labels_json":   string(labelsJSON),
-		"metadata_json": node.MetadataJson,
-	})
-
-	pipe.Set(ctx, fmt.Sprintf("node:byname:%d:%s", node.Type, node.Name), node.Id, 0)
-
-	pipe.SAdd(ctx, "nodes:all", node.Id)
-	pipe.SAdd(ctx, fmt.Sprintf("nodes:type:%d", node.Type), node.Id)
-	pipe.SAdd(ctx, fmt.Sprintf("nodes:status:%d", node.Status), node.Id)
+	s.queueSaveNode(ctx, pipe, node)
 
 	if _, err := pipe.Exec(ctx); err != nil {
 		return fmt.Errorf("failed to save node: %w", err)
@@ -271,44 +1288,67 @@ func (s *Store) saveNode(ctx context.Context, node *nodev1.Node) error {
 	return nil
 }
 
-func (s *Store) deleteIndexes(ctx context.Context, node *nodev1.Node) error {
-	pipe := s.client.Pipeline()
+// queueSaveNode adds the writes for a node's hash and indexes to pipe
+func (s *Store) queueSaveNode(ctx context.Context, pipe redis.Pipeliner, node *nodev1.Node) {
+	labelsJSON, _ := json.Marshal(node.Labels)
+
+	nodeKey := s.nodeKey(node.Id)
+	pipe.HSet(ctx, nodeKey, map[string]interface{}{
+		"id":             node.Id,
+		"type":           int32(node.Type),
+		"name":           node.Name,
+		"status":         int32(node.Status),
+		"status_reason":  node.StatusReason,
+		"desired_status": int32(node.DesiredStatus),
+		"last_seen":      node.LastSeen.AsTime().Format(time.RFC3339),
+		"labels_json":    string(labelsJSON),
+		"metadata_json":  node.MetadataJson,
+		"schema_version": SchemaVersion,
+	})
 
-	pipe.Del(ctx, fmt.Sprintf("node:byname:%d:%s", node.Type, node.Name))
-	pipe.SRem(ctx, fmt.Sprintf("nodes:type:%d", node.Type), node.Id)
-	pipe.SRem(ctx, fmt.Sprintf("nodes:status:%d", node.Status), node.Id)
+	pipe.Set(ctx, s.nameKey(node.Type, node.Name), node.Id, 0)
 
-	if _, err := pipe.Exec(ctx); err != nil {
-		return fmt.Errorf("failed to delete indexes: %w", err)
-	}
+	pipe.SAdd(ctx, s.allNodesKey(), node.Id)
+	pipe.ZAdd(ctx, s.sortedNodesKey(), redis.Z{Member: node.Id})
+	pipe.SAdd(ctx, s.typeKey(node.Type), node.Id)
+	pipe.SAdd(ctx, s.statusKey(node.Status), node.Id)
+}
 
-	return nil
+// queueDeleteIndexes adds the removal of a node's secondary indexes to pipe
+func (s *Store) queueDeleteIndexes(ctx context.Context, pipe redis.Pipeliner, node *nodev1.Node) {
+	pipe.Del(ctx, s.nameKey(node.Type, node.Name))
+	pipe.SRem(ctx, s.typeKey(node.Type), node.Id)
+	pipe.SRem(ctx, s.statusKey(node.Status), node.Id)
 }
 
-func (s *Store) appendEvent(ctx context.Context, eventType nodev1.EventType, nodeID string, changedFields []string) error {
+// queueAppendEvent adds an event to the nodes:events stream to pipe
+func (s *Store) queueAppendEvent(ctx context.Context, pipe redis.Pipeliner, eventType nodev1.EventType, nodeID string, changedFields []string, changes []FieldChange) {
 	changedFieldsJSON, _ := json.Marshal(changedFields)
 
-	args := &redis.XAddArgs{
-		Stream: "nodes:events",
-		Values: map[string]interface{}{
-			"event_type":     int32(eventType),
-			"node_id":        nodeID,
-			"changed_fields": string(changedFieldsJSON),
-			"ts":             time.Now().Unix(),
-		},
+	values := map[string]interface{}{
+		"event_type":     int32(eventType),
+		"node_id":        nodeID,
+		"changed_fields": string(changedFieldsJSON),
+		"ts":             time.Now().Unix(),
 	}
-
-	if _, err := s.client.XAdd(ctx, args).Result(); err != nil {
-		return fmt.Errorf("failed to append event: %w", err)
+	if len(changes) > 0 {
+		changesJSON, _ := json.Marshal(changes)
+		values["changes"] = string(changesJSON)
 	}
 
-	return nil
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.eventsKey(),
+		Values: values,
+	})
 }
 
+// nodeFromHash reads a node hash of any schema version. Fields an older layout
+// lacks keep their zero value, so nodes read fine before Migrate has run.
 func (s *Store) nodeFromHash(data map[string]string) (*nodev1.Node, error) {
 	node := &nodev1.Node{
 		Id:           data["id"],
 		Name:         data["name"],
+		StatusReason: data["status_reason"],
 		MetadataJson: data["metadata_json"],
 	}
 
@@ -320,6 +1360,10 @@ func (s *Store) nodeFromHash(data map[string]string) (*nodev1.Node, error) {
 	fmt.Sscanf(data["status"], "%d", &status)
 	node.Status = nodev1.NodeStatus(status)
 
+	var desiredStatus int32
+	fmt.Sscanf(data["desired_status"], "%d", &desiredStatus)
+	node.DesiredStatus = nodev1.NodeStatus(desiredStatus)
+
 	if lastSeenStr := data["last_seen"]; lastSeenStr != "" {
 		if t, err := time.Parse(time.RFC3339, lastSeenStr); err == nil {
 			node.LastSeen = timestamppb.New(t)
@@ -352,6 +1396,11 @@ func (s *Store) eventFromStreamMessage(msg redis.XMessage) (*Event, error) {
 		json.Unmarshal([]byte(changedFieldsStr), &event.ChangedFields)
 	}
 
+	// Entries written before changes were recorded don't have the field
+	if changesStr, _ := msg.Values["changes"].(string); changesStr != "" {
+		json.Unmarshal([]byte(changesStr), &event.Changes)
+	}
+
 	if tsStr := msg.Values["ts"].(string); tsStr != "" {
 		var ts int64
 		fmt.Sscanf(tsStr, "%d", &ts)
@@ -361,28 +1410,38 @@ func (s *Store) eventFromStreamMessage(msg redis.XMessage) (*Event, error) {
 	return event, nil
 }
 
-func (s *Store) getChangedFields(old, new *nodev1.Node) []string {
-	var fields []string
-
-	if old.Name != new.Name {
-		fields = append(fields, "name")
+// fieldChanges returns the before and after values of the fields that differ
+// between old and new, with labels compared per key. metadata_json is left out
+// so the size of an event doesn't grow with the metadata.
+func fieldChanges(old, new *nodev1.Node) []FieldChange {
+	var changes []FieldChange
+	add := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
 	}
-	if old.Type != new.Type {
-		fields = append(fields, "type")
+
+	add("name", old.Name, new.Name)
+	add("type", old.Type.String(), new.Type.String())
+	add("status", old.Status.String(), new.Status.String())
+	add("status_reason", old.StatusReason, new.StatusReason)
+	add("desired_status", old.DesiredStatus.String(), new.DesiredStatus.String())
+
+	keys := make(map[string]struct{}, len(old.Labels)+len(new.Labels))
+	for k := range old.Labels {
+		keys[k] = struct{}{}
 	}
-	if old.Status != new.Status {
-		fields = append(fields, "status")
+	for k := range new.Labels {
+		keys[k] = struct{}{}
 	}
-
-	oldLabels, _ := json.Marshal(old.Labels)
-	newLabels, _ := json.Marshal(new.Labels)
-	if string(oldLabels) != string(newLabels) {
-		fields = append(fields, "labels")
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
 	}
-
-	if old.MetadataJson != new.MetadataJson {
-		fields = append(fields, "metadata_json")
+	sort.Strings(sorted)
+	for _, k := range sorted {
+		add("labels."+k, old.Labels[k], new.Labels[k])
 	}
 
-	return fields
+	return changes
 }
\ No newline at end of file
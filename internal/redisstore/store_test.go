@@ -3,12 +3,17 @@ package redisstore
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func setupTestStore(t *testing.T) (*Store, *miniredis.Miniredis) {
@@ -41,7 +46,74 @@ func TestCreateNode(t *testing.T) {
 	assert.NotNil(t, created.LastSeen)
 
 	_, err = store.CreateNode(ctx, node)
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrNodeExists)
+}
+
+func TestUpsertNode(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+
+	ctx := context.Background()
+
+	// Concurrent reporters starting at once create the node exactly once
+	const reporters = 8
+	ids := make(chan string, reporters)
+	var createdCount atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < reporters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			node, created, err := store.UpsertNode(ctx, &nodev1.Node{
+				Name:   "sensor-host",
+				Type:   nodev1.NodeType_BAREMETAL,
+				Status: nodev1.NodeStatus_UP,
+			}, nil)
+			if err != nil {
+				t.Errorf("upsert: %v", err)
+				return
+			}
+			if created {
+				createdCount.Add(1)
+			}
+			ids <- node.Id
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	assert.Equal(t, int32(1), createdCount.Load())
+	var first string
+	for id := range ids {
+		if first == "" {
+			first = id
+		}
+		assert.Equal(t, first, id)
+	}
+	all, err := store.ListNodes(ctx, 0, 0, 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+
+	// A mask updates the existing node in place
+	updated, created, err := store.UpsertNode(ctx, &nodev1.Node{
+		Name:   "sensor-host",
+		Type:   nodev1.NodeType_BAREMETAL,
+		Status: nodev1.NodeStatus_DOWN,
+		Labels: map[string]string{"rack": "r1"},
+	}, []string{"status", "labels"})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, first, updated.Id)
+	assert.Equal(t, nodev1.NodeStatus_DOWN, updated.Status)
+
+	stored, err := store.GetNode(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, nodev1.NodeStatus_DOWN, stored.Status)
+	assert.Equal(t, "r1", stored.Labels["rack"])
+
+	_, _, err = store.UpsertNode(ctx, &nodev1.Node{Name: "sensor-host", Type: nodev1.NodeType_BAREMETAL}, []string{"id"})
+	assert.ErrorIs(t, err, ErrInvalidUpdateMask)
 }
 
 func TestGetNode(t *testing.T) {
@@ -65,7 +137,15 @@ func TestGetNode(t *testing.T) {
 	assert.Equal(t, created.Name, retrieved.Name)
 
 	_, err = store.GetNode(ctx, "nonexistent")
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrNodeNotFound)
+
+	byName, err := store.GetNodeByName(ctx, nodev1.NodeType_VM, "test-node")
+	require.NoError(t, err)
+	assert.Equal(t, created.Id, byName.Id)
+
+	// Names are only unique per type
+	_, err = store.GetNodeByName(ctx, nodev1.NodeType_CONTAINER, "test-node")
+	assert.ErrorIs(t, err, ErrNodeNotFound)
 }
 
 func TestUpdateNode(t *testing.T) {
@@ -86,12 +166,92 @@ func TestUpdateNode(t *testing.T) {
 	created.Name = "updated-node"
 	created.Status = nodev1.NodeStatus_DOWN
 
-	updated, err := store.UpdateNode(ctx, created)
+	updated, err := store.UpdateNode(ctx, created, nil)
 	require.NoError(t, err)
 	assert.Equal(t, "updated-node", updated.Name)
 	assert.Equal(t, nodev1.NodeStatus_DOWN, updated.Status)
 }
 
+func TestUpdateNodeWithMask(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+
+	ctx := context.Background()
+	created, err := store.CreateNode(ctx, &nodev1.Node{
+		Name:         "masked-node",
+		Type:         nodev1.NodeType_VM,
+		Status:       nodev1.NodeStatus_UP,
+		Labels:       map[string]string{"env": "prod", "team": "core", "tier": "gold"},
+		MetadataJson: `{"cpu": 4}`,
+	})
+	require.NoError(t, err)
+
+	// Only the masked fields are taken from the sparse patch
+	updated, err := store.UpdateNode(ctx, &nodev1.Node{
+		Id:     created.Id,
+		Status: nodev1.NodeStatus_DOWN,
+		Labels: map[string]string{"env": "staging"},
+	}, []string{"status", "labels.env", "labels.tier"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "masked-node", updated.Name)
+	assert.Equal(t, nodev1.NodeType_VM, updated.Type)
+	assert.Equal(t, nodev1.NodeStatus_DOWN, updated.Status)
+	assert.Equal(t, `{"cpu": 4}`, updated.MetadataJson)
+	assert.Equal(t, map[string]string{"env": "staging", "team": "core"}, updated.Labels)
+
+	stored, err := store.GetNode(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, updated.Labels, stored.Labels)
+	assert.Equal(t, nodev1.NodeStatus_DOWN, stored.Status)
+
+	downNodes, err := store.ListNodes(ctx, 0, nodev1.NodeStatus_DOWN, 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, downNodes, 1, "status index follows the masked update")
+
+	_, err = store.UpdateNode(ctx, &nodev1.Node{Id: created.Id}, []string{"last_seen"})
+	assert.ErrorIs(t, err, ErrInvalidUpdateMask)
+}
+
+func TestUpdateNodeConcurrentPartialUpdates(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+
+	ctx := context.Background()
+	created, err := store.CreateNode(ctx, &nodev1.Node{
+		Name:   "contended-node",
+		Type:   nodev1.NodeType_VM,
+		Status: nodev1.NodeStatus_UP,
+	})
+	require.NoError(t, err)
+
+	// Two writers each own one label key; neither may lose the other's value
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				_, err := store.UpdateNode(ctx, &nodev1.Node{
+					Id:     created.Id,
+					Labels: map[string]string{key: fmt.Sprintf("%d", i)},
+				}, []string{"labels." + key})
+				if err != nil {
+					t.Errorf("update %s: %v", key, err)
+					return
+				}
+			}
+		}(key)
+	}
+	wg.Wait()
+
+	stored, err := store.GetNode(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "19", "b": "19"}, stored.Labels)
+}
+
 func TestUpdateStatus(t *testing.T) {
 	store, mr := setupTestStore(t)
 	defer mr.Close()
@@ -107,9 +267,77 @@ func TestUpdateStatus(t *testing.T) {
 	created, err := store.CreateNode(ctx, node)
 	require.NoError(t, err)
 
-	updated, err := store.UpdateStatus(ctx, created.Id, nodev1.NodeStatus_DEGRADED)
+	updated, changed, err := store.UpdateStatus(ctx, created.Id, nodev1.NodeStatus_DEGRADED, "")
 	require.NoError(t, err)
+	assert.Equal(t, []string{"status"}, changed)
 	assert.Equal(t, nodev1.NodeStatus_DEGRADED, updated.Status)
+	assert.Equal(t, "test-node", updated.Name)
+	assert.Equal(t, nodev1.NodeType_VM, updated.Type)
+
+	isMember, err := store.client.SIsMember(ctx, store.statusKey(nodev1.NodeStatus_UP), created.Id).Result()
+	require.NoError(t, err)
+	assert.False(t, isMember)
+	isMember, err = store.client.SIsMember(ctx, store.statusKey(nodev1.NodeStatus_DEGRADED), created.Id).Result()
+	require.NoError(t, err)
+	assert.True(t, isMember)
+
+	// Setting the current status writes no event
+	before, err := store.CountEvents(ctx)
+	require.NoError(t, err)
+	unchanged, changed, err := store.UpdateStatus(ctx, created.Id, nodev1.NodeStatus_DEGRADED, "")
+	require.NoError(t, err)
+	assert.Nil(t, changed)
+	assert.Equal(t, nodev1.NodeStatus_DEGRADED, unchanged.Status)
+	after, err := store.CountEvents(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	_, _, err = store.UpdateStatus(ctx, "missing", nodev1.NodeStatus_UP, "")
+	assert.ErrorIs(t, err, ErrNodeNotFound)
+}
+
+func TestUpdateStatusConcurrent(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+
+	ctx := context.Background()
+	created, err := store.CreateNode(ctx, &nodev1.Node{
+		Name:   "contended",
+		Type:   nodev1.NodeType_VM,
+		Status: nodev1.NodeStatus_UP,
+		Labels: map[string]string{"env": "prod"},
+	})
+	require.NoError(t, err)
+
+	// Writers racing between statuses must leave the node in exactly the
+	// status set its hash names, with the rest of the node untouched
+	statuses := []nodev1.NodeStatus{nodev1.NodeStatus_UP, nodev1.NodeStatus_DOWN, nodev1.NodeStatus_DEGRADED}
+	var wg sync.WaitGroup
+	for w := 0; w < 6; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				_, _, err := store.UpdateStatus(ctx, created.Id, statuses[(w+i)%len(statuses)], "")
+				if err != nil {
+					assert.Contains(t, err.Error(), "concurrent updates")
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	stored, err := store.GetNode(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "contended", stored.Name)
+	assert.Equal(t, map[string]string{"env": "prod"}, stored.Labels)
+
+	for _, status := range statuses {
+		isMember, err := store.client.SIsMember(ctx, store.statusKey(status), created.Id).Result()
+		require.NoError(t, err)
+		assert.Equal(t, status == stored.Status, isMember, "membership of %s", status)
+	}
 }
 
 func TestDeleteNode(t *testing.T) {
@@ -132,6 +360,67 @@ func TestDeleteNode(t *testing.T) {
 
 	_, err = store.GetNode(ctx, created.Id)
 	assert.Error(t, err)
+
+	// Without a grace period nothing is kept
+	_, _, err = store.GetDeletedNode(ctx, created.Id)
+	assert.ErrorIs(t, err, ErrNodeNotFound)
+}
+
+func TestSoftDeleteAndRestore(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+	store.SetSoftDeleteGrace(time.Hour)
+
+	ctx := context.Background()
+	created, err := store.CreateNode(ctx, &nodev1.Node{
+		Name:   "web-01",
+		Type:   nodev1.NodeType_VM,
+		Status: nodev1.NodeStatus_UP,
+		Labels: map[string]string{"env": "prod"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, store.DeleteNode(ctx, created.Id))
+
+	_, err = store.GetNode(ctx, created.Id)
+	assert.ErrorIs(t, err, ErrNodeNotFound)
+	active, err := store.ListNodes(ctx, nodev1.NodeType_VM, 0, 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, active, "tombstones leave the indexes")
+
+	deleted, deletedAt, err := store.GetDeletedNode(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "web-01", deleted.Name)
+	assert.WithinDuration(t, time.Now(), deletedAt, 5*time.Second)
+
+	events, err := store.ReadEvents(ctx, "0", -1)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, nodev1.EventType_DELETED, events[1].Type, "DELETED is emitted right away")
+
+	restored, err := store.RestoreNode(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, created.Id, restored.Id)
+	assert.Equal(t, "prod", restored.Labels["env"])
+
+	byName, err := store.GetNodeByName(ctx, nodev1.NodeType_VM, "web-01")
+	require.NoError(t, err)
+	assert.Equal(t, created.Id, byName.Id)
+	_, _, err = store.GetDeletedNode(ctx, created.Id)
+	assert.ErrorIs(t, err, ErrNodeNotFound)
+
+	// A name reused meanwhile blocks the restore
+	require.NoError(t, store.DeleteNode(ctx, created.Id))
+	_, err = store.CreateNode(ctx, &nodev1.Node{Name: "web-01", Type: nodev1.NodeType_VM})
+	require.NoError(t, err)
+	_, err = store.RestoreNode(ctx, created.Id)
+	assert.ErrorIs(t, err, ErrNodeExists)
+
+	// Tombstones expire after the grace period
+	mr.FastForward(2 * time.Hour)
+	_, err = store.RestoreNode(ctx, created.Id)
+	assert.ErrorIs(t, err, ErrNodeNotFound)
 }
 
 func TestListNodes(t *testing.T) {
@@ -165,4 +454,552 @@ func TestListNodes(t *testing.T) {
 	bareMetalNodes, err := store.ListNodes(ctx, nodev1.NodeType_BAREMETAL, 0, 0, 0)
 	require.NoError(t, err)
 	assert.Len(t, bareMetalNodes, 3)
+}
+
+func TestGetDrift(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+
+	ctx := context.Background()
+
+	nodes := []*nodev1.Node{
+		{Name: "in-sync", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP, DesiredStatus: nodev1.NodeStatus_UP},
+		{Name: "drifted-down", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_DOWN, DesiredStatus: nodev1.NodeStatus_UP},
+		{Name: "no-desired", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_DOWN},
+		{Name: "drifted-up", Type: nodev1.NodeType_CONTAINER, Status: nodev1.NodeStatus_UP, DesiredStatus: nodev1.NodeStatus_DOWN},
+	}
+	ids := make(map[string]string)
+	for _, node := range nodes {
+		created, err := store.CreateNode(ctx, node)
+		require.NoError(t, err)
+		ids[created.Name] = created.Id
+	}
+
+	drifted, err := store.GetDrift(ctx)
+	require.NoError(t, err)
+	require.Len(t, drifted, 2)
+	assert.Equal(t, "drifted-down", drifted[0].Name)
+	assert.Equal(t, nodev1.NodeStatus_UP, drifted[0].DesiredStatus)
+	assert.Equal(t, "drifted-up", drifted[1].Name)
+
+	// Converging a node to its desired status removes it from the report
+	_, _, err = store.UpdateStatus(ctx, ids["drifted-down"], nodev1.NodeStatus_UP, "")
+	require.NoError(t, err)
+
+	drifted, err = store.GetDrift(ctx)
+	require.NoError(t, err)
+	require.Len(t, drifted, 1)
+	assert.Equal(t, "drifted-up", drifted[0].Name)
+}
+
+func TestListNodesAfterStableWhileInserting(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		_, err := store.CreateNode(ctx, &nodev1.Node{
+			Id:     fmt.Sprintf("node-%02d", i),
+			Name:   fmt.Sprintf("node-%02d", i),
+			Type:   nodev1.NodeType_VM,
+			Status: nodev1.NodeStatus_UP,
+		})
+		require.NoError(t, err)
+	}
+
+	seen := make(map[string]int)
+	cursor := ""
+	for page := 0; ; page++ {
+		nodes, next, err := store.ListNodesAfter(ctx, 0, 0, cursor, 3)
+		require.NoError(t, err)
+		for _, n := range nodes {
+			seen[n.Id]++
+		}
+
+		if page == 0 {
+			// One node lands before the cursor and one after it
+			for _, id := range []string{"node-015", "node-055"} {
+				_, err := store.CreateNode(ctx, &nodev1.Node{
+					Id:     id,
+					Name:   id,
+					Type:   nodev1.NodeType_VM,
+					Status: nodev1.NodeStatus_UP,
+				})
+				require.NoError(t, err)
+			}
+		}
+
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, 1, seen[fmt.Sprintf("node-%02d", i)], "node-%02d", i)
+	}
+	assert.Equal(t, 1, seen["node-055"], "node inserted after the cursor is listed")
+	assert.Zero(t, seen["node-015"], "node inserted before the cursor is not listed")
+	for id, n := range seen {
+		assert.Equal(t, 1, n, "%s listed more than once", id)
+	}
+}
+
+func TestListNodesAfterSkipsDeletedWithoutEndingPage(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		_, err := store.CreateNode(ctx, &nodev1.Node{
+			Id:     fmt.Sprintf("node-%d", i),
+			Name:   fmt.Sprintf("node-%d", i),
+			Type:   nodev1.NodeType_VM,
+			Status: nodev1.NodeStatus_UP,
+		})
+		require.NoError(t, err)
+	}
+
+	// A hash removed behind the index's back, as if deleted mid-listing
+	mr.Del("node:node-1")
+
+	nodes, cursor, err := store.ListNodesAfter(ctx, 0, 0, "", 2)
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+	assert.Equal(t, "node-0", nodes[0].Id)
+	assert.Equal(t, "node-2", nodes[1].Id)
+	assert.Equal(t, "node-2", cursor)
+
+	nodes, cursor, err = store.ListNodesAfter(ctx, 0, 0, cursor, 2)
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+	assert.Equal(t, "node-4", nodes[1].Id)
+	assert.Empty(t, cursor, "listing is exhausted")
+}
+
+func TestListNodesAfterFilters(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+
+	ctx := context.Background()
+
+	for i := 0; i < 9; i++ {
+		nodeType := nodev1.NodeType_VM
+		if i%3 == 0 {
+			nodeType = nodev1.NodeType_CONTAINER
+		}
+		_, err := store.CreateNode(ctx, &nodev1.Node{
+			Id:     fmt.Sprintf("node-%d", i),
+			Name:   fmt.Sprintf("node-%d", i),
+			Type:   nodeType,
+			Status: nodev1.NodeStatus_UP,
+		})
+		require.NoError(t, err)
+	}
+
+	// Pages fill with matching nodes, skipping the others in between
+	var ids []string
+	cursor := ""
+	for {
+		nodes, next, err := store.ListNodesAfter(ctx, nodev1.NodeType_CONTAINER, nodev1.NodeStatus_UP, cursor, 2)
+		require.NoError(t, err)
+		for _, n := range nodes {
+			ids = append(ids, n.Id)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	assert.Equal(t, []string{"node-0", "node-3", "node-6"}, ids)
+
+	nodes, _, err := store.ListNodesAfter(ctx, nodev1.NodeType_CONTAINER, nodev1.NodeStatus_DOWN, "", 0)
+	require.NoError(t, err)
+	assert.Empty(t, nodes)
+}
+
+func TestUpdateStatusReason(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+
+	ctx := context.Background()
+	created, err := store.CreateNode(ctx, &nodev1.Node{Name: "db-01", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP})
+	require.NoError(t, err)
+
+	down, changed, err := store.UpdateStatus(ctx, created.Id, nodev1.NodeStatus_DOWN, "disk full")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"status", "status_reason"}, changed)
+	assert.Equal(t, "disk full", down.StatusReason)
+
+	// The same status with a new reason only updates the reason
+	_, changed, err = store.UpdateStatus(ctx, created.Id, nodev1.NodeStatus_DOWN, "disk full on /var")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"status_reason"}, changed)
+
+	// ... and without a reason it changes nothing
+	same, changed, err := store.UpdateStatus(ctx, created.Id, nodev1.NodeStatus_DOWN, "")
+	require.NoError(t, err)
+	assert.Nil(t, changed)
+	assert.Equal(t, "disk full on /var", same.StatusReason)
+
+	// A new status without a reason clears the stale one
+	up, changed, err := store.UpdateStatus(ctx, created.Id, nodev1.NodeStatus_UP, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"status", "status_reason"}, changed)
+	assert.Empty(t, up.StatusReason)
+
+	// The event log keeps every reason
+	events, err := store.ReadEvents(ctx, "0-0", 0)
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	assert.Equal(t, []FieldChange{
+		{Field: "status", Old: "UP", New: "DOWN"},
+		{Field: "status_reason", Old: "", New: "disk full"},
+	}, events[1].Changes)
+	assert.Equal(t, []FieldChange{{Field: "status_reason", Old: "disk full", New: "disk full on /var"}}, events[2].Changes)
+	assert.Equal(t, []string{"status", "status_reason"}, events[3].ChangedFields)
+
+	results, err := store.UpdateStatusBatch(ctx, []StatusUpdate{
+		{ID: created.Id, Status: nodev1.NodeStatus_DEGRADED, Reason: "high latency"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"status", "status_reason"}, results[0].ChangedFields)
+	got, err := store.GetNode(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "high latency", got.StatusReason)
+}
+
+func TestUpdateStatusBatch(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+
+	ctx := context.Background()
+	a, err := store.CreateNode(ctx, &nodev1.Node{Name: "a", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP})
+	require.NoError(t, err)
+	b, err := store.CreateNode(ctx, &nodev1.Node{Name: "b", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP})
+	require.NoError(t, err)
+
+	before, err := store.client.XLen(ctx, "nodes:events").Result()
+	require.NoError(t, err)
+
+	results, err := store.UpdateStatusBatch(ctx, []StatusUpdate{
+		{ID: a.Id, Status: nodev1.NodeStatus_DOWN},
+		{ID: b.Id, Status: nodev1.NodeStatus_UP},
+		{ID: "missing", Status: nodev1.NodeStatus_DOWN},
+		{ID: a.Id, Status: nodev1.NodeStatus_DEGRADED},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	assert.True(t, results[0].Changed)
+	assert.Equal(t, nodev1.NodeStatus_DOWN, results[0].Node.Status)
+	assert.False(t, results[1].Changed, "same status is a no-op")
+	assert.NoError(t, results[1].Err)
+	assert.Error(t, results[2].Err)
+	assert.Nil(t, results[2].Node)
+	assert.True(t, results[3].Changed)
+
+	// One event per actual change
+	after, err := store.client.XLen(ctx, "nodes:events").Result()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), after-before)
+
+	got, err := store.GetNode(ctx, a.Id)
+	require.NoError(t, err)
+	assert.Equal(t, nodev1.NodeStatus_DEGRADED, got.Status)
+
+	// Indexes follow the final status only
+	down, err := store.ListNodes(ctx, nodev1.NodeType_NODE_TYPE_UNSPECIFIED, nodev1.NodeStatus_DOWN, 0, 10)
+	require.NoError(t, err)
+	assert.Empty(t, down)
+	degraded, err := store.ListNodes(ctx, nodev1.NodeType_NODE_TYPE_UNSPECIFIED, nodev1.NodeStatus_DEGRADED, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, degraded, 1)
+	assert.Equal(t, a.Id, degraded[0].Id)
+}
+
+func TestUpdateStatusBatchConcurrentLabelUpdate(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+
+	ctx := context.Background()
+	var ids []string
+	for _, name := range []string{"a", "b", "c"} {
+		n, err := store.CreateNode(ctx, &nodev1.Node{Name: name, Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP})
+		require.NoError(t, err)
+		ids = append(ids, n.Id)
+	}
+
+	// Label writers race the batches; the batches must neither drop their
+	// labels nor leave a node in a status set its hash doesn't name
+	statuses := []nodev1.NodeStatus{nodev1.NodeStatus_UP, nodev1.NodeStatus_DOWN, nodev1.NodeStatus_DEGRADED}
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				_, err := store.UpdateNode(ctx, &nodev1.Node{
+					Id:     id,
+					Labels: map[string]string{"rev": fmt.Sprintf("%d", i)},
+				}, []string{"labels.rev"})
+				if err != nil {
+					t.Errorf("update labels of %s: %v", id, err)
+					return
+				}
+			}
+		}(id)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			var batch []StatusUpdate
+			for j, id := range ids {
+				batch = append(batch, StatusUpdate{ID: id, Status: statuses[(i+j)%len(statuses)]})
+			}
+			results, err := store.UpdateStatusBatch(ctx, batch)
+			if err != nil {
+				t.Errorf("batch: %v", err)
+				return
+			}
+			for _, r := range results {
+				if r.Err != nil {
+					assert.Contains(t, r.Err.Error(), "concurrent updates")
+				}
+			}
+		}
+	}()
+	wg.Wait()
+
+	for _, id := range ids {
+		stored, err := store.GetNode(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"rev": "19"}, stored.Labels)
+		for _, status := range statuses {
+			isMember, err := store.client.SIsMember(ctx, store.statusKey(status), id).Result()
+			require.NoError(t, err)
+			assert.Equal(t, status == stored.Status, isMember, "membership of %s in %s", id, status)
+		}
+	}
+}
+
+func TestMarkStaleNodes(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+
+	ctx := context.Background()
+	old := timestamppb.New(time.Now().Add(-time.Hour))
+	nodes := map[string]*nodev1.Node{
+		"stale":   {Id: "stale", Name: "stale", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP, LastSeen: old},
+		"fresh":   {Id: "fresh", Name: "fresh", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP, LastSeen: timestamppb.Now()},
+		"demo":    {Id: "demo", Name: "demo", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP, LastSeen: old, Labels: map[string]string{"demo": "true"}},
+		"already": {Id: "already", Name: "already", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UNKNOWN, LastSeen: old},
+	}
+	for _, n := range nodes {
+		require.NoError(t, store.saveNode(ctx, n))
+	}
+
+	marked, err := store.MarkStaleNodes(ctx, time.Now().Add(-time.Minute), nodev1.NodeStatus_UNKNOWN, map[string]string{"demo": "true"})
+	require.NoError(t, err)
+	require.Len(t, marked, 1)
+	assert.Equal(t, "stale", marked[0].Id)
+	assert.Equal(t, nodev1.NodeStatus_UNKNOWN, marked[0].Status)
+
+	got, err := store.GetNode(ctx, "stale")
+	require.NoError(t, err)
+	assert.Equal(t, nodev1.NodeStatus_UNKNOWN, got.Status)
+	assert.Equal(t, old.AsTime().Unix(), got.LastSeen.AsTime().Unix(), "LastSeen is kept")
+	assert.Equal(t, "not seen since "+old.AsTime().UTC().Format(time.RFC3339), got.StatusReason)
+
+	up, err := store.ListNodes(ctx, nodev1.NodeType_NODE_TYPE_UNSPECIFIED, nodev1.NodeStatus_UP, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, up, 2, "fresh and excluded nodes stay UP")
+
+	// A second pass has nothing left to do
+	marked, err = store.MarkStaleNodes(ctx, time.Now().Add(-time.Minute), nodev1.NodeStatus_UNKNOWN, map[string]string{"demo": "true"})
+	require.NoError(t, err)
+	assert.Empty(t, marked)
+}
+
+func TestReadEventsAfterID(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+
+	ctx := context.Background()
+
+	last, err := store.LastEventID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "0-0", last)
+
+	a, err := store.CreateNode(ctx, &nodev1.Node{Name: "a", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP})
+	require.NoError(t, err)
+
+	mark, err := store.LastEventID(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, "0-0", mark)
+
+	_, _, err = store.UpdateStatus(ctx, a.Id, nodev1.NodeStatus_DOWN, "")
+	require.NoError(t, err)
+	require.NoError(t, store.DeleteNode(ctx, a.Id))
+
+	events, err := store.ReadEvents(ctx, "0-0", 0)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	// Only what came after the mark
+	events, err = store.ReadEvents(ctx, mark, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, nodev1.EventType_UPDATED, events[0].Type)
+	assert.Equal(t, nodev1.EventType_DELETED, events[1].Type)
+	assert.Equal(t, a.Id, events[1].NodeID)
+
+	// Nothing newer: the read times out empty
+	events, err = store.ReadEvents(ctx, events[1].ID, 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	for _, id := range []string{"", "abc", "1-", "-1", "1-2-3"} {
+		_, err = store.ReadEvents(ctx, id, 0)
+		assert.ErrorIs(t, err, ErrInvalidEventID, id)
+	}
+}
+
+func TestRecentEventID(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+
+	ctx := context.Background()
+	for _, name := range []string{"a", "b", "c"} {
+		_, err := store.CreateNode(ctx, &nodev1.Node{Name: name, Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP})
+		require.NoError(t, err)
+	}
+
+	from, err := store.RecentEventID(ctx, 2)
+	require.NoError(t, err)
+	events, err := store.ReadEvents(ctx, from, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	last, err := store.LastEventID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, last, events[1].ID)
+
+	// Asking for more than the log holds starts at the beginning
+	from, err = store.RecentEventID(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "0-0", from)
+}
+
+func TestEventsCarryFieldChanges(t *testing.T) {
+	store, mr := setupTestStore(t)
+	defer mr.Close()
+	defer store.Close()
+
+	ctx := context.Background()
+	created, err := store.CreateNode(ctx, &nodev1.Node{
+		Name:         "a",
+		Type:         nodev1.NodeType_VM,
+		Status:       nodev1.NodeStatus_UP,
+		Labels:       map[string]string{"env": "prod", "zone": "a"},
+		MetadataJson: `{"cpu":4}`,
+	})
+	require.NoError(t, err)
+
+	_, _, err = store.UpdateStatus(ctx, created.Id, nodev1.NodeStatus_DOWN, "")
+	require.NoError(t, err)
+
+	patch := &nodev1.Node{
+		Id:           created.Id,
+		Labels:       map[string]string{"env": "dev", "team": "core"},
+		MetadataJson: `{"cpu":8}`,
+	}
+	_, err = store.UpdateNode(ctx, patch, []string{"labels", "metadata_json"})
+	require.NoError(t, err)
+
+	events, err := store.ReadEvents(ctx, "0-0", 0)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	assert.Empty(t, events[0].Changes, "CREATED events carry no changes")
+	assert.Equal(t, []FieldChange{{Field: "status", Old: "UP", New: "DOWN"}}, events[1].Changes)
+
+	// Labels are compared per key; metadata is only listed as changed
+	assert.Contains(t, events[2].ChangedFields, "metadata_json")
+	assert.Equal(t, []FieldChange{
+		{Field: "labels.env", Old: "prod", New: "dev"},
+		{Field: "labels.team", Old: "", New: "core"},
+		{Field: "labels.zone", Old: "a", New: ""},
+	}, events[2].Changes)
+}
+
+func TestClusterKeysShareOneSlot(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	store, err := NewCluster([]string{mr.Addr()}, "", Options{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx := context.Background()
+	created, err := store.CreateNode(ctx, &nodev1.Node{Name: "web-01", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP})
+	require.NoError(t, err)
+	_, err = store.UpdateNode(ctx, &nodev1.Node{Id: created.Id, Status: nodev1.NodeStatus_DOWN}, []string{"status"})
+	require.NoError(t, err)
+
+	byName, err := store.GetNodeByName(ctx, nodev1.NodeType_VM, "web-01")
+	require.NoError(t, err)
+	assert.Equal(t, created.Id, byName.Id)
+
+	down, err := store.ListNodes(ctx, nodev1.NodeType_VM, nodev1.NodeStatus_DOWN, 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, down, 1)
+
+	events, err := store.ReadEvents(ctx, "0", -1)
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	for _, key := range mr.Keys() {
+		assert.True(t, strings.HasPrefix(key, clusterHashTag), "key %q lacks the cluster hash tag", key)
+	}
+}
+
+func TestNewWithOptionsBoundsPool(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	store, err := NewWithOptions(mr.Addr(), "", 0, Options{PoolSize: 2, DialTimeout: time.Second})
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := store.CreateNode(ctx, &nodev1.Node{Name: fmt.Sprintf("node-%d", i), Type: nodev1.NodeType_VM})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	stats := store.PoolStats()
+	assert.LessOrEqual(t, stats.TotalConns, uint32(2))
+	assert.NotZero(t, stats.Hits+stats.Misses)
 }
\ No newline at end of file
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// DefaultHealthCheckInterval is how often RunHealthChecker pings Redis
+const DefaultHealthCheckInterval = 5 * time.Second
+
+// healthCheckTimeout bounds each Redis ping, so a stalled Redis reports
+// NOT_SERVING instead of holding up the next check
+const healthCheckTimeout = 2 * time.Second
+
+// RegisterHealth registers the standard grpc.health.v1.Health service on srv
+// and returns it. The server as a whole ("") and node.v1.NodeService start
+// NOT_SERVING; RunHealthChecker keeps both in line with Redis.
+func RegisterHealth(srv grpc.ServiceRegistrar) *health.Server {
+	hs := health.NewServer()
+	for _, name := range healthServices {
+		hs.SetServingStatus(name, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	healthpb.RegisterHealthServer(srv, hs)
+	return hs
+}
+
+// healthServices are the names the health service answers for
+var healthServices = []string{"", nodev1.NodeService_ServiceDesc.ServiceName}
+
+// RunHealthChecker pings Redis right away and then every interval, setting
+// hs to SERVING while the ping succeeds and NOT_SERVING otherwise. When ctx
+// is done it marks every service NOT_SERVING for good, so load balancers
+// drain the server during shutdown.
+func (s *NodeService) RunHealthChecker(ctx context.Context, hs *health.Server, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultHealthCheckInterval
+	}
+	defer hs.Shutdown()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		if current := s.CheckHealth(ctx, hs); current != last {
+			if current == healthpb.HealthCheckResponse_SERVING {
+				s.logger.Info("grpc health: serving")
+			} else if ctx.Err() == nil {
+				s.logger.Warn("grpc health: not serving, Redis is unreachable")
+			}
+			last = current
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckHealth runs one Redis ping, updates hs and returns the status set
+func (s *NodeService) CheckHealth(ctx context.Context, hs *health.Server) healthpb.HealthCheckResponse_ServingStatus {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	servingStatus := healthpb.HealthCheckResponse_SERVING
+	if err := s.store.Ping(ctx); err != nil {
+		s.logger.Debug("grpc health: Redis ping failed", zap.Error(err))
+		servingStatus = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	for _, name := range healthServices {
+		hs.SetServingStatus(name, servingStatus)
+	}
+	return servingStatus
+}
\ No newline at end of file
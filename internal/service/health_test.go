@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/melkior/nodestatus/internal/redisstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealthFollowsRedis(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+	store, err := redisstore.NewWithOptions(mr.Addr(), "", 0, redisstore.Options{MaxRetries: -1})
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	svc := NewNodeService(store, zap.NewNop())
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	hs := RegisterHealth(srv)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	client := healthpb.NewHealthClient(conn)
+	ctx := context.Background()
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.Status
+	}
+
+	// Nothing has checked Redis yet
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, svc.CheckHealth(ctx, hs))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check(nodev1.NodeService_ServiceDesc.ServiceName))
+
+	mr.Close()
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, svc.CheckHealth(ctx, hs))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(nodev1.NodeService_ServiceDesc.ServiceName))
+
+	require.NoError(t, mr.Restart())
+
+	// The checker picks the recovery up, then reports NOT_SERVING once stopped
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		svc.RunHealthChecker(runCtx, hs, 10*time.Millisecond)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool {
+		return check("") == healthpb.HealthCheckResponse_SERVING
+	}, 3*time.Second, 10*time.Millisecond)
+
+	stop()
+	<-done
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+}
\ No newline at end of file
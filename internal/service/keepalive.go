@@ -0,0 +1,20 @@
+package service
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// KeepaliveServerOptions lets clients send keepalive pings as often as
+// grpcclient does by default. Without it, grpc-go servers close connections
+// that ping more than once every five minutes.
+func KeepaliveServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             15 * time.Second,
+			PermitWithoutStream: true,
+		}),
+	}
+}
\ No newline at end of file
@@ -2,33 +2,135 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
-	"github.com/melkior/nodestatus/internal/events"
 	"github.com/melkior/nodestatus/internal/redisstore"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// DefaultMaxMetadataBytes bounds MetadataJson unless SetMaxMetadataBytes
+// changes it
+const DefaultMaxMetadataBytes = 64 << 10
+
+// DefaultHeartbeatInterval is how long a WatchEvents stream may stay silent
+// before a heartbeat is sent, unless SetHeartbeatInterval changes it. It is
+// below the 60s idle timeout common to load balancers.
+const DefaultHeartbeatInterval = 30 * time.Second
+
+// DefaultSlowWatcherTimeout is how long a send on a WatchEvents stream may
+// stay blocked before the stream is ended, unless SetSlowWatcherTimeout
+// changes it
+const DefaultSlowWatcherTimeout = time.Minute
+
 type NodeService struct {
 	nodev1.UnimplementedNodeServiceServer
 	store  *redisstore.Store
-	broker *events.Broker
 	logger *zap.Logger
+
+	started  time.Time
+	watchers atomic.Int32 // Open WatchEvents streams
+
+	maxMetadataBytes   int
+	heartbeatInterval  time.Duration
+	slowWatcherTimeout time.Duration
+
+	// closing is cancelled by Shutdown to end the WatchEvents handlers,
+	// which are tracked in handlers. handlersMu orders handlers.Add against
+	// Shutdown's Wait.
+	closing    context.Context
+	startClose context.CancelFunc
+	handlersMu sync.Mutex
+	handlers   sync.WaitGroup
 }
 
-func NewNodeService(store *redisstore.Store, broker *events.Broker, logger *zap.Logger) *NodeService {
+func NewNodeService(store *redisstore.Store, logger *zap.Logger) *NodeService {
+	closing, startClose := context.WithCancel(context.Background())
 	return &NodeService{
-		store:  store,
-		broker: broker,
-		logger: logger,
+		store:   store,
+		logger:  logger,
+		started: time.Now(),
+
+		maxMetadataBytes:   DefaultMaxMetadataBytes,
+		heartbeatInterval:  DefaultHeartbeatInterval,
+		slowWatcherTimeout: DefaultSlowWatcherTimeout,
+
+		closing:    closing,
+		startClose: startClose,
 	}
 }
 
+// Shutdown ends every WatchEvents stream with a final server_closing message
+// carrying the position to resume from and waits for the handlers to return.
+// New streams are refused with Unavailable. It returns ctx.Err() if ctx ends
+// first. Call it before grpc.Server's GracefulStop, which otherwise waits for
+// the streams forever.
+func (s *NodeService) Shutdown(ctx context.Context) error {
+	s.handlersMu.Lock()
+	s.startClose()
+	s.handlersMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.handlers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetMaxMetadataBytes changes the size limit on MetadataJson; zero or less
+// removes it
+func (s *NodeService) SetMaxMetadataBytes(n int) {
+	s.maxMetadataBytes = n
+}
+
+// SetHeartbeatInterval changes how long a WatchEvents stream may stay silent
+// before a heartbeat message is sent; zero or less disables heartbeats. The
+// event log is polled every second, which bounds the precision.
+func (s *NodeService) SetHeartbeatInterval(d time.Duration) {
+	s.heartbeatInterval = d
+}
+
+// SetSlowWatcherTimeout changes how long a send on a WatchEvents stream may
+// stay blocked before the stream is ended with ResourceExhausted; zero or
+// less lets a send block until the client goes away
+func (s *NodeService) SetSlowWatcherTimeout(d time.Duration) {
+	s.slowWatcherTimeout = d
+}
+
+// validateMetadata rejects MetadataJson that isn't a JSON object or is over the
+// size limit. Empty metadata is allowed.
+func (s *NodeService) validateMetadata(metadata string) error {
+	if metadata == "" {
+		return nil
+	}
+	if s.maxMetadataBytes > 0 && len(metadata) > s.maxMetadataBytes {
+		return status.Errorf(codes.InvalidArgument, "metadata_json is %d bytes, the limit is %d", len(metadata), s.maxMetadataBytes)
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(metadata), &obj); err != nil || obj == nil {
+		return status.Error(codes.InvalidArgument, "metadata_json must be a JSON object")
+	}
+	return nil
+}
+
 func (s *NodeService) CreateNode(ctx context.Context, req *nodev1.CreateNodeRequest) (*nodev1.CreateNodeResponse, error) {
 	if req.Node == nil {
 		return nil, status.Error(codes.InvalidArgument, "node is required")
@@ -42,7 +144,18 @@ func (s *NodeService) CreateNode(ctx context.Context, req *nodev1.CreateNodeRequ
 		return nil, status.Error(codes.InvalidArgument, "node type is required")
 	}
 
+	if err := s.validateMetadata(req.Node.MetadataJson); err != nil {
+		return nil, err
+	}
+
+	if req.Upsert {
+		return s.upsertNode(ctx, req)
+	}
+
 	node, err := s.store.CreateNode(ctx, req.Node)
+	if errors.Is(err, redisstore.ErrNodeExists) {
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	}
 	if err != nil {
 		s.logger.Error("failed to create node", zap.Error(err))
 		return nil, status.Error(codes.Internal, err.Error())
@@ -53,12 +166,35 @@ func (s *NodeService) CreateNode(ctx context.Context, req *nodev1.CreateNodeRequ
 		zap.String("name", node.Name),
 		zap.String("type", node.Type.String()))
 
-	s.broker.Publish(ctx, &nodev1.WatchEventsResponse{
-		EventType: nodev1.EventType_CREATED,
-		Node:      node,
-	})
+	return &nodev1.CreateNodeResponse{Node: node, Created: true}, nil
+}
+
+// upsertNode serves CreateNode with upsert set: the node is created unless one
+// with the same name and type exists, which is returned and optionally updated
+func (s *NodeService) upsertNode(ctx context.Context, req *nodev1.CreateNodeRequest) (*nodev1.CreateNodeResponse, error) {
+	mask := req.UpdateMask.GetPaths()
+	node, created, err := s.store.UpsertNode(ctx, req.Node, mask)
+	if errors.Is(err, redisstore.ErrInvalidUpdateMask) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		s.logger.Error("failed to upsert node", zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
 
-	return &nodev1.CreateNodeResponse{Node: node}, nil
+	switch {
+	case created:
+		s.logger.Info("node created",
+			zap.String("id", node.Id),
+			zap.String("name", node.Name),
+			zap.String("type", node.Type.String()))
+	case len(mask) > 0:
+		s.logger.Info("node updated by upsert",
+			zap.String("id", node.Id),
+			zap.String("name", node.Name))
+	}
+
+	return &nodev1.CreateNodeResponse{Node: node, Created: created}, nil
 }
 
 func (s *NodeService) UpdateNode(ctx context.Context, req *nodev1.UpdateNodeRequest) (*nodev1.UpdateNodeResponse, error) {
@@ -70,7 +206,14 @@ func (s *NodeService) UpdateNode(ctx context.Context, req *nodev1.UpdateNodeRequ
 		return nil, status.Error(codes.InvalidArgument, "node id is required")
 	}
 
-	node, err := s.store.UpdateNode(ctx, req.Node)
+	if err := s.validateMetadata(req.Node.MetadataJson); err != nil {
+		return nil, err
+	}
+
+	node, err := s.store.UpdateNode(ctx, req.Node, req.UpdateMask.GetPaths())
+	if errors.Is(err, redisstore.ErrInvalidUpdateMask) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 	if err != nil {
 		s.logger.Error("failed to update node", zap.Error(err))
 		return nil, status.Error(codes.Internal, err.Error())
@@ -80,11 +223,6 @@ func (s *NodeService) UpdateNode(ctx context.Context, req *nodev1.UpdateNodeRequ
 		zap.String("id", node.Id),
 		zap.String("name", node.Name))
 
-	s.broker.Publish(ctx, &nodev1.WatchEventsResponse{
-		EventType: nodev1.EventType_UPDATED,
-		Node:      node,
-	})
-
 	return &nodev1.UpdateNodeResponse{Node: node}, nil
 }
 
@@ -97,7 +235,14 @@ func (s *NodeService) UpdateStatus(ctx context.Context, req *nodev1.UpdateStatus
 		return nil, status.Error(codes.InvalidArgument, "status is required")
 	}
 
-	node, err := s.store.UpdateStatus(ctx, req.Id, req.Status)
+	if len(req.Reason) > maxStatusReasonLen {
+		return nil, status.Errorf(codes.InvalidArgument, "reason is longer than %d bytes", maxStatusReasonLen)
+	}
+
+	node, _, err := s.store.UpdateStatus(ctx, req.Id, req.Status, req.Reason)
+	if errors.Is(err, redisstore.ErrNodeNotFound) {
+		return nil, status.Error(codes.NotFound, "node not found")
+	}
 	if err != nil {
 		s.logger.Error("failed to update node status", zap.Error(err))
 		return nil, status.Error(codes.Internal, err.Error())
@@ -105,24 +250,78 @@ func (s *NodeService) UpdateStatus(ctx context.Context, req *nodev1.UpdateStatus
 
 	s.logger.Info("node status updated",
 		zap.String("id", node.Id),
-		zap.String("status", node.Status.String()))
-
-	s.broker.Publish(ctx, &nodev1.WatchEventsResponse{
-		EventType:     nodev1.EventType_UPDATED,
-		Node:          node,
-		ChangedFields: []string{"status"},
-	})
+		zap.String("status", node.Status.String()),
+		zap.String("reason", node.StatusReason))
 
 	return &nodev1.UpdateStatusResponse{Node: node}, nil
 }
 
+// maxBatchUpdates bounds the size of a BatchUpdateStatus request
+const maxBatchUpdates = 1000
+
+// maxStatusReasonLen bounds the reason of a status update, which is stored on
+// the node and copied into its events
+const maxStatusReasonLen = 1024
+
+func (s *NodeService) BatchUpdateStatus(ctx context.Context, req *nodev1.BatchUpdateStatusRequest) (*nodev1.BatchUpdateStatusResponse, error) {
+	if len(req.Updates) > maxBatchUpdates {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d updates per batch", maxBatchUpdates)
+	}
+
+	results := make([]*nodev1.BatchUpdateStatusResult, len(req.Updates))
+
+	// Invalid items fail on their own; the rest go to the store together
+	var updates []redisstore.StatusUpdate
+	var positions []int
+	for i, u := range req.Updates {
+		results[i] = &nodev1.BatchUpdateStatusResult{Id: u.Id}
+		switch {
+		case u.Id == "":
+			results[i].Error = "node id is required"
+		case u.Status == nodev1.NodeStatus_NODE_STATUS_UNSPECIFIED:
+			results[i].Error = "status is required"
+		case len(u.Reason) > maxStatusReasonLen:
+			results[i].Error = fmt.Sprintf("reason is longer than %d bytes", maxStatusReasonLen)
+		default:
+			updates = append(updates, redisstore.StatusUpdate{ID: u.Id, Status: u.Status, Reason: u.Reason})
+			positions = append(positions, i)
+		}
+	}
+
+	applied, err := s.store.UpdateStatusBatch(ctx, updates)
+	if err != nil {
+		s.logger.Error("failed to update node statuses", zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	changed := 0
+	for j, r := range applied {
+		result := results[positions[j]]
+		if r.Err != nil {
+			result.Error = r.Err.Error()
+			continue
+		}
+		result.Node = r.Node
+		result.Changed = r.Changed
+
+		if r.Changed {
+			changed++
+		}
+	}
+
+	s.logger.Info("node statuses batch updated",
+		zap.Int("updates", len(req.Updates)),
+		zap.Int("changed", changed))
+
+	return &nodev1.BatchUpdateStatusResponse{Results: results}, nil
+}
+
 func (s *NodeService) DeleteNode(ctx context.Context, req *nodev1.DeleteNodeRequest) (*nodev1.DeleteNodeResponse, error) {
 	if req.Id == "" {
 		return nil, status.Error(codes.InvalidArgument, "node id is required")
 	}
 
-	node, err := s.store.GetNode(ctx, req.Id)
-	if err != nil {
+	if _, err := s.store.GetNode(ctx, req.Id); err != nil {
 		return nil, status.Error(codes.NotFound, "node not found")
 	}
 
@@ -133,23 +332,32 @@ func (s *NodeService) DeleteNode(ctx context.Context, req *nodev1.DeleteNodeRequ
 
 	s.logger.Info("node deleted", zap.String("id", req.Id))
 
-	s.broker.Publish(ctx, &nodev1.WatchEventsResponse{
-		EventType: nodev1.EventType_DELETED,
-		Node:      node,
-	})
-
 	return &nodev1.DeleteNodeResponse{Id: req.Id}, nil
 }
 
+// GetNode looks a node up by ID, or by name and type through the name index
+// when no ID is given
 func (s *NodeService) GetNode(ctx context.Context, req *nodev1.GetNodeRequest) (*nodev1.GetNodeResponse, error) {
-	if req.Id == "" {
-		return nil, status.Error(codes.InvalidArgument, "node id is required")
+	var node *nodev1.Node
+	var err error
+	switch {
+	case req.Id != "":
+		node, err = s.store.GetNode(ctx, req.Id)
+	case req.Name != "":
+		if req.Type == nodev1.NodeType_NODE_TYPE_UNSPECIFIED {
+			return nil, status.Error(codes.InvalidArgument, "node type is required with a name")
+		}
+		node, err = s.store.GetNodeByName(ctx, req.Type, req.Name)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "node id or name is required")
 	}
-
-	node, err := s.store.GetNode(ctx, req.Id)
-	if err != nil {
+	if errors.Is(err, redisstore.ErrNodeNotFound) {
 		return nil, status.Error(codes.NotFound, "node not found")
 	}
+	if err != nil {
+		s.logger.Error("failed to get node", zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
 
 	return &nodev1.GetNodeResponse{Node: node}, nil
 }
@@ -163,79 +371,287 @@ func (s *NodeService) ListNodes(ctx context.Context, req *nodev1.ListNodesReques
 		pageSize = 1000
 	}
 
-	offset := 0
-	if req.PageToken != "" {
-		fmt.Sscanf(req.PageToken, "%d", &offset)
+	afterID, err := decodePageToken(req.PageToken)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	nodes, err := s.store.ListNodes(ctx, req.TypeFilter, req.StatusFilter, offset, int(pageSize))
+	nodes, cursor, err := s.store.ListNodesAfter(ctx, req.TypeFilter, req.StatusFilter, afterID, int(pageSize))
 	if err != nil {
 		s.logger.Error("failed to list nodes", zap.Error(err))
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
 	var nextPageToken string
-	if len(nodes) == int(pageSize) {
-		nextPageToken = fmt.Sprintf("%d", offset+int(pageSize))
+	if cursor != "" {
+		nextPageToken = encodePageToken(cursor)
+	}
+
+	var total int64
+	if req.IncludeTotal {
+		total, err = s.store.CountNodes(ctx, req.TypeFilter, req.StatusFilter)
+		if err != nil {
+			s.logger.Error("failed to count nodes", zap.Error(err))
+			return nil, status.Error(codes.Internal, err.Error())
+		}
 	}
 
 	return &nodev1.ListNodesResponse{
 		Nodes:         nodes,
 		NextPageToken: nextPageToken,
+		TotalCount:    total,
 	}, nil
 }
 
+// pageTokenPrefix versions the page token format
+const pageTokenPrefix = "after:"
+
+// encodePageToken wraps the last node ID of a page in an opaque token
+func encodePageToken(lastID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(pageTokenPrefix + lastID))
+}
+
+// decodePageToken returns the node ID to resume after, empty for the first page
+func decodePageToken(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || !strings.HasPrefix(string(raw), pageTokenPrefix) {
+		return "", fmt.Errorf("invalid page token")
+	}
+	return strings.TrimPrefix(string(raw), pageTokenPrefix), nil
+}
+
+func (s *NodeService) GetDrift(ctx context.Context, req *nodev1.GetDriftRequest) (*nodev1.GetDriftResponse, error) {
+	nodes, err := s.store.GetDrift(ctx)
+	if err != nil {
+		s.logger.Error("failed to compute drift", zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &nodev1.GetDriftResponse{Nodes: nodes}, nil
+}
+
+// snapshotPageSize is how many nodes a WatchEvents snapshot reads from the
+// store at a time
+const snapshotPageSize = 500
+
+// sendSnapshot streams every stored node as a CREATED event marked snapshot,
+// then the snapshot_complete marker positioned at lastID
+func (s *NodeService) sendSnapshot(ctx context.Context, stream nodev1.NodeService_WatchEventsServer, lastID string) error {
+	afterID := ""
+	for {
+		nodes, cursor, err := s.store.ListNodesAfter(ctx, 0, 0, afterID, snapshotPageSize)
+		if err != nil {
+			s.logger.Error("failed to list nodes for snapshot", zap.Error(err))
+			return status.Error(codes.Internal, err.Error())
+		}
+
+		for _, node := range nodes {
+			if err := stream.Send(&nodev1.WatchEventsResponse{
+				EventType: nodev1.EventType_CREATED,
+				Node:      node,
+				EventId:   lastID,
+				Snapshot:  true,
+			}); err != nil {
+				s.logger.Error("failed to send snapshot", zap.Error(err))
+				return err
+			}
+		}
+
+		if cursor == "" {
+			break
+		}
+		afterID = cursor
+	}
+
+	return stream.Send(&nodev1.WatchEventsResponse{
+		EventId:          lastID,
+		SnapshotComplete: true,
+	})
+}
+
+// watchBlock is how long each read of the event log waits for new events
+// before checking whether the client is still connected
+const watchBlock = time.Second
+
+// WatchEvents streams events from the event log, starting after
+// req.FromEventId when set so a reconnecting client gets what it missed, or
+// with the req.RecentEvents newest events otherwise.
+// Every event carries its log ID for the client to resume from. A stream
+// that stays silent for the heartbeat interval gets a heartbeat message.
 func (s *NodeService) WatchEvents(req *nodev1.WatchEventsRequest, stream nodev1.NodeService_WatchEventsServer) error {
 	ctx := stream.Context()
 	subID := uuid.New().String()
-	sub := s.broker.Subscribe(subID)
-	defer s.broker.Unsubscribe(subID)
 
-	s.logger.Info("client subscribed to events", zap.String("subscriber_id", subID))
+	s.handlersMu.Lock()
+	if s.closing.Err() != nil {
+		s.handlersMu.Unlock()
+		return status.Error(codes.Unavailable, "server is shutting down")
+	}
+	s.handlers.Add(1)
+	s.handlersMu.Unlock()
+	defer s.handlers.Done()
+
+	// Reads also stop when Shutdown starts, so the handler needn't wait out
+	// a blocking read
+	readCtx, cancelRead := context.WithCancel(ctx)
+	defer cancelRead()
+	stopRead := context.AfterFunc(s.closing, cancelRead)
+	defer stopRead()
+
+	lastID := req.GetFromEventId()
+	if lastID == "" {
+		var err error
+		if lastID, err = s.store.RecentEventID(ctx, int(max(req.GetRecentEvents(), 0))); err != nil {
+			s.logger.Error("failed to read last event id", zap.Error(err))
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
 
-	lastID := "0"
-	go func() {
-		ticker := time.NewTicker(1 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				events, err := s.store.GetEventStream(ctx, lastID)
-				if err != nil {
-					continue
-				}
+	s.watchers.Add(1)
+	defer s.watchers.Add(-1)
 
-				for _, event := range events {
-					node, _ := s.store.GetNode(ctx, event.NodeID)
-					if node != nil {
-						s.broker.Publish(ctx, &nodev1.WatchEventsResponse{
-							EventType:     event.Type,
-							Node:          node,
-							ChangedFields: event.ChangedFields,
-						})
-					}
-					lastID = event.ID
-				}
-			}
+	if s.slowWatcherTimeout > 0 {
+		stream = &slowWatcherStream{
+			NodeService_WatchEventsServer: stream,
+			timeout:                       s.slowWatcherTimeout,
+			logger:                        s.logger.With(zap.String("subscriber_id", subID)),
 		}
-	}()
+	}
+
+	s.logger.Info("client subscribed to events",
+		zap.String("subscriber_id", subID),
+		zap.String("from_event_id", req.GetFromEventId()),
+		zap.Int32("recent_events", req.GetRecentEvents()),
+		zap.Bool("include_snapshot", req.GetIncludeSnapshot()))
+
+	// Events after lastID are sent with the node as stored when they are
+	// read, so a change racing the snapshot can only repeat a newer state
+	if req.GetIncludeSnapshot() {
+		if err := s.sendSnapshot(ctx, stream, lastID); err != nil {
+			return err
+		}
+	}
 
+	lastSent := time.Now()
 	for {
-		select {
-		case <-ctx.Done():
+		events, err := s.store.ReadEvents(readCtx, lastID, watchBlock)
+		if s.closing.Err() != nil && ctx.Err() == nil {
+			s.logger.Info("closing event stream for shutdown", zap.String("subscriber_id", subID))
+			return stream.Send(&nodev1.WatchEventsResponse{EventId: lastID, ServerClosing: true})
+		}
+		if ctx.Err() != nil {
 			s.logger.Info("client disconnected from events", zap.String("subscriber_id", subID))
 			return nil
-		case event, ok := <-sub.Channel:
-			if !ok {
-				return nil
+		}
+		if errors.Is(err, redisstore.ErrInvalidEventID) {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		if err != nil {
+			s.logger.Error("failed to read events", zap.Error(err))
+			return status.Error(codes.Unavailable, err.Error())
+		}
+
+		if len(events) == 0 && s.heartbeatInterval > 0 && time.Since(lastSent) >= s.heartbeatInterval {
+			if err := stream.Send(&nodev1.WatchEventsResponse{EventId: lastID, Heartbeat: true}); err != nil {
+				s.logger.Error("failed to send heartbeat", zap.Error(err))
+				return err
 			}
-			if err := stream.Send(event); err != nil {
+			lastSent = time.Now()
+			continue
+		}
+
+		for _, event := range events {
+			node, err := s.store.GetNode(ctx, event.NodeID)
+			if err != nil {
+				if event.Type != nodev1.EventType_DELETED {
+					// Deleted since; its DELETED event follows
+					lastID = event.ID
+					continue
+				}
+				// A soft-deleted node still has its last state
+				if node, _, err = s.store.GetDeletedNode(ctx, event.NodeID); err != nil {
+					node = &nodev1.Node{Id: event.NodeID}
+				}
+			}
+
+			if err := stream.Send(&nodev1.WatchEventsResponse{
+				EventType:     event.Type,
+				Node:          node,
+				ChangedFields: event.ChangedFields,
+				EventId:       event.ID,
+				Changes:       changesToProto(event.Changes),
+			}); err != nil {
 				s.logger.Error("failed to send event", zap.Error(err))
 				return err
 			}
+			lastSent = time.Now()
+			lastID = event.ID
 		}
 	}
+}
+
+// slowWatcherStream ends a WatchEvents stream whose client stopped reading.
+// Such a client fills the stream's flow-control window and then every Send
+// blocks, holding the handler and its event log reader for as long as the
+// connection lives. A send still blocked after timeout fails the stream with
+// ResourceExhausted instead; returning from the handler cancels the stream,
+// which releases the abandoned Send.
+type slowWatcherStream struct {
+	nodev1.NodeService_WatchEventsServer
+	timeout time.Duration
+	logger  *zap.Logger
+}
+
+func (w *slowWatcherStream) Send(resp *nodev1.WatchEventsResponse) error {
+	sent := make(chan error, 1)
+	go func() {
+		sent <- w.NodeService_WatchEventsServer.Send(resp)
+	}()
+
+	timer := time.NewTimer(w.timeout)
+	defer timer.Stop()
+	select {
+	case err := <-sent:
+		return err
+	case <-timer.C:
+		w.logger.Warn("ending event stream of a watcher that stopped reading", zap.Duration("timeout", w.timeout))
+		return status.Errorf(codes.ResourceExhausted, "watcher did not read events for %s", w.timeout)
+	}
+}
+
+// changesToProto converts the before and after values of a stored event
+func changesToProto(changes []redisstore.FieldChange) []*nodev1.FieldChange {
+	if len(changes) == 0 {
+		return nil
+	}
+	out := make([]*nodev1.FieldChange, len(changes))
+	for i, c := range changes {
+		out[i] = &nodev1.FieldChange{Field: c.Field, OldValue: c.Old, NewValue: c.New}
+	}
+	return out
+}
+
+// GetServerStats reports watcher, node and event counts and the uptime
+func (s *NodeService) GetServerStats(ctx context.Context, req *nodev1.GetServerStatsRequest) (*nodev1.GetServerStatsResponse, error) {
+	nodes, err := s.store.CountNodes(ctx, 0, 0)
+	if err != nil {
+		s.logger.Error("failed to count nodes", zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	eventCount, err := s.store.CountEvents(ctx)
+	if err != nil {
+		s.logger.Error("failed to count events", zap.Error(err))
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &nodev1.GetServerStatsResponse{
+		ConnectedWatchers: s.watchers.Load(),
+		TotalNodes:        nodes,
+		EventsPublished:   eventCount,
+		UptimeSeconds:     int64(time.Since(s.started).Seconds()),
+	}, nil
 }
\ No newline at end of file
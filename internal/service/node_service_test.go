@@ -0,0 +1,667 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/melkior/nodestatus/internal/redisstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func setupTestService(t *testing.T) *NodeService {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	store, err := redisstore.New(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	return NewNodeService(store, zap.NewNop())
+}
+
+// lastEventID returns the ID of the newest stored event
+func lastEventID(t *testing.T, svc *NodeService) string {
+	t.Helper()
+	id, err := svc.store.LastEventID(context.Background())
+	require.NoError(t, err)
+	return id
+}
+
+// eventsAfter returns the stored events after id without blocking
+func eventsAfter(t *testing.T, svc *NodeService, id string) []*redisstore.Event {
+	t.Helper()
+	events, err := svc.store.ReadEvents(context.Background(), id, -1)
+	require.NoError(t, err)
+	return events
+}
+
+func TestListNodesPagingWhileInserting(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+
+	var want []string
+	for i := 0; i < 25; i++ {
+		resp, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+			Name:   fmt.Sprintf("node-%d", i),
+			Type:   nodev1.NodeType_CONTAINER,
+			Status: nodev1.NodeStatus_UP,
+		}})
+		require.NoError(t, err)
+		want = append(want, resp.Node.Id)
+	}
+
+	seen := make(map[string]int)
+	pageToken := ""
+	for page := 0; ; page++ {
+		resp, err := svc.ListNodes(ctx, &nodev1.ListNodesRequest{PageSize: 10, PageToken: pageToken})
+		require.NoError(t, err)
+		for _, n := range resp.Nodes {
+			seen[n.Id]++
+		}
+
+		if page == 0 {
+			_, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+				Name:   "late-node",
+				Type:   nodev1.NodeType_CONTAINER,
+				Status: nodev1.NodeStatus_UP,
+			}})
+			require.NoError(t, err)
+		}
+
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+
+	for _, id := range want {
+		assert.Equal(t, 1, seen[id], "node %s", id)
+	}
+	for id, n := range seen {
+		assert.Equal(t, 1, n, "%s listed more than once", id)
+	}
+}
+
+func TestListNodesRejectsInvalidPageToken(t *testing.T) {
+	svc := setupTestService(t)
+
+	for _, token := range []string{"10", "!!not-base64", base64.RawURLEncoding.EncodeToString([]byte("offset:10"))} {
+		_, err := svc.ListNodes(context.Background(), &nodev1.ListNodesRequest{PageToken: token})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "token %q", token)
+	}
+}
+
+func TestListNodesIncludeTotal(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+
+	for i, n := range []struct {
+		nodeType nodev1.NodeType
+		status   nodev1.NodeStatus
+	}{
+		{nodev1.NodeType_VM, nodev1.NodeStatus_UP},
+		{nodev1.NodeType_VM, nodev1.NodeStatus_DOWN},
+		{nodev1.NodeType_VM, nodev1.NodeStatus_UP},
+		{nodev1.NodeType_CONTAINER, nodev1.NodeStatus_UP},
+	} {
+		_, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+			Name:   fmt.Sprintf("node-%d", i),
+			Type:   n.nodeType,
+			Status: n.status,
+		}})
+		require.NoError(t, err)
+	}
+
+	// The total covers every page, not just the one returned
+	resp, err := svc.ListNodes(ctx, &nodev1.ListNodesRequest{PageSize: 1, IncludeTotal: true})
+	require.NoError(t, err)
+	assert.Len(t, resp.Nodes, 1)
+	assert.Equal(t, int64(4), resp.TotalCount)
+
+	for _, tc := range []struct {
+		nodeType nodev1.NodeType
+		status   nodev1.NodeStatus
+		want     int64
+	}{
+		{nodev1.NodeType_VM, 0, 3},
+		{0, nodev1.NodeStatus_UP, 3},
+		{nodev1.NodeType_VM, nodev1.NodeStatus_UP, 2},
+		{nodev1.NodeType_BAREMETAL, 0, 0},
+	} {
+		resp, err := svc.ListNodes(ctx, &nodev1.ListNodesRequest{TypeFilter: tc.nodeType, StatusFilter: tc.status, IncludeTotal: true})
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, resp.TotalCount, "type %s status %s", tc.nodeType, tc.status)
+	}
+
+	// Not counted unless asked for
+	resp, err = svc.ListNodes(ctx, &nodev1.ListNodesRequest{})
+	require.NoError(t, err)
+	assert.Zero(t, resp.TotalCount)
+}
+
+func TestGetNodeByIDOrName(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+
+	created, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{
+		Node: &nodev1.Node{Name: "web-01", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP},
+	})
+	require.NoError(t, err)
+
+	resp, err := svc.GetNode(ctx, &nodev1.GetNodeRequest{Name: "web-01", Type: nodev1.NodeType_VM})
+	require.NoError(t, err)
+	assert.Equal(t, created.Node.Id, resp.Node.Id)
+
+	resp, err = svc.GetNode(ctx, &nodev1.GetNodeRequest{Id: created.Node.Id})
+	require.NoError(t, err)
+	assert.Equal(t, "web-01", resp.Node.Name)
+
+	_, err = svc.GetNode(ctx, &nodev1.GetNodeRequest{Name: "web-01", Type: nodev1.NodeType_CONTAINER})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = svc.GetNode(ctx, &nodev1.GetNodeRequest{Id: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = svc.GetNode(ctx, &nodev1.GetNodeRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = svc.GetNode(ctx, &nodev1.GetNodeRequest{Name: "web-01"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestCreateNodeUpsert(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+	node := &nodev1.Node{Name: "web-01", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP}
+
+	first, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: node, Upsert: true})
+	require.NoError(t, err)
+	assert.True(t, first.Created)
+
+	_, err = svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{Name: "web-01", Type: nodev1.NodeType_VM}})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	again, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{
+		Node:       &nodev1.Node{Name: "web-01", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_DOWN},
+		Upsert:     true,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"status"}},
+	})
+	require.NoError(t, err)
+	assert.False(t, again.Created)
+	assert.Equal(t, first.Node.Id, again.Node.Id)
+	assert.Equal(t, nodev1.NodeStatus_DOWN, again.Node.Status)
+
+	_, err = svc.CreateNode(ctx, &nodev1.CreateNodeRequest{
+		Node:       &nodev1.Node{Name: "web-01", Type: nodev1.NodeType_VM},
+		Upsert:     true,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"last_seen"}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestPageTokenRoundTrip(t *testing.T) {
+	id, err := decodePageToken(encodePageToken("5b0c1a9e-node"))
+	require.NoError(t, err)
+	assert.Equal(t, "5b0c1a9e-node", id)
+
+	id, err = decodePageToken("")
+	require.NoError(t, err)
+	assert.Empty(t, id)
+}
+
+func TestUpdateNodeAppliesUpdateMask(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+
+	created, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+		Name:   "sensor-target",
+		Type:   nodev1.NodeType_BAREMETAL,
+		Status: nodev1.NodeStatus_UP,
+		Labels: map[string]string{"rack": "r1"},
+	}})
+	require.NoError(t, err)
+
+	// A labels-only update needs no prior GetNode
+	resp, err := svc.UpdateNode(ctx, &nodev1.UpdateNodeRequest{
+		Node:       &nodev1.Node{Id: created.Node.Id, Labels: map[string]string{"rack": "r2"}},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"labels"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "sensor-target", resp.Node.Name)
+	assert.Equal(t, nodev1.NodeType_BAREMETAL, resp.Node.Type)
+	assert.Equal(t, nodev1.NodeStatus_UP, resp.Node.Status)
+	assert.Equal(t, map[string]string{"rack": "r2"}, resp.Node.Labels)
+
+	_, err = svc.UpdateNode(ctx, &nodev1.UpdateNodeRequest{
+		Node:       &nodev1.Node{Id: created.Node.Id},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"id"}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestBatchUpdateStatus(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+
+	created, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+		Name:   "reporter-target",
+		Type:   nodev1.NodeType_VM,
+		Status: nodev1.NodeStatus_UP,
+	}})
+	require.NoError(t, err)
+	last := lastEventID(t, svc)
+
+	resp, err := svc.BatchUpdateStatus(ctx, &nodev1.BatchUpdateStatusRequest{Updates: []*nodev1.UpdateStatusRequest{
+		{Id: created.Node.Id, Status: nodev1.NodeStatus_UP},
+		{Id: "", Status: nodev1.NodeStatus_DOWN},
+		{Id: created.Node.Id, Status: nodev1.NodeStatus_NODE_STATUS_UNSPECIFIED},
+		{Id: "missing", Status: nodev1.NodeStatus_DOWN},
+		{Id: created.Node.Id, Status: nodev1.NodeStatus_DOWN},
+	}})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 5)
+
+	assert.False(t, resp.Results[0].Changed)
+	assert.Empty(t, resp.Results[0].Error)
+	assert.NotEmpty(t, resp.Results[1].Error)
+	assert.NotEmpty(t, resp.Results[2].Error)
+	assert.Equal(t, "missing", resp.Results[3].Id)
+	assert.NotEmpty(t, resp.Results[3].Error)
+	assert.True(t, resp.Results[4].Changed)
+	assert.Equal(t, nodev1.NodeStatus_DOWN, resp.Results[4].Node.Status)
+
+	// Only the actual change is logged as an event
+	events := eventsAfter(t, svc, last)
+	require.Len(t, events, 1)
+	assert.Equal(t, nodev1.EventType_UPDATED, events[0].Type)
+	assert.Equal(t, []string{"status"}, events[0].ChangedFields)
+	assert.Equal(t, created.Node.Id, events[0].NodeID)
+
+	_, err = svc.BatchUpdateStatus(ctx, &nodev1.BatchUpdateStatusRequest{
+		Updates: make([]*nodev1.UpdateStatusRequest, maxBatchUpdates+1),
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestUpdateStatusReason(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+
+	created, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+		Name:   "db-01",
+		Type:   nodev1.NodeType_VM,
+		Status: nodev1.NodeStatus_UP,
+	}})
+	require.NoError(t, err)
+	last := lastEventID(t, svc)
+
+	resp, err := svc.UpdateStatus(ctx, &nodev1.UpdateStatusRequest{Id: created.Node.Id, Status: nodev1.NodeStatus_DOWN, Reason: "disk full"})
+	require.NoError(t, err)
+	assert.Equal(t, "disk full", resp.Node.StatusReason)
+	events := eventsAfter(t, svc, last)
+	require.Len(t, events, 1)
+	assert.Equal(t, []string{"status", "status_reason"}, events[0].ChangedFields)
+	last = events[0].ID
+
+	// Repeating the update changes nothing and logs nothing
+	_, err = svc.UpdateStatus(ctx, &nodev1.UpdateStatusRequest{Id: created.Node.Id, Status: nodev1.NodeStatus_DOWN, Reason: "disk full"})
+	require.NoError(t, err)
+	assert.Empty(t, eventsAfter(t, svc, last))
+
+	_, err = svc.UpdateStatus(ctx, &nodev1.UpdateStatusRequest{Id: "missing", Status: nodev1.NodeStatus_UP})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	long := strings.Repeat("x", maxStatusReasonLen+1)
+	_, err = svc.UpdateStatus(ctx, &nodev1.UpdateStatusRequest{Id: created.Node.Id, Status: nodev1.NodeStatus_UP, Reason: long})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	batch, err := svc.BatchUpdateStatus(ctx, &nodev1.BatchUpdateStatusRequest{Updates: []*nodev1.UpdateStatusRequest{
+		{Id: created.Node.Id, Status: nodev1.NodeStatus_UP, Reason: long},
+	}})
+	require.NoError(t, err)
+	assert.Contains(t, batch.Results[0].Error, "reason is longer than")
+}
+
+// watchStream is a WatchEvents server stream that hands sent events to the test
+type watchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *nodev1.WatchEventsResponse
+}
+
+func (w *watchStream) Context() context.Context { return w.ctx }
+
+func (w *watchStream) Send(resp *nodev1.WatchEventsResponse) error {
+	select {
+	case w.sent <- resp:
+		return nil
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+}
+
+func startWatch(t *testing.T, svc *NodeService, req *nodev1.WatchEventsRequest) (*watchStream, context.CancelFunc, chan error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &watchStream{ctx: ctx, sent: make(chan *nodev1.WatchEventsResponse, 10)}
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.WatchEvents(req, stream)
+	}()
+	return stream, cancel, done
+}
+
+func nextEvent(t *testing.T, stream *watchStream) *nodev1.WatchEventsResponse {
+	t.Helper()
+	select {
+	case resp := <-stream.sent:
+		return resp
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for an event")
+		return nil
+	}
+}
+
+func TestWatchEventsResumesFromEventID(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+
+	a, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+		Name: "a", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP,
+	}})
+	require.NoError(t, err)
+
+	// A fresh watch only sees events from now on
+	stream, cancel, done := startWatch(t, svc, &nodev1.WatchEventsRequest{})
+	time.Sleep(50 * time.Millisecond)
+	b, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+		Name: "b", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP,
+	}})
+	require.NoError(t, err)
+
+	created := nextEvent(t, stream)
+	assert.Equal(t, nodev1.EventType_CREATED, created.EventType)
+	assert.Equal(t, b.Node.Id, created.Node.Id)
+	require.NotEmpty(t, created.EventId)
+
+	cancel()
+	require.NoError(t, <-done)
+
+	// Missed while disconnected
+	_, err = svc.UpdateStatus(ctx, &nodev1.UpdateStatusRequest{Id: b.Node.Id, Status: nodev1.NodeStatus_DOWN})
+	require.NoError(t, err)
+	_, err = svc.DeleteNode(ctx, &nodev1.DeleteNodeRequest{Id: a.Node.Id})
+	require.NoError(t, err)
+
+	stream, cancel, done = startWatch(t, svc, &nodev1.WatchEventsRequest{FromEventId: created.EventId})
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	updated := nextEvent(t, stream)
+	assert.Equal(t, nodev1.EventType_UPDATED, updated.EventType)
+	assert.Equal(t, nodev1.NodeStatus_DOWN, updated.Node.Status)
+
+	deleted := nextEvent(t, stream)
+	assert.Equal(t, nodev1.EventType_DELETED, deleted.EventType)
+	assert.Equal(t, a.Node.Id, deleted.Node.Id)
+	assert.Greater(t, deleted.EventId, updated.EventId)
+}
+
+func TestWatchEventsStartsWithRecentEvents(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+
+	var ids []string
+	for _, name := range []string{"a", "b", "c"} {
+		resp, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+			Name: name, Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP,
+		}})
+		require.NoError(t, err)
+		ids = append(ids, resp.Node.Id)
+	}
+
+	// The two newest events come first, oldest first, then live ones
+	stream, cancel, done := startWatch(t, svc, &nodev1.WatchEventsRequest{RecentEvents: 2})
+	defer func() {
+		cancel()
+		<-done
+	}()
+	assert.Equal(t, ids[1], nextEvent(t, stream).Node.Id)
+	assert.Equal(t, ids[2], nextEvent(t, stream).Node.Id)
+
+	_, err := svc.DeleteNode(ctx, &nodev1.DeleteNodeRequest{Id: ids[0]})
+	require.NoError(t, err)
+	deleted := nextEvent(t, stream)
+	assert.Equal(t, nodev1.EventType_DELETED, deleted.EventType)
+	assert.Equal(t, ids[0], deleted.Node.Id)
+}
+
+func TestWatchEventsSendsSnapshotFirst(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+
+	ids := map[string]bool{}
+	for _, name := range []string{"a", "b", "c"} {
+		resp, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+			Name: name, Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP,
+		}})
+		require.NoError(t, err)
+		ids[resp.Node.Id] = true
+	}
+
+	stream, cancel, done := startWatch(t, svc, &nodev1.WatchEventsRequest{IncludeSnapshot: true})
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	for range ids {
+		event := nextEvent(t, stream)
+		assert.True(t, event.Snapshot)
+		assert.Equal(t, nodev1.EventType_CREATED, event.EventType)
+		assert.True(t, ids[event.Node.Id], "unexpected node %s", event.Node.Id)
+	}
+	complete := nextEvent(t, stream)
+	assert.True(t, complete.SnapshotComplete)
+	assert.Nil(t, complete.Node)
+	assert.NotEmpty(t, complete.EventId)
+
+	// Live events follow the snapshot
+	d, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+		Name: "d", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP,
+	}})
+	require.NoError(t, err)
+	live := nextEvent(t, stream)
+	assert.False(t, live.Snapshot)
+	assert.Equal(t, d.Node.Id, live.Node.Id)
+	assert.Greater(t, live.EventId, complete.EventId)
+}
+
+func TestWatchEventsSendsHeartbeatsWhileIdle(t *testing.T) {
+	svc := setupTestService(t)
+	svc.SetHeartbeatInterval(time.Millisecond)
+	ctx := context.Background()
+
+	stream, cancel, done := startWatch(t, svc, &nodev1.WatchEventsRequest{})
+	defer cancel()
+
+	heartbeat := nextEvent(t, stream)
+	assert.True(t, heartbeat.Heartbeat)
+	assert.Nil(t, heartbeat.Node)
+	assert.Equal(t, nodev1.EventType(0), heartbeat.EventType)
+
+	created, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+		Name: "a", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP,
+	}})
+	require.NoError(t, err)
+
+	event := nextEvent(t, stream)
+	for event.Heartbeat {
+		event = nextEvent(t, stream)
+	}
+	assert.Equal(t, created.Node.Id, event.Node.Id)
+
+	// Later heartbeats carry the position after the event
+	heartbeat = nextEvent(t, stream)
+	assert.True(t, heartbeat.Heartbeat)
+	assert.Equal(t, event.EventId, heartbeat.EventId)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestWatchEventsEndsSlowWatcher(t *testing.T) {
+	svc := setupTestService(t)
+	svc.SetSlowWatcherTimeout(50 * time.Millisecond)
+	ctx := context.Background()
+
+	_, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+		Name: "a", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP,
+	}})
+	require.NoError(t, err)
+
+	// Nothing reads the stream, so the first snapshot send never completes
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream := &watchStream{ctx: watchCtx, sent: make(chan *nodev1.WatchEventsResponse)}
+	done := make(chan error, 1)
+	go func() {
+		done <- svc.WatchEvents(&nodev1.WatchEventsRequest{IncludeSnapshot: true}, stream)
+	}()
+
+	select {
+	case err := <-done:
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	case <-time.After(3 * time.Second):
+		t.Fatal("slow watcher was not ended")
+	}
+
+	stats, err := svc.GetServerStats(ctx, &nodev1.GetServerStatsRequest{})
+	require.NoError(t, err)
+	assert.Zero(t, stats.ConnectedWatchers)
+}
+
+func TestWatchEventsRejectsInvalidEventID(t *testing.T) {
+	svc := setupTestService(t)
+
+	_, cancel, done := startWatch(t, svc, &nodev1.WatchEventsRequest{FromEventId: "not-an-id"})
+	defer cancel()
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(<-done))
+}
+
+func TestGetServerStats(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"a", "b"} {
+		_, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+			Name: name, Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP,
+		}})
+		require.NoError(t, err)
+	}
+
+	stats, err := svc.GetServerStats(ctx, &nodev1.GetServerStatsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), stats.ConnectedWatchers)
+	assert.Equal(t, int64(2), stats.TotalNodes)
+	assert.Equal(t, int64(2), stats.EventsPublished)
+
+	_, cancel, done := startWatch(t, svc, &nodev1.WatchEventsRequest{})
+	assert.Eventually(t, func() bool {
+		stats, err := svc.GetServerStats(ctx, &nodev1.GetServerStatsRequest{})
+		return err == nil && stats.ConnectedWatchers == 1
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+	stats, err = svc.GetServerStats(ctx, &nodev1.GetServerStatsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), stats.ConnectedWatchers)
+}
+
+func TestMetadataValidation(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+
+	created, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+		Name: "a", Type: nodev1.NodeType_VM, MetadataJson: `{"cpu": 4}`,
+	}})
+	require.NoError(t, err)
+
+	for _, metadata := range []string{`{"cpu": 4`, `[1, 2]`, `"text"`, `null`} {
+		_, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+			Name: "b", Type: nodev1.NodeType_VM, MetadataJson: metadata,
+		}})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "create with %s", metadata)
+
+		_, err = svc.UpdateNode(ctx, &nodev1.UpdateNodeRequest{
+			Node:       &nodev1.Node{Id: created.Node.Id, MetadataJson: metadata},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"metadata_json"}},
+		})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "update with %s", metadata)
+	}
+
+	svc.SetMaxMetadataBytes(16)
+	_, err = svc.UpdateNode(ctx, &nodev1.UpdateNodeRequest{
+		Node:       &nodev1.Node{Id: created.Node.Id, MetadataJson: `{"description": "too long for the limit"}`},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"metadata_json"}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Empty metadata clears it
+	updated, err := svc.UpdateNode(ctx, &nodev1.UpdateNodeRequest{
+		Node:       &nodev1.Node{Id: created.Node.Id},
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"metadata_json"}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, updated.Node.MetadataJson)
+}
+
+func TestShutdownEndsWatchStreams(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+
+	created, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+		Name: "a", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP,
+	}})
+	require.NoError(t, err)
+
+	stream, cancel, done := startWatch(t, svc, &nodev1.WatchEventsRequest{IncludeSnapshot: true})
+	defer cancel()
+	assert.Equal(t, created.Node.Id, nextEvent(t, stream).Node.Id)
+	complete := nextEvent(t, stream)
+	require.True(t, complete.SnapshotComplete)
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelShutdown()
+	require.NoError(t, svc.Shutdown(shutdownCtx))
+
+	closing := nextEvent(t, stream)
+	assert.True(t, closing.ServerClosing)
+	assert.Nil(t, closing.Node)
+	assert.Equal(t, complete.EventId, closing.EventId)
+	require.NoError(t, <-done)
+
+	// New streams are refused
+	_, cancel2, done2 := startWatch(t, svc, &nodev1.WatchEventsRequest{})
+	defer cancel2()
+	assert.Equal(t, codes.Unavailable, status.Code(<-done2))
+}
\ No newline at end of file
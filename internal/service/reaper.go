@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"go.uber.org/zap"
+)
+
+// ReaperOptions controls how nodes that stopped reporting are marked stale
+type ReaperOptions struct {
+	// StaleAfter is how long a node may go without a LastSeen update
+	StaleAfter time.Duration
+	// Interval is how often nodes are scanned
+	Interval time.Duration
+	// StaleStatus is the status given to stale nodes, UNKNOWN or DOWN
+	StaleStatus nodev1.NodeStatus
+	// ExcludeLabels skips nodes carrying any of these label values
+	ExcludeLabels map[string]string
+}
+
+// RunReaper marks stale nodes every opts.Interval until ctx is done
+func (s *NodeService) RunReaper(ctx context.Context, opts ReaperOptions) {
+	if opts.StaleAfter <= 0 || opts.Interval <= 0 {
+		return
+	}
+
+	s.logger.Info("stale node reaper started",
+		zap.Duration("stale_after", opts.StaleAfter),
+		zap.Duration("interval", opts.Interval),
+		zap.String("stale_status", opts.StaleStatus.String()))
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ReapStaleNodes(ctx, opts); err != nil {
+				s.logger.Error("failed to reap stale nodes", zap.Error(err))
+			}
+		}
+	}
+}
+
+// ReapStaleNodes runs one reaper pass and returns the number of nodes marked
+func (s *NodeService) ReapStaleNodes(ctx context.Context, opts ReaperOptions) (int, error) {
+	cutoff := time.Now().Add(-opts.StaleAfter)
+	marked, err := s.store.MarkStaleNodes(ctx, cutoff, opts.StaleStatus, opts.ExcludeLabels)
+
+	// Nodes marked before an error are already written, so count them anyway
+	if len(marked) > 0 {
+		s.logger.Info("marked stale nodes",
+			zap.Int("count", len(marked)),
+			zap.String("status", opts.StaleStatus.String()))
+	}
+
+	return len(marked), err
+}
\ No newline at end of file
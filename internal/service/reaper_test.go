@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReapStaleNodes(t *testing.T) {
+	svc := setupTestService(t)
+	ctx := context.Background()
+
+	created, err := svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+		Name:   "dead-sensor",
+		Type:   nodev1.NodeType_BAREMETAL,
+		Status: nodev1.NodeStatus_UP,
+	}})
+	require.NoError(t, err)
+	_, err = svc.CreateNode(ctx, &nodev1.CreateNodeRequest{Node: &nodev1.Node{
+		Name:   "sim-node",
+		Type:   nodev1.NodeType_VM,
+		Status: nodev1.NodeStatus_UP,
+		Labels: map[string]string{"demo": "true"},
+	}})
+	require.NoError(t, err)
+
+	last := lastEventID(t, svc)
+
+	opts := ReaperOptions{
+		StaleAfter:    -time.Second, // everything is stale
+		StaleStatus:   nodev1.NodeStatus_DOWN,
+		ExcludeLabels: map[string]string{"demo": "true"},
+	}
+	n, err := svc.ReapStaleNodes(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	events := eventsAfter(t, svc, last)
+	require.Len(t, events, 1)
+	assert.Equal(t, nodev1.EventType_UPDATED, events[0].Type)
+	assert.Equal(t, created.Node.Id, events[0].NodeID)
+	assert.Equal(t, []string{"status", "status_reason"}, events[0].ChangedFields)
+
+	node, err := svc.GetNode(ctx, &nodev1.GetNodeRequest{Id: created.Node.Id})
+	require.NoError(t, err)
+	assert.Equal(t, nodev1.NodeStatus_DOWN, node.Node.Status)
+
+	// Already DOWN, so nothing to do
+	n, err = svc.ReapStaleNodes(ctx, opts)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
\ No newline at end of file
@@ -0,0 +1,50 @@
+package sim
+
+import (
+	"net"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/melkior/nodestatus/internal/auth"
+	"github.com/melkior/nodestatus/internal/redisstore"
+	"github.com/melkior/nodestatus/internal/service"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+const testToken = "test-token"
+
+// startTestBackend runs the real NodeService over miniredis on a loopback
+// listener and returns a sim config pointing at it. Extra server options are
+// applied after the auth interceptors.
+func startTestBackend(t *testing.T, opts ...grpc.ServerOption) (*Config, *redisstore.Store) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	store, err := redisstore.New(mr.Addr(), "", 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer(append([]grpc.ServerOption{
+		grpc.UnaryInterceptor(auth.UnaryAuthInterceptor(testToken)),
+		grpc.StreamInterceptor(auth.StreamAuthInterceptor(testToken)),
+	}, opts...)...)
+	nodev1.RegisterNodeServiceServer(server, service.NewNodeService(store, zap.NewNop()))
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	return &Config{
+		BackendAddr:    lis.Addr().String(),
+		BackendToken:   testToken,
+		SimLabelPrefix: "demo-sim/",
+		SimSeed:        42,
+	}, store
+}
\ No newline at end of file
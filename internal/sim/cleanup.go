@@ -4,61 +4,111 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
-	"sync"
 	"sync/atomic"
+	"text/tabwriter"
 	"time"
 
 	"github.com/melkior/nodestatus/pkg/grpcclient"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Cleaner struct {
 	config *Config
 	logger *zap.Logger
 	client *grpcclient.Client
+	in     io.Reader
+	out    io.Writer
 }
 
 func NewCleaner(cfg *Config, logger *zap.Logger) *Cleaner {
 	return &Cleaner{
 		config: cfg,
 		logger: logger,
+		in:     os.Stdin,
+		out:    os.Stdout,
 	}
 }
 
-func (c *Cleaner) Cleanup(ctx context.Context, force bool) error {
-	client, err := grpcclient.NewClient(c.config.BackendAddr, c.config.BackendToken)
+// SetInput sets where the confirmation prompt reads its answer (stdin by default)
+func (c *Cleaner) SetInput(r io.Reader) {
+	c.in = r
+}
+
+// SetOutput redirects the prompt and dry-run listing, which go to stdout by default
+func (c *Cleaner) SetOutput(w io.Writer) {
+	c.out = w
+}
+
+type CleanupOptions struct {
+	Force           bool
+	DryRun          bool          // List the matching nodes without deleting them
+	Selector        LabelSelector // Extra labels a simulator node must carry, e.g. demo.batch=<id>
+	Manifest        string        // Delete exactly the nodes in this seed manifest instead of matching labels
+	ShutdownTimeout time.Duration // How long cancellation waits for in-flight deletes; 0 uses DefaultShutdownTimeout
+}
+
+func (c *Cleaner) Cleanup(ctx context.Context, opts CleanupOptions) error {
+	client, err := grpcclient.NewClient(c.config.BackendAddr, c.config.BackendToken, grpcclient.WithoutRetry())
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 	defer client.Close()
 	c.client = client
 
-	c.logger.Info("Fetching simulator nodes...")
-	nodes, err := c.client.ListNodes(ctx, 0, 0)
-	if err != nil {
-		return fmt.Errorf("failed to list nodes: %w", err)
+	if err := checkBackend(ctx, client, c.config, !opts.DryRun); err != nil {
+		return err
 	}
 
 	var toDelete []string
-	for _, node := range nodes {
-		if FilterSimulatorLabels(node.Labels) {
-			toDelete = append(toDelete, node.Id)
+	var names []string
+	if opts.Manifest != "" {
+		entries, err := ReadManifest(opts.Manifest)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			toDelete = append(toDelete, entry.ID)
+			names = append(names, entry.Name)
+		}
+	} else {
+		c.logger.Info("Fetching simulator nodes...")
+		nodes, err := c.client.ListNodes(ctx, 0, 0)
+		if err != nil {
+			return fmt.Errorf("failed to list nodes: %w", err)
+		}
+
+		for _, node := range nodes {
+			if FilterSimulatorLabels(node.Labels, opts.Selector) {
+				toDelete = append(toDelete, node.Id)
+				names = append(names, node.Name)
+			}
 		}
 	}
 
 	if len(toDelete) == 0 {
-		c.logger.Info("No simulator nodes found to cleanup")
+		c.logger.Info("No simulator nodes found to cleanup", zap.Any("selector", opts.Selector))
 		return nil
 	}
 
-	c.logger.Info("Found simulator nodes", zap.Int("count", len(toDelete)))
+	c.logger.Info("Found simulator nodes", zap.Int("count", len(toDelete)), zap.Any("selector", opts.Selector))
 
-	if !force {
-		fmt.Printf("About to delete %d nodes. Continue? (y/N): ", len(toDelete))
-		reader := bufio.NewReader(os.Stdin)
-		response, err := reader.ReadString('\n')
+	if opts.DryRun {
+		c.printDryRun(toDelete, names)
+		return nil
+	}
+
+	if !opts.Force {
+		fmt.Fprintf(c.out, "About to delete %d nodes. Continue? (y/N): ", len(toDelete))
+		response, err := readLine(ctx, c.in)
+		if ctx.Err() != nil {
+			c.logger.Info("Cleanup cancelled while waiting for confirmation")
+			return nil
+		}
 		if err != nil {
 			return err
 		}
@@ -70,9 +120,10 @@ func (c *Cleaner) Cleanup(ctx context.Context, force bool) error {
 		}
 	}
 
-	var wg sync.WaitGroup
+	var ops inflight
 	semaphore := make(chan struct{}, 32)
 	var deleted atomic.Int32
+	var missing atomic.Int32
 	var failed atomic.Int32
 	startTime := time.Now()
 
@@ -81,11 +132,15 @@ func (c *Cleaner) Cleanup(ctx context.Context, force bool) error {
 			break
 		}
 
-		wg.Add(1)
-		semaphore <- struct{}{}
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			continue
+		}
+		ops.add()
 
 		go func(nodeID string) {
-			defer wg.Done()
+			defer ops.done()
 			defer func() { <-semaphore }()
 
 			err := RetryWithBackoff(ctx, DefaultRetryConfig(), func() error {
@@ -94,7 +149,10 @@ func (c *Cleaner) Cleanup(ctx context.Context, force bool) error {
 				return c.client.DeleteNode(ctxWithTimeout, nodeID)
 			})
 
-			if err != nil {
+			if status.Code(err) == codes.NotFound {
+				// Manifest entries may already be gone
+				missing.Add(1)
+			} else if err != nil {
 				failed.Add(1)
 				c.logger.Error("Failed to delete node",
 					zap.String("id", nodeID),
@@ -110,13 +168,55 @@ func (c *Cleaner) Cleanup(ctx context.Context, force bool) error {
 		}(id)
 	}
 
-	wg.Wait()
+	if ctx.Err() != nil {
+		if abandoned := ops.drain(opts.ShutdownTimeout); abandoned > 0 {
+			c.logger.Warn("Shutdown timeout reached, abandoning in-flight deletes",
+				zap.Int64("abandoned", abandoned))
+		}
+	} else {
+		ops.wait()
+	}
 
 	duration := time.Since(startTime)
 	c.logger.Info("Cleanup completed",
 		zap.Int32("deleted", deleted.Load()),
+		zap.Int32("already_gone", missing.Load()),
 		zap.Int32("failed", failed.Load()),
 		zap.Duration("duration", duration))
 
 	return nil
+}
+
+// readLine reads one line from in, returning early when ctx is cancelled.
+// The read itself cannot be interrupted, so on cancellation the goroutine is
+// left blocked until in delivers a line or the process exits.
+func readLine(ctx context.Context, in io.Reader) (string, error) {
+	type result struct {
+		line string
+		err  error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		line, err := bufio.NewReader(in).ReadString('\n')
+		done <- result{line, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.line, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// printDryRun lists the nodes a cleanup would delete
+func (c *Cleaner) printDryRun(ids, names []string) {
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME")
+	for i, id := range ids {
+		fmt.Fprintf(w, "%s\t%s\n", id, names[i])
+	}
+	w.Flush()
+	fmt.Fprintf(c.out, "Dry run: %d nodes would be deleted\n", len(ids))
 }
\ No newline at end of file
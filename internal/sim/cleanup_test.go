@@ -0,0 +1,135 @@
+package sim
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	selector, err := ParseLabelSelector([]string{"demo.batch=abc", " env = prod "})
+	require.NoError(t, err)
+	assert.Equal(t, LabelSelector{"demo.batch": "abc", "env": "prod"}, selector)
+
+	for _, bad := range []string{"demo.batch", "=abc"} {
+		_, err := ParseLabelSelector([]string{bad})
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestFilterSimulatorLabelsSelector(t *testing.T) {
+	labels := map[string]string{"demo": "true", "demo.owner": "cli", "demo.batch": "abc"}
+
+	assert.True(t, FilterSimulatorLabels(labels, nil))
+	assert.True(t, FilterSimulatorLabels(labels, LabelSelector{"demo.batch": "abc"}))
+	assert.False(t, FilterSimulatorLabels(labels, LabelSelector{"demo.batch": "xyz"}))
+	assert.False(t, FilterSimulatorLabels(labels, LabelSelector{"env": ""}))
+	assert.False(t, FilterSimulatorLabels(map[string]string{"demo.batch": "abc"}, LabelSelector{"demo.batch": "abc"}))
+}
+
+func TestCleanupScopedToBatch(t *testing.T) {
+	cfg, store := startTestBackend(t)
+	ctx := context.Background()
+
+	for i := 0; i < 6; i++ {
+		batch := "ours"
+		if i%2 == 1 {
+			batch = "theirs"
+		}
+		_, err := store.CreateNode(ctx, &nodev1.Node{
+			Name:   fmt.Sprintf("node-%d", i),
+			Type:   nodev1.NodeType_VM,
+			Status: nodev1.NodeStatus_UP,
+			Labels: map[string]string{"demo": "true", "demo.owner": "cli", "demo.batch": batch},
+		})
+		require.NoError(t, err)
+	}
+
+	var out bytes.Buffer
+	cleaner := NewCleaner(cfg, zap.NewNop())
+	cleaner.SetOutput(&out)
+	selector := LabelSelector{"demo.batch": "ours"}
+
+	// A dry run lists the matching nodes and deletes nothing
+	require.NoError(t, cleaner.Cleanup(ctx, CleanupOptions{DryRun: true, Selector: selector}))
+	nodes, err := store.ListNodes(ctx, 0, 0, 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, nodes, 6)
+	listing := out.String()
+	assert.Contains(t, listing, "Dry run: 3 nodes would be deleted")
+	for _, name := range []string{"node-0", "node-2", "node-4"} {
+		assert.Contains(t, listing, name)
+	}
+	assert.NotContains(t, listing, "node-1")
+
+	require.NoError(t, cleaner.Cleanup(ctx, CleanupOptions{Force: true, Selector: selector}))
+	nodes, err = store.ListNodes(ctx, 0, 0, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, nodes, 3)
+	for _, node := range nodes {
+		assert.Equal(t, "theirs", node.Labels["demo.batch"])
+	}
+}
+
+func TestCleanupPromptCancelledByContext(t *testing.T) {
+	cfg, store := startTestBackend(t)
+	_, err := store.CreateNode(context.Background(), &nodev1.Node{
+		Name:   "node-0",
+		Type:   nodev1.NodeType_VM,
+		Status: nodev1.NodeStatus_UP,
+		Labels: map[string]string{"demo": "true", "demo.owner": "cli"},
+	})
+	require.NoError(t, err)
+
+	// A pipe nobody writes to blocks the prompt like an idle terminal
+	stdin, stdinWriter := io.Pipe()
+	defer stdinWriter.Close()
+
+	var out bytes.Buffer
+	cleaner := NewCleaner(cfg, zap.NewNop())
+	cleaner.SetInput(stdin)
+	cleaner.SetOutput(&out)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- cleaner.Cleanup(ctx, CleanupOptions{})
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("cleanup still blocked on the prompt after cancellation")
+	}
+
+	nodes, err := store.ListNodes(context.Background(), 0, 0, 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, nodes, 1)
+	assert.Equal(t, "About to delete 1 nodes. Continue? (y/N): ", out.String())
+
+	// Anything but yes keeps the nodes
+	cleaner.SetInput(strings.NewReader("n\n"))
+	require.NoError(t, cleaner.Cleanup(context.Background(), CleanupOptions{}))
+	nodes, err = store.ListNodes(context.Background(), 0, 0, 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, nodes, 1)
+
+	cleaner.SetInput(strings.NewReader("y\n"))
+	require.NoError(t, cleaner.Cleanup(context.Background(), CleanupOptions{}))
+	nodes, err = store.ListNodes(context.Background(), 0, 0, 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, nodes)
+}
\ No newline at end of file
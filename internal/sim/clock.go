@@ -0,0 +1,43 @@
+package sim
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the time source for values the simulator writes into nodes.
+type Clock interface {
+	Now() time.Time
+}
+
+type wallClock struct{}
+
+func (wallClock) Now() time.Time {
+	return time.Now()
+}
+
+// logicalEpoch is where every deterministic run starts counting from.
+var logicalEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// LogicalClock only moves when advanced, so runs with the same seed see the
+// same timestamps regardless of how long each tick took on the wall clock.
+type LogicalClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewLogicalClock() *LogicalClock {
+	return &LogicalClock{now: logicalEpoch}
+}
+
+func (c *LogicalClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *LogicalClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
\ No newline at end of file
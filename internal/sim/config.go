@@ -5,7 +5,10 @@ import (
 	"math/rand"
 	"os"
 	"strconv"
+	"sync"
 	"time"
+
+	"github.com/melkior/nodestatus/internal/config"
 )
 
 type Config struct {
@@ -13,16 +16,57 @@ type Config struct {
 	BackendToken    string
 	SimLabelPrefix  string
 	SimSeed         int64
+	SeedFromEnv     bool // SIM_SEED was set explicitly rather than drawn from the clock
+	Deterministic   bool
+	StatusWeights   string // SIM_STATUS_WEIGHTS, overridden by --status-weights
+	ConnPoolSize    int    // SIM_CONN_POOL_SIZE, gRPC connections used by run
+}
+
+// envKeys lists every environment variable LoadConfig reads, which are also
+// the keys allowed in a config file
+var envKeys = []string{
+	"BACKEND_ADDR", "BACKEND_TOKEN", "SIM_LABEL_PREFIX", "SIM_STATUS_WEIGHTS",
+	"SIM_CONN_POOL_SIZE", "SIM_SEED",
 }
 
+// LoadConfig reads the configuration from the environment, or from the file
+// named by SIM_CONFIG_FILE with environment variables taking precedence.
 func LoadConfig() (*Config, error) {
+	if path := os.Getenv("SIM_CONFIG_FILE"); path != "" {
+		return LoadConfigFromFile(path)
+	}
+	return loadConfig(config.Source{})
+}
+
+// LoadConfigFromFile reads the configuration from a YAML file keyed by
+// lowercase environment variable names. Environment variables override the
+// file.
+func LoadConfigFromFile(path string) (*Config, error) {
+	src, err := config.NewFileSource(path, envKeys)
+	if err != nil {
+		return nil, err
+	}
+	return loadConfig(src)
+}
+
+func loadConfig(src config.Source) (*Config, error) {
 	cfg := &Config{
-		BackendAddr:    getEnvOrDefault("BACKEND_ADDR", "localhost:50051"),
-		BackendToken:   os.Getenv("BACKEND_TOKEN"),
-		SimLabelPrefix: getEnvOrDefault("SIM_LABEL_PREFIX", "demo-sim/"),
+		BackendAddr:    src.GetOrDefault("BACKEND_ADDR", "localhost:50051"),
+		BackendToken:   src.Get("BACKEND_TOKEN"),
+		SimLabelPrefix: src.GetOrDefault("SIM_LABEL_PREFIX", "demo-sim/"),
+		StatusWeights:  src.Get("SIM_STATUS_WEIGHTS"),
+		ConnPoolSize:   1,
+	}
+
+	if poolStr := src.Get("SIM_CONN_POOL_SIZE"); poolStr != "" {
+		size, err := strconv.Atoi(poolStr)
+		if err != nil || size < 1 {
+			return nil, fmt.Errorf("invalid SIM_CONN_POOL_SIZE %q: must be a positive integer", poolStr)
+		}
+		cfg.ConnPoolSize = size
 	}
 
-	seedStr := getEnvOrDefault("SIM_SEED", "")
+	seedStr := src.Get("SIM_SEED")
 	if seedStr == "" || seedStr == "random" {
 		cfg.SimSeed = time.Now().UnixNano()
 	} else {
@@ -31,18 +75,66 @@ func LoadConfig() (*Config, error) {
 			return nil, fmt.Errorf("invalid SIM_SEED: %w", err)
 		}
 		cfg.SimSeed = seed
+		cfg.SeedFromEnv = true
 	}
 
 	return cfg, nil
 }
 
+// NewRand returns the run's random source, seeded with SimSeed. It is safe
+// for concurrent use.
 func (c *Config) NewRand() *rand.Rand {
-	return rand.New(rand.NewSource(c.SimSeed))
+	return rand.New(&lockedSource{src: rand.NewSource(c.SimSeed).(rand.Source64)})
+}
+
+// lockedSource serializes a rand.Source, so a Rand built on it can be drawn
+// from by concurrent operations. Draws made in a fixed order, as in
+// deterministic runs, still yield the seed's sequence.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source64
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
+
+// NewClock returns wall time, or a logical clock when the run must be reproducible.
+func (c *Config) NewClock() Clock {
+	if c.Deterministic {
+		return NewLogicalClock()
+	}
+	return wallClock{}
+}
+
+// NewRetryRand returns the jitter source for retries. Deterministic runs draw
+// it from its own seeded stream so that retries caused by transient backend
+// errors don't shift the main operation sequence.
+func (c *Config) NewRetryRand() *rand.Rand {
+	if !c.Deterministic {
+		return nil
+	}
+	return rand.New(rand.NewSource(c.SimSeed + 1))
 }
 
-func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// NewBatchID returns the demo.batch label value for a seed or run.
+func (c *Config) NewBatchID(rng *rand.Rand, clock Clock) string {
+	if c.Deterministic {
+		return fmt.Sprintf("%d", rng.Int63())
 	}
-	return defaultValue
+	return fmt.Sprintf("%d", clock.Now().Unix())
 }
\ No newline at end of file
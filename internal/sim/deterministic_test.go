@@ -0,0 +1,90 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+var mutatingRPCs = map[string]bool{
+	"/node.v1.NodeService/CreateNode":        true,
+	"/node.v1.NodeService/UpdateNode":        true,
+	"/node.v1.NodeService/UpdateStatus":      true,
+	"/node.v1.NodeService/BatchUpdateStatus": true,
+	"/node.v1.NodeService/DeleteNode":        true,
+}
+
+// recordMutations runs a deterministic seed + run against a fresh backend and
+// returns every mutating RPC it served, minus server-assigned IDs and times.
+func recordMutations(t *testing.T) []string {
+	t.Helper()
+
+	var (
+		mu  sync.Mutex
+		log []string
+	)
+	recorder := grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if !mutatingRPCs[info.FullMethod] {
+			return resp, err
+		}
+
+		entry := info.FullMethod
+		if withNode, ok := resp.(interface{ GetNode() *nodev1.Node }); ok && withNode.GetNode() != nil {
+			n := withNode.GetNode()
+			entry = fmt.Sprintf("%s %s %s %s %v %s", entry, n.Type, n.Name, n.Status, n.Labels, n.MetadataJson)
+		}
+
+		mu.Lock()
+		log = append(log, entry)
+		mu.Unlock()
+		return resp, err
+	})
+
+	cfg, _ := startTestBackend(t, recorder)
+	cfg.Deterministic = true
+	ctx := context.Background()
+
+	_, err := NewSeeder(cfg, zap.NewNop()).Seed(ctx, SeedOptions{
+		Total:        20,
+		PctBaremetal: 0.2,
+		PctVM:        0.4,
+		PctContainer: 0.4,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, NewRunner(cfg, zap.NewNop()).Run(ctx, RunOptions{
+		Duration:              "1s",
+		UpdateQPS:             1000,
+		MaxConcurrency:        8,
+		ProbStatusFlip:        0.4,
+		ProbLabelChange:       0.3,
+		ProbMetadataChange:    0.2,
+		ProbDeleteAndRecreate: 0.1,
+		BatchSize:             10,
+	}))
+
+	mu.Lock()
+	defer mu.Unlock()
+	return log
+}
+
+func TestDeterministicRunsReplayIdenticalRPCs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the simulator on its one-second tick")
+	}
+
+	first := recordMutations(t)
+	second := recordMutations(t)
+
+	// 20 creates from the seed plus the 10 operations of the single tick
+	require.GreaterOrEqual(t, len(first), 30)
+	assert.Equal(t, first, second)
+}
\ No newline at end of file
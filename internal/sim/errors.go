@@ -0,0 +1,63 @@
+package sim
+
+import (
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorCode returns the gRPC code of err. Context errors map to Canceled or
+// DeadlineExceeded and other non-gRPC errors to Unknown.
+func ErrorCode(err error) codes.Code {
+	if s, ok := status.FromError(err); ok {
+		return s.Code()
+	}
+	return status.FromContextError(err).Code()
+}
+
+// ErrorCounts counts failed operations by gRPC code. The zero value is ready
+// to use.
+type ErrorCounts struct {
+	mu     sync.Mutex
+	counts map[codes.Code]int64
+}
+
+// Record counts err under its code and returns the code
+func (e *ErrorCounts) Record(err error) codes.Code {
+	code := ErrorCode(err)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.counts == nil {
+		e.counts = make(map[codes.Code]int64)
+	}
+	e.counts[code]++
+	return code
+}
+
+// Count returns the failures recorded under code
+func (e *ErrorCounts) Count(code codes.Code) int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.counts[code]
+}
+
+// Codes returns the codes with at least one failure, in ascending order
+func (e *ErrorCounts) Codes() []codes.Code {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	result := make([]codes.Code, 0, len(e.counts))
+	for code := range e.counts {
+		result = append(result, code)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+// retryableCode reports whether RetryWithBackoff retries errors with code
+func retryableCode(code codes.Code) bool {
+	return isRetryable(status.Error(code, ""))
+}
\ No newline at end of file
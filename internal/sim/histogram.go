@@ -0,0 +1,216 @@
+package sim
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/melkior/nodestatus/pkg/grpcclient"
+	"go.uber.org/zap"
+)
+
+const histogramBarWidth = 40
+
+type HistogramOptions struct {
+	Field   string
+	Buckets []float64 // Ascending upper boundaries; a final open bucket catches the rest
+	JSON    bool
+}
+
+type Histogram struct {
+	config *Config
+	logger *zap.Logger
+	client *grpcclient.Client
+}
+
+type HistogramBucket struct {
+	Label string   `json:"label"`
+	Lower *float64 `json:"lower,omitempty"` // Inclusive, nil for the first bucket
+	Upper *float64 `json:"upper,omitempty"` // Exclusive, nil for the last bucket
+	Count int      `json:"count"`
+}
+
+type HistogramData struct {
+	Field      string            `json:"field"`
+	Total      int               `json:"total"`
+	Buckets    []HistogramBucket `json:"buckets"`
+	Missing    int               `json:"missing"`     // Nodes without the field
+	NonNumeric int               `json:"non_numeric"` // Nodes where the field isn't a number
+}
+
+func NewHistogram(cfg *Config, logger *zap.Logger) *Histogram {
+	return &Histogram{
+		config: cfg,
+		logger: logger,
+	}
+}
+
+func (h *Histogram) Print(ctx context.Context, opts HistogramOptions) error {
+	client, err := grpcclient.NewClient(h.config.BackendAddr, h.config.BackendToken)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+	h.client = client
+
+	if err := checkBackend(ctx, client, h.config, false); err != nil {
+		return err
+	}
+
+	nodes, err := h.client.ListNodes(ctx, 0, 0)
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	hist, err := BuildHistogram(nodes, opts.Field, opts.Buckets)
+	if err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		data, err := json.MarshalIndent(hist, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	return h.printTable(hist)
+}
+
+// BuildHistogram buckets the numeric metadata field of every node. Bucket i
+// holds values in [bounds[i-1], bounds[i]); the first bucket is open below and
+// an extra last bucket holds everything >= the final boundary.
+func BuildHistogram(nodes []*nodev1.Node, field string, bounds []float64) (*HistogramData, error) {
+	if field == "" {
+		return nil, fmt.Errorf("metadata field is required")
+	}
+	if len(bounds) == 0 {
+		return nil, fmt.Errorf("at least one bucket boundary is required")
+	}
+	for i := 1; i < len(bounds); i++ {
+		if bounds[i] <= bounds[i-1] {
+			return nil, fmt.Errorf("bucket boundaries must be strictly increasing (got %v after %v)", bounds[i], bounds[i-1])
+		}
+	}
+
+	hist := &HistogramData{
+		Field:   field,
+		Buckets: make([]HistogramBucket, len(bounds)+1),
+	}
+	for i := range hist.Buckets {
+		bucket := &hist.Buckets[i]
+		switch {
+		case i == 0:
+			bucket.Upper = &bounds[0]
+			bucket.Label = "< " + formatBound(bounds[0])
+		case i == len(bounds):
+			bucket.Lower = &bounds[i-1]
+			bucket.Label = ">= " + formatBound(bounds[i-1])
+		default:
+			bucket.Lower = &bounds[i-1]
+			bucket.Upper = &bounds[i]
+			bucket.Label = formatBound(bounds[i-1]) + " - " + formatBound(bounds[i])
+		}
+	}
+
+	for _, node := range nodes {
+		hist.Total++
+
+		value, ok, err := metadataNumber(node.MetadataJson, field)
+		if !ok {
+			hist.Missing++
+			continue
+		}
+		if err != nil {
+			hist.NonNumeric++
+			continue
+		}
+
+		idx := len(bounds)
+		for i, bound := range bounds {
+			if value < bound {
+				idx = i
+				break
+			}
+		}
+		hist.Buckets[idx].Count++
+	}
+
+	return hist, nil
+}
+
+// metadataNumber looks up a top-level field in a node's metadata JSON.
+// ok is false when the metadata or the field is absent.
+func metadataNumber(metadataJSON, field string) (value float64, ok bool, err error) {
+	if metadataJSON == "" {
+		return 0, false, nil
+	}
+
+	var metadata map[string]interface{}
+	if err := json.Unmarshal([]byte(metadataJSON), &metadata); err != nil {
+		return 0, false, nil
+	}
+
+	raw, ok := metadata[field]
+	if !ok {
+		return 0, false, nil
+	}
+
+	switch v := raw.(type) {
+	case float64:
+		return v, true, nil
+	case string:
+		// Some producers write numbers as strings
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, true, err
+	default:
+		return 0, true, fmt.Errorf("field %s is %T, not a number", field, raw)
+	}
+}
+
+func formatBound(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func (h *Histogram) printTable(hist *HistogramData) error {
+	fmt.Printf("\n===== Histogram: %s =====\n", hist.Field)
+	fmt.Printf("Total Nodes: %d\n", hist.Total)
+	fmt.Println()
+
+	maxCount := 0
+	for _, bucket := range hist.Buckets {
+		if bucket.Count > maxCount {
+			maxCount = bucket.Count
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BUCKET\tCOUNT\tPERCENT\t")
+	for _, bucket := range hist.Buckets {
+		pct := 0.0
+		if hist.Total > 0 {
+			pct = float64(bucket.Count) * 100 / float64(hist.Total)
+		}
+		bar := ""
+		if maxCount > 0 {
+			bar = strings.Repeat("█", bucket.Count*histogramBarWidth/maxCount)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\t%s\n", bucket.Label, bucket.Count, pct, bar)
+	}
+	w.Flush()
+
+	if hist.Missing > 0 || hist.NonNumeric > 0 {
+		fmt.Println()
+		fmt.Printf("Missing field: %d\n", hist.Missing)
+		fmt.Printf("Non-numeric: %d\n", hist.NonNumeric)
+	}
+	fmt.Println("===========================")
+
+	return nil
+}
\ No newline at end of file
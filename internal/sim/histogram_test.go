@@ -0,0 +1,52 @@
+package sim
+
+import (
+	"fmt"
+	"testing"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildHistogramBucketCounts(t *testing.T) {
+	var nodes []*nodev1.Node
+	addNode := func(metadata string) {
+		nodes = append(nodes, &nodev1.Node{Name: fmt.Sprintf("n-%d", len(nodes)), MetadataJson: metadata})
+	}
+
+	// 2 below 8, 3 in [8,32), 1 in [32,128), 2 at or above 128
+	for _, ram := range []int{4, 7, 8, 16, 31, 64, 128, 512} {
+		addNode(fmt.Sprintf(`{"ram_gb": %d}`, ram))
+	}
+	addNode(`{"ram_gb": "16"}`)   // numeric string counts
+	addNode(`{"ram_gb": "lots"}`) // non-numeric
+	addNode(`{"cpu_cores": 8}`)   // missing field
+	addNode("")                   // no metadata
+
+	hist, err := BuildHistogram(nodes, "ram_gb", []float64{8, 32, 128})
+	require.NoError(t, err)
+
+	labels := make([]string, len(hist.Buckets))
+	counts := make([]int, len(hist.Buckets))
+	for i, bucket := range hist.Buckets {
+		labels[i] = bucket.Label
+		counts[i] = bucket.Count
+	}
+	assert.Equal(t, []string{"< 8", "8 - 32", "32 - 128", ">= 128"}, labels)
+	assert.Equal(t, []int{2, 4, 1, 2}, counts)
+	assert.Equal(t, 12, hist.Total)
+	assert.Equal(t, 2, hist.Missing)
+	assert.Equal(t, 1, hist.NonNumeric)
+}
+
+func TestBuildHistogramRejectsBadBoundaries(t *testing.T) {
+	_, err := BuildHistogram(nil, "ram_gb", nil)
+	assert.Error(t, err)
+
+	_, err = BuildHistogram(nil, "ram_gb", []float64{8, 8, 16})
+	assert.Error(t, err)
+
+	_, err = BuildHistogram(nil, "", []float64{8})
+	assert.Error(t, err)
+}
\ No newline at end of file
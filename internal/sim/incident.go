@@ -0,0 +1,298 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/melkior/nodestatus/pkg/grpcclient"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// incidentConcurrency bounds the status updates an incident has in flight
+const incidentConcurrency = 32
+
+// incidentReason is the status reason of the nodes an incident takes down,
+// so they can be told apart from real failures
+const incidentReason = "simulated incident"
+
+// errNotStarted marks targets apply skipped because its context was done
+var errNotStarted = errors.New("not started")
+
+// DefaultIncidentStatusWeights takes most affected nodes DOWN and the rest
+// DEGRADED
+func DefaultIncidentStatusWeights() *StatusWeights {
+	return &StatusWeights{weights: map[nodev1.NodeStatus]float64{
+		nodev1.NodeStatus_DOWN:     0.7,
+		nodev1.NodeStatus_DEGRADED: 0.3,
+	}}
+}
+
+type IncidentOptions struct {
+	Pct             float64        // Share of simulator nodes affected, in (0, 1]
+	Hold            time.Duration  // How long affected nodes stay in their incident status
+	Recover         time.Duration  // Restores are spread evenly over this window; 0 restores all at once
+	StatusWeights   *StatusWeights // Incident statuses; DefaultIncidentStatusWeights when nil
+	Selector        LabelSelector  // Extra labels an affected node must carry
+	ShutdownTimeout time.Duration  // How long an interrupted incident keeps restoring nodes; 0 uses DefaultShutdownTimeout
+}
+
+// IncidentResult summarizes an incident for programmatic callers
+type IncidentResult struct {
+	Affected int // Nodes moved to an incident status
+	Restored int // Nodes put back to their prior status
+	Gone     int // Affected nodes deleted before they could be restored
+	Failed   int // Flips and restores that failed after retries
+	Duration time.Duration
+}
+
+// Incident drives a share of the simulator's nodes DOWN or DEGRADED, holds
+// them there, then restores each to the status it had before
+type Incident struct {
+	config *Config
+	logger *zap.Logger
+	client *grpcclient.Client
+	rng    *rand.Rand
+	start  time.Time
+}
+
+// incidentTarget is an affected node and the status it is restored to
+type incidentTarget struct {
+	id     string
+	name   string
+	prior  nodev1.NodeStatus
+	status nodev1.NodeStatus
+}
+
+func NewIncident(cfg *Config, logger *zap.Logger) *Incident {
+	return &Incident{
+		config: cfg,
+		logger: logger,
+	}
+}
+
+func (inc *Incident) Run(ctx context.Context, opts IncidentOptions) (*IncidentResult, error) {
+	if opts.Pct <= 0 || opts.Pct > 1 {
+		return nil, fmt.Errorf("--pct must be in (0, 1], got %v", opts.Pct)
+	}
+	if opts.Hold < 0 || opts.Recover < 0 {
+		return nil, fmt.Errorf("--hold and --recover must not be negative")
+	}
+	weights := opts.StatusWeights
+	if weights == nil {
+		weights = DefaultIncidentStatusWeights()
+	}
+
+	client, err := grpcclient.NewClient(inc.config.BackendAddr, inc.config.BackendToken, grpcclient.WithoutRetry())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	defer client.Close()
+	inc.client = client
+
+	if err := checkBackend(ctx, client, inc.config, true); err != nil {
+		return nil, err
+	}
+	inc.rng = inc.config.NewRand()
+	inc.start = time.Now()
+
+	targets, err := inc.pickTargets(ctx, opts.Pct, opts.Selector, weights)
+	if err != nil {
+		return nil, err
+	}
+	result := &IncidentResult{}
+	if len(targets) == 0 {
+		inc.logger.Info("No simulator nodes found, nothing to do", zap.Any("selector", opts.Selector))
+		return result, nil
+	}
+
+	inc.mark("Incident started", zap.Int("nodes", len(targets)), zap.Float64("pct", opts.Pct))
+	errs := inc.apply(ctx, ctx, targets, 0, func(ctx context.Context, t incidentTarget) error {
+		_, err := inc.client.UpdateStatusReason(ctx, t.id, t.status, incidentReason)
+		return err
+	})
+
+	// Only nodes that may have changed need restoring. An update cut short
+	// by an interrupt may still have been applied, and restoring a node
+	// that wasn't flipped only rewrites its current status.
+	var affected []incidentTarget
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			result.Affected++
+			affected = append(affected, targets[i])
+		case errors.Is(err, errNotStarted):
+		case ctx.Err() != nil:
+			affected = append(affected, targets[i])
+		default:
+			result.Failed++
+			inc.logger.Error("Failed to flip node", zap.String("id", targets[i].id), zap.Error(err))
+		}
+	}
+	inc.mark("Nodes down", zap.Int("affected", result.Affected), zap.Int("failed", result.Failed))
+
+	if ctx.Err() == nil {
+		inc.mark("Holding", zap.Duration("hold", opts.Hold))
+		sleepContext(ctx, opts.Hold)
+	}
+
+	window := opts.Recover
+	if ctx.Err() != nil {
+		inc.mark("Interrupted, restoring nodes now", zap.Duration("timeout", opts.ShutdownTimeout))
+		window = 0
+	} else {
+		inc.mark("Recovering", zap.Duration("recover", opts.Recover))
+	}
+
+	restoreCtx, cancel := restoreContext(ctx, opts.ShutdownTimeout)
+	defer cancel()
+	errs = inc.apply(ctx, restoreCtx, affected, window, func(ctx context.Context, t incidentTarget) error {
+		_, err := inc.client.UpdateStatus(ctx, t.id, t.prior)
+		return err
+	})
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			result.Restored++
+		case status.Code(err) == codes.NotFound:
+			result.Gone++
+		default:
+			result.Failed++
+			inc.logger.Error("Failed to restore node",
+				zap.String("id", affected[i].id),
+				zap.String("status", affected[i].prior.String()),
+				zap.Error(err))
+		}
+	}
+
+	result.Duration = time.Since(inc.start)
+	inc.mark("Incident over",
+		zap.Int("affected", result.Affected),
+		zap.Int("restored", result.Restored),
+		zap.Int("gone", result.Gone),
+		zap.Int("failed", result.Failed))
+	return result, nil
+}
+
+// pickTargets draws the affected nodes and their incident statuses, keeping
+// the status each had before
+func (inc *Incident) pickTargets(ctx context.Context, pct float64, selector LabelSelector, weights *StatusWeights) ([]incidentTarget, error) {
+	nodes, err := inc.client.ListNodes(ctx, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	var candidates []*nodev1.Node
+	for _, node := range nodes {
+		if FilterSimulatorLabels(node.Labels, selector) {
+			candidates = append(candidates, node)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	// ListNodes returns Redis set order, so sort before drawing to keep
+	// seeded runs reproducible
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Id < candidates[j].Id })
+	inc.rng.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	n := int(math.Ceil(pct * float64(len(candidates))))
+	targets := make([]incidentTarget, n)
+	for i, node := range candidates[:n] {
+		targets[i] = incidentTarget{
+			id:     node.Id,
+			name:   node.Name,
+			prior:  node.Status,
+			status: weights.Pick(inc.rng),
+		}
+	}
+	return targets, nil
+}
+
+// apply runs fn for every target with retries, at most incidentConcurrency at
+// a time, starting them evenly over window. Pacing stops when pace is done, so
+// the remaining targets start at once; calls use rpc. The result holds each
+// target's error, errNotStarted for those skipped once rpc was done.
+func (inc *Incident) apply(pace, rpc context.Context, targets []incidentTarget, window time.Duration, fn func(context.Context, incidentTarget) error) []error {
+	errs := make([]error, len(targets))
+	semaphore := make(chan struct{}, incidentConcurrency)
+	var wg sync.WaitGroup
+
+	var gap time.Duration
+	if len(targets) > 1 {
+		gap = window / time.Duration(len(targets)-1)
+	}
+
+	for i, target := range targets {
+		if i > 0 && gap > 0 && pace.Err() == nil {
+			sleepContext(pace, gap)
+		}
+
+		select {
+		case semaphore <- struct{}{}:
+		case <-rpc.Done():
+			errs[i] = errNotStarted
+			continue
+		}
+		wg.Add(1)
+
+		go func(i int, t incidentTarget) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			errs[i] = RetryWithBackoff(rpc, DefaultRetryConfig(), func() error {
+				ctxWithTimeout, cancel := context.WithTimeout(rpc, 5*time.Second)
+				defer cancel()
+				return fn(ctxWithTimeout, t)
+			})
+		}(i, target)
+	}
+
+	wg.Wait()
+	return errs
+}
+
+// mark logs a timeline entry with the time since the incident started
+func (inc *Incident) mark(msg string, fields ...zap.Field) {
+	fields = append([]zap.Field{zap.Duration("t", time.Since(inc.start).Round(time.Millisecond))}, fields...)
+	inc.logger.Info(msg, fields...)
+}
+
+// restoreContext is a context for putting nodes back that outlives ctx: once
+// ctx is cancelled, it lasts up to timeout longer. A timeout of zero uses
+// DefaultShutdownTimeout.
+func restoreContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+
+	restoreCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	var timer *time.Timer
+	var mu sync.Mutex
+	stop := context.AfterFunc(ctx, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		timer = time.AfterFunc(timeout, cancel)
+	})
+
+	return restoreCtx, func() {
+		stop()
+		mu.Lock()
+		if timer != nil {
+			timer.Stop()
+		}
+		mu.Unlock()
+		cancel()
+	}
+}
\ No newline at end of file
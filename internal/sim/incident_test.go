@@ -0,0 +1,107 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/melkior/nodestatus/internal/redisstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// seedIncidentNodes creates n simulator nodes with the given statuses in turn
+// and returns their statuses by ID
+func seedIncidentNodes(t *testing.T, store *redisstore.Store, n int, statuses ...nodev1.NodeStatus) map[string]nodev1.NodeStatus {
+	t.Helper()
+	prior := make(map[string]nodev1.NodeStatus)
+	for i := 0; i < n; i++ {
+		node, err := store.CreateNode(context.Background(), &nodev1.Node{
+			Name:   fmt.Sprintf("node-%d", i),
+			Type:   nodev1.NodeType_VM,
+			Status: statuses[i%len(statuses)],
+			Labels: map[string]string{"demo": "true", "demo.owner": "cli"},
+		})
+		require.NoError(t, err)
+		prior[node.Id] = node.Status
+	}
+	return prior
+}
+
+// countChanged returns how many nodes differ from their prior status
+func countChanged(t *testing.T, store *redisstore.Store, prior map[string]nodev1.NodeStatus) int {
+	t.Helper()
+	nodes, err := store.ListNodes(context.Background(), 0, 0, 0, 0)
+	require.NoError(t, err)
+	changed := 0
+	for _, node := range nodes {
+		if node.Status != prior[node.Id] {
+			changed++
+		}
+	}
+	return changed
+}
+
+func TestIncidentRestoresPriorStatus(t *testing.T) {
+	cfg, store := startTestBackend(t)
+	prior := seedIncidentNodes(t, store, 10, nodev1.NodeStatus_UP, nodev1.NodeStatus_UNKNOWN)
+
+	weights, err := ParseStatusWeights("down=1")
+	require.NoError(t, err)
+
+	done := make(chan *IncidentResult, 1)
+	go func() {
+		result, err := NewIncident(cfg, zap.NewNop()).Run(context.Background(), IncidentOptions{
+			Pct:           0.3,
+			Hold:          200 * time.Millisecond,
+			Recover:       20 * time.Millisecond,
+			StatusWeights: weights,
+		})
+		assert.NoError(t, err)
+		done <- result
+	}()
+
+	// Three nodes are held DOWN, then put back
+	assert.Eventually(t, func() bool { return countChanged(t, store, prior) == 3 }, time.Second, 10*time.Millisecond)
+	result := <-done
+	assert.Equal(t, 3, result.Affected)
+	assert.Equal(t, 3, result.Restored)
+	assert.Zero(t, result.Failed)
+	assert.Zero(t, countChanged(t, store, prior))
+}
+
+func TestIncidentRestoresWhenInterrupted(t *testing.T) {
+	cfg, store := startTestBackend(t)
+	prior := seedIncidentNodes(t, store, 4, nodev1.NodeStatus_UP)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan *IncidentResult, 1)
+	go func() {
+		result, err := NewIncident(cfg, zap.NewNop()).Run(ctx, IncidentOptions{Pct: 1, Hold: time.Hour, Recover: time.Hour})
+		assert.NoError(t, err)
+		done <- result
+	}()
+
+	require.Eventually(t, func() bool { return countChanged(t, store, prior) == 4 }, time.Second, 10*time.Millisecond)
+	cancel()
+
+	select {
+	case result := <-done:
+		assert.Equal(t, 4, result.Restored)
+	case <-time.After(5 * time.Second):
+		t.Fatal("interrupted incident did not return")
+	}
+	assert.Zero(t, countChanged(t, store, prior))
+}
+
+func TestIncidentRejectsInvalidPct(t *testing.T) {
+	cfg, _ := startTestBackend(t)
+	for _, pct := range []float64{0, -0.1, 1.5} {
+		_, err := NewIncident(cfg, zap.NewNop()).Run(context.Background(), IncidentOptions{Pct: pct})
+		assert.Error(t, err, "pct %v", pct)
+	}
+}
\ No newline at end of file
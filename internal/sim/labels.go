@@ -9,35 +9,43 @@ import (
 
 type LabelGenerator struct {
 	rng         *rand.Rand
+	clock       Clock
 	batchID     string
 	labelPrefix string
+	schema      *LabelSchema
 }
 
-func NewLabelGenerator(rng *rand.Rand, labelPrefix string) *LabelGenerator {
+// NewLabelGenerator generates labels from schema, or from DefaultLabelSchema
+// when it is nil
+func NewLabelGenerator(rng *rand.Rand, clock Clock, batchID, labelPrefix string, schema *LabelSchema) *LabelGenerator {
+	if schema == nil {
+		schema = DefaultLabelSchema()
+	}
 	return &LabelGenerator{
 		rng:         rng,
-		batchID:     fmt.Sprintf("%d", time.Now().Unix()),
+		clock:       clock,
+		batchID:     batchID,
 		labelPrefix: labelPrefix,
+		schema:      schema,
 	}
 }
 
 func (lg *LabelGenerator) Generate(extraLabels []string) map[string]string {
 	labels := make(map[string]string)
 
+	for i := range lg.schema.Labels {
+		spec := &lg.schema.Labels[i]
+		if value, ok := spec.pick(lg.rng); ok {
+			labels[spec.Key] = value
+		}
+	}
+
+	// Set after the schema so FilterSimulatorLabels always finds them
 	labels["demo"] = "true"
 	labels["demo.owner"] = "cli"
 	labels["demo.batch"] = lg.batchID
 	labels[lg.labelPrefix+"managed"] = "true"
 
-	envs := []string{"dev", "staging", "prod", "test"}
-	labels["env"] = envs[lg.rng.Intn(len(envs))]
-
-	dcs := []string{"us-east-1", "us-west-2", "eu-central-1", "ap-southeast-1"}
-	labels["datacenter"] = dcs[lg.rng.Intn(len(dcs))]
-
-	services := []string{"api", "web", "db", "cache", "worker", "analytics", "monitoring"}
-	labels["service"] = services[lg.rng.Intn(len(services))]
-
 	for _, label := range extraLabels {
 		parts := strings.SplitN(label, "=", 2)
 		if len(parts) == 2 {
@@ -57,7 +65,7 @@ func (lg *LabelGenerator) UpdateLabels(existing map[string]string) map[string]st
 	operations := []string{"scale", "update", "patch", "rotate", "refresh"}
 	updated["last_operation"] = operations[lg.rng.Intn(len(operations))]
 
-	updated["updated_at"] = time.Now().Format(time.RFC3339)
+	updated["updated_at"] = lg.clock.Now().Format(time.RFC3339)
 
 	if lg.rng.Float64() < 0.3 {
 		versions := []string{"v1.0", "v1.1", "v2.0", "v2.1", "v3.0"}
@@ -72,6 +80,39 @@ func (lg *LabelGenerator) UpdateLabels(existing map[string]string) map[string]st
 	return updated
 }
 
-func FilterSimulatorLabels(labels map[string]string) bool {
-	return labels["demo"] == "true" && labels["demo.owner"] == "cli"
+// LabelSelector narrows a label match to nodes carrying every key=value pair
+type LabelSelector map[string]string
+
+// Matches reports whether labels carry every pair of the selector. An empty
+// selector matches any labels.
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for key, value := range s {
+		if actual, ok := labels[key]; !ok || actual != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseLabelSelector parses key=value pairs such as demo.batch=1700000000
+func ParseLabelSelector(pairs []string) (LabelSelector, error) {
+	selector := make(LabelSelector, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid label %q: expected key=value", pair)
+		}
+		selector[key] = strings.TrimSpace(value)
+	}
+	return selector, nil
+}
+
+// FilterSimulatorLabels reports whether labels mark a simulator node that also
+// matches selector. A nil selector matches every simulator node.
+func FilterSimulatorLabels(labels map[string]string, selector LabelSelector) bool {
+	if labels["demo"] != "true" || labels["demo.owner"] != "cli" {
+		return false
+	}
+	return selector.Matches(labels)
 }
\ No newline at end of file
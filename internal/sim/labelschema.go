@@ -0,0 +1,121 @@
+package sim
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LabelSchema describes the labels seeded nodes get besides the simulator's
+// own demo.* markers. It is loaded from YAML or JSON (JSON is valid YAML).
+type LabelSchema struct {
+	Labels []LabelSpec `yaml:"labels"`
+}
+
+// LabelSpec draws one label's value from Values
+type LabelSpec struct {
+	Key    string   `yaml:"key"`
+	Values []string `yaml:"values"`
+	// Weights are relative weights for Values, which are equally likely when
+	// empty
+	Weights []float64 `yaml:"weights"`
+	// Probability is the chance a node gets the label at all; every node
+	// does when unset
+	Probability *float64 `yaml:"probability"`
+}
+
+// DefaultLabelSchema is the env, datacenter and service labels the simulator
+// has always generated
+func DefaultLabelSchema() *LabelSchema {
+	return &LabelSchema{Labels: []LabelSpec{
+		{Key: "env", Values: []string{"dev", "staging", "prod", "test"}},
+		{Key: "datacenter", Values: []string{"us-east-1", "us-west-2", "eu-central-1", "ap-southeast-1"}},
+		{Key: "service", Values: []string{"api", "web", "db", "cache", "worker", "analytics", "monitoring"}},
+	}}
+}
+
+// LoadLabelSchema reads and validates a label schema file.
+func LoadLabelSchema(path string) (*LabelSchema, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read label schema: %w", err)
+	}
+	return ParseLabelSchema(raw)
+}
+
+// ParseLabelSchema decodes and validates a label schema document. Keys under
+// demo are reserved, since FilterSimulatorLabels relies on them.
+func ParseLabelSchema(raw []byte) (*LabelSchema, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	dec.KnownFields(true)
+
+	var s LabelSchema
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("invalid label schema: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, spec := range s.Labels {
+		switch {
+		case spec.Key == "":
+			return nil, fmt.Errorf("label schema: every label needs a key")
+		case spec.Key == "demo" || strings.HasPrefix(spec.Key, "demo."):
+			return nil, fmt.Errorf("label %q: demo labels are reserved for the simulator", spec.Key)
+		case seen[spec.Key]:
+			return nil, fmt.Errorf("label %q: listed twice", spec.Key)
+		case len(spec.Values) == 0:
+			return nil, fmt.Errorf("label %q: values are required", spec.Key)
+		case len(spec.Weights) > 0 && len(spec.Weights) != len(spec.Values):
+			return nil, fmt.Errorf("label %q: %d weights for %d values", spec.Key, len(spec.Weights), len(spec.Values))
+		case spec.Probability != nil && (*spec.Probability < 0 || *spec.Probability > 1):
+			return nil, fmt.Errorf("label %q: probability must be between 0 and 1, got %v", spec.Key, *spec.Probability)
+		}
+		seen[spec.Key] = true
+
+		total := 0.0
+		for _, w := range spec.Weights {
+			if w < 0 {
+				return nil, fmt.Errorf("label %q: weights must not be negative", spec.Key)
+			}
+			total += w
+		}
+		if len(spec.Weights) > 0 && total == 0 {
+			return nil, fmt.Errorf("label %q: weights must not all be zero", spec.Key)
+		}
+	}
+
+	return &s, nil
+}
+
+// pick draws a value, reporting false when the node doesn't get the label
+func (spec *LabelSpec) pick(rng *rand.Rand) (string, bool) {
+	if spec.Probability != nil && rng.Float64() >= *spec.Probability {
+		return "", false
+	}
+	if len(spec.Weights) == 0 {
+		return spec.Values[rng.Intn(len(spec.Values))], true
+	}
+
+	total := 0.0
+	for _, w := range spec.Weights {
+		total += w
+	}
+	roll := rng.Float64() * total
+	last := 0
+	for i, w := range spec.Weights {
+		if w == 0 {
+			continue
+		}
+		if roll < w {
+			return spec.Values[i], true
+		}
+		roll -= w
+		last = i
+	}
+	// Rounding can leave roll just past the last weight
+	return spec.Values[last], true
+}
\ No newline at end of file
@@ -0,0 +1,66 @@
+package sim
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testLabelSchema = `
+labels:
+  - key: datacenter
+    values: [par1, par2, ams1]
+    weights: [0, 1, 0]
+  - key: team
+    values: [platform, payments]
+    probability: 0.7
+`
+
+func TestLabelSchemaShapesGeneratedLabels(t *testing.T) {
+	schema, err := ParseLabelSchema([]byte(testLabelSchema))
+	require.NoError(t, err)
+
+	gen := NewLabelGenerator(rand.New(rand.NewSource(1)), NewLogicalClock(), "batch-1", "demo-sim/", schema)
+
+	withTeam := 0
+	const nodes = 2000
+	for i := 0; i < nodes; i++ {
+		labels := gen.Generate(nil)
+		assert.Equal(t, "par2", labels["datacenter"])
+		assert.NotContains(t, labels, "env", "the schema replaces the default labels")
+		assert.True(t, FilterSimulatorLabels(labels, LabelSelector{"demo.batch": "batch-1"}))
+		if _, ok := labels["team"]; ok {
+			withTeam++
+		}
+	}
+	assert.InDelta(t, 0.7, float64(withTeam)/nodes, 0.05)
+}
+
+func TestDefaultLabelSchema(t *testing.T) {
+	gen := NewLabelGenerator(rand.New(rand.NewSource(1)), NewLogicalClock(), "batch-1", "demo-sim/", nil)
+
+	labels := gen.Generate([]string{"region=us-east"})
+	for _, key := range []string{"env", "datacenter", "service", "region", "demo-sim/managed"} {
+		assert.Contains(t, labels, key)
+	}
+	assert.True(t, FilterSimulatorLabels(labels, nil))
+}
+
+func TestParseLabelSchemaRejectsInvalid(t *testing.T) {
+	for name, doc := range map[string]string{
+		"reserved key":    "labels: [{key: demo.owner, values: [me]}]",
+		"no values":       "labels: [{key: team}]",
+		"weights count":   "labels: [{key: team, values: [a, b], weights: [1]}]",
+		"zero weights":    "labels: [{key: team, values: [a, b], weights: [0, 0]}]",
+		"probability":     "labels: [{key: team, values: [a], probability: 1.5}]",
+		"duplicate key":   "labels: [{key: team, values: [a]}, {key: team, values: [b]}]",
+		"unknown field":   "labels: [{key: team, values: [a], weight: [1]}]",
+		"missing key":     "labels: [{values: [a]}]",
+		"negative weight": "labels: [{key: team, values: [a, b], weights: [-1, 2]}]",
+	} {
+		_, err := ParseLabelSchema([]byte(doc))
+		assert.Error(t, err, name)
+	}
+}
\ No newline at end of file
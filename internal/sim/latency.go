@@ -0,0 +1,117 @@
+package sim
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyWindow bounds the samples a LatencyRecorder keeps, so long runs
+// report percentiles over recent operations without growing without limit
+const latencyWindow = 10000
+
+// LatencyRecorder collects operation latencies for percentile reporting.
+// The zero value is ready to use.
+type LatencyRecorder struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+// Record adds one latency sample, replacing the oldest once the window is full
+func (l *LatencyRecorder) Record(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.samples) < latencyWindow {
+		l.samples = append(l.samples, d)
+		return
+	}
+	l.samples[l.next] = d
+	l.next = (l.next + 1) % latencyWindow
+}
+
+// Count returns the number of samples held
+func (l *LatencyRecorder) Count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.samples)
+}
+
+// Percentiles returns the nearest-rank percentile (0-100) for each p, or
+// zeros when nothing has been recorded
+func (l *LatencyRecorder) Percentiles(ps ...float64) []time.Duration {
+	l.mu.Lock()
+	sorted := make([]time.Duration, len(l.samples))
+	copy(sorted, l.samples)
+	l.mu.Unlock()
+
+	result := make([]time.Duration, len(ps))
+	if len(sorted) == 0 {
+		return result
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	for i, p := range ps {
+		rank := int(p/100*float64(len(sorted))+0.5) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		if rank >= len(sorted) {
+			rank = len(sorted) - 1
+		}
+		result[i] = sorted[rank]
+	}
+	return result
+}
+
+// RPCLatency is the latency of one kind of RPC. Total includes retries and
+// the backoff between them, Attempt only the final attempt, so a gap between
+// the two points at retries rather than a slow backend.
+type RPCLatency struct {
+	Total   LatencyRecorder
+	Attempt LatencyRecorder
+}
+
+// RPCLatencies keeps an RPCLatency per RPC kind. The zero value is ready to
+// use.
+type RPCLatencies struct {
+	mu   sync.Mutex
+	rpcs map[string]*RPCLatency
+}
+
+// Record adds the timings of a retried call of kind rpc
+func (l *RPCLatencies) Record(rpc string, res RetryResult) {
+	latency := l.Get(rpc)
+	latency.Total.Record(res.Elapsed)
+	latency.Attempt.Record(res.LastAttempt)
+}
+
+// Get returns the latencies of rpc, creating them on first use
+func (l *RPCLatencies) Get(rpc string) *RPCLatency {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rpcs == nil {
+		l.rpcs = make(map[string]*RPCLatency)
+	}
+	latency, ok := l.rpcs[rpc]
+	if !ok {
+		latency = &RPCLatency{}
+		l.rpcs[rpc] = latency
+	}
+	return latency
+}
+
+// Names returns the recorded RPC kinds in alphabetical order
+func (l *RPCLatencies) Names() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	names := make([]string, 0, len(l.rpcs))
+	for name := range l.rpcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
\ No newline at end of file
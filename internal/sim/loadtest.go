@@ -0,0 +1,202 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultSaturationRatio = 0.9
+	defaultMaxErrorRate    = 0.05
+)
+
+type LoadTestOptions struct {
+	Run          RunOptions // Base workload; UpdateQPS and Duration are set per step
+	StartQPS     float64
+	StepQPS      float64
+	Steps        int
+	StepDuration time.Duration
+
+	// A step is saturated when achieved QPS falls below SaturationRatio of
+	// its target or its error rate exceeds MaxErrorRate. Zero values use
+	// 0.9 and 0.05.
+	SaturationRatio float64
+	MaxErrorRate    float64
+}
+
+// LoadStepResult is the outcome of one load test step
+type LoadStepResult struct {
+	TargetQPS   float64
+	AchievedQPS float64
+	RPCs        int64
+	Errors      int64
+	ErrorRate   float64
+	P50         time.Duration
+	P95         time.Duration
+	P99         time.Duration
+	Duration    time.Duration
+	Saturated   bool
+}
+
+type LoadTestResult struct {
+	Steps []LoadStepResult
+	// SaturationStep is the index of the first saturated step, -1 when the
+	// backend kept up with every step
+	SaturationStep int
+}
+
+// LoadTester runs the simulator workload at increasing QPS steps
+type LoadTester struct {
+	config *Config
+	logger *zap.Logger
+	out    io.Writer
+}
+
+func NewLoadTester(cfg *Config, logger *zap.Logger) *LoadTester {
+	return &LoadTester{
+		config: cfg,
+		logger: logger,
+		out:    os.Stdout,
+	}
+}
+
+// SetOutput redirects the results table, which goes to stdout by default
+func (lt *LoadTester) SetOutput(w io.Writer) {
+	lt.out = w
+}
+
+// LoadSteps returns the target QPS for each step of the schedule
+func LoadSteps(start, step float64, n int) []float64 {
+	steps := make([]float64, n)
+	for i := range steps {
+		steps[i] = start + float64(i)*step
+	}
+	return steps
+}
+
+// Run executes every step in order and prints the results table. Steps
+// completed before ctx is cancelled are still reported.
+func (lt *LoadTester) Run(ctx context.Context, opts LoadTestOptions) (*LoadTestResult, error) {
+	if opts.Steps <= 0 {
+		return nil, fmt.Errorf("--steps must be positive")
+	}
+	if opts.StartQPS <= 0 || opts.StepQPS < 0 {
+		return nil, fmt.Errorf("--start-qps must be positive and --step-qps non-negative")
+	}
+	// The runner's first batch goes out one tick after it starts
+	if opts.StepDuration < 2*time.Second {
+		return nil, fmt.Errorf("--step-duration must be at least 2s")
+	}
+	if opts.Run.Scenario != nil {
+		return nil, fmt.Errorf("a scenario cannot be combined with a load test")
+	}
+	if opts.SaturationRatio <= 0 {
+		opts.SaturationRatio = defaultSaturationRatio
+	}
+	if opts.MaxErrorRate <= 0 {
+		opts.MaxErrorRate = defaultMaxErrorRate
+	}
+
+	result := &LoadTestResult{SaturationStep: -1}
+	schedule := LoadSteps(opts.StartQPS, opts.StepQPS, opts.Steps)
+
+	for i, qps := range schedule {
+		if ctx.Err() != nil {
+			break
+		}
+
+		lt.logger.Info("Starting load test step",
+			zap.Int("step", i+1),
+			zap.Int("of", len(schedule)),
+			zap.Float64("target_qps", qps),
+			zap.Duration("duration", opts.StepDuration))
+
+		step, err := lt.runStep(ctx, opts, qps)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+		step.Saturated = step.AchievedQPS < qps*opts.SaturationRatio || step.ErrorRate > opts.MaxErrorRate
+		if step.Saturated && result.SaturationStep < 0 {
+			result.SaturationStep = i
+		}
+		result.Steps = append(result.Steps, step)
+	}
+
+	lt.printResults(result)
+	return result, nil
+}
+
+// runStep drives a fresh Runner for one step so each step's counters and
+// latencies start from zero
+func (lt *LoadTester) runStep(ctx context.Context, opts LoadTestOptions, qps float64) (LoadStepResult, error) {
+	runOpts := opts.Run
+	runOpts.UpdateQPS = qps
+	runOpts.Duration = opts.StepDuration.String()
+	runOpts.Ramp = 0
+	runOpts.MetricsAddr = ""
+	runOpts.QPSAlertThreshold = 0
+	// A batch size below the target would cap throughput and look like
+	// saturation, so let the rate alone drive the runner
+	runOpts.BatchSize = 0
+
+	runner := NewRunner(lt.config, lt.logger)
+	runner.SetOutput(io.Discard)
+
+	start := time.Now()
+	if err := runner.Run(ctx, runOpts); err != nil {
+		return LoadStepResult{}, err
+	}
+	elapsed := time.Since(start)
+
+	stats := runner.Stats()
+	step := LoadStepResult{
+		TargetQPS: qps,
+		RPCs:      stats.TotalRPCs.Load(),
+		Errors:    stats.ErrorCount.Load(),
+		Duration:  elapsed,
+	}
+	step.AchievedQPS = float64(step.RPCs) / elapsed.Seconds()
+	if step.RPCs > 0 {
+		step.ErrorRate = float64(step.Errors) / float64(step.RPCs)
+	}
+	latency := stats.Latency.Percentiles(50, 95, 99)
+	step.P50, step.P95, step.P99 = latency[0], latency[1], latency[2]
+
+	return step, nil
+}
+
+func (lt *LoadTester) printResults(result *LoadTestResult) {
+	fmt.Fprintln(lt.out, "\n========== Load Test Results ==========")
+
+	w := tabwriter.NewWriter(lt.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STEP\tTARGET QPS\tACHIEVED QPS\tRPCS\tERRORS\tERROR RATE\tP50\tP95\tP99\t")
+	for i, step := range result.Steps {
+		marker := ""
+		if i == result.SaturationStep {
+			marker = "<- saturation"
+		}
+		fmt.Fprintf(w, "%d\t%.1f\t%.1f\t%d\t%d\t%.2f%%\t%v\t%v\t%v\t%s\n",
+			i+1, step.TargetQPS, step.AchievedQPS, step.RPCs, step.Errors, step.ErrorRate*100,
+			step.P50.Round(time.Microsecond), step.P95.Round(time.Microsecond), step.P99.Round(time.Microsecond), marker)
+	}
+	w.Flush()
+
+	switch {
+	case len(result.Steps) == 0:
+		fmt.Fprintln(lt.out, "No steps completed")
+	case result.SaturationStep < 0:
+		fmt.Fprintf(lt.out, "No saturation up to %.1f QPS\n", result.Steps[len(result.Steps)-1].TargetQPS)
+	case result.SaturationStep == 0:
+		fmt.Fprintf(lt.out, "Saturated at the first step (%.1f QPS)\n", result.Steps[0].TargetQPS)
+	default:
+		fmt.Fprintf(lt.out, "Saturation point: %.1f QPS (last healthy step %.1f QPS)\n",
+			result.Steps[result.SaturationStep].TargetQPS, result.Steps[result.SaturationStep-1].TargetQPS)
+	}
+	fmt.Fprintln(lt.out, "=======================================")
+}
\ No newline at end of file
@@ -0,0 +1,102 @@
+package sim
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLoadSteps(t *testing.T) {
+	assert.Equal(t, []float64{10, 25, 40}, LoadSteps(10, 15, 3))
+	assert.Equal(t, []float64{5, 5}, LoadSteps(5, 0, 2))
+	assert.Empty(t, LoadSteps(5, 5, 0))
+}
+
+func TestLatencyRecorderPercentiles(t *testing.T) {
+	var l LatencyRecorder
+	assert.Equal(t, []time.Duration{0, 0}, l.Percentiles(50, 99))
+
+	for i := 1; i <= 100; i++ {
+		l.Record(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, 100, l.Count())
+	assert.Equal(t,
+		[]time.Duration{50 * time.Millisecond, 95 * time.Millisecond, 99 * time.Millisecond, 100 * time.Millisecond},
+		l.Percentiles(50, 95, 99, 100))
+}
+
+func TestLatencyRecorderKeepsRecentWindow(t *testing.T) {
+	var l LatencyRecorder
+	for i := 0; i < latencyWindow; i++ {
+		l.Record(time.Second)
+	}
+	for i := 0; i < latencyWindow; i++ {
+		l.Record(time.Millisecond)
+	}
+
+	assert.Equal(t, latencyWindow, l.Count())
+	assert.Equal(t, []time.Duration{time.Millisecond}, l.Percentiles(100))
+}
+
+func TestLoadTesterRunsEveryStep(t *testing.T) {
+	if testing.Short() {
+		t.Skip("load test steps run in wall time")
+	}
+
+	cfg, _ := startTestBackend(t)
+	ctx := context.Background()
+
+	_, err := NewSeeder(cfg, zap.NewNop()).Seed(ctx, SeedOptions{
+		Total:        10,
+		PctBaremetal: 0.2,
+		PctVM:        0.3,
+		PctContainer: 0.5,
+	})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	lt := NewLoadTester(cfg, zap.NewNop())
+	lt.SetOutput(&out)
+
+	result, err := lt.Run(ctx, LoadTestOptions{
+		Run: RunOptions{
+			MaxConcurrency: 4,
+			ProbStatusFlip: 1,
+			BatchSize:      1,
+		},
+		StartQPS:     5,
+		StepQPS:      5,
+		Steps:        2,
+		StepDuration: 2 * time.Second,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, result.Steps, 2)
+	for i, want := range []float64{5, 10} {
+		step := result.Steps[i]
+		assert.Equal(t, want, step.TargetQPS, "step %d", i+1)
+		assert.Positive(t, step.RPCs, "step %d", i+1)
+		assert.Zero(t, step.Errors, "step %d", i+1)
+		assert.Positive(t, step.AchievedQPS, "step %d", i+1)
+		assert.Positive(t, step.P50, "step %d", i+1)
+		assert.GreaterOrEqual(t, step.P99, step.P50, "step %d", i+1)
+	}
+
+	assert.Contains(t, out.String(), "TARGET QPS")
+	assert.Contains(t, out.String(), "ACHIEVED QPS")
+}
+
+func TestLoadTesterRejectsInvalidSchedule(t *testing.T) {
+	lt := NewLoadTester(&Config{}, zap.NewNop())
+
+	_, err := lt.Run(context.Background(), LoadTestOptions{StartQPS: 10, Steps: 0, StepDuration: time.Second})
+	assert.Error(t, err)
+
+	_, err = lt.Run(context.Background(), LoadTestOptions{StartQPS: 10, Steps: 2, StepDuration: time.Second})
+	assert.Error(t, err)
+}
\ No newline at end of file
@@ -0,0 +1,89 @@
+package sim
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+)
+
+// ManifestEntry records one node created by a seed run
+type ManifestEntry struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// ManifestWriter appends created nodes to a manifest file, one JSON object per
+// line. Each entry is written as soon as its node is created, so a crashed
+// seed still leaves a usable partial manifest.
+type ManifestWriter struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// CreateManifest creates (or truncates) the manifest file at path
+func CreateManifest(path string) (*ManifestWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create manifest: %w", err)
+	}
+	return &ManifestWriter{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+// Add records a created node. It is safe for concurrent use.
+func (w *ManifestWriter) Add(node *nodev1.Node) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.enc.Encode(ManifestEntry{
+		ID:   node.Id,
+		Name: node.Name,
+		Type: node.Type.String(),
+	})
+}
+
+func (w *ManifestWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// ReadManifest reads the entries of a manifest file. A truncated final line,
+// left behind when a seed crashed mid-write, is skipped.
+func ReadManifest(path string) ([]ManifestEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer file.Close()
+
+	var entries []ManifestEntry
+	var pendingErr error
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		// Only the last line may be truncated
+		if pendingErr != nil {
+			return nil, pendingErr
+		}
+
+		var entry ManifestEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.ID == "" {
+			pendingErr = fmt.Errorf("invalid manifest entry on line %d", line)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	return entries, nil
+}
\ No newline at end of file
@@ -0,0 +1,78 @@
+package sim
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestReadManifestSkipsTruncatedLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	content := `{"id":"a","name":"web-1","type":"VM"}
+{"id":"b","name":"db-1","type":"BAREMETAL"}
+{"id":"c","na`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	entries, err := ReadManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, []ManifestEntry{
+		{ID: "a", Name: "web-1", Type: "VM"},
+		{ID: "b", Name: "db-1", Type: "BAREMETAL"},
+	}, entries)
+
+	// Corruption before the last line is an error
+	content = "{\"id\":\"a\"}\nnot json\n{\"id\":\"b\"}\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	_, err = ReadManifest(path)
+	assert.Error(t, err)
+}
+
+func TestSeedManifestDrivesCleanup(t *testing.T) {
+	cfg, store := startTestBackend(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "seed.json")
+
+	// A node from someone else's run, carrying the same simulator labels
+	other, err := store.CreateNode(ctx, &nodev1.Node{
+		Name:   "other-run",
+		Type:   nodev1.NodeType_VM,
+		Status: nodev1.NodeStatus_UP,
+		Labels: map[string]string{"demo": "true", "demo.owner": "cli"},
+	})
+	require.NoError(t, err)
+
+	_, err = NewSeeder(cfg, zap.NewNop()).Seed(ctx, SeedOptions{
+		Total:        10,
+		PctBaremetal: 0.2,
+		PctVM:        0.4,
+		PctContainer: 0.4,
+		Manifest:     path,
+	})
+	require.NoError(t, err)
+
+	entries, err := ReadManifest(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 10)
+	for _, entry := range entries {
+		node, err := store.GetNode(ctx, entry.ID)
+		require.NoError(t, err)
+		assert.Equal(t, entry.Name, node.Name)
+		assert.Equal(t, entry.Type, node.Type.String())
+	}
+
+	// Delete one node up front: cleanup treats it as already gone
+	require.NoError(t, store.DeleteNode(ctx, entries[0].ID))
+
+	require.NoError(t, NewCleaner(cfg, zap.NewNop()).Cleanup(ctx, CleanupOptions{Force: true, Manifest: path}))
+
+	nodes, err := store.ListNodes(ctx, 0, 0, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, other.Id, nodes[0].Id)
+}
\ No newline at end of file
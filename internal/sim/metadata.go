@@ -2,7 +2,77 @@ package sim
 
 import (
 	"encoding/json"
+	"fmt"
+	"math"
 	"math/rand"
+	"sort"
+	"strings"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+)
+
+// metadataField is one key of the simulated metadata. generate draws a fresh
+// value; valid checks a value decoded from JSON, where numbers are float64.
+type metadataField struct {
+	name     string
+	generate func(rng *rand.Rand) interface{}
+	valid    func(v interface{}) bool
+}
+
+// commonLeadFields and commonTailFields come before and after the type fields,
+// which keeps the draw order, and so seeded output, stable
+var commonLeadFields = []metadataField{
+	intChoice("cpu_cores", 2, 4, 8, 16, 32, 64, 128),
+	intChoice("ram_gb", 4, 8, 16, 32, 64, 128, 256, 512),
+}
+
+var commonTailFields = []metadataField{
+	stringChoice("os", "Ubuntu 22.04", "RHEL 9", "Debian 12", "Rocky 9", "Alpine 3.18"),
+	stringChoice("kernel_version", "5.15.0", "5.19.0", "6.1.0", "6.5.0"),
+	{
+		name:     "uptime_days",
+		generate: func(rng *rand.Rand) interface{} { return rng.Intn(365) },
+		valid:    func(v interface{}) bool { return isWholeNumber(v, 0, math.MaxInt32) },
+	},
+	{
+		name:     "load_avg",
+		generate: func(rng *rand.Rand) interface{} { return float64(rng.Intn(100)) / 100.0 },
+		valid:    func(v interface{}) bool { f, ok := v.(float64); return ok && f >= 0 },
+	},
+	stringChoice("owner", "team-alpha", "team-beta", "team-gamma", "team-delta"),
+	chance("monitoring_enabled", 0.8),
+	chance("backup_enabled", 0.6),
+	chance("auto_scaling", 0.3),
+}
+
+// typeFields is the per-type part of the schema
+var typeFields = map[nodev1.NodeType][]metadataField{
+	nodev1.NodeType_BAREMETAL: {
+		stringChoice("hardware_vendor", "Dell", "HP", "IBM", "Cisco", "Supermicro"),
+		stringChoice("raid_type", "RAID0", "RAID1", "RAID5", "RAID10"),
+		stringChoice("disk_type", "SSD", "NVMe", "HDD"),
+	},
+	nodev1.NodeType_VM: {
+		stringChoice("hypervisor", "VMware", "KVM", "Xen", "Hyper-V"),
+		intChoice("disk_gb", 100, 250, 500, 1000, 2000),
+		stringChoice("network_type", "virtio", "e1000", "vmxnet3"),
+	},
+	nodev1.NodeType_CONTAINER: {
+		stringChoice("runtime", "docker", "containerd", "cri-o", "podman"),
+		stringChoice("image", "alpine:latest", "ubuntu:22.04", "nginx:1.25", "redis:7", "postgres:15"),
+		intRange("replicas", minReplicas, maxReplicas),
+	},
+}
+
+// optionalFields may be added by Update
+var optionalFields = map[string]func(v interface{}) bool{
+	"last_patched":  isString,
+	"active_alerts": isString,
+}
+
+const (
+	minReplicas = 1
+	maxReplicas = 10
 )
 
 type MetadataGenerator struct {
@@ -13,54 +83,69 @@ func NewMetadataGenerator(rng *rand.Rand) *MetadataGenerator {
 	return &MetadataGenerator{rng: rng}
 }
 
+// Generate returns metadata for a node type given by name, e.g. "VM"
 func (mg *MetadataGenerator) Generate(nodeType string) string {
-	metadata := make(map[string]interface{})
-
-	cpuCores := []int{2, 4, 8, 16, 32, 64, 128}
-	metadata["cpu_cores"] = cpuCores[mg.rng.Intn(len(cpuCores))]
-
-	ramGB := []int{4, 8, 16, 32, 64, 128, 256, 512}
-	metadata["ram_gb"] = ramGB[mg.rng.Intn(len(ramGB))]
-
-	switch nodeType {
-	case "BAREMETAL":
-		metadata["hardware_vendor"] = mg.randomFrom([]string{"Dell", "HP", "IBM", "Cisco", "Supermicro"})
-		metadata["raid_type"] = mg.randomFrom([]string{"RAID0", "RAID1", "RAID5", "RAID10"})
-		metadata["disk_type"] = mg.randomFrom([]string{"SSD", "NVMe", "HDD"})
-	case "VM":
-		metadata["hypervisor"] = mg.randomFrom([]string{"VMware", "KVM", "Xen", "Hyper-V"})
-		metadata["disk_gb"] = mg.randomFrom([]int{100, 250, 500, 1000, 2000})
-		metadata["network_type"] = mg.randomFrom([]string{"virtio", "e1000", "vmxnet3"})
-	case "CONTAINER":
-		metadata["runtime"] = mg.randomFrom([]string{"docker", "containerd", "cri-o", "podman"})
-		metadata["image"] = mg.randomFrom([]string{"alpine:latest", "ubuntu:22.04", "nginx:1.25", "redis:7", "postgres:15"})
-		metadata["replicas"] = mg.rng.Intn(10) + 1
-	}
-
-	metadata["os"] = mg.randomFrom([]string{"Ubuntu 22.04", "RHEL 9", "Debian 12", "Rocky 9", "Alpine 3.18"})
-	metadata["kernel_version"] = mg.randomFrom([]string{"5.15.0", "5.19.0", "6.1.0", "6.5.0"})
-	metadata["uptime_days"] = mg.rng.Intn(365)
-	metadata["load_avg"] = float64(mg.rng.Intn(100)) / 100.0
-
-	owners := []string{"team-alpha", "team-beta", "team-gamma", "team-delta"}
-	metadata["owner"] = owners[mg.rng.Intn(len(owners))]
-
-	metadata["monitoring_enabled"] = mg.rng.Float64() < 0.8
-	metadata["backup_enabled"] = mg.rng.Float64() < 0.6
-	metadata["auto_scaling"] = mg.rng.Float64() < 0.3
+	return mg.GenerateForType(nodev1.NodeType(nodev1.NodeType_value[nodeType]))
+}
 
-	jsonData, _ := json.Marshal(metadata)
-	return string(jsonData)
+// GenerateForType returns metadata JSON with the common fields and those of
+// nodeType
+func (mg *MetadataGenerator) GenerateForType(nodeType nodev1.NodeType) string {
+	metadata := make(map[string]interface{})
+	for _, f := range schemaFor(nodeType) {
+		metadata[f.name] = f.generate(mg.rng)
+	}
+	return encodeMetadata(metadata)
 }
 
+// Update returns existing with its changing fields moved on. The node type is
+// inferred from the fields present; use UpdateForType when it is known.
 func (mg *MetadataGenerator) Update(existing string) string {
 	var metadata map[string]interface{}
-	if err := json.Unmarshal([]byte(existing), &metadata); err != nil {
+	_ = json.Unmarshal([]byte(existing), &metadata)
+	nodeType, _ := detectMetadataType(metadata)
+	return mg.UpdateForType(nodeType, existing)
+}
+
+// UpdateForType returns existing with its changing fields moved on and the
+// rest kept to the nodeType schema: missing or malformed fields are drawn
+// again and fields of other types are dropped, so metadata stays coherent
+// however many updates it goes through.
+func (mg *MetadataGenerator) UpdateForType(nodeType nodev1.NodeType, existing string) string {
+	var metadata map[string]interface{}
+	if err := json.Unmarshal([]byte(existing), &metadata); err != nil || metadata == nil {
 		metadata = make(map[string]interface{})
 	}
 
+	schema := schemaFor(nodeType)
+	keep := make(map[string]bool, len(schema))
+	for _, f := range schema {
+		keep[f.name] = true
+		if v, ok := metadata[f.name]; !ok || !f.valid(v) {
+			metadata[f.name] = f.generate(mg.rng)
+		}
+	}
+	for name := range metadata {
+		if !keep[name] && optionalFields[name] == nil {
+			delete(metadata, name)
+		}
+	}
+
 	metadata["load_avg"] = float64(mg.rng.Intn(100)) / 100.0
-	metadata["uptime_days"] = mg.rng.Intn(365)
+
+	// Uptime only grows, until the occasional reboot
+	uptime := asNumber(metadata["uptime_days"])
+	if mg.rng.Float64() < 0.02 {
+		uptime = 0
+	} else {
+		uptime += float64(mg.rng.Intn(2))
+	}
+	metadata["uptime_days"] = uptime
+
+	if nodeType == nodev1.NodeType_CONTAINER && mg.rng.Float64() < 0.2 {
+		replicas := asNumber(metadata["replicas"]) + float64(mg.rng.Intn(3)-1)
+		metadata["replicas"] = math.Max(minReplicas, math.Min(maxReplicas, replicas))
+	}
 
 	if mg.rng.Float64() < 0.3 {
 		metadata["last_patched"] = "2024-01-15"
@@ -71,17 +156,143 @@ func (mg *MetadataGenerator) Update(existing string) string {
 		metadata["active_alerts"] = alerts[mg.rng.Intn(len(alerts))]
 	}
 
-	jsonData, _ := json.Marshal(metadata)
-	return string(jsonData)
+	return encodeMetadata(metadata)
+}
+
+// ValidateMetadata checks that metadata is a JSON object matching the schema
+// of exactly one node type: every common and type field present with a value
+// of the right kind, and no unknown fields.
+func ValidateMetadata(metadata string) error {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(metadata), &fields); err != nil {
+		return fmt.Errorf("metadata is not a JSON object: %w", err)
+	}
+
+	nodeType, err := detectMetadataType(fields)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	known := make(map[string]bool)
+	for _, f := range schemaFor(nodeType) {
+		known[f.name] = true
+		v, ok := fields[f.name]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("missing %s", f.name))
+		case !f.valid(v):
+			problems = append(problems, fmt.Sprintf("invalid %s: %v", f.name, v))
+		}
+	}
+	for name, v := range fields {
+		if known[name] {
+			continue
+		}
+		if valid := optionalFields[name]; valid == nil {
+			problems = append(problems, fmt.Sprintf("unknown field %s", name))
+		} else if !valid(v) {
+			problems = append(problems, fmt.Sprintf("invalid %s: %v", name, v))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("invalid %s metadata: %s", nodeType, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// detectMetadataType returns the type whose specific fields appear in
+// metadata, failing when none or several do
+func detectMetadataType(metadata map[string]interface{}) (nodev1.NodeType, error) {
+	var found []nodev1.NodeType
+	for nodeType, fields := range typeFields {
+		for _, f := range fields {
+			if _, ok := metadata[f.name]; ok {
+				found = append(found, nodeType)
+				break
+			}
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nodev1.NodeType_NODE_TYPE_UNSPECIFIED, fmt.Errorf("metadata has no node type specific fields")
+	case 1:
+		return found[0], nil
+	default:
+		sort.Slice(found, func(i, j int) bool { return found[i] < found[j] })
+		return nodev1.NodeType_NODE_TYPE_UNSPECIFIED, fmt.Errorf("metadata mixes fields of %v", found)
+	}
+}
+
+func schemaFor(nodeType nodev1.NodeType) []metadataField {
+	schema := append([]metadataField{}, commonLeadFields...)
+	schema = append(schema, typeFields[nodeType]...)
+	return append(schema, commonTailFields...)
+}
+
+// encodeMetadata marshals values produced from the schema, which only holds
+// strings, numbers and booleans
+func encodeMetadata(metadata map[string]interface{}) string {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		panic(fmt.Sprintf("sim: metadata is not JSON encodable: %v", err))
+	}
+	return string(data)
+}
+
+func stringChoice(name string, options ...string) metadataField {
+	return metadataField{
+		name:     name,
+		generate: func(rng *rand.Rand) interface{} { return options[rng.Intn(len(options))] },
+		valid:    isString,
+	}
 }
 
-func (mg *MetadataGenerator) randomFrom(options interface{}) interface{} {
-	switch v := options.(type) {
-	case []string:
-		return v[mg.rng.Intn(len(v))]
-	case []int:
-		return v[mg.rng.Intn(len(v))]
+func intChoice(name string, options ...int) metadataField {
+	return metadataField{
+		name:     name,
+		generate: func(rng *rand.Rand) interface{} { return options[rng.Intn(len(options))] },
+		valid:    func(v interface{}) bool { return isWholeNumber(v, 0, math.MaxInt32) },
+	}
+}
+
+func intRange(name string, min, max int) metadataField {
+	return metadataField{
+		name:     name,
+		generate: func(rng *rand.Rand) interface{} { return min + rng.Intn(max-min+1) },
+		valid:    func(v interface{}) bool { return isWholeNumber(v, float64(min), float64(max)) },
+	}
+}
+
+func chance(name string, p float64) metadataField {
+	return metadataField{
+		name:     name,
+		generate: func(rng *rand.Rand) interface{} { return rng.Float64() < p },
+		valid:    func(v interface{}) bool { _, ok := v.(bool); return ok },
+	}
+}
+
+func isString(v interface{}) bool {
+	_, ok := v.(string)
+	return ok
+}
+
+// asNumber reads a number that is either freshly generated or decoded
+func asNumber(v interface{}) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case float64:
+		return n
 	default:
-		return nil
+		return 0
 	}
+}
+
+func isWholeNumber(v interface{}, min, max float64) bool {
+	f, ok := v.(float64)
+	return ok && f == math.Trunc(f) && f >= min && f <= max
 }
\ No newline at end of file
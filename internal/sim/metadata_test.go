@@ -0,0 +1,87 @@
+package sim
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratedMetadataStaysValidAcrossUpdates(t *testing.T) {
+	mg := NewMetadataGenerator(rand.New(rand.NewSource(7)))
+
+	for _, nodeType := range []nodev1.NodeType{nodev1.NodeType_BAREMETAL, nodev1.NodeType_VM, nodev1.NodeType_CONTAINER} {
+		metadata := mg.GenerateForType(nodeType)
+		require.NoError(t, ValidateMetadata(metadata), nodeType)
+
+		var first map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(metadata), &first))
+
+		for i := 0; i < 500; i++ {
+			metadata = mg.UpdateForType(nodeType, metadata)
+			require.NoError(t, ValidateMetadata(metadata), "%s after %d updates", nodeType, i+1)
+		}
+
+		var last map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(metadata), &last))
+		for _, f := range typeFields[nodeType] {
+			if f.name == "replicas" {
+				continue
+			}
+			assert.Equal(t, first[f.name], last[f.name], "%s.%s is stable", nodeType, f.name)
+		}
+	}
+}
+
+func TestGenerateMatchesGenerateForType(t *testing.T) {
+	a := NewMetadataGenerator(rand.New(rand.NewSource(1)))
+	b := NewMetadataGenerator(rand.New(rand.NewSource(1)))
+	assert.Equal(t, a.Generate("VM"), b.GenerateForType(nodev1.NodeType_VM))
+}
+
+func TestUpdateForTypeRepairsMetadata(t *testing.T) {
+	mg := NewMetadataGenerator(rand.New(rand.NewSource(3)))
+
+	// VM fields on a container, plus a missing replicas count
+	vm := mg.GenerateForType(nodev1.NodeType_VM)
+	updated := mg.UpdateForType(nodev1.NodeType_CONTAINER, vm)
+	require.NoError(t, ValidateMetadata(updated))
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(updated), &fields))
+	assert.NotContains(t, fields, "hypervisor")
+	assert.Contains(t, fields, "replicas")
+
+	assert.NoError(t, ValidateMetadata(mg.UpdateForType(nodev1.NodeType_BAREMETAL, "not json")))
+}
+
+func TestValidateMetadataRejects(t *testing.T) {
+	mg := NewMetadataGenerator(rand.New(rand.NewSource(5)))
+
+	var container map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(mg.GenerateForType(nodev1.NodeType_CONTAINER)), &container))
+
+	for name, mutate := range map[string]func(m map[string]interface{}){
+		"missing field":  func(m map[string]interface{}) { delete(m, "os") },
+		"wrong kind":     func(m map[string]interface{}) { m["cpu_cores"] = "eight" },
+		"fractional int": func(m map[string]interface{}) { m["replicas"] = 2.5 },
+		"out of range":   func(m map[string]interface{}) { m["replicas"] = 0 },
+		"unknown field":  func(m map[string]interface{}) { m["color"] = "blue" },
+		"mixed types":    func(m map[string]interface{}) { m["hypervisor"] = "KVM" },
+		"no type fields": func(m map[string]interface{}) { delete(m, "runtime"); delete(m, "image"); delete(m, "replicas") },
+	} {
+		m := make(map[string]interface{}, len(container))
+		for k, v := range container {
+			m[k] = v
+		}
+		mutate(m)
+		raw, err := json.Marshal(m)
+		require.NoError(t, err)
+		assert.Error(t, ValidateMetadata(string(raw)), name)
+	}
+
+	assert.Error(t, ValidateMetadata("[1, 2]"))
+}
\ No newline at end of file
@@ -0,0 +1,103 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// WriteMetrics writes the run counters in the Prometheus text exposition
+// format. Values are read from the atomic fields, so it is safe to call
+// while the run is in progress.
+func (s *RunStats) WriteMetrics(w io.Writer, now time.Time) {
+	elapsed := now.Sub(s.StartTime).Seconds()
+	totalRPCs := s.TotalRPCs.Load()
+	qps := 0.0
+	if elapsed > 0 {
+		qps = float64(totalRPCs) / elapsed
+	}
+
+	fmt.Fprintln(w, "# HELP demo_sim_rpcs_total Total RPCs issued by the simulator.")
+	fmt.Fprintln(w, "# TYPE demo_sim_rpcs_total counter")
+	fmt.Fprintf(w, "demo_sim_rpcs_total %d\n", totalRPCs)
+
+	fmt.Fprintln(w, "# HELP demo_sim_operations_total Simulated operations by kind.")
+	fmt.Fprintln(w, "# TYPE demo_sim_operations_total counter")
+	fmt.Fprintf(w, "demo_sim_operations_total{op=\"create\"} %d\n", s.CreateCount.Load())
+	fmt.Fprintf(w, "demo_sim_operations_total{op=\"update\"} %d\n", s.UpdateCount.Load())
+	fmt.Fprintf(w, "demo_sim_operations_total{op=\"delete\"} %d\n", s.DeleteCount.Load())
+	fmt.Fprintf(w, "demo_sim_operations_total{op=\"status_flip\"} %d\n", s.StatusFlips.Load())
+
+	fmt.Fprintln(w, "# HELP demo_sim_errors_total Operations that failed after retries.")
+	fmt.Fprintln(w, "# TYPE demo_sim_errors_total counter")
+	fmt.Fprintf(w, "demo_sim_errors_total %d\n", s.ErrorCount.Load())
+
+	fmt.Fprintln(w, "# HELP demo_sim_errors_by_code_total Failed operations by gRPC code of the last error.")
+	fmt.Fprintln(w, "# TYPE demo_sim_errors_by_code_total counter")
+	for _, code := range s.Errors.Codes() {
+		fmt.Fprintf(w, "demo_sim_errors_by_code_total{code=%q} %d\n", code, s.Errors.Count(code))
+	}
+
+	fmt.Fprintln(w, "# HELP demo_sim_verify_total Mutations read back with --verify, by outcome.")
+	fmt.Fprintln(w, "# TYPE demo_sim_verify_total counter")
+	fmt.Fprintf(w, "demo_sim_verify_total{result=\"ok\"} %d\n", s.VerifyChecks.Load()-s.VerifyFailures.Load())
+	fmt.Fprintf(w, "demo_sim_verify_total{result=\"failed\"} %d\n", s.VerifyFailures.Load())
+	fmt.Fprintf(w, "demo_sim_verify_total{result=\"skipped\"} %d\n", s.VerifySkipped.Load())
+
+	fmt.Fprintln(w, "# HELP demo_sim_rate_limit_waits_total Operations that waited for the rate limiter.")
+	fmt.Fprintln(w, "# TYPE demo_sim_rate_limit_waits_total counter")
+	fmt.Fprintf(w, "demo_sim_rate_limit_waits_total %d\n", s.RateLimit.Waits.Load())
+
+	fmt.Fprintln(w, "# HELP demo_sim_rate_limit_wait_seconds_total Time operations spent waiting for the rate limiter.")
+	fmt.Fprintln(w, "# TYPE demo_sim_rate_limit_wait_seconds_total counter")
+	fmt.Fprintf(w, "demo_sim_rate_limit_wait_seconds_total %g\n", time.Duration(s.RateLimit.WaitTime.Load()).Seconds())
+
+	fmt.Fprintln(w, "# HELP demo_sim_qps Average RPCs per second since the run started.")
+	fmt.Fprintln(w, "# TYPE demo_sim_qps gauge")
+	fmt.Fprintf(w, "demo_sim_qps %g\n", qps)
+
+	fmt.Fprintln(w, "# HELP demo_sim_uptime_seconds Seconds since the run started.")
+	fmt.Fprintln(w, "# TYPE demo_sim_uptime_seconds gauge")
+	fmt.Fprintf(w, "demo_sim_uptime_seconds %g\n", elapsed)
+}
+
+// metricsHandler serves the run stats on /metrics
+func metricsHandler(stats *RunStats) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		stats.WriteMetrics(w, time.Now())
+	})
+	return mux
+}
+
+// serveMetrics listens on addr and serves /metrics until ctx is done. The
+// listener is bound before returning so a bad address fails the run early.
+func (r *Runner) serveMetrics(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on metrics address %s: %w", addr, err)
+	}
+
+	srv := &http.Server{Handler: metricsHandler(r.stats), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			r.logger.Error("Metrics server failed", zap.Error(err))
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	r.logger.Info("Serving metrics", zap.String("addr", ln.Addr().String()))
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,56 @@
+package sim
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	stats := &RunStats{StartTime: time.Now().Add(-10 * time.Second)}
+	stats.TotalRPCs.Store(50)
+	stats.CreateCount.Store(5)
+	stats.StatusFlips.Store(30)
+	stats.ErrorCount.Store(2)
+	stats.Errors.Record(status.Error(codes.Unavailable, "down"))
+	stats.Errors.Record(status.Error(codes.InvalidArgument, "bad"))
+	stats.RateLimit.Waits.Store(7)
+	stats.RateLimit.WaitTime.Store(int64(1500 * time.Millisecond))
+
+	srv := httptest.NewServer(metricsHandler(stats))
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain"))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	out := string(body)
+
+	assert.Contains(t, out, "# TYPE demo_sim_rpcs_total counter\ndemo_sim_rpcs_total 50\n")
+	assert.Contains(t, out, `demo_sim_operations_total{op="create"} 5`)
+	assert.Contains(t, out, `demo_sim_operations_total{op="status_flip"} 30`)
+	assert.Contains(t, out, "demo_sim_errors_total 2\n")
+	assert.Contains(t, out, `demo_sim_errors_by_code_total{code="InvalidArgument"} 1`)
+	assert.Contains(t, out, `demo_sim_errors_by_code_total{code="Unavailable"} 1`)
+	assert.Contains(t, out, "demo_sim_rate_limit_waits_total 7\n")
+	assert.Contains(t, out, "demo_sim_rate_limit_wait_seconds_total 1.5\n")
+	assert.Contains(t, out, "# TYPE demo_sim_qps gauge\ndemo_sim_qps 4.")
+
+	// Counters keep moving while the run is in progress
+	stats.TotalRPCs.Add(1)
+	resp, err = srv.Client().Get(srv.URL + "/metrics")
+	require.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Contains(t, string(body), "demo_sim_rpcs_total 51\n")
+}
\ No newline at end of file
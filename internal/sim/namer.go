@@ -2,6 +2,7 @@ package sim
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
@@ -10,13 +11,21 @@ import (
 	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
 )
 
+// ErrNamesExhausted is returned by Namer.Next once a unique pool is used up
+var ErrNamesExhausted = errors.New("names pool exhausted")
+
 type Namer struct {
-	rng       *rand.Rand
-	pool      []string
-	counters  map[nodev1.NodeType]int
-	mu        sync.Mutex
+	rng      *rand.Rand
+	pool     []string
+	unique   bool
+	next     int // Next pool index in unique mode
+	counters map[nodev1.NodeType]int
+	mu       sync.Mutex
 }
 
+// NewNamer returns a namer drawing from the names in poolFile with
+// replacement, or generating names when poolFile is empty. Generated names
+// embed a per-type counter, so they never repeat within a run.
 func NewNamer(rng *rand.Rand, poolFile string) (*Namer, error) {
 	n := &Namer{
 		rng:      rng,
@@ -34,15 +43,92 @@ func NewNamer(rng *rand.Rand, poolFile string) (*Namer, error) {
 	return n, nil
 }
 
+// NewUniqueNamer is NewNamer, except that the pool is shuffled and consumed
+// without replacement so no name is handed out twice. Duplicate lines in
+// poolFile are dropped.
+func NewUniqueNamer(rng *rand.Rand, poolFile string) (*Namer, error) {
+	n, err := NewNamer(rng, poolFile)
+	if err != nil {
+		return nil, err
+	}
+	n.unique = true
+
+	seen := make(map[string]bool, len(n.pool))
+	pool := n.pool[:0]
+	for _, name := range n.pool {
+		if !seen[name] {
+			seen[name] = true
+			pool = append(pool, name)
+		}
+	}
+	n.pool = pool
+	n.rng.Shuffle(len(n.pool), func(i, j int) {
+		n.pool[i], n.pool[j] = n.pool[j], n.pool[i]
+	})
+
+	return n, nil
+}
+
+// newNamerFor picks NewUniqueNamer or NewNamer
+func newNamerFor(rng *rand.Rand, poolFile string, unique bool) (*Namer, error) {
+	if unique {
+		return NewUniqueNamer(rng, poolFile)
+	}
+	return NewNamer(rng, poolFile)
+}
+
+// Generate returns a name for a node of nodeType. Once a unique pool is
+// exhausted it falls back to generated names; use Next to get an error
+// instead.
 func (n *Namer) Generate(nodeType nodev1.NodeType) string {
+	name, err := n.Next(nodeType)
+	if err != nil {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		return n.generated(nodeType)
+	}
+	return name
+}
+
+// Next returns a name for a node of nodeType, or ErrNamesExhausted when a
+// unique pool has no names left
+func (n *Namer) Next(nodeType nodev1.NodeType) (string, error) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
+	if n.unique && len(n.pool) > 0 {
+		if n.next >= len(n.pool) {
+			return "", fmt.Errorf("%w: all %d names used", ErrNamesExhausted, len(n.pool))
+		}
+		name := n.pool[n.next]
+		n.next++
+		return name, nil
+	}
+
 	if len(n.pool) > 0 {
 		idx := n.rng.Intn(len(n.pool))
-		return n.pool[idx]
+		return n.pool[idx], nil
+	}
+
+	return n.generated(nodeType), nil
+}
+
+// Remaining returns how many names Next can still hand out, or -1 when it
+// never runs out
+func (n *Namer) Remaining() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.unique && len(n.pool) > 0 {
+		return len(n.pool) - n.next
 	}
+	return -1
+}
 
+// generated returns a counter based name. The counter is per type and so is
+// the prefix, so names can't collide within a run; the random suffix keeps
+// runs apart. Callers hold n.mu.
+func (n *Namer) generated(nodeType nodev1.NodeType) string {
 	n.counters[nodeType]++
 	counter := n.counters[nodeType]
 
@@ -0,0 +1,65 @@
+package sim
+
+import (
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeNamesPool(t *testing.T, names string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "names.txt")
+	require.NoError(t, os.WriteFile(path, []byte(names), 0o600))
+	return path
+}
+
+func TestUniqueNamerConsumesPoolWithoutReplacement(t *testing.T) {
+	pool := writeNamesPool(t, "alpha\nbravo\ncharlie\nalpha\n")
+
+	n, err := NewUniqueNamer(rand.New(rand.NewSource(1)), pool)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n.Remaining(), "duplicate lines are dropped")
+
+	var names []string
+	for i := 0; i < 3; i++ {
+		name, err := n.Next(nodev1.NodeType_VM)
+		require.NoError(t, err)
+		names = append(names, name)
+	}
+	assert.ElementsMatch(t, []string{"alpha", "bravo", "charlie"}, names)
+	assert.Zero(t, n.Remaining())
+
+	_, err = n.Next(nodev1.NodeType_VM)
+	assert.True(t, errors.Is(err, ErrNamesExhausted))
+}
+
+func TestNamerRemainingIsUnlimitedWithoutUniquePool(t *testing.T) {
+	n, err := NewNamer(rand.New(rand.NewSource(1)), writeNamesPool(t, "alpha\n"))
+	require.NoError(t, err)
+	assert.Equal(t, -1, n.Remaining())
+
+	n, err = NewUniqueNamer(rand.New(rand.NewSource(1)), "")
+	require.NoError(t, err)
+	assert.Equal(t, -1, n.Remaining())
+}
+
+func TestGeneratedNamesDontCollide(t *testing.T) {
+	// The per-type counter keeps names apart whatever the random suffix
+	n, err := NewNamer(rand.New(rand.NewSource(1)), "")
+	require.NoError(t, err)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 5000; i++ {
+		for _, nodeType := range []nodev1.NodeType{nodev1.NodeType_BAREMETAL, nodev1.NodeType_VM, nodev1.NodeType_CONTAINER} {
+			name := n.Generate(nodeType)
+			require.False(t, seen[name], "duplicate name %s", name)
+			seen[name] = true
+		}
+	}
+}
\ No newline at end of file
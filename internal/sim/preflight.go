@@ -0,0 +1,35 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/melkior/nodestatus/pkg/grpcclient"
+)
+
+// preflightTimeout bounds the backend check a command runs before it starts
+const preflightTimeout = 5 * time.Second
+
+// checkBackend makes sure the backend answers and, for commands that write,
+// accepts BACKEND_TOKEN, so a wrong address or token stops the command with
+// one clear message instead of a log full of identical RPC errors
+func checkBackend(ctx context.Context, client *grpcclient.Client, cfg *Config, write bool) error {
+	ctx, cancel := context.WithTimeout(ctx, preflightTimeout)
+	defer cancel()
+
+	err := client.Preflight(ctx, write)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, grpcclient.ErrUnreachable):
+		return fmt.Errorf("cannot reach the backend at %s, check BACKEND_ADDR: %w", cfg.BackendAddr, err)
+	case errors.Is(err, grpcclient.ErrUnauthorized) && cfg.BackendToken == "":
+		return fmt.Errorf("BACKEND_TOKEN is not set, set it to the server's ADMIN_TOKEN: %w", err)
+	case errors.Is(err, grpcclient.ErrUnauthorized):
+		return fmt.Errorf("the backend at %s rejected BACKEND_TOKEN, it must match the server's ADMIN_TOKEN: %w", cfg.BackendAddr, err)
+	default:
+		return err
+	}
+}
\ No newline at end of file
@@ -0,0 +1,54 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/melkior/nodestatus/pkg/grpcclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCommandsFailFastOnBadBackendConfig(t *testing.T) {
+	cfg, store := startTestBackend(t)
+	ctx := context.Background()
+	seed := SeedOptions{Total: 5, PctVM: 1}
+
+	wrong := *cfg
+	wrong.BackendToken = "wrong-token"
+	_, err := NewSeeder(&wrong, zap.NewNop()).Seed(ctx, seed)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, grpcclient.ErrUnauthorized))
+	assert.Contains(t, err.Error(), "rejected BACKEND_TOKEN")
+
+	unset := *cfg
+	unset.BackendToken = ""
+	err = NewRunner(&unset, zap.NewNop()).Run(ctx, RunOptions{Duration: "1s", UpdateQPS: 10})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "BACKEND_TOKEN is not set")
+
+	// Nothing was attempted
+	count, err := store.CountNodes(ctx, 0, 0)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	// Reads don't need the token
+	stats := NewStats(&unset, zap.NewNop())
+	stats.SetOutput(io.Discard)
+	assert.NoError(t, stats.Print(ctx, StatsOptions{JSON: true}))
+
+	// Nothing listens on a port that was just released
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	down := *cfg
+	down.BackendAddr = lis.Addr().String()
+	lis.Close()
+	_, err = NewSeeder(&down, zap.NewNop()).Seed(ctx, seed)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, grpcclient.ErrUnreachable))
+	assert.Contains(t, err.Error(), "check BACKEND_ADDR")
+}
\ No newline at end of file
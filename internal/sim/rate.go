@@ -3,48 +3,118 @@ package sim
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// WaitStats counts the Take calls that had to wait for tokens
+type WaitStats struct {
+	Waits    atomic.Int64
+	WaitTime atomic.Int64 // Nanoseconds spent waiting, summed over callers
+}
+
 type TokenBucket struct {
 	rate       float64
 	capacity   float64
-	tokens     float64
+	tokens     float64 // Negative while callers wait on reserved tokens
 	lastRefill time.Time
 	mu         sync.Mutex
+	stats      *WaitStats
 }
 
 func NewTokenBucket(rate float64, capacity float64) *TokenBucket {
+	return newTokenBucketWithStats(rate, capacity, &WaitStats{})
+}
+
+// newTokenBucketWithStats records waits into stats, so they can outlive the
+// bucket when the runner swaps it between phases
+func newTokenBucketWithStats(rate, capacity float64, stats *WaitStats) *TokenBucket {
 	return &TokenBucket{
 		rate:       rate,
 		capacity:   capacity,
 		tokens:     capacity,
 		lastRefill: time.Now(),
+		stats:      stats,
 	}
 }
 
+// Take removes n tokens, waiting until they are available. The tokens are
+// reserved up front and the caller sleeps once until their exact refill
+// time, so concurrent callers queue in order instead of waking and racing
+// for the lock. If ctx ends first the reservation is returned.
 func (tb *TokenBucket) Take(ctx context.Context, n float64) error {
-	for {
+	tb.mu.Lock()
+	tb.refill()
+	tb.tokens -= n
+	var wait time.Duration
+	if tb.tokens < 0 {
+		wait = time.Duration(-tb.tokens / tb.rate * float64(time.Second))
+	}
+	tb.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	tb.stats.Waits.Add(1)
+	start := time.Now()
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		tb.stats.WaitTime.Add(int64(time.Since(start)))
 		tb.mu.Lock()
 		tb.refill()
-
-		if tb.tokens >= n {
-			tb.tokens -= n
-			tb.mu.Unlock()
-			return nil
+		tb.tokens += n
+		if tb.tokens > tb.capacity {
+			tb.tokens = tb.capacity
 		}
-
-		waitTime := time.Duration((n-tb.tokens)/tb.rate*1000) * time.Millisecond
 		tb.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		tb.stats.WaitTime.Add(int64(time.Since(start)))
+		return nil
+	}
+}
 
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(waitTime):
-		}
+// Available returns the tokens that can be taken without waiting, or a
+// negative count of tokens already reserved by waiting callers
+func (tb *TokenBucket) Available() float64 {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.refill()
+	return tb.tokens
+}
+
+// WaitStats returns the counters of Take calls that waited
+func (tb *TokenBucket) WaitStats() *WaitStats {
+	return tb.stats
+}
+
+// SetRate changes the refill rate. Capacity scales with it so the bucket
+// still holds the same number of seconds of burst.
+func (tb *TokenBucket) SetRate(rate float64) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.refill()
+
+	burst := tb.capacity / tb.rate
+	tb.rate = rate
+	tb.capacity = rate * burst
+	if tb.tokens > tb.capacity {
+		tb.tokens = tb.capacity
 	}
 }
 
+func (tb *TokenBucket) Rate() float64 {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	return tb.rate
+}
+
 func (tb *TokenBucket) TryTake(n float64) bool {
 	tb.mu.Lock()
 	defer tb.mu.Unlock()
@@ -58,6 +128,23 @@ func (tb *TokenBucket) TryTake(n float64) bool {
 	return false
 }
 
+// rampFloor is the fraction of the target rate a ramp starts from. It keeps
+// the rate above zero so Take always has a finite wait.
+const rampFloor = 0.01
+
+// rampRate returns the rate at elapsed into a linear ramp towards target.
+func rampRate(target float64, elapsed, ramp time.Duration) float64 {
+	if ramp <= 0 || elapsed >= ramp {
+		return target
+	}
+
+	fraction := float64(elapsed) / float64(ramp)
+	if fraction < rampFloor {
+		fraction = rampFloor
+	}
+	return target * fraction
+}
+
 func (tb *TokenBucket) refill() {
 	now := time.Now()
 	elapsed := now.Sub(tb.lastRefill).Seconds()
@@ -0,0 +1,83 @@
+package sim
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRampRate(t *testing.T) {
+	ramp := 2 * time.Minute
+
+	assert.Equal(t, 0.2, rampRate(20, 0, ramp))
+	assert.Equal(t, 10.0, rampRate(20, time.Minute, ramp))
+	assert.Equal(t, 20.0, rampRate(20, ramp, ramp))
+	assert.Equal(t, 20.0, rampRate(20, 5*time.Minute, ramp))
+	assert.Equal(t, 20.0, rampRate(20, 0, 0))
+}
+
+func TestTokenBucketSetRate(t *testing.T) {
+	tb := NewTokenBucket(10, 20)
+
+	tb.SetRate(1)
+	assert.Equal(t, 1.0, tb.Rate())
+	assert.Equal(t, 2.0, tb.capacity)
+	assert.LessOrEqual(t, tb.tokens, 2.0)
+
+	// Raising the rate grows the capacity but does not mint tokens
+	tb.SetRate(50)
+	assert.Equal(t, 100.0, tb.capacity)
+	assert.Less(t, tb.tokens, 3.0)
+}
+
+func TestTokenBucketAchievesConfiguredRate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("paces real calls")
+	}
+
+	for _, qps := range []float64{4, 200} {
+		// A capacity of 1 leaves no burst to hide pacing errors behind
+		tb := NewTokenBucket(qps, 1)
+		require.NoError(t, tb.Take(context.Background(), 1))
+
+		n := int(qps)
+		if n < 5 {
+			n = 5
+		}
+
+		// Concurrent callers must be paced as one stream, not race each other
+		var wg sync.WaitGroup
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				require.NoError(t, tb.Take(context.Background(), 1))
+			}()
+		}
+		wg.Wait()
+
+		achieved := float64(n) / time.Since(start).Seconds()
+		assert.InEpsilon(t, qps, achieved, 0.1, "qps %v", qps)
+		assert.Equal(t, int64(n), tb.WaitStats().Waits.Load())
+		assert.Positive(t, tb.WaitStats().WaitTime.Load())
+	}
+}
+
+func TestTokenBucketCancelledTakeReturnsTokens(t *testing.T) {
+	tb := NewTokenBucket(1, 1)
+	require.NoError(t, tb.Take(context.Background(), 1))
+	assert.Less(t, tb.Available(), 0.1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, tb.Take(ctx, 1), context.DeadlineExceeded)
+
+	// The cancelled reservation isn't owed, so the bucket is back to roughly
+	// what refilled meanwhile
+	assert.InDelta(t, 0.02, tb.Available(), 0.05)
+}
\ No newline at end of file
@@ -6,8 +6,7 @@ import (
 	"math/rand"
 	"time"
 
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"github.com/melkior/nodestatus/pkg/grpcclient"
 )
 
 type RetryConfig struct {
@@ -16,6 +15,7 @@ type RetryConfig struct {
 	MaxDelay     time.Duration
 	Multiplier   float64
 	Jitter       float64
+	Rand         *rand.Rand // Jitter source; the global source when nil
 }
 
 func DefaultRetryConfig() RetryConfig {
@@ -28,31 +28,65 @@ func DefaultRetryConfig() RetryConfig {
 	}
 }
 
+// RetryResult describes how a RetryWithBackoffResult call ended
+type RetryResult struct {
+	Err      error // nil on success
+	Attempts int   // Calls made to fn, the successful one included
+	// Permanent is set when Err was not retryable, so the call was rejected
+	// rather than retried until the attempts ran out
+	Permanent bool
+	// Elapsed spans every attempt and the backoff between them, LastAttempt
+	// only the final call to fn
+	Elapsed     time.Duration
+	LastAttempt time.Duration
+}
+
 func RetryWithBackoff(ctx context.Context, cfg RetryConfig, fn func() error) error {
+	return RetryWithBackoffResult(ctx, cfg, fn).Err
+}
+
+// RetryWithBackoffResult is RetryWithBackoff, but also reports the number of
+// attempts made and whether the last error was retryable
+func RetryWithBackoffResult(ctx context.Context, cfg RetryConfig, fn func() error) RetryResult {
+	start := time.Now()
+	result := func(err error, attempts int, permanent bool, lastAttempt time.Duration) RetryResult {
+		return RetryResult{
+			Err:         err,
+			Attempts:    attempts,
+			Permanent:   permanent,
+			Elapsed:     time.Since(start),
+			LastAttempt: lastAttempt,
+		}
+	}
+
 	var lastErr error
+	var lastAttempt time.Duration
 	delay := cfg.InitialDelay
 
-	for attempt := 1; attempt <= cfg.MaxAttempts; attempt++ {
+	attempt := 1
+	for ; attempt <= cfg.MaxAttempts; attempt++ {
+		attemptStart := time.Now()
 		err := fn()
+		lastAttempt = time.Since(attemptStart)
 		if err == nil {
-			return nil
+			return result(nil, attempt, false, lastAttempt)
 		}
 
 		lastErr = err
 
 		if !isRetryable(err) {
-			return err
+			return result(err, attempt, true, lastAttempt)
 		}
 
 		if attempt == cfg.MaxAttempts {
 			break
 		}
 
-		jitteredDelay := addJitter(delay, cfg.Jitter)
+		jitteredDelay := addJitter(delay, cfg.Jitter, cfg.Rand)
 
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return result(ctx.Err(), attempt, false, lastAttempt)
 		case <-time.After(jitteredDelay):
 		}
 
@@ -62,24 +96,14 @@ func RetryWithBackoff(ctx context.Context, cfg RetryConfig, fn func() error) err
 		}
 	}
 
-	return lastErr
+	return result(lastErr, attempt, false, lastAttempt)
 }
 
 func isRetryable(err error) bool {
-	st, ok := status.FromError(err)
-	if !ok {
-		return false
-	}
-
-	switch st.Code() {
-	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
-		return true
-	default:
-		return false
-	}
+	return grpcclient.IsRetryable(err)
 }
 
-func addJitter(duration time.Duration, jitterPct float64) time.Duration {
+func addJitter(duration time.Duration, jitterPct float64, rng *rand.Rand) time.Duration {
 	if jitterPct <= 0 {
 		return duration
 	}
@@ -88,7 +112,12 @@ func addJitter(duration time.Duration, jitterPct float64) time.Duration {
 	minDelay := float64(duration) - jitter
 	maxDelay := float64(duration) + jitter
 
-	return time.Duration(minDelay + rand.Float64()*(maxDelay-minDelay))
+	roll := rand.Float64
+	if rng != nil {
+		roll = rng.Float64
+	}
+
+	return time.Duration(minDelay + roll()*(maxDelay-minDelay))
 }
 
 func ExponentialBackoff(attempt int, baseDelay time.Duration, maxDelay time.Duration) time.Duration {
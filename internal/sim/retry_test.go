@@ -0,0 +1,99 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func fastRetryConfig() RetryConfig {
+	return RetryConfig{MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, Multiplier: 2}
+}
+
+func TestRetryWithBackoffResult(t *testing.T) {
+	ctx := context.Background()
+
+	calls := 0
+	res := RetryWithBackoffResult(ctx, fastRetryConfig(), func() error {
+		calls++
+		if calls < 2 {
+			return status.Error(codes.Unavailable, "down")
+		}
+		return nil
+	})
+	assert.NoError(t, res.Err)
+	assert.Equal(t, 2, res.Attempts)
+
+	// Retryable errors are retried until the attempts run out
+	res = RetryWithBackoffResult(ctx, fastRetryConfig(), func() error {
+		return status.Error(codes.Unavailable, "down")
+	})
+	assert.Equal(t, codes.Unavailable, ErrorCode(res.Err))
+	assert.Equal(t, 3, res.Attempts)
+	assert.False(t, res.Permanent)
+
+	// Rejections are returned after the first attempt
+	res = RetryWithBackoffResult(ctx, fastRetryConfig(), func() error {
+		return status.Error(codes.InvalidArgument, "bad")
+	})
+	assert.Equal(t, codes.InvalidArgument, ErrorCode(res.Err))
+	assert.Equal(t, 1, res.Attempts)
+	assert.True(t, res.Permanent)
+}
+
+func TestErrorCounts(t *testing.T) {
+	var counts ErrorCounts
+	assert.Empty(t, counts.Codes())
+
+	counts.Record(status.Error(codes.Unavailable, "down"))
+	counts.Record(status.Error(codes.Unavailable, "down"))
+	counts.Record(status.Error(codes.InvalidArgument, "bad"))
+	assert.Equal(t, codes.Canceled, counts.Record(context.Canceled))
+	assert.Equal(t, codes.Unknown, counts.Record(errors.New("no names left")))
+
+	assert.Equal(t, []codes.Code{codes.Canceled, codes.Unknown, codes.InvalidArgument, codes.Unavailable}, counts.Codes())
+	assert.Equal(t, int64(2), counts.Count(codes.Unavailable))
+	assert.Equal(t, int64(0), counts.Count(codes.NotFound))
+
+	assert.True(t, retryableCode(codes.Unavailable))
+	assert.False(t, retryableCode(codes.InvalidArgument))
+}
+
+func TestRetryWithBackoffResultTimesAttempts(t *testing.T) {
+	calls := 0
+	res := RetryWithBackoffResult(context.Background(), fastRetryConfig(), func() error {
+		calls++
+		if calls < 3 {
+			time.Sleep(20 * time.Millisecond)
+			return status.Error(codes.Unavailable, "down")
+		}
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	assert.NoError(t, res.Err)
+
+	// The failed attempts count towards Elapsed but not LastAttempt
+	assert.GreaterOrEqual(t, res.Elapsed, 40*time.Millisecond)
+	assert.GreaterOrEqual(t, res.LastAttempt, time.Millisecond)
+	assert.Less(t, res.LastAttempt, 20*time.Millisecond)
+}
+
+func TestRPCLatencies(t *testing.T) {
+	var l RPCLatencies
+	assert.Empty(t, l.Names())
+
+	l.Record("update_status", RetryResult{Elapsed: 30 * time.Millisecond, LastAttempt: 10 * time.Millisecond})
+	l.Record("delete", RetryResult{Elapsed: 5 * time.Millisecond, LastAttempt: 5 * time.Millisecond})
+	l.Record("update_status", RetryResult{Elapsed: 10 * time.Millisecond, LastAttempt: 10 * time.Millisecond})
+
+	assert.Equal(t, []string{"delete", "update_status"}, l.Names())
+	rpc := l.Get("update_status")
+	assert.Equal(t, 2, rpc.Total.Count())
+	assert.Equal(t, []time.Duration{30 * time.Millisecond}, rpc.Total.Percentiles(100))
+	assert.Equal(t, []time.Duration{10 * time.Millisecond}, rpc.Attempt.Percentiles(100))
+}
\ No newline at end of file
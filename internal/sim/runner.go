@@ -3,8 +3,12 @@ package sim
 import (
 	"context"
 	"fmt"
+	"io"
+	"math"
 	"math/rand"
-	"sync"
+	"os"
+	"sort"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -16,83 +20,192 @@ import (
 type RunOptions struct {
 	Duration              string
 	UpdateQPS             float64
+	Ramp                  time.Duration // Linear ramp from near zero to UpdateQPS; 0 starts at full rate
 	MaxConcurrency        int
 	ProbStatusFlip        float64
 	ProbLabelChange       float64
 	ProbMetadataChange    float64
 	ProbDeleteAndRecreate float64
 	Jitter                bool
-	BatchSize             int
+	BatchSize             int // Most operations started per one-second tick; 0 leaves the rate to UpdateQPS alone
 	NamesPool             string
+	UniqueNames           bool           // Use each NamesPool name at most once
+	StatusWeights         *StatusWeights // Defaults to DefaultFlipStatusWeights when nil
+	TypeWeights           *TypeWeights   // Bias node selection by type; nil picks nodes uniformly
+	Scenario              *Scenario      // Timed phases overriding the fields above; replaces Duration
+	MetricsAddr           string         // Serve RunStats as Prometheus metrics on this address; empty disables
+	QPSAlertThreshold     float64        // Warn when achieved QPS falls below this fraction of the target; 0 disables
+	ShutdownTimeout       time.Duration  // How long cancellation waits for in-flight operations; 0 uses DefaultShutdownTimeout
+	Verify                bool           // Read back status flips and deletes to check they took effect; doubles their RPCs
+
+	// TargetLabels, when set, makes the run operate on every node carrying
+	// these labels instead of only simulator nodes. Those nodes may belong to
+	// someone else, so they are never deleted and recreated, and the run asks
+	// for confirmation on the input unless Confirmed is set.
+	TargetLabels LabelSelector
+	Confirmed    bool
 }
 
 type Runner struct {
-	config     *Config
-	logger     *zap.Logger
-	client     *grpcclient.Client
-	namer      *Namer
-	labelGen   *LabelGenerator
-	metaGen    *MetadataGenerator
-	rng        *rand.Rand
-	rateLimiter *TokenBucket
-	stats      *RunStats
+	config   *Config
+	logger   *zap.Logger
+	client   *grpcclient.Client
+	namer    *Namer
+	labelGen *LabelGenerator
+	metaGen  *MetadataGenerator
+	rng      *rand.Rand
+	clock    Clock
+	retryCfg RetryConfig
+	stats    *RunStats
+	target   LabelSelector // Set by RunOptions.TargetLabels; nil targets simulator nodes
+	in       io.Reader
+	out      io.Writer
+
+	// QPS alerting compares the rate since the previous stats report
+	alertThreshold float64
+	lastReport     time.Time
+	lastReportRPCs int64
+
+	// Set by RunOptions.Verify
+	verify bool
+	claims *nodeClaims
 }
 
 type RunStats struct {
-	TotalRPCs    atomic.Int64
-	CreateCount  atomic.Int64
-	UpdateCount  atomic.Int64
-	DeleteCount  atomic.Int64
-	StatusFlips  atomic.Int64
-	ErrorCount   atomic.Int64
-	StartTime    time.Time
+	TotalRPCs   atomic.Int64
+	CreateCount atomic.Int64
+	UpdateCount atomic.Int64
+	DeleteCount atomic.Int64
+	StatusFlips atomic.Int64
+	ErrorCount  atomic.Int64
+	Errors      ErrorCounts // ErrorCount broken down by gRPC code
+	StartTime   time.Time
+	Latency     LatencyRecorder // Per-operation latency, retries included
+	RPCs        RPCLatencies    // Latency per RPC kind, with and without retries
+	RateLimit   WaitStats       // Operations held back to keep to the target QPS
+
+	// With RunOptions.Verify: mutations read back, those found not applied,
+	// and those not checked because another operation on the node overlapped
+	VerifyChecks   atomic.Int64
+	VerifyFailures atomic.Int64
+	VerifySkipped  atomic.Int64
 }
 
 func NewRunner(cfg *Config, logger *zap.Logger) *Runner {
+	now := time.Now()
 	return &Runner{
-		config: cfg,
-		logger: logger,
-		stats:  &RunStats{StartTime: time.Now()},
+		config:     cfg,
+		logger:     logger,
+		stats:      &RunStats{StartTime: now},
+		in:         os.Stdin,
+		out:        os.Stdout,
+		lastReport: now,
+		claims:     newNodeClaims(),
 	}
 }
 
+// SetOutput redirects the final statistics and the TargetLabels
+// confirmation prompt, which go to stdout by default
+func (r *Runner) SetOutput(w io.Writer) {
+	r.out = w
+}
+
+// SetInput sets where the TargetLabels confirmation is read (stdin by default)
+func (r *Runner) SetInput(in io.Reader) {
+	r.in = in
+}
+
+// Stats returns the counters for the current run
+func (r *Runner) Stats() *RunStats {
+	return r.stats
+}
+
 func (r *Runner) Run(ctx context.Context, opts RunOptions) error {
 	r.rng = r.config.NewRand()
+	r.clock = r.config.NewClock()
+	r.retryCfg = DefaultRetryConfig()
+	r.retryCfg.Rand = r.config.NewRetryRand()
+	r.alertThreshold = opts.QPSAlertThreshold
 
-	client, err := grpcclient.NewClient(r.config.BackendAddr, r.config.BackendToken)
+	// Operations retry with the seeded retryCfg, so the client must not retry too
+	client, err := grpcclient.NewPooledClient(r.config.BackendAddr, r.config.BackendToken, r.config.ConnPoolSize, grpcclient.WithoutRetry())
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
 	defer client.Close()
 	r.client = client
 
-	namer, err := NewNamer(r.rng, opts.NamesPool)
+	if err := checkBackend(ctx, client, r.config, true); err != nil {
+		return err
+	}
+
+	namer, err := newNamerFor(r.rng, opts.NamesPool, opts.UniqueNames)
 	if err != nil {
 		return err
 	}
 	r.namer = namer
-	r.labelGen = NewLabelGenerator(r.rng, r.config.SimLabelPrefix)
+	r.labelGen = NewLabelGenerator(r.rng, r.clock, r.config.NewBatchID(r.rng, r.clock), r.config.SimLabelPrefix, nil)
 	r.metaGen = NewMetadataGenerator(r.rng)
 
-	r.rateLimiter = NewTokenBucket(opts.UpdateQPS, opts.UpdateQPS*2)
+	if len(opts.TargetLabels) > 0 {
+		r.target = opts.TargetLabels
+		if ok, err := r.confirmTarget(ctx, opts.Confirmed); !ok || err != nil {
+			return err
+		}
+	}
 
 	duration, err := r.parseDuration(opts.Duration)
 	if err != nil {
 		return err
 	}
 
+	r.verify = opts.Verify
+	if r.verify {
+		r.logger.Info("Verifying status flips and deletes by reading the node back")
+	}
+
+	var player *scenarioPlayer
+	if opts.Scenario != nil {
+		if duration > 0 {
+			return fmt.Errorf("--duration cannot be combined with a scenario; phase durations define the run length")
+		}
+		player = newScenarioPlayer(opts.Scenario, opts, r.clock.Now())
+		opts = player.options()
+		r.logger.Info("Starting scenario",
+			zap.String("scenario", opts.Scenario.Name),
+			zap.Int("phases", len(opts.Scenario.Phases)),
+			zap.Duration("total_duration", opts.Scenario.TotalDuration()))
+		r.logPhase(player, opts)
+	}
+
+	if opts.MetricsAddr != "" {
+		metricsCtx, stopMetrics := context.WithCancel(ctx)
+		defer stopMetrics()
+		if err := r.serveMetrics(metricsCtx, opts.MetricsAddr); err != nil {
+			return err
+		}
+	}
+
+	if r.config.Deterministic {
+		r.logger.Info("Deterministic mode: operations run one at a time on a logical clock",
+			zap.Int64("seed", r.config.SimSeed))
+	}
+
 	var endTime time.Time
 	if duration > 0 {
-		endTime = time.Now().Add(duration)
+		endTime = r.clock.Now().Add(duration)
 		r.logger.Info("Starting simulation",
 			zap.Duration("duration", duration),
 			zap.Float64("qps", opts.UpdateQPS),
 			zap.Int("max_concurrency", opts.MaxConcurrency))
-	} else {
+	} else if player == nil {
 		r.logger.Info("Starting infinite simulation",
 			zap.Float64("qps", opts.UpdateQPS),
 			zap.Int("max_concurrency", opts.MaxConcurrency))
 	}
+	if player == nil {
+		r.warnBatchSize(opts)
+	}
 
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
@@ -100,66 +213,168 @@ func (r *Runner) Run(ctx context.Context, opts RunOptions) error {
 	reportTicker := time.NewTicker(30 * time.Second)
 	defer reportTicker.Stop()
 
+	// Swapped on phase transitions; in-flight operations keep the ones they started with
+	rateLimiter := newTokenBucketWithStats(opts.UpdateQPS, opts.UpdateQPS*2, &r.stats.RateLimit)
 	semaphore := make(chan struct{}, opts.MaxConcurrency)
-	var wg sync.WaitGroup
+	var ops inflight
+	// Operations the rate has granted but that are not started yet; the
+	// fraction carries over so rates below one per second still run
+	var budget float64
+	// Set while no target node has a weighted type, so it is logged once
+	var noWeightedTargets bool
+
+	// The ramp ticker is nil (never fires) when there is no ramp or it has finished
+	var rampTick <-chan time.Time
+	rampStart := r.clock.Now()
+	if opts.Ramp > 0 {
+		rateLimiter.SetRate(rampRate(opts.UpdateQPS, 0, opts.Ramp))
+		rampTicker := time.NewTicker(time.Second)
+		defer rampTicker.Stop()
+		rampTick = rampTicker.C
+		r.logger.Info("Ramping up load",
+			zap.Duration("ramp", opts.Ramp),
+			zap.Float64("start_qps", rateLimiter.Rate()),
+			zap.Float64("target_qps", opts.UpdateQPS))
+	}
 
 	for {
 		select {
 		case <-ctx.Done():
 			r.logger.Info("Shutting down simulation...")
-			wg.Wait()
+			if abandoned := ops.drain(opts.ShutdownTimeout); abandoned > 0 {
+				r.logger.Warn("Shutdown timeout reached, abandoning in-flight operations",
+					zap.Int64("abandoned", abandoned))
+			}
 			r.printFinalStats()
 			return nil
 
 		case <-reportTicker.C:
-			r.printStats()
+			r.printStats(rateLimiter.Rate())
+
+		case <-rampTick:
+			elapsed := r.clock.Now().Sub(rampStart)
+			rateLimiter.SetRate(rampRate(opts.UpdateQPS, elapsed, opts.Ramp))
+			if elapsed >= opts.Ramp {
+				r.logger.Info("Ramp complete", zap.Float64("qps", opts.UpdateQPS))
+				rampTick = nil
+			}
 
 		case <-ticker.C:
-			if duration > 0 && time.Now().After(endTime) {
+			// The logical clock advances one second per tick, so --duration
+			// counts ticks rather than wall time
+			if clock, ok := r.clock.(*LogicalClock); ok {
+				clock.Advance(time.Second)
+			}
+
+			if duration > 0 && r.clock.Now().After(endTime) {
 				r.logger.Info("Duration reached, shutting down...")
-				wg.Wait()
+				ops.wait()
 				r.printFinalStats()
 				return nil
 			}
 
-			nodes, err := r.getSimulatorNodes(ctx)
+			if player != nil {
+				changed, done := player.advance(r.clock.Now())
+				if done {
+					r.logger.Info("Scenario complete, shutting down...")
+					ops.wait()
+					r.printFinalStats()
+					return nil
+				}
+				if changed {
+					opts = player.options()
+					rateLimiter = newTokenBucketWithStats(opts.UpdateQPS, opts.UpdateQPS*2, &r.stats.RateLimit)
+					if rampTick != nil {
+						rateLimiter.SetRate(rampRate(opts.UpdateQPS, r.clock.Now().Sub(rampStart), opts.Ramp))
+					}
+					semaphore = make(chan struct{}, opts.MaxConcurrency)
+					r.logPhase(player, opts)
+				}
+			}
+
+			nodes, err := r.getTargetNodes(ctx)
 			if err != nil {
 				r.logger.Error("Failed to list nodes", zap.Error(err))
 				continue
 			}
 
 			if len(nodes) == 0 {
-				r.logger.Warn("No simulator nodes found, skipping tick")
+				r.logger.Warn("No target nodes found, skipping tick", zap.Any("target_labels", r.target))
 				continue
 			}
 
-			batchSize := opts.BatchSize
-			if batchSize > len(nodes) {
-				batchSize = len(nodes)
+			// The rate, not the batch size, decides how much work a tick
+			// starts; BatchSize only caps it
+			budget += rateLimiter.Rate()
+			count := int(budget)
+			budget -= float64(count)
+			if opts.BatchSize > 0 && count > opts.BatchSize {
+				count = opts.BatchSize
 			}
 
-			for i := 0; i < batchSize; i++ {
-				node := nodes[r.rng.Intn(len(nodes))]
-				operation := r.selectOperation(opts)
-
-				wg.Add(1)
-				semaphore <- struct{}{}
+			var byType map[nodev1.NodeType][]*nodev1.Node
+			if opts.TypeWeights != nil {
+				byType = partitionByType(nodes)
+			}
 
-				go func(n *nodev1.Node, op string) {
-					defer wg.Done()
-					defer func() { <-semaphore }()
+			for i := 0; i < count; i++ {
+				// Don't start more work once shutdown has begun
+				if ctx.Err() != nil {
+					break
+				}
+
+				var node *nodev1.Node
+				if byType != nil {
+					if node = opts.TypeWeights.PickNode(r.rng, byType); node == nil {
+						if !noWeightedTargets {
+							r.logger.Warn("No target nodes of the weighted types, skipping ticks until there are")
+							noWeightedTargets = true
+						}
+						break
+					}
+					if noWeightedTargets {
+						r.logger.Info("Target nodes of the weighted types found, resuming")
+						noWeightedTargets = false
+					}
+				} else {
+					node = nodes[r.rng.Intn(len(nodes))]
+				}
+				operation := r.selectOperation(opts)
 
-					if err := r.rateLimiter.Take(ctx, 1); err != nil {
+				if r.config.Deterministic {
+					// Run inline so the RPC order, and every draw from the
+					// shared RNG, follows the order operations were picked
+					if err := rateLimiter.Take(ctx, 1); err != nil {
+						break
+					}
+					r.executeOperation(ctx, node, operation, opts)
+					continue
+				}
+
+				// A hung backend keeps the semaphore full, so don't let it
+				// hold up cancellation
+				select {
+				case semaphore <- struct{}{}:
+				case <-ctx.Done():
+					continue
+				}
+				ops.add()
+
+				go func(n *nodev1.Node, op string, opts RunOptions, limiter *TokenBucket, sem chan struct{}) {
+					defer ops.done()
+					defer func() { <-sem }()
+
+					if err := limiter.Take(ctx, 1); err != nil {
 						return
 					}
 
 					r.executeOperation(ctx, n, op, opts)
-				}(node, operation)
+				}(node, operation, opts, rateLimiter, semaphore)
 			}
 
 			if opts.Jitter {
 				jitterMs := int(200 * r.rng.Float64())
-				time.Sleep(time.Duration(jitterMs) * time.Millisecond)
+				sleepContext(ctx, time.Duration(jitterMs)*time.Millisecond)
 			}
 		}
 	}
@@ -168,11 +383,16 @@ func (r *Runner) Run(ctx context.Context, opts RunOptions) error {
 func (r *Runner) selectOperation(opts RunOptions) string {
 	roll := r.rng.Float64()
 
-	if roll < opts.ProbDeleteAndRecreate {
+	// Nodes selected by TargetLabels are not the simulator's to delete
+	probDelete := opts.ProbDeleteAndRecreate
+	if r.target != nil {
+		probDelete = 0
+	}
+	if roll < probDelete {
 		return "delete_recreate"
 	}
 
-	roll -= opts.ProbDeleteAndRecreate
+	roll -= probDelete
 	if roll < opts.ProbStatusFlip {
 		return "status_flip"
 	}
@@ -192,12 +412,27 @@ func (r *Runner) selectOperation(opts RunOptions) string {
 
 func (r *Runner) executeOperation(ctx context.Context, node *nodev1.Node, operation string, opts RunOptions) {
 	r.stats.TotalRPCs.Add(1)
+	start := time.Now()
+	defer func() { r.stats.Latency.Record(time.Since(start)) }()
+
+	// Every operation claims its node, so a check can tell when another one
+	// overlapped it
+	var claim *nodeClaim
+	if opts.Verify {
+		cl := r.claims.claim(node.Id)
+		defer r.claims.release(cl)
+		claim = &cl
+	}
 
 	switch operation {
 	case "delete_recreate":
-		r.deleteAndRecreate(ctx, node)
+		r.deleteAndRecreate(ctx, node, claim)
 	case "status_flip":
-		r.flipStatus(ctx, node)
+		weights := opts.StatusWeights
+		if weights == nil {
+			weights = DefaultFlipStatusWeights()
+		}
+		r.flipStatus(ctx, node, weights, claim)
 	case "label_change":
 		r.updateLabels(ctx, node)
 	case "metadata_change":
@@ -205,123 +440,239 @@ func (r *Runner) executeOperation(ctx context.Context, node *nodev1.Node, operat
 	}
 }
 
-func (r *Runner) deleteAndRecreate(ctx context.Context, node *nodev1.Node) {
-	err := RetryWithBackoff(ctx, DefaultRetryConfig(), func() error {
+// deleteAndRecreate replaces node with a new one of the same type. With a
+// claim it first checks the old node is gone.
+func (r *Runner) deleteAndRecreate(ctx context.Context, node *nodev1.Node, claim *nodeClaim) {
+	res := RetryWithBackoffResult(ctx, r.retryCfg, func() error {
 		ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
 		return r.client.DeleteNode(ctxWithTimeout, node.Id)
 	})
+	r.stats.RPCs.Record("delete", res)
 
-	if err != nil {
-		r.stats.ErrorCount.Add(1)
-		r.logger.Error("Failed to delete node", zap.String("id", node.Id), zap.Error(err))
+	if res.Err != nil {
+		r.recordFailure("Failed to delete node", res, zap.String("id", node.Id))
 		return
 	}
 
 	r.stats.DeleteCount.Add(1)
+	if claim != nil {
+		r.verifyDeleted(ctx, *claim)
+	}
+
+	name, err := r.namer.Next(node.Type)
+	if err != nil {
+		r.stats.ErrorCount.Add(1)
+		r.stats.Errors.Record(err)
+		r.logger.Error("Failed to name recreated node", zap.Error(err))
+		return
+	}
 
 	newNode := &nodev1.Node{
-		Name:         r.namer.Generate(node.Type),
+		Name:         name,
 		Type:         node.Type,
 		Status:       node.Status,
 		Labels:       node.Labels,
-		MetadataJson: r.metaGen.Generate(node.Type.String()),
+		MetadataJson: r.metaGen.GenerateForType(node.Type),
 	}
 
-	err = RetryWithBackoff(ctx, DefaultRetryConfig(), func() error {
+	res = RetryWithBackoffResult(ctx, r.retryCfg, func() error {
 		ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
 		_, err := r.client.CreateNode(ctxWithTimeout, newNode)
 		return err
 	})
+	r.stats.RPCs.Record("create", res)
 
-	if err != nil {
-		r.stats.ErrorCount.Add(1)
-		r.logger.Error("Failed to recreate node", zap.Error(err))
+	if res.Err != nil {
+		r.recordFailure("Failed to recreate node", res)
 	} else {
 		r.stats.CreateCount.Add(1)
 	}
 }
 
-func (r *Runner) flipStatus(ctx context.Context, node *nodev1.Node) {
-	statuses := []nodev1.NodeStatus{
-		nodev1.NodeStatus_UP,
-		nodev1.NodeStatus_DOWN,
-		nodev1.NodeStatus_DEGRADED,
-		nodev1.NodeStatus_UNKNOWN,
-	}
+// recordFailure counts a failed operation under the gRPC code of its error
+// and logs it with the attempts made
+func (r *Runner) recordFailure(msg string, res RetryResult, fields ...zap.Field) {
+	r.stats.ErrorCount.Add(1)
+	code := r.stats.Errors.Record(res.Err)
+	fields = append(fields,
+		zap.Stringer("code", code),
+		zap.Int("attempts", res.Attempts),
+		zap.Bool("retryable", !res.Permanent),
+		zap.Error(res.Err))
+	r.logger.Error(msg, fields...)
+}
 
-	newStatus := statuses[r.rng.Intn(len(statuses))]
-	for newStatus == node.Status {
-		newStatus = statuses[r.rng.Intn(len(statuses))]
-	}
+// flipStatus moves node to another status. With a claim it then checks the
+// node has that status.
+func (r *Runner) flipStatus(ctx context.Context, node *nodev1.Node, weights *StatusWeights, claim *nodeClaim) {
+	newStatus := weights.PickOther(r.rng, node.Status)
 
-	err := RetryWithBackoff(ctx, DefaultRetryConfig(), func() error {
+	res := RetryWithBackoffResult(ctx, r.retryCfg, func() error {
 		ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
 		_, err := r.client.UpdateStatus(ctxWithTimeout, node.Id, newStatus)
 		return err
 	})
+	r.stats.RPCs.Record("update_status", res)
 
-	if err != nil {
-		r.stats.ErrorCount.Add(1)
-		r.logger.Error("Failed to update status", zap.String("id", node.Id), zap.Error(err))
+	if res.Err != nil {
+		r.recordFailure("Failed to update status", res, zap.String("id", node.Id))
 	} else {
 		r.stats.StatusFlips.Add(1)
 		r.stats.UpdateCount.Add(1)
+		if claim != nil {
+			r.verifyStatus(ctx, *claim, newStatus)
+		}
 	}
 }
 
 func (r *Runner) updateLabels(ctx context.Context, node *nodev1.Node) {
 	node.Labels = r.labelGen.UpdateLabels(node.Labels)
 
-	err := RetryWithBackoff(ctx, DefaultRetryConfig(), func() error {
+	res := RetryWithBackoffResult(ctx, r.retryCfg, func() error {
 		ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
-		_, err := r.client.UpdateNode(ctxWithTimeout, node)
+		_, err := r.client.UpdateNodeFields(ctxWithTimeout, node, "labels")
 		return err
 	})
+	r.stats.RPCs.Record("update_labels", res)
 
-	if err != nil {
-		r.stats.ErrorCount.Add(1)
-		r.logger.Error("Failed to update labels", zap.String("id", node.Id), zap.Error(err))
+	if res.Err != nil {
+		r.recordFailure("Failed to update labels", res, zap.String("id", node.Id))
 	} else {
 		r.stats.UpdateCount.Add(1)
 	}
 }
 
 func (r *Runner) updateMetadata(ctx context.Context, node *nodev1.Node) {
-	node.MetadataJson = r.metaGen.Update(node.MetadataJson)
+	node.MetadataJson = r.metaGen.UpdateForType(node.Type, node.MetadataJson)
 
-	err := RetryWithBackoff(ctx, DefaultRetryConfig(), func() error {
+	res := RetryWithBackoffResult(ctx, r.retryCfg, func() error {
 		ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
 		defer cancel()
-		_, err := r.client.UpdateNode(ctxWithTimeout, node)
+		_, err := r.client.UpdateNodeFields(ctxWithTimeout, node, "metadata_json")
 		return err
 	})
+	r.stats.RPCs.Record("update_metadata", res)
 
-	if err != nil {
-		r.stats.ErrorCount.Add(1)
-		r.logger.Error("Failed to update metadata", zap.String("id", node.Id), zap.Error(err))
+	if res.Err != nil {
+		r.recordFailure("Failed to update metadata", res, zap.String("id", node.Id))
 	} else {
 		r.stats.UpdateCount.Add(1)
 	}
 }
 
-func (r *Runner) getSimulatorNodes(ctx context.Context) ([]*nodev1.Node, error) {
-	allNodes, err := r.client.ListNodes(ctx, 0, 0)
+// isTarget reports whether the run operates on node: a simulator node, or
+// with TargetLabels any node carrying them
+func (r *Runner) isTarget(node *nodev1.Node) bool {
+	if r.target != nil {
+		return r.target.Matches(node.Labels)
+	}
+	return FilterSimulatorLabels(node.Labels, nil)
+}
+
+// confirmTarget warns that TargetLabels reaches nodes the simulator doesn't
+// own and, unless confirmed, asks for "yes" on the input. It reports whether
+// the run should go ahead.
+func (r *Runner) confirmTarget(ctx context.Context, confirmed bool) (bool, error) {
+	nodes, err := r.getTargetNodes(ctx)
 	if err != nil {
-		return nil, err
+		return false, fmt.Errorf("failed to list target nodes: %w", err)
+	}
+	r.logger.Warn("Targeting nodes by label, including nodes not created by the simulator",
+		zap.Any("target_labels", r.target),
+		zap.Int("matching_nodes", len(nodes)))
+
+	if confirmed {
+		return true, nil
 	}
 
-	var simNodes []*nodev1.Node
-	for _, node := range allNodes {
-		if FilterSimulatorLabels(node.Labels) {
-			simNodes = append(simNodes, node)
+	fmt.Fprintf(r.out, "WARNING: this run will update %d nodes matching %v, including nodes not created by the simulator.\n", len(nodes), map[string]string(r.target))
+	fmt.Fprint(r.out, "Type 'yes' to continue: ")
+	response, err := readLine(ctx, r.in)
+	if ctx.Err() != nil {
+		r.logger.Info("Run cancelled while waiting for confirmation")
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if strings.TrimSpace(strings.ToLower(response)) != "yes" {
+		r.logger.Info("Run cancelled by user")
+		return false, nil
+	}
+	return true, nil
+}
+
+func (r *Runner) getTargetNodes(ctx context.Context) ([]*nodev1.Node, error) {
+	// Filter as pages arrive so other nodes are never all held at once
+	nodes, errc := r.client.ListNodesStream(ctx, 0, 0)
+
+	var targets []*nodev1.Node
+	for node := range nodes {
+		if r.isTarget(node) {
+			targets = append(targets, node)
 		}
 	}
+	if err := <-errc; err != nil {
+		return nil, err
+	}
 
-	return simNodes, nil
+	// ListNodes returns Redis set order, which differs between runs
+	if r.config.Deterministic {
+		sort.Slice(targets, func(i, j int) bool {
+			if targets[i].Type != targets[j].Type {
+				return targets[i].Type < targets[j].Type
+			}
+			return targets[i].Name < targets[j].Name
+		})
+	}
+
+	return targets, nil
+}
+
+func (r *Runner) logPhase(player *scenarioPlayer, opts RunOptions) {
+	phase := player.phase()
+	r.logger.Info("Entering scenario phase",
+		zap.String("phase", phase.Name),
+		zap.Int("index", player.index+1),
+		zap.Int("of", len(player.scenario.Phases)),
+		zap.Duration("duration", phase.duration),
+		zap.Float64("qps", opts.UpdateQPS),
+		zap.Int("max_concurrency", opts.MaxConcurrency),
+		zap.Int("batch_size", opts.BatchSize),
+		zap.Float64("prob_status_flip", opts.ProbStatusFlip),
+		zap.Float64("prob_label_change", opts.ProbLabelChange),
+		zap.Float64("prob_metadata_change", opts.ProbMetadataChange),
+		zap.Float64("prob_delete_and_recreate", opts.ProbDeleteAndRecreate))
+	r.warnBatchSize(opts)
+}
+
+// batchSizeWarning explains how BatchSize will change what UpdateQPS asks
+// for, or returns "" when it has no effect worth pointing out
+func batchSizeWarning(batchSize int, qps float64) string {
+	perTick := int(math.Ceil(qps))
+	switch {
+	case batchSize <= 0:
+		return ""
+	case batchSize < perTick:
+		return fmt.Sprintf("batch size %d caps the rate at %d operations per second, below the %g QPS target", batchSize, batchSize, qps)
+	case batchSize > perTick:
+		return fmt.Sprintf("batch size %d exceeds the %d operations per tick that %g QPS allows and has no effect", batchSize, perTick, qps)
+	default:
+		return ""
+	}
+}
+
+func (r *Runner) warnBatchSize(opts RunOptions) {
+	if msg := batchSizeWarning(opts.BatchSize, opts.UpdateQPS); msg != "" {
+		r.logger.Warn("Batch size does not match the update QPS",
+			zap.String("detail", msg),
+			zap.Int("batch_size", opts.BatchSize),
+			zap.Float64("qps", opts.UpdateQPS))
+	}
 }
 
 func (r *Runner) parseDuration(durationStr string) (time.Duration, error) {
@@ -331,12 +682,27 @@ func (r *Runner) parseDuration(durationStr string) (time.Duration, error) {
 	return time.ParseDuration(durationStr)
 }
 
-func (r *Runner) printStats() {
-	elapsed := time.Since(r.stats.StartTime)
+func (r *Runner) printStats(targetQPS float64) {
+	now := time.Now()
+	elapsed := now.Sub(r.stats.StartTime)
 	totalRPCs := r.stats.TotalRPCs.Load()
 	qps := float64(totalRPCs) / elapsed.Seconds()
 
-	r.logger.Info("Simulation stats",
+	// The cumulative average hides a backend that just started struggling,
+	// so the alert looks at the rate since the previous report
+	intervalQPS := float64(totalRPCs-r.lastReportRPCs) / now.Sub(r.lastReport).Seconds()
+	r.lastReport = now
+	r.lastReportRPCs = totalRPCs
+	latency := r.stats.Latency.Percentiles(50, 95, 99)
+
+	// Warn is rendered in color by the console encoder, which makes the
+	// shortfall stand out between the regular stats lines
+	msg, level := "Simulation stats", zap.InfoLevel
+	if qpsBelowTarget(intervalQPS, targetQPS, r.alertThreshold) {
+		msg, level = "Simulation stats: QPS below target, backend may be struggling", zap.WarnLevel
+	}
+
+	fields := []zap.Field{
 		zap.Int64("total_rpcs", totalRPCs),
 		zap.Int64("creates", r.stats.CreateCount.Load()),
 		zap.Int64("updates", r.stats.UpdateCount.Load()),
@@ -344,22 +710,87 @@ func (r *Runner) printStats() {
 		zap.Int64("status_flips", r.stats.StatusFlips.Load()),
 		zap.Int64("errors", r.stats.ErrorCount.Load()),
 		zap.Float64("qps", qps),
-		zap.Duration("elapsed", elapsed))
+		zap.Float64("interval_qps", intervalQPS),
+		zap.Float64("target_qps", targetQPS),
+		zap.Duration("latency_p50", latency[0]),
+		zap.Duration("latency_p95", latency[1]),
+		zap.Duration("latency_p99", latency[2]),
+		zap.Int64("rate_limit_waits", r.stats.RateLimit.Waits.Load()),
+		zap.Duration("rate_limit_wait", time.Duration(r.stats.RateLimit.WaitTime.Load())),
+		zap.Duration("elapsed", elapsed),
+	}
+	if r.verify {
+		fields = append(fields,
+			zap.Int64("verify_checks", r.stats.VerifyChecks.Load()),
+			zap.Int64("verify_failures", r.stats.VerifyFailures.Load()),
+			zap.Int64("verify_skipped", r.stats.VerifySkipped.Load()))
+	}
+	r.logger.Log(level, msg, append(fields, r.rpcLatencyFields()...)...)
+}
+
+// rpcLatencyFields reports the percentiles of each RPC kind, both for the
+// whole call and for its final attempt alone
+func (r *Runner) rpcLatencyFields() []zap.Field {
+	var fields []zap.Field
+	for _, name := range r.stats.RPCs.Names() {
+		rpc := r.stats.RPCs.Get(name)
+		total := rpc.Total.Percentiles(50, 95, 99)
+		attempt := rpc.Attempt.Percentiles(50, 95, 99)
+		fields = append(fields, zap.Dict(name+"_latency",
+			zap.Duration("p50", total[0]),
+			zap.Duration("p95", total[1]),
+			zap.Duration("p99", total[2]),
+			zap.Duration("attempt_p50", attempt[0]),
+			zap.Duration("attempt_p95", attempt[1]),
+			zap.Duration("attempt_p99", attempt[2])))
+	}
+	return fields
+}
+
+// qpsBelowTarget reports whether achieved throughput fell under threshold
+// (a fraction of target). A threshold of 0 disables the check.
+func qpsBelowTarget(achieved, target, threshold float64) bool {
+	if threshold <= 0 || target <= 0 {
+		return false
+	}
+	return achieved < target*threshold
 }
 
 func (r *Runner) printFinalStats() {
-	fmt.Println("\n========== Final Statistics ==========")
+	fmt.Fprintln(r.out, "\n========== Final Statistics ==========")
 	elapsed := time.Since(r.stats.StartTime)
 	totalRPCs := r.stats.TotalRPCs.Load()
 
-	fmt.Printf("Duration: %v\n", elapsed)
-	fmt.Printf("Total RPCs: %d\n", totalRPCs)
-	fmt.Printf("  - Creates: %d\n", r.stats.CreateCount.Load())
-	fmt.Printf("  - Updates: %d\n", r.stats.UpdateCount.Load())
-	fmt.Printf("  - Deletes: %d\n", r.stats.DeleteCount.Load())
-	fmt.Printf("  - Status Flips: %d\n", r.stats.StatusFlips.Load())
-	fmt.Printf("Errors: %d (%.2f%%)\n", r.stats.ErrorCount.Load(),
+	fmt.Fprintf(r.out, "Duration: %v\n", elapsed)
+	fmt.Fprintf(r.out, "Total RPCs: %d\n", totalRPCs)
+	fmt.Fprintf(r.out, "  - Creates: %d\n", r.stats.CreateCount.Load())
+	fmt.Fprintf(r.out, "  - Updates: %d\n", r.stats.UpdateCount.Load())
+	fmt.Fprintf(r.out, "  - Deletes: %d\n", r.stats.DeleteCount.Load())
+	fmt.Fprintf(r.out, "  - Status Flips: %d\n", r.stats.StatusFlips.Load())
+	fmt.Fprintf(r.out, "Errors: %d (%.2f%%)\n", r.stats.ErrorCount.Load(),
 		float64(r.stats.ErrorCount.Load())*100/float64(totalRPCs+1))
-	fmt.Printf("Average QPS: %.2f\n", float64(totalRPCs)/elapsed.Seconds())
-	fmt.Println("======================================")
+	for _, code := range r.stats.Errors.Codes() {
+		kind := "rejected"
+		if retryableCode(code) {
+			kind = "retries exhausted"
+		}
+		fmt.Fprintf(r.out, "  - %s: %d (%s)\n", code, r.stats.Errors.Count(code), kind)
+	}
+	fmt.Fprintf(r.out, "Average QPS: %.2f\n", float64(totalRPCs)/elapsed.Seconds())
+	latency := r.stats.Latency.Percentiles(50, 95, 99)
+	fmt.Fprintf(r.out, "Latency: p50 %v, p95 %v, p99 %v\n", latency[0], latency[1], latency[2])
+	for _, name := range r.stats.RPCs.Names() {
+		rpc := r.stats.RPCs.Get(name)
+		total := rpc.Total.Percentiles(50, 95, 99)
+		attempt := rpc.Attempt.Percentiles(50, 95, 99)
+		fmt.Fprintf(r.out, "  - %s (%d): p50 %v, p95 %v, p99 %v; final attempt p50 %v, p95 %v, p99 %v\n",
+			name, rpc.Total.Count(), total[0], total[1], total[2], attempt[0], attempt[1], attempt[2])
+	}
+	fmt.Fprintf(r.out, "Rate limit waits: %d (%v total)\n", r.stats.RateLimit.Waits.Load(),
+		time.Duration(r.stats.RateLimit.WaitTime.Load()).Round(time.Millisecond))
+	if r.verify {
+		fmt.Fprintf(r.out, "Verification: %d checked, %d failed, %d skipped (overlapping operations)\n",
+			r.stats.VerifyChecks.Load(), r.stats.VerifyFailures.Load(), r.stats.VerifySkipped.Load())
+	}
+	fmt.Fprintln(r.out, "======================================")
 }
\ No newline at end of file
@@ -0,0 +1,175 @@
+package sim
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestQPSBelowTarget(t *testing.T) {
+	assert.True(t, qpsBelowTarget(4, 10, 0.5))
+	assert.False(t, qpsBelowTarget(5, 10, 0.5))
+	assert.False(t, qpsBelowTarget(9, 10, 0.5))
+	assert.False(t, qpsBelowTarget(0, 10, 0), "threshold 0 disables the alert")
+	assert.False(t, qpsBelowTarget(0, 0, 0.5))
+}
+
+func TestPrintStatsWarnsWhenQPSBelowThreshold(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	r := NewRunner(&Config{}, zap.New(core))
+	r.alertThreshold = 0.5
+
+	// 30 RPCs over the last 10s is 3 QPS against a target of 10
+	r.lastReport = time.Now().Add(-10 * time.Second)
+	r.stats.TotalRPCs.Store(30)
+	r.printStats(10)
+
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1)
+	assert.Equal(t, zapcore.WarnLevel, entries[0].Level)
+	assert.Contains(t, entries[0].Message, "QPS below target")
+
+	// 90 more RPCs over the next 10s is 9 QPS, above the threshold
+	r.lastReport = time.Now().Add(-10 * time.Second)
+	r.stats.TotalRPCs.Store(120)
+	r.printStats(10)
+
+	entries = logs.TakeAll()
+	require.Len(t, entries, 1)
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	assert.Equal(t, "Simulation stats", entries[0].Message)
+}
+
+func TestRunTargetLabelsNeedsConfirmationAndNeverDeletes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the simulator on its one-second tick")
+	}
+
+	cfg, store := startTestBackend(t)
+	cfg.Deterministic = true
+	ctx := context.Background()
+
+	staging, err := store.CreateNode(ctx, &nodev1.Node{
+		Name:   "api-1",
+		Type:   nodev1.NodeType_VM,
+		Status: nodev1.NodeStatus_UP,
+		Labels: map[string]string{"env": "staging"},
+	})
+	require.NoError(t, err)
+	prod, err := store.CreateNode(ctx, &nodev1.Node{
+		Name:   "api-2",
+		Type:   nodev1.NodeType_VM,
+		Status: nodev1.NodeStatus_UP,
+		Labels: map[string]string{"env": "prod"},
+	})
+	require.NoError(t, err)
+
+	opts := RunOptions{
+		Duration:              "1s",
+		UpdateQPS:             1000,
+		MaxConcurrency:        1,
+		ProbStatusFlip:        0.5,
+		ProbDeleteAndRecreate: 0.5,
+		BatchSize:             10,
+		TargetLabels:          LabelSelector{"env": "staging"},
+	}
+
+	// Anything but yes runs nothing
+	var out bytes.Buffer
+	runner := NewRunner(cfg, zap.NewNop())
+	runner.SetInput(strings.NewReader("y\n"))
+	runner.SetOutput(&out)
+	require.NoError(t, runner.Run(ctx, opts))
+	assert.Contains(t, out.String(), "this run will update 1 nodes matching map[env:staging]")
+	assert.Zero(t, runner.Stats().TotalRPCs.Load())
+
+	runner = NewRunner(cfg, zap.NewNop())
+	runner.SetInput(strings.NewReader("yes\n"))
+	runner.SetOutput(io.Discard)
+	require.NoError(t, runner.Run(ctx, opts))
+	assert.Positive(t, runner.Stats().StatusFlips.Load())
+	assert.Zero(t, runner.Stats().DeleteCount.Load(), "targeted nodes must never be deleted")
+
+	got, err := store.GetNode(ctx, staging.Id)
+	require.NoError(t, err)
+	assert.NotEqual(t, nodev1.NodeStatus_UP, got.Status)
+
+	got, err = store.GetNode(ctx, prod.Id)
+	require.NoError(t, err)
+	assert.Equal(t, nodev1.NodeStatus_UP, got.Status, "nodes outside the selector are left alone")
+}
+
+func TestBatchSizeWarning(t *testing.T) {
+	assert.Empty(t, batchSizeWarning(0, 15), "0 leaves the rate to the QPS")
+	assert.Empty(t, batchSizeWarning(15, 15))
+	assert.Empty(t, batchSizeWarning(3, 2.5), "a fractional rate rounds up per tick")
+	assert.Contains(t, batchSizeWarning(10, 50), "caps the rate at 10 operations per second")
+	assert.Contains(t, batchSizeWarning(200, 50), "has no effect")
+}
+
+func TestRunDispatchFollowsQPS(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the simulator on its one-second tick")
+	}
+
+	cfg, _ := startTestBackend(t)
+	ctx := context.Background()
+	_, err := NewSeeder(cfg, zap.NewNop()).Seed(ctx, SeedOptions{Total: 3, PctVM: 1})
+	require.NoError(t, err)
+
+	run := func(qps float64, batchSize int) int64 {
+		runner := NewRunner(cfg, zap.NewNop())
+		runner.SetOutput(io.Discard)
+		require.NoError(t, runner.Run(ctx, RunOptions{
+			Duration:       "1500ms",
+			UpdateQPS:      qps,
+			MaxConcurrency: 4,
+			ProbStatusFlip: 1,
+			BatchSize:      batchSize,
+		}))
+		return runner.Stats().StatusFlips.Load()
+	}
+
+	// One tick runs; the rate sets its size, not the number of nodes
+	assert.EqualValues(t, 8, run(8, 0))
+	assert.EqualValues(t, 2, run(8, 2), "the batch size caps the tick")
+	assert.EqualValues(t, 8, run(8, 200), "a larger batch size doesn't add work")
+}
+
+func TestRunWarnsOnceWithoutWeightedTypes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the simulator on its one-second tick")
+	}
+
+	cfg, _ := startTestBackend(t)
+	ctx := context.Background()
+	_, err := NewSeeder(cfg, zap.NewNop()).Seed(ctx, SeedOptions{Total: 3, PctVM: 1})
+	require.NoError(t, err)
+
+	weights, err := ParseTypeWeights("container=1")
+	require.NoError(t, err)
+	core, logs := observer.New(zapcore.WarnLevel)
+	runner := NewRunner(cfg, zap.New(core))
+	runner.SetOutput(io.Discard)
+	require.NoError(t, runner.Run(ctx, RunOptions{
+		Duration:       "2500ms",
+		UpdateQPS:      5,
+		MaxConcurrency: 4,
+		ProbStatusFlip: 1,
+		TypeWeights:    weights,
+	}))
+
+	// Only VMs exist: every tick is skipped, but the warning is logged once
+	assert.Zero(t, runner.Stats().StatusFlips.Load())
+	assert.Equal(t, 1, logs.FilterMessageSnippet("No target nodes of the weighted types").Len())
+}
\ No newline at end of file
@@ -0,0 +1,184 @@
+package sim
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is a sequence of timed phases, each overriding parts of the run's
+// base RunOptions. It is loaded from YAML or JSON (JSON is valid YAML).
+type Scenario struct {
+	Name   string          `yaml:"name"`
+	Phases []ScenarioPhase `yaml:"phases"`
+}
+
+// ScenarioPhase overrides the fields that are set; unset fields keep the
+// value from the command line flags.
+type ScenarioPhase struct {
+	Name                  string   `yaml:"name"`
+	Duration              string   `yaml:"duration"`
+	UpdateQPS             *float64 `yaml:"update_qps"`
+	MaxConcurrency        *int     `yaml:"max_concurrency"`
+	ProbStatusFlip        *float64 `yaml:"prob_status_flip"`
+	ProbLabelChange       *float64 `yaml:"prob_label_change"`
+	ProbMetadataChange    *float64 `yaml:"prob_metadata_change"`
+	ProbDeleteAndRecreate *float64 `yaml:"prob_delete_and_recreate"`
+	Jitter                *bool    `yaml:"jitter"`
+	BatchSize             *int     `yaml:"batch_size"`
+	StatusWeights         string   `yaml:"status_weights"`
+
+	duration      time.Duration
+	statusWeights *StatusWeights
+}
+
+// LoadScenario reads and validates a scenario file.
+func LoadScenario(path string) (*Scenario, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %w", err)
+	}
+	return ParseScenario(raw)
+}
+
+// ParseScenario decodes and validates a scenario document. Unknown keys are
+// rejected so a typo doesn't silently fall back to the flag value.
+func ParseScenario(raw []byte) (*Scenario, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	dec.KnownFields(true)
+
+	var s Scenario
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("invalid scenario: %w", err)
+	}
+
+	if len(s.Phases) == 0 {
+		return nil, fmt.Errorf("scenario has no phases")
+	}
+
+	for i := range s.Phases {
+		p := &s.Phases[i]
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("phase-%d", i+1)
+		}
+
+		d, err := time.ParseDuration(p.Duration)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("phase %q: duration must be positive, e.g. 5m (got %q)", p.Name, p.Duration)
+		}
+		p.duration = d
+
+		for field, prob := range map[string]*float64{
+			"prob_status_flip":         p.ProbStatusFlip,
+			"prob_label_change":        p.ProbLabelChange,
+			"prob_metadata_change":     p.ProbMetadataChange,
+			"prob_delete_and_recreate": p.ProbDeleteAndRecreate,
+		} {
+			if prob != nil && (*prob < 0 || *prob > 1) {
+				return nil, fmt.Errorf("phase %q: %s must be between 0 and 1", p.Name, field)
+			}
+		}
+		if p.UpdateQPS != nil && *p.UpdateQPS <= 0 {
+			return nil, fmt.Errorf("phase %q: update_qps must be positive", p.Name)
+		}
+		if p.MaxConcurrency != nil && *p.MaxConcurrency <= 0 {
+			return nil, fmt.Errorf("phase %q: max_concurrency must be positive", p.Name)
+		}
+		if p.BatchSize != nil && *p.BatchSize < 0 {
+			return nil, fmt.Errorf("phase %q: batch_size must not be negative", p.Name)
+		}
+
+		if p.StatusWeights != "" {
+			weights, err := ParseStatusWeights(p.StatusWeights)
+			if err != nil {
+				return nil, fmt.Errorf("phase %q: %w", p.Name, err)
+			}
+			p.statusWeights = weights
+		}
+	}
+
+	return &s, nil
+}
+
+// TotalDuration is the sum of all phase durations.
+func (s *Scenario) TotalDuration() time.Duration {
+	var total time.Duration
+	for _, p := range s.Phases {
+		total += p.duration
+	}
+	return total
+}
+
+// apply returns base with this phase's overrides.
+func (p *ScenarioPhase) apply(base RunOptions) RunOptions {
+	opts := base
+	if p.UpdateQPS != nil {
+		opts.UpdateQPS = *p.UpdateQPS
+	}
+	if p.MaxConcurrency != nil {
+		opts.MaxConcurrency = *p.MaxConcurrency
+	}
+	if p.ProbStatusFlip != nil {
+		opts.ProbStatusFlip = *p.ProbStatusFlip
+	}
+	if p.ProbLabelChange != nil {
+		opts.ProbLabelChange = *p.ProbLabelChange
+	}
+	if p.ProbMetadataChange != nil {
+		opts.ProbMetadataChange = *p.ProbMetadataChange
+	}
+	if p.ProbDeleteAndRecreate != nil {
+		opts.ProbDeleteAndRecreate = *p.ProbDeleteAndRecreate
+	}
+	if p.Jitter != nil {
+		opts.Jitter = *p.Jitter
+	}
+	if p.BatchSize != nil {
+		opts.BatchSize = *p.BatchSize
+	}
+	if p.statusWeights != nil {
+		opts.StatusWeights = p.statusWeights
+	}
+	return opts
+}
+
+// scenarioPlayer tracks which phase is active as the run's clock advances.
+type scenarioPlayer struct {
+	scenario *Scenario
+	base     RunOptions
+	index    int
+	phaseEnd time.Time
+}
+
+func newScenarioPlayer(s *Scenario, base RunOptions, start time.Time) *scenarioPlayer {
+	return &scenarioPlayer{
+		scenario: s,
+		base:     base,
+		phaseEnd: start.Add(s.Phases[0].duration),
+	}
+}
+
+func (p *scenarioPlayer) phase() *ScenarioPhase {
+	return &p.scenario.Phases[p.index]
+}
+
+func (p *scenarioPlayer) options() RunOptions {
+	return p.phase().apply(p.base)
+}
+
+// advance moves past every phase that has ended by now. It reports whether
+// the active phase changed and whether the scenario is over.
+func (p *scenarioPlayer) advance(now time.Time) (changed, done bool) {
+	for !now.Before(p.phaseEnd) {
+		p.index++
+		if p.index >= len(p.scenario.Phases) {
+			return changed, true
+		}
+		p.phaseEnd = p.phaseEnd.Add(p.phase().duration)
+		changed = true
+	}
+	return changed, false
+}
\ No newline at end of file
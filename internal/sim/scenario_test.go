@@ -0,0 +1,81 @@
+package sim
+
+import (
+	"testing"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testScenario = `
+name: incident
+phases:
+  - name: steady
+    duration: 10m
+  - name: flip-spike
+    duration: 5m
+    prob_status_flip: 0.5
+    status_weights: down=0.7,up=0.1,degraded=0.1,unknown=0.1
+  - duration: 5m
+    prob_delete_and_recreate: 0.9
+    batch_size: 200
+`
+
+func TestScenarioPhasesOverrideBaseOptions(t *testing.T) {
+	s, err := ParseScenario([]byte(testScenario))
+	require.NoError(t, err)
+	assert.Equal(t, 20*time.Minute, s.TotalDuration())
+	assert.Equal(t, "phase-3", s.Phases[2].Name)
+
+	base := RunOptions{UpdateQPS: 15, ProbStatusFlip: 0.25, ProbDeleteAndRecreate: 0.02, BatchSize: 50}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	player := newScenarioPlayer(s, base, start)
+
+	assert.Equal(t, base, player.options())
+
+	changed, done := player.advance(start.Add(9 * time.Minute))
+	assert.False(t, changed)
+	assert.False(t, done)
+
+	changed, done = player.advance(start.Add(10 * time.Minute))
+	assert.True(t, changed)
+	assert.False(t, done)
+	opts := player.options()
+	assert.Equal(t, "flip-spike", player.phase().Name)
+	assert.Equal(t, 0.5, opts.ProbStatusFlip)
+	assert.Equal(t, 0.02, opts.ProbDeleteAndRecreate)
+	require.NotNil(t, opts.StatusWeights)
+	assert.Equal(t, 0.7, opts.StatusWeights.weights[nodev1.NodeStatus_DOWN])
+
+	// Phases never see each other's overrides
+	changed, done = player.advance(start.Add(16 * time.Minute))
+	assert.True(t, changed)
+	assert.False(t, done)
+	opts = player.options()
+	assert.Equal(t, 0.25, opts.ProbStatusFlip)
+	assert.Equal(t, 0.9, opts.ProbDeleteAndRecreate)
+	assert.Equal(t, 200, opts.BatchSize)
+	assert.Nil(t, opts.StatusWeights)
+
+	_, done = player.advance(start.Add(20 * time.Minute))
+	assert.True(t, done)
+}
+
+func TestParseScenarioValidates(t *testing.T) {
+	for name, doc := range map[string]string{
+		"no phases":        `name: empty`,
+		"missing duration": `phases: [{name: a}]`,
+		"bad probability":  `phases: [{duration: 1m, prob_status_flip: 1.5}]`,
+		"unknown field":    `phases: [{duration: 1m, prob_status_flips: 0.5}]`,
+		"bad weights":      `phases: [{duration: 1m, status_weights: "up=0.5"}]`,
+	} {
+		_, err := ParseScenario([]byte(doc))
+		assert.Error(t, err, name)
+	}
+
+	// JSON is accepted as well
+	_, err := ParseScenario([]byte(`{"phases": [{"duration": "30s", "update_qps": 5}]}`))
+	assert.NoError(t, err)
+}
\ No newline at end of file
@@ -16,11 +16,25 @@ import (
 )
 
 type SeedOptions struct {
-	Total        int
-	PctBaremetal float64
-	PctVM        float64
-	PctContainer float64
-	Labels       []string
+	Total           int
+	PctBaremetal    float64
+	PctVM           float64
+	PctContainer    float64
+	Labels          []string
+	StatusWeights   *StatusWeights // Defaults to DefaultSeedStatusWeights when nil
+	Manifest        string         // Path to record created nodes in, empty to skip
+	NamesPool       string         // File of candidate names, empty to generate names
+	UniqueNames     bool           // Use each pool name at most once
+	LabelSchema     *LabelSchema   // Labels to generate; DefaultLabelSchema when nil
+	ShutdownTimeout time.Duration  // How long cancellation waits for in-flight creates; 0 uses DefaultShutdownTimeout
+}
+
+// SeedResult summarizes a seed run for programmatic callers
+type SeedResult struct {
+	Created  int
+	Failed   int
+	Duration time.Duration
+	PerType  map[nodev1.NodeType]int // Created nodes by type
 }
 
 type Seeder struct {
@@ -31,6 +45,7 @@ type Seeder struct {
 	labelGen *LabelGenerator
 	metaGen  *MetadataGenerator
 	rng      *rand.Rand
+	retryCfg RetryConfig
 }
 
 func NewSeeder(cfg *Config, logger *zap.Logger) *Seeder {
@@ -40,41 +55,78 @@ func NewSeeder(cfg *Config, logger *zap.Logger) *Seeder {
 	}
 }
 
-func (s *Seeder) Seed(ctx context.Context, opts SeedOptions) error {
+func (s *Seeder) Seed(ctx context.Context, opts SeedOptions) (*SeedResult, error) {
 	s.rng = s.config.NewRand()
+	s.retryCfg = DefaultRetryConfig()
+	s.retryCfg.Rand = s.config.NewRetryRand()
 
-	client, err := grpcclient.NewClient(s.config.BackendAddr, s.config.BackendToken)
+	client, err := grpcclient.NewClient(s.config.BackendAddr, s.config.BackendToken, grpcclient.WithoutRetry())
 	if err != nil {
-		return fmt.Errorf("failed to create client: %w", err)
+		return nil, fmt.Errorf("failed to create client: %w", err)
 	}
 	defer client.Close()
 	s.client = client
 
-	namer, err := NewNamer(s.rng, "")
+	if err := checkBackend(ctx, client, s.config, true); err != nil {
+		return nil, err
+	}
+
+	namer, err := newNamerFor(s.rng, opts.NamesPool, opts.UniqueNames)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	s.namer = namer
-	s.labelGen = NewLabelGenerator(s.rng, s.config.SimLabelPrefix)
+	if remaining := namer.Remaining(); remaining >= 0 && remaining < opts.Total {
+		s.logger.Warn("Names pool is smaller than the number of nodes to create",
+			zap.Int("names", remaining),
+			zap.Int("total", opts.Total))
+	}
+	clock := s.config.NewClock()
+	batchID := s.config.NewBatchID(s.rng, clock)
+	s.labelGen = NewLabelGenerator(s.rng, clock, batchID, s.config.SimLabelPrefix, opts.LabelSchema)
 	s.metaGen = NewMetadataGenerator(s.rng)
 
 	s.logger.Info("Starting seed operation",
 		zap.Int("total", opts.Total),
+		zap.String("batch", batchID),
 		zap.Float64("pct_baremetal", opts.PctBaremetal),
 		zap.Float64("pct_vm", opts.PctVM),
 		zap.Float64("pct_container", opts.PctContainer),
 		zap.Int64("seed", s.config.SimSeed))
 
+	weights := opts.StatusWeights
+	if weights == nil {
+		weights = DefaultSeedStatusWeights()
+	}
+
+	var manifest *ManifestWriter
+	if opts.Manifest != "" {
+		manifest, err = CreateManifest(opts.Manifest)
+		if err != nil {
+			return nil, err
+		}
+		defer manifest.Close()
+	}
+
 	numBaremetal := int(float64(opts.Total) * opts.PctBaremetal)
 	numVM := int(float64(opts.Total) * opts.PctVM)
 	numContainer := opts.Total - numBaremetal - numVM
 
-	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, 32)
+	concurrency := 32
+	if s.config.Deterministic {
+		// One create at a time keeps the RPC order identical across runs
+		concurrency = 1
+	}
+
+	var ops inflight
+	semaphore := make(chan struct{}, concurrency)
 	errorChan := make(chan error, opts.Total)
 
 	var created atomic.Int32
 	var failed atomic.Int32
+	var namesErr error
+	var perTypeMu sync.Mutex
+	perType := make(map[nodev1.NodeType]int)
 	startTime := time.Now()
 
 	createNodes := func(nodeType nodev1.NodeType, count int) {
@@ -83,23 +135,36 @@ func (s *Seeder) Seed(ctx context.Context, opts SeedOptions) error {
 				return
 			}
 
-			wg.Add(1)
-			semaphore <- struct{}{}
+			// Generated before spawning so the RNG is consumed in creation order
+			node, err := s.generateNode(nodeType, opts.Labels, weights)
+			if err != nil {
+				namesErr = err
+				return
+			}
+
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			ops.add()
 
 			go func() {
-				defer wg.Done()
+				defer ops.done()
 				defer func() { <-semaphore }()
 
-				node := s.generateNode(nodeType, opts.Labels)
-
-				err := RetryWithBackoff(ctx, DefaultRetryConfig(), func() error {
+				var createdNode *nodev1.Node
+				err := RetryWithBackoff(ctx, s.retryCfg, func() error {
 					ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
 					defer cancel()
 
-					_, err := s.client.CreateNode(ctxWithTimeout, node)
+					var err error
+					createdNode, err = s.client.CreateNode(ctxWithTimeout, node)
 					if err != nil {
 						if st, ok := status.FromError(err); ok && st.Code() == codes.AlreadyExists {
-							node.Name = s.namer.Generate(nodeType)
+							if name, nameErr := s.namer.Next(nodeType); nameErr == nil {
+								node.Name = name
+							}
 							return err
 						}
 						return err
@@ -115,6 +180,16 @@ func (s *Seeder) Seed(ctx context.Context, opts SeedOptions) error {
 						zap.Error(err))
 				} else {
 					created.Add(1)
+					perTypeMu.Lock()
+					perType[nodeType]++
+					perTypeMu.Unlock()
+					if manifest != nil {
+						if err := manifest.Add(createdNode); err != nil {
+							s.logger.Error("Failed to write manifest entry",
+								zap.String("id", createdNode.Id),
+								zap.Error(err))
+						}
+					}
 					if created.Load()%100 == 0 {
 						s.logger.Info("Progress",
 							zap.Int32("created", created.Load()),
@@ -125,12 +200,32 @@ func (s *Seeder) Seed(ctx context.Context, opts SeedOptions) error {
 		}
 	}
 
-	createNodes(nodev1.NodeType_BAREMETAL, numBaremetal)
-	createNodes(nodev1.NodeType_VM, numVM)
-	createNodes(nodev1.NodeType_CONTAINER, numContainer)
+	for _, batch := range []struct {
+		nodeType nodev1.NodeType
+		count    int
+	}{
+		{nodev1.NodeType_BAREMETAL, numBaremetal},
+		{nodev1.NodeType_VM, numVM},
+		{nodev1.NodeType_CONTAINER, numContainer},
+	} {
+		if namesErr != nil {
+			break
+		}
+		createNodes(batch.nodeType, batch.count)
+	}
 
-	wg.Wait()
-	close(errorChan)
+	if ctx.Err() != nil {
+		if abandoned := ops.drain(opts.ShutdownTimeout); abandoned > 0 {
+			// The abandoned creates may still send on errorChan, so leave it open
+			s.logger.Warn("Shutdown timeout reached, abandoning in-flight creates",
+				zap.Int64("abandoned", abandoned))
+		} else {
+			close(errorChan)
+		}
+	} else {
+		ops.wait()
+		close(errorChan)
+	}
 
 	duration := time.Since(startTime)
 	s.logger.Info("Seed operation completed",
@@ -139,29 +234,35 @@ func (s *Seeder) Seed(ctx context.Context, opts SeedOptions) error {
 		zap.Duration("duration", duration),
 		zap.Float64("rate", float64(created.Load())/duration.Seconds()))
 
-	return nil
+	// Copied under the lock since abandoned creates may still update it
+	perTypeMu.Lock()
+	perTypeCopy := make(map[nodev1.NodeType]int, len(perType))
+	for nodeType, n := range perType {
+		perTypeCopy[nodeType] = n
+	}
+	perTypeMu.Unlock()
+
+	result := &SeedResult{
+		Created:  int(created.Load()),
+		Failed:   int(failed.Load()),
+		Duration: duration,
+		PerType:  perTypeCopy,
+	}
+	if namesErr != nil {
+		return result, fmt.Errorf("stopped after %d of %d nodes: %w", result.Created+result.Failed, opts.Total, namesErr)
+	}
+	return result, nil
 }
 
-func (s *Seeder) generateNode(nodeType nodev1.NodeType, extraLabels []string) *nodev1.Node {
-	name := s.namer.Generate(nodeType)
-	labels := s.labelGen.Generate(extraLabels)
-	metadata := s.metaGen.Generate(nodeType.String())
-
-	statuses := []nodev1.NodeStatus{
-		nodev1.NodeStatus_UP,
-		nodev1.NodeStatus_UP,
-		nodev1.NodeStatus_UP,
-		nodev1.NodeStatus_UP,
-		nodev1.NodeStatus_UP,
-		nodev1.NodeStatus_UP,
-		nodev1.NodeStatus_UP,
-		nodev1.NodeStatus_UP,
-		nodev1.NodeStatus_DOWN,
-		nodev1.NodeStatus_DEGRADED,
-		nodev1.NodeStatus_UNKNOWN,
+func (s *Seeder) generateNode(nodeType nodev1.NodeType, extraLabels []string, weights *StatusWeights) (*nodev1.Node, error) {
+	name, err := s.namer.Next(nodeType)
+	if err != nil {
+		return nil, err
 	}
+	labels := s.labelGen.Generate(extraLabels)
+	metadata := s.metaGen.GenerateForType(nodeType)
 
-	status := statuses[s.rng.Intn(len(statuses))]
+	status := weights.Pick(s.rng)
 
 	return &nodev1.Node{
 		Name:         name,
@@ -169,5 +270,5 @@ func (s *Seeder) generateNode(nodeType nodev1.NodeType, extraLabels []string) *n
 		Status:       status,
 		Labels:       labels,
 		MetadataJson: metadata,
-	}
+	}, nil
 }
\ No newline at end of file
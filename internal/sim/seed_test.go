@@ -0,0 +1,71 @@
+package sim
+
+import (
+	"context"
+	"testing"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSeedResultMatchesBackend(t *testing.T) {
+	// Reject every VM create with a non-retryable error
+	rejectVMs := grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if create, ok := req.(*nodev1.CreateNodeRequest); ok && create.GetNode().GetType() == nodev1.NodeType_VM {
+			return nil, status.Error(codes.InvalidArgument, "vm quota exceeded")
+		}
+		return handler(ctx, req)
+	})
+
+	cfg, store := startTestBackend(t, rejectVMs)
+	cfg.Deterministic = true
+	ctx := context.Background()
+
+	result, err := NewSeeder(cfg, zap.NewNop()).Seed(ctx, SeedOptions{
+		Total:        20,
+		PctBaremetal: 0.25,
+		PctVM:        0.25,
+		PctContainer: 0.5,
+	})
+	require.NoError(t, err)
+
+	nodes, err := store.ListNodes(ctx, 0, 0, 0, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, len(nodes), result.Created)
+	assert.Equal(t, 15, result.Created)
+	assert.Equal(t, 5, result.Failed)
+	assert.Equal(t, map[nodev1.NodeType]int{
+		nodev1.NodeType_BAREMETAL: 5,
+		nodev1.NodeType_CONTAINER: 10,
+	}, result.PerType)
+	assert.Positive(t, result.Duration)
+}
+
+func TestSeedUniqueNamesStopsWhenPoolRunsOut(t *testing.T) {
+	cfg, store := startTestBackend(t)
+	cfg.Deterministic = true
+	ctx := context.Background()
+
+	pool := writeNamesPool(t, "n1\nn2\nn3\nn4\n")
+	result, err := NewSeeder(cfg, zap.NewNop()).Seed(ctx, SeedOptions{
+		Total:        6,
+		PctBaremetal: 0,
+		PctVM:        1,
+		PctContainer: 0,
+		NamesPool:    pool,
+		UniqueNames:  true,
+	})
+	require.ErrorIs(t, err, ErrNamesExhausted)
+	assert.Equal(t, 4, result.Created)
+	assert.Zero(t, result.Failed)
+
+	nodes, err := store.ListNodes(ctx, 0, 0, 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, nodes, 4)
+}
\ No newline at end of file
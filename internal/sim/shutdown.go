@@ -0,0 +1,71 @@
+package sim
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultShutdownTimeout bounds how long a cancelled seed, run or cleanup
+// waits for in-flight operations
+const DefaultShutdownTimeout = 10 * time.Second
+
+// inflight tracks running operations so a cancelled command can wait for
+// them with a deadline instead of hanging on a stuck backend
+type inflight struct {
+	wg sync.WaitGroup
+	n  atomic.Int64
+}
+
+func (f *inflight) add() {
+	f.n.Add(1)
+	f.wg.Add(1)
+}
+
+func (f *inflight) done() {
+	f.n.Add(-1)
+	f.wg.Done()
+}
+
+// wait blocks until every operation has finished
+func (f *inflight) wait() {
+	f.wg.Wait()
+}
+
+// drain waits up to timeout for the operations to finish and returns how many
+// were still running. A timeout of zero uses DefaultShutdownTimeout.
+func (f *inflight) drain(timeout time.Duration) int64 {
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return 0
+	case <-timer.C:
+		return f.n.Load()
+	}
+}
+
+// sleepContext pauses for d, returning ctx.Err() early if ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
\ No newline at end of file
@@ -0,0 +1,97 @@
+package sim
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+func TestInflightDrain(t *testing.T) {
+	var ops inflight
+	ops.add()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		ops.done()
+	}()
+	assert.Equal(t, int64(0), ops.drain(time.Second))
+}
+
+func TestInflightDrainAbandonsAfterTimeout(t *testing.T) {
+	var ops inflight
+	release := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		ops.add()
+		go func() {
+			defer ops.done()
+			<-release
+		}()
+	}
+
+	start := time.Now()
+	assert.Equal(t, int64(3), ops.drain(20*time.Millisecond))
+	assert.Less(t, time.Since(start), time.Second)
+
+	close(release)
+	ops.wait()
+}
+
+func TestSleepContext(t *testing.T) {
+	assert.NoError(t, sleepContext(context.Background(), time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	assert.ErrorIs(t, sleepContext(ctx, time.Minute), context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestSeedReturnsPromptlyWhenCancelled(t *testing.T) {
+	// Creates hang until the client gives up on them
+	started := make(chan struct{}, 64)
+	hang := grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if _, ok := req.(*nodev1.CreateNodeRequest); ok {
+			started <- struct{}{}
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return handler(ctx, req)
+	})
+
+	cfg, _ := startTestBackend(t, hang)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	var result *SeedResult
+	var err error
+	go func() {
+		defer close(done)
+		result, err = NewSeeder(cfg, zap.NewNop()).Seed(ctx, SeedOptions{
+			Total:           100,
+			PctBaremetal:    0,
+			PctVM:           1,
+			PctContainer:    0,
+			ShutdownTimeout: time.Second,
+		})
+	}()
+
+	<-started
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Seed did not return after cancellation")
+	}
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Created)
+}
\ No newline at end of file
@@ -4,17 +4,34 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"text/tabwriter"
+	"io"
 	"os"
+	"text/tabwriter"
+	"sync"
+	"time"
 
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
 	"github.com/melkior/nodestatus/pkg/grpcclient"
 	"go.uber.org/zap"
 )
 
+const defaultStatsPageSize = 500
+
+type StatsOptions struct {
+	JSON     bool
+	PageSize int
+	Watch    bool
+	Interval time.Duration
+	// Out, with Watch, appends a sample every Interval to this file instead
+	// of redrawing: JSON lines for a .jsonl path, CSV otherwise
+	Out string
+}
+
 type Stats struct {
 	config *Config
 	logger *zap.Logger
 	client *grpcclient.Client
+	out    io.Writer
 }
 
 type StatsData struct {
@@ -29,10 +46,16 @@ func NewStats(cfg *Config, logger *zap.Logger) *Stats {
 	return &Stats{
 		config: cfg,
 		logger: logger,
+		out:    os.Stdout,
 	}
 }
 
-func (s *Stats) Print(ctx context.Context, jsonOutput bool) error {
+// SetOutput redirects the printed statistics, which go to stdout by default
+func (s *Stats) SetOutput(w io.Writer) {
+	s.out = w
+}
+
+func (s *Stats) Print(ctx context.Context, opts StatsOptions) error {
 	client, err := grpcclient.NewClient(s.config.BackendAddr, s.config.BackendToken)
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
@@ -40,48 +63,150 @@ func (s *Stats) Print(ctx context.Context, jsonOutput bool) error {
 	defer client.Close()
 	s.client = client
 
-	stats, err := s.collect(ctx)
+	if err := checkBackend(ctx, client, s.config, false); err != nil {
+		return err
+	}
+
+	if opts.Watch && opts.Out != "" {
+		return s.record(ctx, opts)
+	}
+	if opts.Watch {
+		return s.watch(ctx, opts)
+	}
+
+	stats, err := s.collect(ctx, opts.PageSize)
 	if err != nil {
 		return err
 	}
 
-	if jsonOutput {
+	if opts.JSON {
 		return s.printJSON(stats)
 	}
 	return s.printTable(stats)
 }
 
-func (s *Stats) collect(ctx context.Context) (*StatsData, error) {
-	allNodes, err := s.client.ListNodes(ctx, 0, 0)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list all nodes: %w", err)
+// collect walks the fleet page by page and folds each page into the counts,
+// so memory stays bounded by the page size rather than the fleet size.
+func (s *Stats) collect(ctx context.Context, pageSize int) (*StatsData, error) {
+	acc := newStatsAccumulator()
+	if err := s.walkNodes(ctx, pageSize, acc.add); err != nil {
+		return nil, err
 	}
+	return acc.snapshot(), nil
+}
 
-	stats := &StatsData{
-		Total:           len(allNodes),
-		ByType:          make(map[string]int),
-		ByStatus:        make(map[string]int),
-		ByTypeAndStatus: make(map[string]map[string]int),
+// walkNodes calls fn for every node in the fleet, one ListNodes page at a time.
+func (s *Stats) walkNodes(ctx context.Context, pageSize int, fn func(*nodev1.Node)) error {
+	if pageSize <= 0 {
+		pageSize = defaultStatsPageSize
 	}
 
-	for _, node := range allNodes {
-		typeStr := node.Type.String()
-		statusStr := node.Status.String()
+	pageToken := ""
+	for {
+		resp, err := s.client.NodeService().ListNodes(ctx, &nodev1.ListNodesRequest{
+			PageSize:  int32(pageSize),
+			PageToken: pageToken,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list nodes: %w", err)
+		}
 
-		stats.ByType[typeStr]++
-		stats.ByStatus[statusStr]++
+		for _, node := range resp.Nodes {
+			fn(node)
+		}
 
-		if _, ok := stats.ByTypeAndStatus[typeStr]; !ok {
-			stats.ByTypeAndStatus[typeStr] = make(map[string]int)
+		if resp.NextPageToken == "" {
+			return nil
 		}
-		stats.ByTypeAndStatus[typeStr][statusStr]++
+		pageToken = resp.NextPageToken
+	}
+}
+
+// statsAccumulator maintains running counts and is safe for concurrent use.
+type statsAccumulator struct {
+	mu   sync.Mutex
+	data *StatsData
+}
 
-		if FilterSimulatorLabels(node.Labels) {
-			stats.SimulatorNodes++
+func newStatsAccumulator() *statsAccumulator {
+	return &statsAccumulator{
+		data: &StatsData{
+			ByType:          make(map[string]int),
+			ByStatus:        make(map[string]int),
+			ByTypeAndStatus: make(map[string]map[string]int),
+		},
+	}
+}
+
+func (a *statsAccumulator) add(node *nodev1.Node) {
+	a.apply(summarizeNode(node), 1)
+}
+
+func (a *statsAccumulator) remove(summary nodeSummary) {
+	a.apply(summary, -1)
+}
+
+func (a *statsAccumulator) apply(summary nodeSummary, delta int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	typeStr := summary.Type.String()
+	statusStr := summary.Status.String()
+
+	a.data.Total += delta
+	a.data.ByType[typeStr] += delta
+	a.data.ByStatus[statusStr] += delta
+
+	if _, ok := a.data.ByTypeAndStatus[typeStr]; !ok {
+		a.data.ByTypeAndStatus[typeStr] = make(map[string]int)
+	}
+	a.data.ByTypeAndStatus[typeStr][statusStr] += delta
+
+	if summary.Simulator {
+		a.data.SimulatorNodes += delta
+	}
+
+	// Drop emptied buckets so running counts compare equal to a fresh collection
+	if a.data.ByType[typeStr] == 0 {
+		delete(a.data.ByType, typeStr)
+	}
+	if a.data.ByStatus[statusStr] == 0 {
+		delete(a.data.ByStatus, statusStr)
+	}
+	if a.data.ByTypeAndStatus[typeStr][statusStr] == 0 {
+		delete(a.data.ByTypeAndStatus[typeStr], statusStr)
+		if len(a.data.ByTypeAndStatus[typeStr]) == 0 {
+			delete(a.data.ByTypeAndStatus, typeStr)
+		}
+	}
+}
+
+// snapshot returns a copy of the current counts that callers may keep.
+func (a *statsAccumulator) snapshot() *StatsData {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	snap := &StatsData{
+		Total:           a.data.Total,
+		ByType:          make(map[string]int, len(a.data.ByType)),
+		ByStatus:        make(map[string]int, len(a.data.ByStatus)),
+		ByTypeAndStatus: make(map[string]map[string]int, len(a.data.ByTypeAndStatus)),
+		SimulatorNodes:  a.data.SimulatorNodes,
+	}
+	for k, v := range a.data.ByType {
+		snap.ByType[k] = v
+	}
+	for k, v := range a.data.ByStatus {
+		snap.ByStatus[k] = v
+	}
+	for typeStr, byStatus := range a.data.ByTypeAndStatus {
+		snap.ByTypeAndStatus[typeStr] = make(map[string]int, len(byStatus))
+		for k, v := range byStatus {
+			snap.ByTypeAndStatus[typeStr][k] = v
 		}
 	}
 
-	return stats, nil
+	return snap
 }
 
 func (s *Stats) printJSON(stats *StatsData) error {
@@ -89,18 +214,18 @@ func (s *Stats) printJSON(stats *StatsData) error {
 	if err != nil {
 		return err
 	}
-	fmt.Println(string(data))
+	fmt.Fprintln(s.out, string(data))
 	return nil
 }
 
 func (s *Stats) printTable(stats *StatsData) error {
-	fmt.Println("\n===== Node Statistics =====")
-	fmt.Printf("Total Nodes: %d\n", stats.Total)
-	fmt.Printf("Simulator Nodes: %d\n", stats.SimulatorNodes)
-	fmt.Println()
+	fmt.Fprintln(s.out, "\n===== Node Statistics =====")
+	fmt.Fprintf(s.out, "Total Nodes: %d\n", stats.Total)
+	fmt.Fprintf(s.out, "Simulator Nodes: %d\n", stats.SimulatorNodes)
+	fmt.Fprintln(s.out)
 
-	fmt.Println("By Type:")
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(s.out, "By Type:")
+	w := tabwriter.NewWriter(s.out, 0, 0, 2, ' ', 0)
 	fmt.Fprintln(w, "TYPE\tCOUNT\tPERCENT")
 	for _, nodeType := range []string{"BAREMETAL", "VM", "CONTAINER"} {
 		count := stats.ByType[nodeType]
@@ -108,10 +233,10 @@ func (s *Stats) printTable(stats *StatsData) error {
 		fmt.Fprintf(w, "%s\t%d\t%.1f%%\n", nodeType, count, pct)
 	}
 	w.Flush()
-	fmt.Println()
+	fmt.Fprintln(s.out)
 
-	fmt.Println("By Status:")
-	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(s.out, "By Status:")
+	w = tabwriter.NewWriter(s.out, 0, 0, 2, ' ', 0)
 	fmt.Fprintln(w, "STATUS\tCOUNT\tPERCENT")
 	for _, status := range []string{"UP", "DOWN", "DEGRADED", "UNKNOWN"} {
 		count := stats.ByStatus[status]
@@ -119,10 +244,10 @@ func (s *Stats) printTable(stats *StatsData) error {
 		fmt.Fprintf(w, "%s\t%d\t%.1f%%\n", status, count, pct)
 	}
 	w.Flush()
-	fmt.Println()
+	fmt.Fprintln(s.out)
 
-	fmt.Println("By Type and Status:")
-	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(s.out, "By Type and Status:")
+	w = tabwriter.NewWriter(s.out, 0, 0, 2, ' ', 0)
 	fmt.Fprintln(w, "TYPE\tUP\tDOWN\tDEGRADED\tUNKNOWN")
 	for _, nodeType := range []string{"BAREMETAL", "VM", "CONTAINER"} {
 		fmt.Fprintf(w, "%s\t", nodeType)
@@ -136,7 +261,7 @@ func (s *Stats) printTable(stats *StatsData) error {
 		fmt.Fprintln(w)
 	}
 	w.Flush()
-	fmt.Println("===========================")
+	fmt.Fprintln(s.out, "===========================")
 
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,163 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// nodeSummary is the part of a node the stats counts depend on.
+type nodeSummary struct {
+	Type      nodev1.NodeType
+	Status    nodev1.NodeStatus
+	Simulator bool
+}
+
+func summarizeNode(node *nodev1.Node) nodeSummary {
+	return nodeSummary{
+		Type:      node.Type,
+		Status:    node.Status,
+		Simulator: FilterSimulatorLabels(node.Labels, nil),
+	}
+}
+
+// liveStats keeps running counts up to date from WatchEvents. It remembers the
+// last summary per node so updates and deletes can undo the previous counts,
+// which also makes replayed or duplicated events harmless.
+type liveStats struct {
+	mu         sync.Mutex
+	acc        *statsAccumulator
+	known      map[string]nodeSummary
+	generation atomic.Uint64
+}
+
+func newLiveStats() *liveStats {
+	return &liveStats{
+		acc:   newStatsAccumulator(),
+		known: make(map[string]nodeSummary),
+	}
+}
+
+func (l *liveStats) apply(eventType nodev1.EventType, node *nodev1.Node) {
+	if node == nil || node.Id == "" {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	prev, exists := l.known[node.Id]
+
+	switch eventType {
+	case nodev1.EventType_CREATED, nodev1.EventType_UPDATED:
+		next := summarizeNode(node)
+		if exists {
+			if prev == next {
+				return
+			}
+			l.acc.remove(prev)
+		}
+		l.acc.apply(next, 1)
+		l.known[node.Id] = next
+
+	case nodev1.EventType_DELETED:
+		if !exists {
+			return
+		}
+		l.acc.remove(prev)
+		delete(l.known, node.Id)
+
+	default:
+		return
+	}
+
+	l.generation.Add(1)
+}
+
+func (l *liveStats) snapshot() *StatsData {
+	return l.acc.snapshot()
+}
+
+// watch seeds the counts with one paged listing and then keeps them current
+// from the event stream, redrawing whenever they change.
+func (s *Stats) watch(ctx context.Context, opts StatsOptions) error {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	// Subscribe before listing so nothing between the two is missed
+	stream, err := s.client.WatchEvents(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to watch events: %w", err)
+	}
+
+	live := newLiveStats()
+	if err := s.walkNodes(ctx, opts.PageSize, func(node *nodev1.Node) {
+		live.apply(nodev1.EventType_CREATED, node)
+	}); err != nil {
+		return err
+	}
+
+	streamErr := make(chan error, 1)
+	go func() {
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				streamErr <- err
+				return
+			}
+			if event.Heartbeat {
+				continue
+			}
+			live.apply(event.EventType, event.Node)
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	drawn := live.generation.Load()
+	if err := s.redraw(live.snapshot(), opts.JSON); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err := <-streamErr:
+			if ctx.Err() != nil || err == io.EOF || status.Code(err) == codes.Canceled {
+				return nil
+			}
+			s.logger.Error("Event stream failed", zap.Error(err))
+			return fmt.Errorf("event stream failed: %w", err)
+
+		case <-ticker.C:
+			if gen := live.generation.Load(); gen != drawn {
+				drawn = gen
+				if err := s.redraw(live.snapshot(), opts.JSON); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func (s *Stats) redraw(stats *StatsData, jsonOutput bool) error {
+	if jsonOutput {
+		return s.printJSON(stats)
+	}
+	// Clear the screen so the table redraws in place
+	fmt.Fprint(s.out, "\033[H\033[2J")
+	return s.printTable(stats)
+}
\ No newline at end of file
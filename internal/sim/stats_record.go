@@ -0,0 +1,145 @@
+package sim
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	statsRecordTypes    = []string{"BAREMETAL", "VM", "CONTAINER"}
+	statsRecordStatuses = []string{"UP", "DOWN", "DEGRADED", "UNKNOWN"}
+)
+
+// StatsRow is one recorded sample of the fleet counts
+type StatsRow struct {
+	Timestamp time.Time `json:"timestamp"`
+	*StatsData
+}
+
+// statsRecorder appends samples to a file as CSV rows or JSON lines
+type statsRecorder struct {
+	jsonl bool
+	csv   *csv.Writer
+	enc   *json.Encoder
+}
+
+// newStatsRecorder picks JSON lines for a .jsonl path and CSV otherwise. The
+// CSV header is written only when the file is empty, so appending to the
+// output of an earlier run keeps a single header.
+func newStatsRecorder(w io.Writer, path string, empty bool) (*statsRecorder, error) {
+	r := &statsRecorder{jsonl: strings.EqualFold(filepath.Ext(path), ".jsonl")}
+	if r.jsonl {
+		r.enc = json.NewEncoder(w)
+		return r, nil
+	}
+
+	r.csv = csv.NewWriter(w)
+	if empty {
+		header := []string{"timestamp", "total", "simulator_nodes"}
+		for _, t := range statsRecordTypes {
+			header = append(header, "type:"+t)
+		}
+		for _, s := range statsRecordStatuses {
+			header = append(header, "status:"+s)
+		}
+		if err := r.csv.Write(header); err != nil {
+			return nil, err
+		}
+		r.csv.Flush()
+		if err := r.csv.Error(); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *statsRecorder) write(row StatsRow) error {
+	if r.jsonl {
+		return r.enc.Encode(row)
+	}
+
+	record := []string{
+		row.Timestamp.UTC().Format(time.RFC3339),
+		strconv.Itoa(row.Total),
+		strconv.Itoa(row.SimulatorNodes),
+	}
+	for _, t := range statsRecordTypes {
+		record = append(record, strconv.Itoa(row.ByType[t]))
+	}
+	for _, s := range statsRecordStatuses {
+		record = append(record, strconv.Itoa(row.ByStatus[s]))
+	}
+	if err := r.csv.Write(record); err != nil {
+		return err
+	}
+	// Flush every row so the file can be charted while the run continues
+	r.csv.Flush()
+	return r.csv.Error()
+}
+
+// record appends a sample to opts.Out right away and then every interval
+// until ctx is cancelled. A failed listing is logged and skipped so one
+// backend hiccup does not end a long recording.
+func (s *Stats) record(ctx context.Context, opts StatsOptions) error {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	f, err := os.OpenFile(opts.Out, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open stats output: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat stats output: %w", err)
+	}
+	recorder, err := newStatsRecorder(f, opts.Out, info.Size() == 0)
+	if err != nil {
+		return fmt.Errorf("failed to write stats header: %w", err)
+	}
+
+	s.logger.Info("Recording stats",
+		zap.String("out", opts.Out),
+		zap.Duration("interval", interval),
+	)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	rows := 0
+	for {
+		stats, err := s.collect(ctx, opts.PageSize)
+		switch {
+		case ctx.Err() != nil:
+			s.logger.Info("Stats recording stopped", zap.Int("rows", rows))
+			return nil
+		case err != nil:
+			s.logger.Warn("Skipping stats sample", zap.Error(err))
+		default:
+			if err := recorder.write(StatsRow{Timestamp: time.Now(), StatsData: stats}); err != nil {
+				return fmt.Errorf("failed to write stats row: %w", err)
+			}
+			rows++
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Stats recording stopped", zap.Int("rows", rows))
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,186 @@
+package sim
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/melkior/nodestatus/pkg/grpcclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestStatsCollectStreamingMatchesBuffered(t *testing.T) {
+	cfg, store := startTestBackend(t)
+	ctx := context.Background()
+
+	types := []nodev1.NodeType{nodev1.NodeType_BAREMETAL, nodev1.NodeType_VM, nodev1.NodeType_CONTAINER}
+	statuses := []nodev1.NodeStatus{nodev1.NodeStatus_UP, nodev1.NodeStatus_DOWN, nodev1.NodeStatus_DEGRADED, nodev1.NodeStatus_UNKNOWN}
+	for i := 0; i < 53; i++ {
+		labels := map[string]string{"env": "test"}
+		if i%3 == 0 {
+			labels["demo"] = "true"
+			labels["demo.owner"] = "cli"
+		}
+		_, err := store.CreateNode(ctx, &nodev1.Node{
+			Name:   fmt.Sprintf("node-%d", i),
+			Type:   types[i%len(types)],
+			Status: statuses[i%len(statuses)],
+			Labels: labels,
+		})
+		require.NoError(t, err)
+	}
+
+	client, err := grpcclient.NewClient(cfg.BackendAddr, cfg.BackendToken)
+	require.NoError(t, err)
+	defer client.Close()
+
+	allNodes, err := client.ListNodes(ctx, 0, 0)
+	require.NoError(t, err)
+	buffered := newStatsAccumulator()
+	for _, node := range allNodes {
+		buffered.add(node)
+	}
+
+	stats := NewStats(cfg, zap.NewNop())
+	stats.client = client
+
+	streamed, err := stats.collect(ctx, 7)
+	require.NoError(t, err)
+
+	assert.Equal(t, 53, streamed.Total)
+	assert.Equal(t, 18, streamed.SimulatorNodes)
+	assert.Equal(t, buffered.snapshot(), streamed)
+}
+
+func TestLiveStatsRunningCounts(t *testing.T) {
+	live := newLiveStats()
+	simLabels := map[string]string{"demo": "true", "demo.owner": "cli"}
+
+	live.apply(nodev1.EventType_CREATED, &nodev1.Node{Id: "a", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP, Labels: simLabels})
+	live.apply(nodev1.EventType_CREATED, &nodev1.Node{Id: "b", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP})
+	live.apply(nodev1.EventType_CREATED, &nodev1.Node{Id: "c", Type: nodev1.NodeType_CONTAINER, Status: nodev1.NodeStatus_DOWN})
+
+	snap := live.snapshot()
+	assert.Equal(t, 3, snap.Total)
+	assert.Equal(t, 2, snap.ByType["VM"])
+	assert.Equal(t, 2, snap.ByStatus["UP"])
+	assert.Equal(t, 1, snap.SimulatorNodes)
+
+	// Status change moves the node between buckets
+	live.apply(nodev1.EventType_UPDATED, &nodev1.Node{Id: "b", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_DEGRADED})
+	snap = live.snapshot()
+	assert.Equal(t, 3, snap.Total)
+	assert.Equal(t, 1, snap.ByStatus["UP"])
+	assert.Equal(t, 1, snap.ByStatus["DEGRADED"])
+	assert.Equal(t, 1, snap.ByTypeAndStatus["VM"]["DEGRADED"])
+
+	// A replayed create for a known node must not double count
+	live.apply(nodev1.EventType_CREATED, &nodev1.Node{Id: "a", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP, Labels: simLabels})
+	assert.Equal(t, 3, live.snapshot().Total)
+
+	live.apply(nodev1.EventType_DELETED, &nodev1.Node{Id: "a"})
+	live.apply(nodev1.EventType_DELETED, &nodev1.Node{Id: "missing"})
+	snap = live.snapshot()
+	assert.Equal(t, 2, snap.Total)
+	assert.Equal(t, 0, snap.SimulatorNodes)
+	assert.Equal(t, 0, snap.ByStatus["UP"])
+	assert.Equal(t, 1, snap.ByType["CONTAINER"])
+
+	expected := newStatsAccumulator()
+	expected.add(&nodev1.Node{Id: "b", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_DEGRADED})
+	expected.add(&nodev1.Node{Id: "c", Type: nodev1.NodeType_CONTAINER, Status: nodev1.NodeStatus_DOWN})
+	assert.Equal(t, expected.snapshot(), snap)
+}
+
+func TestStatsPrintWritesToOutput(t *testing.T) {
+	cfg, store := startTestBackend(t)
+	ctx := context.Background()
+
+	for i, nodeType := range []nodev1.NodeType{nodev1.NodeType_VM, nodev1.NodeType_VM, nodev1.NodeType_CONTAINER} {
+		_, err := store.CreateNode(ctx, &nodev1.Node{
+			Name:   fmt.Sprintf("node-%d", i),
+			Type:   nodeType,
+			Status: nodev1.NodeStatus_UP,
+		})
+		require.NoError(t, err)
+	}
+
+	var out bytes.Buffer
+	stats := NewStats(cfg, zap.NewNop())
+	stats.SetOutput(&out)
+
+	require.NoError(t, stats.Print(ctx, StatsOptions{}))
+	assert.Contains(t, out.String(), "Total Nodes: 3")
+	assert.Regexp(t, `VM\s+2\s+66\.7%`, out.String())
+
+	out.Reset()
+	require.NoError(t, stats.Print(ctx, StatsOptions{JSON: true}))
+	var data StatsData
+	require.NoError(t, json.Unmarshal(out.Bytes(), &data))
+	assert.Equal(t, 3, data.Total)
+	assert.Equal(t, 1, data.ByType["CONTAINER"])
+}
+
+func TestStatsRecordAppendsRows(t *testing.T) {
+	cfg, store := startTestBackend(t)
+	ctx := context.Background()
+
+	for i, status := range []nodev1.NodeStatus{nodev1.NodeStatus_UP, nodev1.NodeStatus_UP, nodev1.NodeStatus_DOWN} {
+		_, err := store.CreateNode(ctx, &nodev1.Node{
+			Name:   fmt.Sprintf("node-%d", i),
+			Type:   nodev1.NodeType_VM,
+			Status: status,
+		})
+		require.NoError(t, err)
+	}
+
+	dir := t.TempDir()
+	run := func(out string) {
+		runCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		stats := NewStats(cfg, zap.NewNop())
+		require.NoError(t, stats.Print(runCtx, StatsOptions{Watch: true, Interval: 20 * time.Millisecond, Out: out}))
+	}
+
+	// Two runs into the same CSV keep a single header
+	csvPath := filepath.Join(dir, "stats.csv")
+	run(csvPath)
+	run(csvPath)
+
+	f, err := os.Open(csvPath)
+	require.NoError(t, err)
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	require.Greater(t, len(rows), 2)
+	assert.Equal(t, []string{"timestamp", "total", "simulator_nodes",
+		"type:BAREMETAL", "type:VM", "type:CONTAINER",
+		"status:UP", "status:DOWN", "status:DEGRADED", "status:UNKNOWN"}, rows[0])
+	for _, row := range rows[1:] {
+		assert.NotEqual(t, "timestamp", row[0])
+		assert.Equal(t, []string{"3", "0", "0", "3", "0", "2", "1", "0", "0"}, row[1:])
+	}
+
+	jsonlPath := filepath.Join(dir, "stats.jsonl")
+	run(jsonlPath)
+
+	content, err := os.ReadFile(jsonlPath)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.NotEmpty(t, lines)
+	var row StatsRow
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &row))
+	assert.False(t, row.Timestamp.IsZero())
+	assert.Equal(t, 3, row.Total)
+	assert.Equal(t, 2, row.ByStatus["UP"])
+}
\ No newline at end of file
@@ -0,0 +1,127 @@
+package sim
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// nodeClaims tracks the operations in flight per node, so verification can
+// tell a write that was lost from one overwritten by the run itself
+type nodeClaims struct {
+	mu       sync.Mutex
+	inflight map[string]int
+	// touches counts operations started on a node while any is in flight
+	touches map[string]uint64
+}
+
+// nodeClaim is one operation's hold on a node
+type nodeClaim struct {
+	id    string
+	touch uint64
+	// contended is set when another operation was already in flight
+	contended bool
+}
+
+func newNodeClaims() *nodeClaims {
+	return &nodeClaims{
+		inflight: make(map[string]int),
+		touches:  make(map[string]uint64),
+	}
+}
+
+func (c *nodeClaims) claim(id string) nodeClaim {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inflight[id]++
+	c.touches[id]++
+	return nodeClaim{id: id, touch: c.touches[id], contended: c.inflight[id] > 1}
+}
+
+func (c *nodeClaims) release(cl nodeClaim) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inflight[cl.id]--
+	if c.inflight[cl.id] <= 0 {
+		delete(c.inflight, cl.id)
+		delete(c.touches, cl.id)
+	}
+}
+
+// alone reports whether no other operation on the node overlapped cl so far
+func (c *nodeClaims) alone(cl nodeClaim) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !cl.contended && c.touches[cl.id] == cl.touch
+}
+
+// verifyStatus reads node back and checks it has the status just written
+func (r *Runner) verifyStatus(ctx context.Context, cl nodeClaim, want nodev1.NodeStatus) {
+	node, ok := r.readBack(ctx, cl)
+	if !ok {
+		return
+	}
+	if node == nil {
+		r.verifyFailed("Verification failed: node vanished after a status update", zap.String("id", cl.id))
+		return
+	}
+	if node.Status != want {
+		r.verifyFailed("Verification failed: status update not applied",
+			zap.String("id", cl.id),
+			zap.Stringer("want", want),
+			zap.Stringer("got", node.Status))
+	}
+}
+
+// verifyDeleted checks the node of cl can no longer be read
+func (r *Runner) verifyDeleted(ctx context.Context, cl nodeClaim) {
+	node, ok := r.readBack(ctx, cl)
+	if ok && node != nil {
+		r.verifyFailed("Verification failed: deleted node still readable", zap.String("id", cl.id))
+	}
+}
+
+// readBack gets the node of cl. node is nil when it doesn't exist, and ok is
+// false when the read failed or another operation on the node overlapped,
+// which leaves nothing to compare against.
+func (r *Runner) readBack(ctx context.Context, cl nodeClaim) (node *nodev1.Node, ok bool) {
+	if !r.claims.alone(cl) {
+		r.stats.VerifySkipped.Add(1)
+		return nil, false
+	}
+
+	res := RetryWithBackoffResult(ctx, r.retryCfg, func() error {
+		ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		var err error
+		node, err = r.client.GetNode(ctxWithTimeout, cl.id)
+		if status.Code(err) == codes.NotFound {
+			node = nil
+			return nil
+		}
+		return err
+	})
+	r.stats.RPCs.Record("verify", res)
+
+	if res.Err != nil {
+		r.recordFailure("Failed to read node back for verification", res, zap.String("id", cl.id))
+		return nil, false
+	}
+	// Checked again: an operation may have started while the read was out
+	if !r.claims.alone(cl) {
+		r.stats.VerifySkipped.Add(1)
+		return nil, false
+	}
+	r.stats.VerifyChecks.Add(1)
+	return node, true
+}
+
+func (r *Runner) verifyFailed(msg string, fields ...zap.Field) {
+	r.stats.VerifyFailures.Add(1)
+	r.logger.Error(msg, fields...)
+}
\ No newline at end of file
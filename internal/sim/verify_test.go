@@ -0,0 +1,80 @@
+package sim
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+func TestNodeClaims(t *testing.T) {
+	claims := newNodeClaims()
+
+	a := claims.claim("n1")
+	assert.True(t, claims.alone(a))
+
+	// A later operation on the node spoils the check of the earlier one,
+	// and the later one saw the earlier still in flight
+	b := claims.claim("n1")
+	assert.False(t, claims.alone(a))
+	assert.False(t, claims.alone(b))
+
+	other := claims.claim("n2")
+	assert.True(t, claims.alone(other))
+
+	claims.release(a)
+	claims.release(b)
+	claims.release(other)
+	assert.Empty(t, claims.inflight)
+	assert.Empty(t, claims.touches)
+
+	assert.True(t, claims.alone(claims.claim("n1")))
+}
+
+func TestRunVerify(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the simulator on its one-second tick")
+	}
+
+	run := func(t *testing.T, opts ...grpc.ServerOption) *RunStats {
+		cfg, _ := startTestBackend(t, opts...)
+		ctx := context.Background()
+		_, err := NewSeeder(cfg, zap.NewNop()).Seed(ctx, SeedOptions{Total: 20, PctVM: 1})
+		require.NoError(t, err)
+
+		runner := NewRunner(cfg, zap.NewNop())
+		runner.SetOutput(io.Discard)
+		require.NoError(t, runner.Run(ctx, RunOptions{
+			Duration:              "1500ms",
+			UpdateQPS:             10,
+			MaxConcurrency:        1,
+			ProbStatusFlip:        0.7,
+			ProbDeleteAndRecreate: 0.3,
+			Verify:                true,
+		}))
+		return runner.Stats()
+	}
+
+	t.Run("healthy backend", func(t *testing.T) {
+		stats := run(t)
+		assert.Positive(t, stats.VerifyChecks.Load())
+		assert.Zero(t, stats.VerifyFailures.Load())
+	})
+
+	t.Run("lost status updates", func(t *testing.T) {
+		// Acknowledge status updates without applying them
+		stats := run(t, grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if info.FullMethod == nodev1.NodeService_UpdateStatus_FullMethodName {
+				return &nodev1.UpdateStatusResponse{}, nil
+			}
+			return handler(ctx, req)
+		}))
+		assert.Positive(t, stats.StatusFlips.Load())
+		assert.Equal(t, stats.StatusFlips.Load(), stats.VerifyFailures.Load())
+	})
+}
\ No newline at end of file
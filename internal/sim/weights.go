@@ -0,0 +1,226 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+)
+
+// statusWeightTolerance is how far from 1.0 a weight spec may sum.
+const statusWeightTolerance = 0.001
+
+// statusOrder fixes the iteration order so picks are reproducible for a seed.
+var statusOrder = []nodev1.NodeStatus{
+	nodev1.NodeStatus_UP,
+	nodev1.NodeStatus_DOWN,
+	nodev1.NodeStatus_DEGRADED,
+	nodev1.NodeStatus_UNKNOWN,
+}
+
+// StatusWeights picks node statuses according to relative weights.
+type StatusWeights struct {
+	weights map[nodev1.NodeStatus]float64
+}
+
+// DefaultSeedStatusWeights is the seeder's historical mix: mostly UP.
+func DefaultSeedStatusWeights() *StatusWeights {
+	return &StatusWeights{weights: map[nodev1.NodeStatus]float64{
+		nodev1.NodeStatus_UP:       8.0 / 11,
+		nodev1.NodeStatus_DOWN:     1.0 / 11,
+		nodev1.NodeStatus_DEGRADED: 1.0 / 11,
+		nodev1.NodeStatus_UNKNOWN:  1.0 / 11,
+	}}
+}
+
+// DefaultFlipStatusWeights is the runner's historical mix: any status equally.
+func DefaultFlipStatusWeights() *StatusWeights {
+	return &StatusWeights{weights: map[nodev1.NodeStatus]float64{
+		nodev1.NodeStatus_UP:       0.25,
+		nodev1.NodeStatus_DOWN:     0.25,
+		nodev1.NodeStatus_DEGRADED: 0.25,
+		nodev1.NodeStatus_UNKNOWN:  0.25,
+	}}
+}
+
+// ParseStatusWeights parses a spec such as "up=0.7,degraded=0.1,down=0.1,unknown=0.1".
+// Statuses left out get weight zero; the weights must sum to 1.0.
+func ParseStatusWeights(spec string) (*StatusWeights, error) {
+	w := &StatusWeights{weights: make(map[nodev1.NodeStatus]float64)}
+
+	sum := 0.0
+	for _, term := range strings.Split(spec, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		parts := strings.SplitN(term, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid status weight %q (want status=weight)", term)
+		}
+
+		name := strings.ToUpper(strings.TrimSpace(parts[0]))
+		value, ok := nodev1.NodeStatus_value[name]
+		status := nodev1.NodeStatus(value)
+		if !ok || status == nodev1.NodeStatus_NODE_STATUS_UNSPECIFIED {
+			return nil, fmt.Errorf("unknown status %q in status weights", parts[0])
+		}
+		if _, dup := w.weights[status]; dup {
+			return nil, fmt.Errorf("status %s listed twice in status weights", name)
+		}
+
+		weight, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight %q for status %s", parts[1], name)
+		}
+
+		w.weights[status] = weight
+		sum += weight
+	}
+
+	if math.Abs(sum-1.0) > statusWeightTolerance {
+		return nil, fmt.Errorf("status weights must sum to 1.0 (got %.2f)", sum)
+	}
+
+	return w, nil
+}
+
+// Pick draws a status.
+func (w *StatusWeights) Pick(rng *rand.Rand) nodev1.NodeStatus {
+	return w.pick(rng, nodev1.NodeStatus_NODE_STATUS_UNSPECIFIED)
+}
+
+// PickOther draws a status different from current, renormalizing the
+// remaining weights. It returns current if no other status has weight.
+func (w *StatusWeights) PickOther(rng *rand.Rand, current nodev1.NodeStatus) nodev1.NodeStatus {
+	return w.pick(rng, current)
+}
+
+func (w *StatusWeights) pick(rng *rand.Rand, exclude nodev1.NodeStatus) nodev1.NodeStatus {
+	total := 0.0
+	for _, status := range statusOrder {
+		if status != exclude {
+			total += w.weights[status]
+		}
+	}
+	if total == 0 {
+		return exclude
+	}
+
+	roll := rng.Float64() * total
+	last := exclude
+	for _, status := range statusOrder {
+		weight := w.weights[status]
+		if status == exclude || weight == 0 {
+			continue
+		}
+		if roll < weight {
+			return status
+		}
+		roll -= weight
+		last = status
+	}
+
+	// Floating point leftovers land on the last eligible status
+	return last
+}
+
+// typeOrder fixes the iteration order so picks are reproducible for a seed.
+var typeOrder = []nodev1.NodeType{
+	nodev1.NodeType_BAREMETAL,
+	nodev1.NodeType_VM,
+	nodev1.NodeType_CONTAINER,
+}
+
+// TypeWeights biases which node types the runner operates on.
+type TypeWeights struct {
+	weights map[nodev1.NodeType]float64
+}
+
+// ParseTypeWeights parses a spec such as "container=0.8,vm=0.2". Types left
+// out get weight zero and are never operated on; the weights must sum to 1.0.
+func ParseTypeWeights(spec string) (*TypeWeights, error) {
+	w := &TypeWeights{weights: make(map[nodev1.NodeType]float64)}
+
+	sum := 0.0
+	for _, term := range strings.Split(spec, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		parts := strings.SplitN(term, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid type weight %q (want type=weight)", term)
+		}
+
+		name := strings.ToUpper(strings.TrimSpace(parts[0]))
+		value, ok := nodev1.NodeType_value[name]
+		nodeType := nodev1.NodeType(value)
+		if !ok || nodeType == nodev1.NodeType_NODE_TYPE_UNSPECIFIED {
+			return nil, fmt.Errorf("unknown node type %q in type weights", parts[0])
+		}
+		if _, dup := w.weights[nodeType]; dup {
+			return nil, fmt.Errorf("type %s listed twice in type weights", name)
+		}
+
+		weight, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight %q for type %s", parts[1], name)
+		}
+
+		w.weights[nodeType] = weight
+		sum += weight
+	}
+
+	if math.Abs(sum-1.0) > statusWeightTolerance {
+		return nil, fmt.Errorf("type weights must sum to 1.0 (got %.2f)", sum)
+	}
+
+	return w, nil
+}
+
+// partitionByType groups nodes by type, keeping their order
+func partitionByType(nodes []*nodev1.Node) map[nodev1.NodeType][]*nodev1.Node {
+	byType := make(map[nodev1.NodeType][]*nodev1.Node)
+	for _, node := range nodes {
+		byType[node.Type] = append(byType[node.Type], node)
+	}
+	return byType
+}
+
+// PickNode draws a type by weight, renormalized over the types that have
+// nodes, then one of its nodes uniformly. It returns nil when no weighted
+// type has any node.
+func (w *TypeWeights) PickNode(rng *rand.Rand, byType map[nodev1.NodeType][]*nodev1.Node) *nodev1.Node {
+	total := 0.0
+	for _, nodeType := range typeOrder {
+		if len(byType[nodeType]) > 0 {
+			total += w.weights[nodeType]
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	roll := rng.Float64() * total
+	var last []*nodev1.Node
+	for _, nodeType := range typeOrder {
+		nodes, weight := byType[nodeType], w.weights[nodeType]
+		if len(nodes) == 0 || weight == 0 {
+			continue
+		}
+		if roll < weight {
+			return nodes[rng.Intn(len(nodes))]
+		}
+		roll -= weight
+		last = nodes
+	}
+
+	// Floating point leftovers land on the last eligible type
+	return last[rng.Intn(len(last))]
+}
\ No newline at end of file
@@ -0,0 +1,109 @@
+package sim
+
+import (
+	"math/rand"
+	"testing"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatusWeights(t *testing.T) {
+	w, err := ParseStatusWeights("up=0.5, DOWN=0.4,degraded=0.1")
+	require.NoError(t, err)
+	assert.Equal(t, 0.4, w.weights[nodev1.NodeStatus_DOWN])
+	assert.Zero(t, w.weights[nodev1.NodeStatus_UNKNOWN])
+
+	for _, spec := range []string{
+		"up=0.5,down=0.4",           // sums to 0.9
+		"up=1.0,bogus=0",            // unknown status
+		"up=0.5,up=0.5",             // duplicate
+		"up=-0.5,down=1.5",          // negative
+		"up",                        // missing weight
+		"node_status_unspecified=1", // not a real status
+	} {
+		_, err := ParseStatusWeights(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestStatusWeightsPickFollowsWeights(t *testing.T) {
+	w, err := ParseStatusWeights("up=0.6,down=0.4")
+	require.NoError(t, err)
+
+	rng := rand.New(rand.NewSource(1))
+	counts := make(map[nodev1.NodeStatus]int)
+	for i := 0; i < 10000; i++ {
+		counts[w.Pick(rng)]++
+	}
+
+	assert.InDelta(t, 6000, counts[nodev1.NodeStatus_UP], 300)
+	assert.InDelta(t, 4000, counts[nodev1.NodeStatus_DOWN], 300)
+	assert.Zero(t, counts[nodev1.NodeStatus_DEGRADED])
+	assert.Zero(t, counts[nodev1.NodeStatus_UNKNOWN])
+}
+
+func TestStatusWeightsPickOtherNeverReturnsCurrent(t *testing.T) {
+	w, err := ParseStatusWeights("up=0.9,down=0.1")
+	require.NoError(t, err)
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		assert.Equal(t, nodev1.NodeStatus_DOWN, w.PickOther(rng, nodev1.NodeStatus_UP))
+	}
+
+	// Nothing else to flip to
+	only, err := ParseStatusWeights("up=1")
+	require.NoError(t, err)
+	assert.Equal(t, nodev1.NodeStatus_UP, only.PickOther(rng, nodev1.NodeStatus_UP))
+}
+
+func TestParseTypeWeights(t *testing.T) {
+	w, err := ParseTypeWeights("Container=0.8, vm=0.2")
+	require.NoError(t, err)
+	assert.Equal(t, 0.8, w.weights[nodev1.NodeType_CONTAINER])
+	assert.Zero(t, w.weights[nodev1.NodeType_BAREMETAL])
+
+	for _, spec := range []string{
+		"vm=0.5",                  // sums to 0.5
+		"vm=1,pod=0",              // unknown type
+		"vm=0.5,vm=0.5",           // duplicate
+		"node_type_unspecified=1", // not a real type
+		"vm",                      // missing weight
+	} {
+		_, err := ParseTypeWeights(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestTypeWeightsPickNode(t *testing.T) {
+	var nodes []*nodev1.Node
+	for i := 0; i < 6; i++ {
+		nodes = append(nodes,
+			&nodev1.Node{Id: "bm", Type: nodev1.NodeType_BAREMETAL},
+			&nodev1.Node{Id: "vm", Type: nodev1.NodeType_VM},
+			&nodev1.Node{Id: "ct", Type: nodev1.NodeType_CONTAINER})
+	}
+	byType := partitionByType(nodes)
+
+	w, err := ParseTypeWeights("container=0.9,vm=0.1")
+	require.NoError(t, err)
+
+	rng := rand.New(rand.NewSource(1))
+	counts := make(map[nodev1.NodeType]int)
+	for i := 0; i < 10000; i++ {
+		counts[w.PickNode(rng, byType).Type]++
+	}
+	assert.InDelta(t, 9000, counts[nodev1.NodeType_CONTAINER], 300)
+	assert.InDelta(t, 1000, counts[nodev1.NodeType_VM], 300)
+	assert.Zero(t, counts[nodev1.NodeType_BAREMETAL])
+
+	// Without containers the VMs take every operation
+	delete(byType, nodev1.NodeType_CONTAINER)
+	assert.Equal(t, nodev1.NodeType_VM, w.PickNode(rng, byType).Type)
+
+	// No weighted type has nodes left
+	delete(byType, nodev1.NodeType_VM)
+	assert.Nil(t, w.PickNode(rng, byType))
+}
\ No newline at end of file
@@ -10,21 +10,48 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
 	"github.com/melkior/nodestatus/internal/data"
 	"github.com/melkior/nodestatus/internal/logging"
+	"github.com/melkior/nodestatus/internal/tui/export"
+	"github.com/melkior/nodestatus/internal/tui/theme"
 	"github.com/melkior/nodestatus/internal/tui/views"
 	"github.com/melkior/nodestatus/pkg/grpcclient"
 )
 
 // Config holds the TUI configuration
 type Config struct {
-	BackendAddr   string
-	BackendToken  string
-	FPS           int
-	ChartsRefresh time.Duration
-	WindowSecs    int
+	BackendAddr    string
+	BackendToken   string
+	FPS            int
+	ChartsRefresh  time.Duration
+	WindowSecs     int
+	SampleInterval time.Duration // Aggregator sample interval (default 1s); WindowSecs stays the history length
+	LabelColumn    string        // Label key shown as an extra list column, empty to hide
+	MinWidth       int           // Below this size a notice replaces the layout (default 80x24)
+	MinHeight      int
+	MaxReconnects  int           // Stream reconnect attempts before giving up (0 keeps the consumer default)
+	LogSampleRate  float64       // Fraction of events shown in the logs view (0 shows all); charts count every event
+	PollInterval   time.Duration // ListNodes polling while WatchEvents is down (default 5s, negative disables)
+	EventLogPath   string        // Record logged events to this JSON lines file from startup, empty to start idle
+	KeymapPath     string        // JSON file of action → keys overrides merged over the default bindings
+	Theme          string        // Color theme, "dark" (default) or "light"; the theme key cycles it at runtime
 }
 
+// defaultPollInterval is how often nodes are polled while the event stream is
+// unavailable
+const defaultPollInterval = 5 * time.Second
+
+// logEventsBuffer is how many sampled events may wait for Update before
+// dispatchEvent drops them
+const logEventsBuffer = 1000
+
+// Default minimum terminal size, matching the documented requirements
+const (
+	defaultMinWidth  = 80
+	defaultMinHeight = 24
+)
+
 // Tab represents a view tab
 type Tab int
 
@@ -46,14 +73,25 @@ type Model struct {
 	detailsView *views.DetailsView
 	logsView    *views.LogsView
 	chartsView  *views.ChartsView
+	batchView   *views.BatchResultsView
 
 	// Data
 	aggregator     *data.Aggregator
+	logSampler     *data.EventSampler
+	logEvents      chan *data.Event // Sampled events on their way from the consumer's worker to Update
+	eventLog       *export.EventLog // Open while the logs view is being recorded to disk
 	streamConsumer interface {
 		Start(context.Context) error
 		Stop()
 		Events() <-chan *data.Event
 		Errors() <-chan error
+		Status() <-chan data.ReconnectStatus
+		State() data.ConnState
+		Dropped() uint64
+	}
+	// batcher applies batch actions; nil in mock mode
+	batcher interface {
+		BatchUpdateStatus(ctx context.Context, updates []*nodev1.UpdateStatusRequest) ([]*nodev1.BatchUpdateStatusResult, error)
 	}
 
 	// UI state
@@ -61,25 +99,50 @@ type Model struct {
 	tabs         []string
 	help         help.Model
 	keys         keyMap
+	theme        theme.Theme
+	styles       styles
 	width        int
 	height       int
 	err          error
 	quitting     bool
+	reconnect    data.ReconnectStatus // Latest status reported by the stream consumer
+
+	// Aggregator generations the views were last refreshed at
+	nodesGen    uint64
+	snapshotGen uint64
+
+	// Transient notification shown above the help line
+	toast        string
+	toastIsError bool
+	toastUntil   time.Time
 }
 
 // keyMap defines all key bindings
 type keyMap struct {
 	Up        key.Binding
 	Down      key.Binding
+	Top       key.Binding
+	Bottom    key.Binding
 	Left      key.Binding
 	Right     key.Binding
 	Charts    key.Binding
 	Filter    key.Binding
 	Reset     key.Binding
+	Export    key.Binding
+	Drift     key.Binding
+	Reconcile key.Binding
+	Labels    key.Binding
+	Selector  key.Binding
+	LogType   key.Binding
+	LogNode   key.Binding
+	LogRecord key.Binding
+	Theme     key.Binding
 	Tab       key.Binding
 	Enter     key.Binding
 	Help      key.Binding
 	Quit      key.Binding
+
+	viewKeys map[string]string // Pressed key → key the views handle, "" to withhold it
 }
 
 // ShortHelp returns short help
@@ -90,10 +153,13 @@ func (k keyMap) ShortHelp() []key.Binding {
 // FullHelp returns full help
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Up, k.Down, k.Left, k.Right},
+		{k.Up, k.Down, k.Top, k.Bottom},
+		{k.Left, k.Right},
 		{k.Tab, k.Enter, k.Charts},
-		{k.Filter, k.Reset},
-		{k.Help, k.Quit},
+		{k.Filter, k.Reset, k.Export},
+		{k.Drift, k.Reconcile, k.Labels, k.Selector},
+		{k.LogType, k.LogNode, k.LogRecord},
+		{k.Theme, k.Help, k.Quit},
 	}
 }
 
@@ -106,6 +172,14 @@ var defaultKeys = keyMap{
 		key.WithKeys("down", "j"),
 		key.WithHelp("↓/j", "down"),
 	),
+	Top: key.NewBinding(
+		key.WithKeys("home", "g"),
+		key.WithHelp("home/g", "top"),
+	),
+	Bottom: key.NewBinding(
+		key.WithKeys("end", "G"),
+		key.WithHelp("end/G", "bottom"),
+	),
 	Left: key.NewBinding(
 		key.WithKeys("left", "h"),
 		key.WithHelp("←/h", "prev tab"),
@@ -126,6 +200,42 @@ var defaultKeys = keyMap{
 		key.WithKeys("r"),
 		key.WithHelp("r", "reset filters"),
 	),
+	Export: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "export list"),
+	),
+	Drift: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "drift only"),
+	),
+	Reconcile: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "reconcile drift"),
+	),
+	Labels: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "label column"),
+	),
+	Selector: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "label selector"),
+	),
+	LogType: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "log event type"),
+	),
+	LogNode: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "log selected node"),
+	),
+	LogRecord: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "record log to file"),
+	),
+	Theme: key.NewBinding(
+		key.WithKeys("T"),
+		key.WithHelp("T", "switch theme"),
+	),
 	Tab: key.NewBinding(
 		key.WithKeys("tab"),
 		key.WithHelp("tab", "next tab"),
@@ -148,8 +258,19 @@ var defaultKeys = keyMap{
 
 type tickMsg time.Time
 
+// eventMsg carries the sampled events queued for the logs view
 type eventMsg struct {
-	event *data.Event
+	events []*data.Event
+}
+
+// batchResultMsg carries the per-node outcome of a batch operation
+type batchResultMsg struct {
+	result *data.BatchResult
+}
+
+// reconnectMsg carries a reconnect status from the stream consumer
+type reconnectMsg struct {
+	status data.ReconnectStatus
 }
 
 type errorMsg struct {
@@ -160,18 +281,39 @@ type errorMsg struct {
 // NewModel creates a new TUI model
 func NewModel(config Config) (*Model, error) {
 	logging.Debug("Creating new TUI model with config: %+v", config)
+	if config.MinWidth <= 0 {
+		config.MinWidth = defaultMinWidth
+	}
+	if config.MinHeight <= 0 {
+		config.MinHeight = defaultMinHeight
+	}
+	if config.PollInterval == 0 {
+		config.PollInterval = defaultPollInterval
+	}
+	keys, err := loadKeyMap(config.KeymapPath)
+	if err != nil {
+		return nil, err
+	}
+	initialTheme, err := theme.ByName(config.Theme)
+	if err != nil {
+		return nil, err
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// Create aggregator
 	logging.Debug("Creating data aggregator with %d seconds window", config.WindowSecs)
-	aggregator := data.NewAggregator(config.WindowSecs)
+	aggregator := newAggregator(config.WindowSecs, config.SampleInterval)
 
 	// Create views
 	logging.Debug("Creating TUI views...")
 	listView := views.NewListView()
+	listView.SetLabelColumn(config.LabelColumn)
 	detailsView := views.NewDetailsView()
 	logsView := views.NewLogsView(1000)
+	logSampler := data.NewEventSampler(config.LogSampleRate)
+	logsView.SetSampleRate(logSampler.Rate())
 	chartsView := views.NewChartsView(aggregator)
+	batchView := views.NewBatchResultsView()
 
 	// Create model
 	m := &Model{
@@ -182,11 +324,22 @@ func NewModel(config Config) (*Model, error) {
 		detailsView: detailsView,
 		logsView:    logsView,
 		chartsView:  chartsView,
+		batchView:   batchView,
 		aggregator:  aggregator,
+		logSampler:  logSampler,
+		logEvents:   make(chan *data.Event, logEventsBuffer),
 		activeTab:   TabList,
 		tabs:        []string{"List", "Details", "Logs", "Charts"},
 		help:        help.New(),
-		keys:        defaultKeys,
+		keys:        keys,
+	}
+	m.setTheme(initialTheme)
+
+	if config.EventLogPath != "" {
+		if err := m.startRecording(config.EventLogPath); err != nil {
+			cancel()
+			return nil, err
+		}
 	}
 
 	logging.Debug("TUI model created successfully")
@@ -205,6 +358,8 @@ func (m *Model) Init() tea.Cmd {
 	logging.Debug("Setting up initial commands...")
 	return tea.Batch(
 		m.tick(),
+		m.waitForReconnect(),
+		m.waitForLogEvents(),
 		tea.EnterAltScreen,
 	)
 }
@@ -212,11 +367,38 @@ func (m *Model) Init() tea.Cmd {
 // Update handles messages
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
+	// What the active view gets, nil when a key was rebound away from it
+	viewMsg := msg
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		logging.Debug("Key pressed: %s", msg.String())
 
+		translated, ok := m.keys.viewKey(msg)
+		if ok {
+			viewMsg = translated
+		} else {
+			viewMsg = nil
+		}
+
+		// The batch results panel is modal until dismissed
+		if m.batchView.Visible() {
+			if msg.String() == "ctrl+c" {
+				m.quitting = true
+				m.cancel()
+				return m, tea.Quit
+			}
+			if viewMsg == nil {
+				return m, nil
+			}
+			return m, m.batchView.Update(viewMsg)
+		}
+
+		// While the list is editing a selector, every key belongs to the input
+		if m.activeTab == TabList && m.listView.Editing() {
+			return m, m.listView.Update(msg)
+		}
+
 		switch {
 		case key.Matches(msg, m.keys.Quit):
 			m.quitting = true
@@ -242,6 +424,33 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
+		case key.Matches(msg, m.keys.Export):
+			m.exportList()
+
+		case key.Matches(msg, m.keys.Reconcile):
+			if m.activeTab == TabList {
+				cmds = append(cmds, m.reconcileDrift())
+			}
+
+		case key.Matches(msg, m.keys.LogNode):
+			if m.activeTab == TabLogs {
+				// Toggle between the node selected in the list and all nodes
+				if m.logsView.NodeFilter() != nil {
+					m.logsView.SetNodeFilter(nil)
+				} else if node := m.listView.GetSelectedNode(); node != nil {
+					m.logsView.SetNodeFilter(node)
+				}
+			}
+
+		case key.Matches(msg, m.keys.LogRecord):
+			if m.activeTab == TabLogs {
+				m.toggleRecording()
+			}
+
+		case key.Matches(msg, m.keys.Theme):
+			m.setTheme(theme.Next(m.theme))
+			m.showToast(fmt.Sprintf("Theme: %s", m.theme.Name), false)
+
 		case key.Matches(msg, m.keys.Help):
 			m.help.ShowAll = !m.help.ShowAll
 		}
@@ -255,14 +464,50 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.detailsView.Update(msg)
 		m.logsView.Update(msg)
 		m.chartsView.Update(msg)
+		m.batchView.Update(msg)
+
+	case batchResultMsg:
+		m.showBatchResult(msg.result)
+
+	case eventMsg:
+		for _, e := range msg.events {
+			m.logsView.AddEvent(e)
+		}
+		cmds = append(cmds, m.waitForLogEvents())
+
+	case reconnectMsg:
+		if msg.status.Connected && m.reconnect.Attempt > 0 {
+			m.showToast("Reconnected to backend", false)
+		}
+		// The consumer only reports Connected once it has resynced, so an
+		// error from the outage is stale by then
+		if msg.status.Connected {
+			m.err = nil
+		}
+		m.reconnect = msg.status
+		cmds = append(cmds, m.waitForReconnect())
 
 	case tickMsg:
-		// Update views with latest data
-		nodes := m.aggregator.GetNodes()
-		m.listView.SetNodes(nodes)
+		// Update views with latest data, skipping the copies and the table
+		// rebuild while nothing changed
+		if nodes, gen, changed := m.aggregator.GetNodesIfChanged(m.nodesGen); changed {
+			m.nodesGen = gen
+			m.listView.SetNodes(nodes)
+			// Keep the inspected node current so details never lag the list
+			if id := m.detailsView.NodeID(); id != "" {
+				node, _ := m.aggregator.GetNode(id)
+				m.detailsView.RefreshNode(node)
+			}
+		}
 		// Update charts with latest snapshot
-		snapshot := m.aggregator.Snapshot()
-		m.chartsView.SetSnapshot(snapshot)
+		if snapshot, gen, changed := m.aggregator.SnapshotIfChanged(m.snapshotGen); changed {
+			m.snapshotGen = gen
+			m.chartsView.SetSnapshot(snapshot)
+		}
+
+		if m.toast != "" && time.Now().After(m.toastUntil) {
+			m.toast = ""
+		}
 
 		// Continue ticking
 		cmds = append(cmds, m.tick())
@@ -270,24 +515,27 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	// Update active view
+	if viewMsg == nil {
+		return m, tea.Batch(cmds...)
+	}
 	switch m.activeTab {
 	case TabList:
-		cmd := m.listView.Update(msg)
+		cmd := m.listView.Update(viewMsg)
 		if cmd != nil {
 			cmds = append(cmds, cmd)
 		}
 	case TabDetails:
-		cmd := m.detailsView.Update(msg)
+		cmd := m.detailsView.Update(viewMsg)
 		if cmd != nil {
 			cmds = append(cmds, cmd)
 		}
 	case TabLogs:
-		cmd := m.logsView.Update(msg)
+		cmd := m.logsView.Update(viewMsg)
 		if cmd != nil {
 			cmds = append(cmds, cmd)
 		}
 	case TabCharts:
-		cmd := m.chartsView.Update(msg)
+		cmd := m.chartsView.Update(viewMsg)
 		if cmd != nil {
 			cmds = append(cmds, cmd)
 		}
@@ -302,31 +550,62 @@ func (m *Model) View() string {
 		return ""
 	}
 
+	// The view math goes negative on tiny terminals, so show a notice instead
+	// until the window is enlarged. Size is unknown until the first resize.
+	if m.width > 0 && (m.width < m.config.MinWidth || m.height < m.config.MinHeight) {
+		return m.renderTooSmall()
+	}
+
 	var b strings.Builder
 
 	// Render tabs
 	b.WriteString(m.renderTabs())
 	b.WriteString("\n\n")
 
-	// Render active view
-	switch m.activeTab {
-	case TabList:
-		b.WriteString(m.listView.View())
-	case TabDetails:
-		b.WriteString(m.detailsView.View())
-	case TabLogs:
-		b.WriteString(m.logsView.View())
-	case TabCharts:
-		b.WriteString(m.chartsView.View())
+	// Render active view, or the batch results panel in its place
+	if m.batchView.Visible() {
+		b.WriteString(m.batchView.View())
+	} else {
+		switch m.activeTab {
+		case TabList:
+			b.WriteString(m.listView.View())
+		case TabDetails:
+			b.WriteString(m.detailsView.View())
+		case TabLogs:
+			b.WriteString(m.logsView.View())
+		case TabCharts:
+			b.WriteString(m.chartsView.View())
+		}
 	}
 
 	// Error display
 	if m.err != nil {
-		errorStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("196")).
-			Bold(true)
 		b.WriteString("\n")
-		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+		b.WriteString(m.styles.error.Render(fmt.Sprintf("Error: %v", m.err)))
+	}
+
+	// Reconnect indicator
+	if indicator := m.renderReconnect(time.Now()); indicator != "" {
+		b.WriteString("\n")
+		b.WriteString(indicator)
+	}
+
+	// Events the consumer could not keep up with
+	if m.streamConsumer != nil {
+		if dropped := m.streamConsumer.Dropped(); dropped > 0 {
+			b.WriteString("\n")
+			b.WriteString(m.styles.warning.Render(fmt.Sprintf("⚠ %d events dropped while the event queue was full", dropped)))
+		}
+	}
+
+	// Toast
+	if m.toast != "" {
+		style := m.styles.success
+		if m.toastIsError {
+			style = m.styles.error
+		}
+		b.WriteString("\n")
+		b.WriteString(style.Render(m.toast))
 	}
 
 	// Help
@@ -337,24 +616,230 @@ func (m *Model) View() string {
 	return b.String()
 }
 
+// renderTooSmall renders the notice shown while the terminal is below the minimum size
+func (m *Model) renderTooSmall() string {
+	msg := fmt.Sprintf("Terminal too small (need at least %dx%d, have %dx%d)\nEnlarge the window to continue, or press q to quit.",
+		m.config.MinWidth, m.config.MinHeight, m.width, m.height)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center,
+		m.styles.warning.Render(msg))
+}
+
+// renderReconnect renders the reconnect attempt and countdown to the next
+// retry, or nothing while the stream is connected
+func (m *Model) renderReconnect(now time.Time) string {
+	if m.reconnect.Connected || m.reconnect.Attempt == 0 {
+		return ""
+	}
+
+	remaining := m.reconnect.NextRetry.Sub(now)
+	if remaining < 0 {
+		remaining = 0
+	}
+	attempt := fmt.Sprintf("%d", m.reconnect.Attempt)
+	if m.reconnect.MaxAttempts > 0 {
+		attempt = fmt.Sprintf("%d/%d", m.reconnect.Attempt, m.reconnect.MaxAttempts)
+	}
+	msg := fmt.Sprintf("⟳ Reconnecting: attempt %s, backoff %s, next retry in %s",
+		attempt,
+		m.reconnect.Backoff.Round(100*time.Millisecond),
+		remaining.Round(time.Second))
+	if m.reconnect.PollInterval > 0 {
+		msg += fmt.Sprintf(" (polling every %s)", m.reconnect.PollInterval)
+	}
+	return m.styles.warning.Render(msg)
+}
+
 // renderTabs renders the tab bar
 func (m *Model) renderTabs() string {
 	var tabs []string
 
 	for i, tab := range m.tabs {
 		if Tab(i) == m.activeTab {
-			tabs = append(tabs, activeTabStyle.Render(tab))
+			tabs = append(tabs, m.styles.activeTab.Render(tab))
 		} else {
-			tabs = append(tabs, inactiveTabStyle.Render(tab))
+			tabs = append(tabs, m.styles.inactiveTab.Render(tab))
 		}
 	}
 
+	tabs = append(tabs, "  "+m.renderConnState(time.Now()))
+
 	return lipgloss.JoinHorizontal(
 		lipgloss.Top,
 		tabs...,
 	)
 }
 
+// renderConnState renders the connection indicator of the tab bar, with the
+// time left until the next retry while reconnecting
+func (m *Model) renderConnState(now time.Time) string {
+	state := data.StateDisconnected
+	if m.streamConsumer != nil {
+		state = m.streamConsumer.State()
+	}
+
+	switch state {
+	case data.StateConnected:
+		return m.styles.success.Render("● Connected")
+	case data.StateReconnecting:
+		msg := "⟳ Reconnecting"
+		if remaining := m.reconnect.NextRetry.Sub(now); remaining > 0 {
+			msg += fmt.Sprintf(" (%s)", remaining.Round(time.Second))
+		}
+		return m.styles.warning.Render(msg)
+	default:
+		return m.styles.error.Render("✕ Disconnected")
+	}
+}
+
+// showBatchResult opens the results panel for a batch operation and raises a
+// toast summarizing it, so partial failures are never reported as one generic error
+func (m *Model) showBatchResult(result *data.BatchResult) {
+	if result == nil {
+		return
+	}
+
+	failed := len(result.Failed())
+	succeeded := len(result.Items) - failed
+	logging.Info("%s: %d succeeded, %d failed", result.Operation, succeeded, failed)
+
+	m.batchView.SetResult(result)
+	m.showToast(fmt.Sprintf("%s: %d succeeded, %d failed", result.Operation, succeeded, failed), failed > 0)
+}
+
+// reconcileReason is the status reason recorded by reconcileDrift
+const reconcileReason = "reconciled to desired status from the dashboard"
+
+// batchTimeout bounds a batch action's RPC
+const batchTimeout = 30 * time.Second
+
+// reconcileDrift sets every drifted node in the filtered list to its desired
+// status with one BatchUpdateStatus call. The per-node outcome opens the batch
+// results panel.
+func (m *Model) reconcileDrift() tea.Cmd {
+	var updates []*nodev1.UpdateStatusRequest
+	names := make(map[string]string)
+	for _, node := range m.listView.FilteredNodes() {
+		if node.Drifted() {
+			updates = append(updates, &nodev1.UpdateStatusRequest{Id: node.ID, Status: node.Desired, Reason: reconcileReason})
+			names[node.ID] = node.Name
+		}
+	}
+	if len(updates) == 0 {
+		m.showToast("No drifted nodes to reconcile", false)
+		return nil
+	}
+	if m.batcher == nil {
+		m.showToast("Reconciling needs a backend connection", true)
+		return nil
+	}
+
+	batcher, ctx := m.batcher, m.ctx
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(ctx, batchTimeout)
+		defer cancel()
+		results, err := batcher.BatchUpdateStatus(ctx, updates)
+		return batchResultMsg{result: newBatchResult("Reconcile drift", updates, names, results, err)}
+	}
+}
+
+// newBatchResult turns a BatchUpdateStatus response into per-node results. A
+// failed call fails every item with its error.
+func newBatchResult(operation string, updates []*nodev1.UpdateStatusRequest, names map[string]string, results []*nodev1.BatchUpdateStatusResult, err error) *data.BatchResult {
+	result := &data.BatchResult{Operation: operation}
+	for i, update := range updates {
+		item := data.BatchItemResult{NodeID: update.Id, NodeName: names[update.Id]}
+		switch {
+		case err != nil:
+			item.Error = err.Error()
+		case i >= len(results):
+			item.Error = "no result returned"
+		default:
+			item.Error = results[i].Error
+			if name := results[i].GetNode().GetName(); name != "" {
+				item.NodeName = name
+			}
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result
+}
+
+// exportList writes the filtered/sorted list view nodes to CSV and JSON files
+// in the working directory
+func (m *Model) exportList() {
+	nodes := m.listView.FilteredNodes()
+	now := time.Now()
+
+	var paths []string
+	for _, format := range []export.Format{export.FormatCSV, export.FormatJSON} {
+		path, err := export.ToFile(".", format, nodes, now)
+		if err != nil {
+			logging.Error("Export failed: %v", err)
+			m.showToast(fmt.Sprintf("Export failed: %v", err), true)
+			return
+		}
+		paths = append(paths, path)
+	}
+
+	logging.Info("Exported %d nodes to %s", len(nodes), strings.Join(paths, ", "))
+	m.showToast(fmt.Sprintf("Exported %d nodes to %s", len(nodes), strings.Join(paths, ", ")), false)
+}
+
+// toggleRecording starts teeing the logs view to events-<timestamp>.jsonl in
+// the working directory, or stops and flushes the current recording
+func (m *Model) toggleRecording() {
+	if m.eventLog != nil {
+		path := m.eventLog.Path()
+		if err := m.stopRecording(); err != nil {
+			m.showToast(fmt.Sprintf("Event log %s: %v", path, err), true)
+			return
+		}
+		m.showToast(fmt.Sprintf("Stopped recording events to %s", path), false)
+		return
+	}
+
+	path := export.EventLogPath(".", time.Now())
+	if err := m.startRecording(path); err != nil {
+		m.showToast(err.Error(), true)
+		return
+	}
+	m.showToast(fmt.Sprintf("Recording events to %s", path), false)
+}
+
+// startRecording opens path and attaches it to the logs view
+func (m *Model) startRecording(path string) error {
+	eventLog, err := export.OpenEventLog(path, export.DefaultFlushInterval)
+	if err != nil {
+		logging.Error("Event recording failed: %v", err)
+		return err
+	}
+	logging.Info("Recording events to %s", path)
+	m.eventLog = eventLog
+	m.logsView.SetRecorder(eventLog)
+	return nil
+}
+
+// stopRecording detaches and closes the event log, flushing buffered lines
+func (m *Model) stopRecording() error {
+	if m.eventLog == nil {
+		return nil
+	}
+	m.logsView.SetRecorder(nil)
+	err := m.eventLog.Close()
+	if err != nil {
+		logging.Error("Event log %s: %v", m.eventLog.Path(), err)
+	}
+	m.eventLog = nil
+	return err
+}
+
+// showToast displays a transient notification
+func (m *Model) showToast(text string, isError bool) {
+	m.toast = text
+	m.toastIsError = isError
+	m.toastUntil = time.Now().Add(5 * time.Second)
+}
+
 // tick returns a tick command
 func (m *Model) tick() tea.Cmd {
 	// Ensure reasonable tick rate (max 30 FPS)
@@ -371,6 +856,42 @@ func (m *Model) tick() tea.Cmd {
 	})
 }
 
+// waitForReconnect waits for the next reconnect status from the stream consumer
+func (m *Model) waitForReconnect() tea.Cmd {
+	if m.streamConsumer == nil {
+		return nil
+	}
+	statusChan := m.streamConsumer.Status()
+	return func() tea.Msg {
+		status, ok := <-statusChan
+		if !ok {
+			return nil
+		}
+		return reconnectMsg{status: status}
+	}
+}
+
+// waitForLogEvents waits for sampled events and delivers all those queued by
+// then in one message
+func (m *Model) waitForLogEvents() tea.Cmd {
+	return func() tea.Msg {
+		var events []*data.Event
+		select {
+		case e := <-m.logEvents:
+			events = append(events, e)
+		case <-m.ctx.Done():
+			return nil
+		}
+		for {
+			select {
+			case e := <-m.logEvents:
+				events = append(events, e)
+			default:
+				return eventMsg{events: events}
+			}
+		}
+	}
+}
 
 // startStreaming starts the data streaming
 func (m *Model) startStreaming() {
@@ -381,6 +902,7 @@ func (m *Model) startStreaming() {
 		logging.Info("Using mock data stream consumer")
 		// Use mock stream consumer for testing
 		mockConsumer := data.NewMockStreamConsumer(m.aggregator)
+		mockConsumer.SetEventHandler(m.dispatchEvent)
 		m.streamConsumer = mockConsumer
 
 		if err := mockConsumer.Start(m.ctx); err != nil {
@@ -399,10 +921,18 @@ func (m *Model) startStreaming() {
 			return
 		}
 		logging.Debug("gRPC client created successfully")
+		m.batcher = client
 
 		// Create stream consumer
 		logging.Debug("Creating stream consumer...")
 		consumer := data.NewStreamConsumer(client.NodeService(), m.aggregator)
+		consumer.SetEventHandler(m.dispatchEvent)
+		if m.config.MaxReconnects > 0 {
+			consumer.SetMaxRetries(m.config.MaxReconnects)
+		}
+		if m.config.PollInterval > 0 {
+			consumer.SetPollInterval(m.config.PollInterval)
+		}
 		m.streamConsumer = consumer
 
 		logging.Debug("Starting stream consumer...")
@@ -419,7 +949,8 @@ func (m *Model) startStreaming() {
 	go m.processEventsBackground()
 }
 
-// processEventsBackground processes events in background
+// processEventsBackground watches for stream errors. Events are applied by
+// the consumer's own worker through dispatchEvent.
 func (m *Model) processEventsBackground() {
 	logging.Debug("ProcessEventsBackground goroutine started")
 
@@ -428,53 +959,15 @@ func (m *Model) processEventsBackground() {
 		return
 	}
 
-	// Create separate context for event processing
-	eventCtx := m.ctx
-
-	// Get channels once to avoid potential nil issues
-	logging.Debug("Getting event and error channels from stream consumer")
-	eventChan := m.streamConsumer.Events()
 	errorChan := m.streamConsumer.Errors()
 
-	logging.Debug("Event channel: %v, Error channel: %v", eventChan, errorChan)
-
-	logging.Debug("Starting event processing loop...")
-	eventCount := 0
-	lastLog := time.Now()
-
 	for {
 		select {
-		case <-eventCtx.Done():
+		case <-m.ctx.Done():
 			// Context cancelled, clean shutdown
 			logging.Info("Event processing context cancelled, shutting down")
 			return
 
-		case event, ok := <-eventChan:
-			if !ok {
-				// Channel closed, exit cleanly
-				logging.Info("Event channel closed, exiting event processor")
-				return
-			}
-			logging.Debug("Received event from channel: %v", event)
-			if event != nil {
-				eventCount++
-				logging.Debug("Processing event #%d, type=%v", eventCount, event.Type)
-				if time.Since(lastLog) > 5*time.Second {
-					logging.Debug("Processed %d events so far", eventCount)
-					lastLog = time.Now()
-				}
-
-				// Non-blocking updates
-				go func(e *data.Event) {
-					m.aggregator.HandleEvent(e)
-				}(event)
-
-				// Update logs view in a non-blocking way
-				go func(e *data.Event) {
-					m.logsView.AddEvent(e)
-				}(event)
-			}
-
 		case err, ok := <-errorChan:
 			if !ok {
 				// Channel closed
@@ -482,18 +975,29 @@ func (m *Model) processEventsBackground() {
 				return
 			}
 			if err != nil {
-				// Store error without blocking
 				logging.Error("Stream error received: %v", err)
 				m.err = err
 			}
-
-		default:
-			// Non-blocking check - prevent tight loop
-			time.Sleep(10 * time.Millisecond)
 		}
 	}
 }
 
+// dispatchEvent feeds an event to the aggregator, which counts every event
+// for metrics, and queues the sampled subset for the logs view. It runs on
+// the consumer's worker, so the view itself is only touched in Update.
+func (m *Model) dispatchEvent(e *data.Event) {
+	m.aggregator.HandleEvent(e)
+	if !m.logSampler.Sample() {
+		return
+	}
+	// The log is a sample already; drop rather than stall the aggregator
+	// behind a busy UI
+	select {
+	case m.logEvents <- e:
+	default:
+	}
+}
+
 // Cleanup cleans up resources
 func (m *Model) Cleanup() {
 	m.cancel()
@@ -503,6 +1007,7 @@ func (m *Model) Cleanup() {
 	if m.streamConsumer != nil {
 		m.streamConsumer.Stop()
 	}
+	m.stopRecording()
 }
 
 // Run starts the TUI application
@@ -531,4 +1036,17 @@ func Run(ctx context.Context, config Config) error {
 
 	logging.Info("TUI application finished successfully")
 	return nil
+}
+
+// newAggregator creates the aggregator for a history of windowSecs sampled
+// every interval. A zero interval keeps the per-second default.
+func newAggregator(windowSecs int, interval time.Duration) *data.Aggregator {
+	if interval <= 0 {
+		return data.NewAggregator(windowSecs)
+	}
+	samples := int(time.Duration(windowSecs) * time.Second / interval)
+	if samples < 1 {
+		samples = 1
+	}
+	return data.NewAggregatorWithInterval(samples, interval)
 }
\ No newline at end of file
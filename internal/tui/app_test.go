@@ -0,0 +1,424 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/melkior/nodestatus/internal/data"
+	"github.com/melkior/nodestatus/internal/tui/theme"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestViewShowsNoticeBelowMinimumSize(t *testing.T) {
+	m, err := NewModel(Config{WindowSecs: 60})
+	if err != nil {
+		t.Fatalf("NewModel: %v", err)
+	}
+	defer m.Cleanup()
+
+	m.Update(tea.WindowSizeMsg{Width: 40, Height: 10})
+	view := m.View()
+	if !strings.Contains(view, "Terminal too small (need at least 80x24") {
+		t.Fatalf("expected too-small notice, got:\n%s", view)
+	}
+	if strings.Contains(view, "Total:") {
+		t.Errorf("list layout should not render on a tiny terminal:\n%s", view)
+	}
+
+	// Enlarging the window resumes the normal layout
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	view = m.View()
+	if strings.Contains(view, "Terminal too small") {
+		t.Fatalf("notice should clear once enlarged:\n%s", view)
+	}
+	if !strings.Contains(view, "List") || !strings.Contains(view, "Total:") {
+		t.Errorf("expected the normal list layout, got:\n%s", view)
+	}
+}
+
+func TestViewRespectsConfiguredMinimumSize(t *testing.T) {
+	m, err := NewModel(Config{WindowSecs: 60, MinWidth: 100, MinHeight: 30})
+	if err != nil {
+		t.Fatalf("NewModel: %v", err)
+	}
+	defer m.Cleanup()
+
+	m.Update(tea.WindowSizeMsg{Width: 90, Height: 40})
+	if view := m.View(); !strings.Contains(view, "need at least 100x30") {
+		t.Errorf("expected configured minimum in notice, got:\n%s", view)
+	}
+}
+
+func TestViewShowsReconnectAttemptAndBackoff(t *testing.T) {
+	m, err := NewModel(Config{WindowSecs: 60})
+	if err != nil {
+		t.Fatalf("NewModel: %v", err)
+	}
+	defer m.Cleanup()
+
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.Update(reconnectMsg{status: data.ReconnectStatus{
+		Attempt:     3,
+		MaxAttempts: 10,
+		Backoff:     4 * time.Second,
+		NextRetry:   time.Now().Add(4 * time.Second),
+	}})
+
+	view := m.View()
+	for _, want := range []string{"attempt 3/10", "backoff 4s", "next retry in 4s"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in reconnect indicator, got:\n%s", want, view)
+		}
+	}
+
+	// The countdown tracks the time left until the next retry
+	if got := m.renderReconnect(time.Now().Add(3 * time.Second)); !strings.Contains(got, "next retry in 1s") {
+		t.Errorf("expected countdown to reach 1s, got %q", got)
+	}
+
+	m.Update(reconnectMsg{status: data.ReconnectStatus{Connected: true}})
+	if view := m.View(); strings.Contains(view, "Reconnecting") {
+		t.Errorf("indicator should clear once connected:\n%s", view)
+	}
+}
+
+// stateConsumer reports a fixed connection state; nothing is streamed
+type stateConsumer struct {
+	state data.ConnState
+}
+
+func (c *stateConsumer) Start(context.Context) error         { return nil }
+func (c *stateConsumer) Stop()                               {}
+func (c *stateConsumer) Events() <-chan *data.Event          { return nil }
+func (c *stateConsumer) Errors() <-chan error                { return nil }
+func (c *stateConsumer) Status() <-chan data.ReconnectStatus { return nil }
+func (c *stateConsumer) State() data.ConnState               { return c.state }
+func (c *stateConsumer) Dropped() uint64                     { return 0 }
+
+func TestHeaderShowsConnectionState(t *testing.T) {
+	m, err := NewModel(Config{WindowSecs: 60})
+	if err != nil {
+		t.Fatalf("NewModel: %v", err)
+	}
+	defer m.Cleanup()
+
+	consumer := &stateConsumer{state: data.StateReconnecting}
+	m.streamConsumer = consumer
+	m.err = fmt.Errorf("stream receive error")
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.Update(reconnectMsg{status: data.ReconnectStatus{
+		Attempt:   2,
+		Backoff:   5 * time.Second,
+		NextRetry: time.Now().Add(5 * time.Second),
+	}})
+
+	if got := m.renderConnState(time.Now().Add(2 * time.Second)); !strings.Contains(got, "Reconnecting (3s)") {
+		t.Errorf("expected the retry countdown in the header, got %q", got)
+	}
+
+	// Once reconnected and resynced the outage's error is cleared
+	consumer.state = data.StateConnected
+	m.Update(reconnectMsg{status: data.ReconnectStatus{Connected: true}})
+	view := m.View()
+	if !strings.Contains(view, "Connected") || strings.Contains(view, "Reconnecting") {
+		t.Errorf("expected the header to show Connected, got:\n%s", view)
+	}
+	if m.err != nil || strings.Contains(view, "stream receive error") {
+		t.Errorf("the error should clear once reconnected, got %v", m.err)
+	}
+
+	consumer.state = data.StateDisconnected
+	if view := m.View(); !strings.Contains(view, "Disconnected") {
+		t.Errorf("expected the header to show Disconnected, got:\n%s", view)
+	}
+}
+
+func TestEventSamplingCountsAllEventsButLogsFraction(t *testing.T) {
+	m, err := NewModel(Config{WindowSecs: 60, LogSampleRate: 0.1})
+	if err != nil {
+		t.Fatalf("NewModel: %v", err)
+	}
+	defer m.Cleanup()
+
+	for i := 0; i < 1000; i++ {
+		m.dispatchEvent(&data.Event{
+			Type: nodev1.EventType_CREATED,
+			Node: &data.Node{ID: fmt.Sprintf("node-%d", i), Status: nodev1.NodeStatus_UP},
+		})
+	}
+
+	snap := m.aggregator.Snapshot()
+	if snap.TotalEvents != 1000 || snap.TotalNodes != 1000 {
+		t.Errorf("metrics should count every event, got %d events for %d nodes", snap.TotalEvents, snap.TotalNodes)
+	}
+	// The consumer's worker only queues events; Update adds them to the view
+	if got := m.logsView.EventCount(); got != 0 {
+		t.Errorf("the logs view changed outside Update: %d events", got)
+	}
+	m.Update(m.waitForLogEvents()())
+	if got := m.logsView.EventCount(); got != 100 {
+		t.Errorf("expected 100 sampled events in the logs view, got %d", got)
+	}
+
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.activeTab = TabLogs
+	if view := m.View(); !strings.Contains(view, "[SAMPLED 10%]") {
+		t.Errorf("expected sampling marker in logs header, got:\n%s", view)
+	}
+}
+
+// watchlessBackend serves ListNodes but refuses WatchEvents, like a proxy
+// that blocks streaming
+type watchlessBackend struct {
+	nodev1.UnimplementedNodeServiceServer
+
+	mu    sync.Mutex
+	nodes []*nodev1.Node
+}
+
+func (b *watchlessBackend) ListNodes(ctx context.Context, req *nodev1.ListNodesRequest) (*nodev1.ListNodesResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return &nodev1.ListNodesResponse{Nodes: append([]*nodev1.Node(nil), b.nodes...)}, nil
+}
+
+func (b *watchlessBackend) WatchEvents(req *nodev1.WatchEventsRequest, stream nodev1.NodeService_WatchEventsServer) error {
+	return status.Error(codes.Unavailable, "streaming blocked")
+}
+
+func (b *watchlessBackend) add(n *nodev1.Node) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nodes = append(b.nodes, n)
+}
+
+func TestPollingFallbackPopulatesNodesWhenWatchFails(t *testing.T) {
+	backend := &watchlessBackend{}
+	backend.add(&nodev1.Node{Id: "a", Name: "node-a", Type: nodev1.NodeType_VM, Status: nodev1.NodeStatus_UP})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	nodev1.RegisterNodeServiceServer(srv, backend)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	m, err := NewModel(Config{
+		BackendAddr:  lis.Addr().String(),
+		WindowSecs:   60,
+		PollInterval: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewModel: %v", err)
+	}
+	defer m.Cleanup()
+
+	m.startStreaming()
+	if m.err != nil {
+		t.Fatalf("startStreaming: %v", m.err)
+	}
+
+	// A node added after the initial load can only arrive through polling
+	backend.add(&nodev1.Node{Id: "b", Name: "node-b", Type: nodev1.NodeType_CONTAINER, Status: nodev1.NodeStatus_DOWN})
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(m.aggregator.GetNodes()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected polling to load 2 nodes, got %d", len(m.aggregator.GetNodes()))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case st := <-m.streamConsumer.Status():
+		if st.PollInterval != 20*time.Millisecond || st.MaxAttempts != 0 {
+			t.Errorf("expected unlimited retries while polling every 20ms, got %+v", st)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a reconnect status while polling")
+	}
+	if m.err != nil {
+		t.Errorf("polling fallback should not surface a stream error, got %v", m.err)
+	}
+}
+
+func TestThemeFromConfigAndThemeKey(t *testing.T) {
+	if _, err := NewModel(Config{WindowSecs: 60, Theme: "solarized"}); err == nil {
+		t.Fatal("NewModel should reject an unknown theme")
+	}
+
+	m, err := NewModel(Config{WindowSecs: 60, Theme: "light"})
+	if err != nil {
+		t.Fatalf("NewModel: %v", err)
+	}
+	defer m.Cleanup()
+
+	if m.theme.Name != "light" {
+		t.Fatalf("expected the light theme, got %q", m.theme.Name)
+	}
+	if got := m.styles.error.GetForeground(); got != theme.Light.Error {
+		t.Errorf("error style should use the light palette, got %v", got)
+	}
+
+	// The theme key cycles back to dark for the model and the views
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("T")})
+	if m.theme.Name != "dark" {
+		t.Fatalf("T should switch to the dark theme, got %q", m.theme.Name)
+	}
+	if got := m.styles.error.GetForeground(); got != theme.Dark.Error {
+		t.Errorf("error style should follow the switch, got %v", got)
+	}
+	if !strings.Contains(m.View(), "Theme: dark") {
+		t.Errorf("expected a toast naming the new theme")
+	}
+}
+
+// fakeBatcher records BatchUpdateStatus calls and rejects one node
+type fakeBatcher struct {
+	updates []*nodev1.UpdateStatusRequest
+	reject  string
+}
+
+func (b *fakeBatcher) BatchUpdateStatus(ctx context.Context, updates []*nodev1.UpdateStatusRequest) ([]*nodev1.BatchUpdateStatusResult, error) {
+	b.updates = updates
+	results := make([]*nodev1.BatchUpdateStatusResult, len(updates))
+	for i, u := range updates {
+		if u.Id == b.reject {
+			results[i] = &nodev1.BatchUpdateStatusResult{Id: u.Id, Error: "node not found"}
+			continue
+		}
+		results[i] = &nodev1.BatchUpdateStatusResult{Id: u.Id, Changed: true, Node: &nodev1.Node{Id: u.Id, Status: u.Status}}
+	}
+	return results, nil
+}
+
+func TestReconcileDriftShowsBatchResults(t *testing.T) {
+	m, err := NewModel(Config{WindowSecs: 60})
+	if err != nil {
+		t.Fatalf("NewModel: %v", err)
+	}
+	defer m.Cleanup()
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+
+	batcher := &fakeBatcher{reject: "b"}
+	m.batcher = batcher
+	m.listView.SetNodes([]*data.Node{
+		{ID: "a", Name: "node-a", Status: nodev1.NodeStatus_DOWN, Desired: nodev1.NodeStatus_UP},
+		{ID: "b", Name: "node-b", Status: nodev1.NodeStatus_DEGRADED, Desired: nodev1.NodeStatus_UP},
+		{ID: "c", Name: "node-c", Status: nodev1.NodeStatus_UP, Desired: nodev1.NodeStatus_UP},
+	})
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("R")})
+	if cmd == nil {
+		t.Fatal("expected a command for the batch call")
+	}
+	// The command batches the tick and the reconcile call; find the result
+	var result batchResultMsg
+	for _, msg := range runCmd(cmd) {
+		if r, ok := msg.(batchResultMsg); ok {
+			result = r
+		}
+	}
+	if result.result == nil {
+		t.Fatal("reconcile produced no batch result")
+	}
+
+	if len(batcher.updates) != 2 {
+		t.Fatalf("expected only the 2 drifted nodes to be updated, got %d", len(batcher.updates))
+	}
+	for _, u := range batcher.updates {
+		if u.Status != nodev1.NodeStatus_UP {
+			t.Errorf("node %s set to %s, want its desired status UP", u.Id, u.Status)
+		}
+	}
+
+	m.Update(result)
+	if !m.batchView.Visible() {
+		t.Fatal("batch results panel should open")
+	}
+	failed := result.result.Failed()
+	if len(failed) != 1 || failed[0].NodeID != "b" || failed[0].NodeName != "node-b" {
+		t.Errorf("unexpected failures: %+v", failed)
+	}
+	if !strings.Contains(m.View(), "node not found") {
+		t.Errorf("panel should show the failure reason:\n%s", m.View())
+	}
+}
+
+// runCmd runs cmd and the commands of any tea.BatchMsg it returns
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var msgs []tea.Msg
+		for _, c := range batch {
+			msgs = append(msgs, runCmd(c)...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
+
+func TestRecordingToggledWhileEventsStream(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewModel(Config{WindowSecs: 60, LogSampleRate: 1, EventLogPath: filepath.Join(dir, "0.jsonl")})
+	if err != nil {
+		t.Fatalf("NewModel: %v", err)
+	}
+	defer m.Cleanup()
+
+	const total = 200
+	go func() {
+		for i := 0; i < total; i++ {
+			m.dispatchEvent(&data.Event{
+				Type: nodev1.EventType_CREATED,
+				Node: &data.Node{ID: fmt.Sprintf("node-%d", i), Status: nodev1.NodeStatus_UP},
+			})
+		}
+	}()
+
+	// Switching recorders in Update while the worker dispatches loses nothing
+	for delivered, i := 0, 1; delivered < total; i++ {
+		msg := m.waitForLogEvents()().(eventMsg)
+		m.Update(msg)
+		delivered += len(msg.events)
+		if err := m.stopRecording(); err != nil {
+			t.Fatalf("stopRecording: %v", err)
+		}
+		if err := m.startRecording(filepath.Join(dir, fmt.Sprintf("%d.jsonl", i))); err != nil {
+			t.Fatalf("startRecording: %v", err)
+		}
+	}
+	if err := m.stopRecording(); err != nil {
+		t.Fatalf("stopRecording: %v", err)
+	}
+
+	recorded := 0
+	files, _ := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("read %s: %v", file, err)
+		}
+		recorded += strings.Count(string(content), "\n")
+	}
+	if recorded != total {
+		t.Errorf("expected %d recorded events across the logs, got %d", total, recorded)
+	}
+}
\ No newline at end of file
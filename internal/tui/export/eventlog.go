@@ -0,0 +1,165 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/melkior/nodestatus/internal/data"
+)
+
+// DefaultFlushInterval is how often a running EventLog flushes buffered lines
+const DefaultFlushInterval = time.Second
+
+// EventRecord is the JSON line written for each event
+type EventRecord struct {
+	Timestamp     string   `json:"timestamp"`
+	Type          string   `json:"type"`
+	NodeID        string   `json:"node_id"`
+	NodeName      string   `json:"node_name,omitempty"`
+	ChangedFields []string `json:"changed_fields,omitempty"`
+}
+
+// NewEventRecord converts an event to its JSON line representation
+func NewEventRecord(event *data.Event) EventRecord {
+	rec := EventRecord{
+		Timestamp:     event.Timestamp.UTC().Format(time.RFC3339Nano),
+		Type:          event.Type.String(),
+		ChangedFields: event.ChangedFields,
+	}
+	if event.Node != nil {
+		rec.NodeID = event.Node.ID
+		rec.NodeName = event.Node.Name
+	}
+	return rec
+}
+
+// EventLog appends events to a file as JSON lines. Writes are buffered and
+// flushed every flush interval and on Close, so recording never blocks on disk
+// for each event.
+type EventLog struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	buf    *bufio.Writer
+	enc    *json.Encoder
+	err    error // First write error, reported by Close
+	closed bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// OpenEventLog opens path for appending and starts flushing it every interval
+// (DefaultFlushInterval when zero or negative)
+func OpenEventLog(path string, interval time.Duration) (*EventLog, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %w", err)
+	}
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+
+	buf := bufio.NewWriter(f)
+	l := &EventLog{
+		path: path,
+		file: f,
+		buf:  buf,
+		enc:  json.NewEncoder(buf),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go l.flushLoop(interval)
+	return l, nil
+}
+
+// EventLogPath returns the default file name for a recording started at now
+func EventLogPath(dir string, now time.Time) string {
+	return filepath.Join(dir, fmt.Sprintf("events-%s.jsonl", now.Format("20060102-150405")))
+}
+
+// Path returns the file the log writes to
+func (l *EventLog) Path() string {
+	return l.path
+}
+
+// Record buffers one event as a JSON line. Events recorded after Close are dropped.
+func (l *EventLog) Record(event *data.Event) {
+	if event == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed || l.err != nil {
+		return
+	}
+	if err := l.enc.Encode(NewEventRecord(event)); err != nil {
+		l.err = err
+	}
+}
+
+// Flush writes buffered lines to the file
+func (l *EventLog) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.flushLocked()
+}
+
+func (l *EventLog) flushLocked() error {
+	if l.closed {
+		return l.err
+	}
+	if err := l.buf.Flush(); err != nil && l.err == nil {
+		l.err = err
+	}
+	return l.err
+}
+
+// Close stops the flush timer, flushes remaining lines and closes the file.
+// It returns the first error seen while writing.
+func (l *EventLog) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return l.err
+	}
+	l.closed = true
+	close(l.stop)
+	l.mu.Unlock()
+	<-l.done
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.buf.Flush(); err != nil && l.err == nil {
+		l.err = err
+	}
+	if err := l.file.Close(); err != nil && l.err == nil {
+		l.err = err
+	}
+	return l.err
+}
+
+// flushLoop flushes the buffer on every tick until Close
+func (l *EventLog) flushLoop(interval time.Duration) {
+	defer close(l.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.Flush()
+		}
+	}
+}
\ No newline at end of file
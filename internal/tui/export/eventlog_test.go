@@ -0,0 +1,89 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/melkior/nodestatus/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readEventLines(t *testing.T, path string) []EventRecord {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var records []EventRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec EventRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		records = append(records, rec)
+	}
+	require.NoError(t, scanner.Err())
+	return records
+}
+
+func TestEventLogWritesJSONLines(t *testing.T) {
+	path := EventLogPath(t.TempDir(), time.Date(2024, 5, 1, 12, 30, 45, 0, time.UTC))
+	assert.Equal(t, "events-20240501-123045.jsonl", filepath.Base(path))
+
+	l, err := OpenEventLog(path, time.Hour)
+	require.NoError(t, err)
+
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	l.Record(&data.Event{
+		Type:          nodev1.EventType_UPDATED,
+		Node:          &data.Node{ID: "id-1", Name: "alpha"},
+		ChangedFields: []string{"status"},
+		Timestamp:     ts,
+	})
+	l.Record(&data.Event{Type: nodev1.EventType_DELETED, Node: &data.Node{ID: "id-2"}, Timestamp: ts})
+
+	// Nothing reaches the file until a flush
+	assert.Empty(t, readEventLines(t, path))
+
+	require.NoError(t, l.Flush())
+	records := readEventLines(t, path)
+	require.Len(t, records, 2)
+	assert.Equal(t, EventRecord{
+		Timestamp:     "2024-05-01T12:00:00Z",
+		Type:          "UPDATED",
+		NodeID:        "id-1",
+		NodeName:      "alpha",
+		ChangedFields: []string{"status"},
+	}, records[0])
+	assert.Equal(t, "DELETED", records[1].Type)
+	assert.Equal(t, "id-2", records[1].NodeID)
+
+	// Close flushes the tail and later events are dropped
+	l.Record(&data.Event{Type: nodev1.EventType_CREATED, Node: &data.Node{ID: "id-3"}, Timestamp: ts})
+	require.NoError(t, l.Close())
+	l.Record(&data.Event{Type: nodev1.EventType_CREATED, Node: &data.Node{ID: "id-4"}, Timestamp: ts})
+	require.NoError(t, l.Close())
+
+	records = readEventLines(t, path)
+	require.Len(t, records, 3)
+	assert.Equal(t, "id-3", records[2].NodeID)
+}
+
+func TestEventLogFlushesOnTimer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	l, err := OpenEventLog(path, 10*time.Millisecond)
+	require.NoError(t, err)
+	defer l.Close()
+
+	l.Record(&data.Event{Type: nodev1.EventType_CREATED, Node: &data.Node{ID: "id-1"}, Timestamp: time.Now()})
+
+	assert.Eventually(t, func() bool {
+		return len(readEventLines(t, path)) == 1
+	}, time.Second, 10*time.Millisecond)
+}
\ No newline at end of file
@@ -0,0 +1,138 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/melkior/nodestatus/internal/data"
+)
+
+// Format selects the output encoding
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+)
+
+const timeLayout = time.RFC3339
+
+// Record is the exported representation of a node
+type Record struct {
+	ID       string            `json:"id"`
+	Name     string            `json:"name"`
+	Type     string            `json:"type"`
+	Status   string            `json:"status"`
+	LastSeen string            `json:"last_seen"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
+// NewRecord converts a node to an export record
+func NewRecord(node *data.Node) Record {
+	labels := make(map[string]string, len(node.Labels))
+	for k, v := range node.Labels {
+		labels[k] = v
+	}
+
+	return Record{
+		ID:       node.ID,
+		Name:     node.Name,
+		Type:     node.Type.String(),
+		Status:   node.Status.String(),
+		LastSeen: node.LastSeen.Format(timeLayout),
+		Labels:   labels,
+	}
+}
+
+// WriteCSV writes nodes as CSV, one "label:<key>" column per distinct label key
+func WriteCSV(w io.Writer, nodes []*data.Node) error {
+	labelKeys := collectLabelKeys(nodes)
+
+	header := []string{"id", "name", "type", "status", "last_seen"}
+	for _, k := range labelKeys {
+		header = append(header, "label:"+k)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		rec := NewRecord(node)
+		row := []string{rec.ID, rec.Name, rec.Type, rec.Status, rec.LastSeen}
+		for _, k := range labelKeys {
+			row = append(row, rec.Labels[k])
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes nodes as an indented JSON array
+func WriteJSON(w io.Writer, nodes []*data.Node) error {
+	records := make([]Record, 0, len(nodes))
+	for _, node := range nodes {
+		records = append(records, NewRecord(node))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+// ToFile writes nodes into dir as nodes-<timestamp>.<format> and returns the path
+func ToFile(dir string, format Format, nodes []*data.Node, now time.Time) (string, error) {
+	path := filepath.Join(dir, fmt.Sprintf("nodes-%s.%s", now.Format("20060102-150405"), format))
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create export file: %w", err)
+	}
+
+	switch format {
+	case FormatCSV:
+		err = WriteCSV(f, nodes)
+	case FormatJSON:
+		err = WriteJSON(f, nodes)
+	default:
+		err = fmt.Errorf("unsupported export format: %s", format)
+	}
+
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", err
+	}
+
+	return path, nil
+}
+
+// collectLabelKeys returns the sorted union of label keys across nodes
+func collectLabelKeys(nodes []*data.Node) []string {
+	seen := make(map[string]struct{})
+	for _, node := range nodes {
+		for k := range node.Labels {
+			seen[k] = struct{}{}
+		}
+	}
+
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
\ No newline at end of file
@@ -0,0 +1,75 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/melkior/nodestatus/internal/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testNodes() []*data.Node {
+	seen := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return []*data.Node{
+		{
+			ID:       "id-1",
+			Name:     "alpha",
+			Type:     nodev1.NodeType_VM,
+			Status:   nodev1.NodeStatus_UP,
+			Labels:   map[string]string{"env": "prod", "service": "api"},
+			LastSeen: seen,
+		},
+		{
+			ID:       "id-2",
+			Name:     "beta",
+			Type:     nodev1.NodeType_CONTAINER,
+			Status:   nodev1.NodeStatus_DOWN,
+			Labels:   map[string]string{"datacenter": "eu-central-1"},
+			LastSeen: seen,
+		},
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, testNodes()))
+
+	expected := "id,name,type,status,last_seen,label:datacenter,label:env,label:service\n" +
+		"id-1,alpha,VM,UP,2024-05-01T12:00:00Z,,prod,api\n" +
+		"id-2,beta,CONTAINER,DOWN,2024-05-01T12:00:00Z,eu-central-1,,\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, testNodes()))
+
+	var records []Record
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &records))
+	require.Len(t, records, 2)
+	assert.Equal(t, "alpha", records[0].Name)
+	assert.Equal(t, "VM", records[0].Type)
+	assert.Equal(t, "prod", records[0].Labels["env"])
+	assert.Equal(t, "DOWN", records[1].Status)
+}
+
+func TestToFile(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 5, 1, 12, 30, 45, 0, time.UTC)
+
+	path, err := ToFile(dir, FormatCSV, testNodes(), now)
+	require.NoError(t, err)
+	assert.Contains(t, path, "nodes-20240501-123045.csv")
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "alpha")
+
+	_, err = ToFile(dir, Format("xml"), testNodes(), now)
+	assert.Error(t, err)
+}
\ No newline at end of file
@@ -0,0 +1,183 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyAction is a binding a keymap file can override. view is the key the
+// views handle for the action, empty for actions the model handles itself.
+type keyAction struct {
+	name    string
+	binding func(*keyMap) *key.Binding
+	view    string
+}
+
+var keyActions = []keyAction{
+	{"up", func(k *keyMap) *key.Binding { return &k.Up }, "up"},
+	{"down", func(k *keyMap) *key.Binding { return &k.Down }, "down"},
+	{"top", func(k *keyMap) *key.Binding { return &k.Top }, "home"},
+	{"bottom", func(k *keyMap) *key.Binding { return &k.Bottom }, "end"},
+	{"left", func(k *keyMap) *key.Binding { return &k.Left }, ""},
+	{"right", func(k *keyMap) *key.Binding { return &k.Right }, ""},
+	{"charts", func(k *keyMap) *key.Binding { return &k.Charts }, ""},
+	{"filter", func(k *keyMap) *key.Binding { return &k.Filter }, "f"},
+	{"reset", func(k *keyMap) *key.Binding { return &k.Reset }, "r"},
+	{"export", func(k *keyMap) *key.Binding { return &k.Export }, ""},
+	{"drift", func(k *keyMap) *key.Binding { return &k.Drift }, "d"},
+	{"reconcile", func(k *keyMap) *key.Binding { return &k.Reconcile }, ""},
+	{"labels", func(k *keyMap) *key.Binding { return &k.Labels }, "L"},
+	{"selector", func(k *keyMap) *key.Binding { return &k.Selector }, "/"},
+	{"log_type", func(k *keyMap) *key.Binding { return &k.LogType }, "t"},
+	{"log_node", func(k *keyMap) *key.Binding { return &k.LogNode }, ""},
+	{"log_record", func(k *keyMap) *key.Binding { return &k.LogRecord }, ""},
+	{"theme", func(k *keyMap) *key.Binding { return &k.Theme }, ""},
+	{"tab", func(k *keyMap) *key.Binding { return &k.Tab }, ""},
+	{"enter", func(k *keyMap) *key.Binding { return &k.Enter }, ""},
+	{"help", func(k *keyMap) *key.Binding { return &k.Help }, ""},
+	{"quit", func(k *keyMap) *key.Binding { return &k.Quit }, ""},
+}
+
+// keyGlyphs shortens key names in the help line
+var keyGlyphs = map[string]string{
+	"up":    "↑",
+	"down":  "↓",
+	"left":  "←",
+	"right": "→",
+}
+
+// loadKeyMap merges the action → keys overrides in the JSON file at path over
+// defaultKeys. An empty path returns the defaults.
+func loadKeyMap(path string) (keyMap, error) {
+	k := defaultKeys
+	if path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return keyMap{}, fmt.Errorf("failed to read keymap: %w", err)
+		}
+		var overrides map[string][]string
+		if err := json.Unmarshal(raw, &overrides); err != nil {
+			return keyMap{}, fmt.Errorf("invalid keymap %s: %w", path, err)
+		}
+		if err := k.apply(overrides); err != nil {
+			return keyMap{}, fmt.Errorf("invalid keymap %s: %w", path, err)
+		}
+		if err := k.validate(); err != nil {
+			return keyMap{}, fmt.Errorf("invalid keymap %s: %w", path, err)
+		}
+	}
+	k.buildViewKeys()
+	return k, nil
+}
+
+// apply replaces the keys of each overridden action, keeping its description
+func (k *keyMap) apply(overrides map[string][]string) error {
+	actions := make(map[string]keyAction, len(keyActions))
+	for _, a := range keyActions {
+		actions[a.name] = a
+	}
+
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		a, ok := actions[name]
+		if !ok {
+			return fmt.Errorf("unknown action %q", name)
+		}
+		keys := overrides[name]
+		if len(keys) == 0 {
+			return fmt.Errorf("action %q has no keys", name)
+		}
+		help := make([]string, len(keys))
+		for i, kk := range keys {
+			if kk == "" {
+				return fmt.Errorf("action %q has an empty key", name)
+			}
+			help[i] = kk
+			if glyph, ok := keyGlyphs[kk]; ok {
+				help[i] = glyph
+			}
+		}
+
+		b := a.binding(k)
+		*b = key.NewBinding(
+			key.WithKeys(keys...),
+			key.WithHelp(strings.Join(help, "/"), b.Help().Desc),
+		)
+	}
+	return nil
+}
+
+// validate rejects a key bound to more than one action
+func (k *keyMap) validate() error {
+	owner := make(map[string]string)
+	for _, a := range keyActions {
+		for _, kk := range a.binding(k).Keys() {
+			if other, ok := owner[kk]; ok && other != a.name {
+				return fmt.Errorf("key %q is bound to both %s and %s", kk, other, a.name)
+			}
+			owner[kk] = a.name
+		}
+	}
+	return nil
+}
+
+// buildViewKeys maps every key of a view-handled action to the key the views
+// expect, and swallows default keys that were rebound away so they no longer
+// reach the views
+func (k *keyMap) buildViewKeys() {
+	k.viewKeys = make(map[string]string)
+	for _, a := range keyActions {
+		if a.view == "" {
+			continue
+		}
+		for _, kk := range a.binding(&defaultKeys).Keys() {
+			k.viewKeys[kk] = ""
+		}
+	}
+	for _, a := range keyActions {
+		if a.view == "" {
+			continue
+		}
+		for _, kk := range a.binding(k).Keys() {
+			k.viewKeys[kk] = a.view
+		}
+	}
+}
+
+// viewKey translates a key press into the one the views handle. ok is false
+// when the key was rebound away from a view action and must not reach them.
+func (k keyMap) viewKey(msg tea.KeyMsg) (tea.KeyMsg, bool) {
+	target, found := k.viewKeys[msg.String()]
+	switch {
+	case !found:
+		return msg, true
+	case target == "":
+		return msg, false
+	case target == msg.String():
+		return msg, true
+	}
+
+	switch target {
+	case "up":
+		return tea.KeyMsg{Type: tea.KeyUp}, true
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}, true
+	case "home":
+		return tea.KeyMsg{Type: tea.KeyHome}, true
+	case "end":
+		return tea.KeyMsg{Type: tea.KeyEnd}, true
+	default:
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(target)}, true
+	}
+}
\ No newline at end of file
@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func writeKeymap(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keymap.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write keymap: %v", err)
+	}
+	return path
+}
+
+func runes(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestKeymapOverridesDefaults(t *testing.T) {
+	path := writeKeymap(t, `{"charts": ["C"], "up": ["up", "i"], "log_type": ["y"]}`)
+	k, err := loadKeyMap(path)
+	if err != nil {
+		t.Fatalf("loadKeyMap: %v", err)
+	}
+
+	if !key.Matches(runes("C"), k.Charts) || key.Matches(runes("c"), k.Charts) {
+		t.Errorf("charts should be bound to C only, got %v", k.Charts.Keys())
+	}
+	if got := k.Charts.Help(); got.Key != "C" || got.Desc != "charts" {
+		t.Errorf("charts help = %+v", got)
+	}
+	if got := k.Up.Help().Key; got != "↑/i" {
+		t.Errorf("up help key = %q", got)
+	}
+	// Untouched actions keep their defaults
+	if !key.Matches(runes("q"), k.Quit) {
+		t.Errorf("quit lost its default keys: %v", k.Quit.Keys())
+	}
+
+	// Remapped keys reach the views as the keys they handle; rebound
+	// defaults don't reach them at all
+	if msg, ok := k.viewKey(runes("i")); !ok || msg.Type != tea.KeyUp {
+		t.Errorf("i should translate to up, got %v %v", msg, ok)
+	}
+	if msg, ok := k.viewKey(runes("y")); !ok || msg.String() != "t" {
+		t.Errorf("y should translate to t, got %v %v", msg, ok)
+	}
+	for _, s := range []string{"k", "t"} {
+		if _, ok := k.viewKey(runes(s)); ok {
+			t.Errorf("%s was rebound and should not reach the views", s)
+		}
+	}
+	if msg, ok := k.viewKey(runes("a")); !ok || msg.String() != "a" {
+		t.Errorf("keys outside the keymap should pass through, got %v %v", msg, ok)
+	}
+}
+
+func TestDefaultKeymapJumpsWithVimKeys(t *testing.T) {
+	k, err := loadKeyMap("")
+	if err != nil {
+		t.Fatalf("loadKeyMap: %v", err)
+	}
+	if msg, ok := k.viewKey(runes("G")); !ok || msg.Type != tea.KeyEnd {
+		t.Errorf("G should translate to end, got %v %v", msg, ok)
+	}
+	if msg, ok := k.viewKey(runes("f")); !ok || msg.String() != "f" {
+		t.Errorf("f should pass through unchanged, got %v %v", msg, ok)
+	}
+}
+
+func TestKeymapRejectsInvalidFiles(t *testing.T) {
+	tests := map[string]string{
+		"duplicate":  `{"charts": ["f"]}`,
+		"unknown":    `{"teleport": ["z"]}`,
+		"no keys":    `{"quit": []}`,
+		"empty key":  `{"quit": [""]}`,
+		"not json":   `charts = ["C"]`,
+		"wrong type": `{"quit": "q"}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadKeyMap(writeKeymap(t, content)); err == nil {
+				t.Errorf("expected an error for %s", content)
+			}
+		})
+	}
+
+	_, err := loadKeyMap(writeKeymap(t, `{"charts": ["j"]}`))
+	if err == nil || !strings.Contains(err.Error(), `key "j" is bound to both down and charts`) {
+		t.Errorf("expected the clashing actions in the error, got %v", err)
+	}
+
+	if _, err := NewModel(Config{WindowSecs: 60, KeymapPath: filepath.Join(t.TempDir(), "missing.json")}); err == nil {
+		t.Error("NewModel should fail on a missing keymap")
+	}
+}
+
+func TestModelUsesKeymap(t *testing.T) {
+	m, err := NewModel(Config{WindowSecs: 60, KeymapPath: writeKeymap(t, `{"charts": ["C"]}`)})
+	if err != nil {
+		t.Fatalf("NewModel: %v", err)
+	}
+	defer m.Cleanup()
+
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.Update(runes("c"))
+	if m.activeTab != TabList {
+		t.Fatalf("c should no longer open charts, active tab %v", m.activeTab)
+	}
+	m.Update(runes("C"))
+	if m.activeTab != TabCharts {
+		t.Fatalf("C should open charts, active tab %v", m.activeTab)
+	}
+
+	m.help.ShowAll = true
+	if view := m.View(); !regexp.MustCompile(`\bC\s+charts`).MatchString(view) {
+		t.Errorf("help should show the effective binding:\n%s", view)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,147 @@
+// Package theme holds the color palettes of the TUI. The model and the views
+// take their colors from a Theme rather than hardcoding them, so the
+// dashboard can switch between dark and light terminals at runtime.
+package theme
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme is a named set of colors
+type Theme struct {
+	Name string
+
+	Primary    lipgloss.Color // Titles, field labels, the cursor and modal borders
+	Accent     lipgloss.Color // Charts title
+	Text       lipgloss.Color // Regular values such as node names
+	Muted      lipgloss.Color // Hints, footers, timestamps and borders
+	SelectedFg lipgloss.Color // Selected table row and active tab
+	SelectedBg lipgloss.Color
+
+	Success lipgloss.Color
+	Warning lipgloss.Color
+	Error   lipgloss.Color
+
+	StatusUp       lipgloss.Color
+	StatusDown     lipgloss.Color
+	StatusDegraded lipgloss.Color
+	StatusUnknown  lipgloss.Color
+	Drift          lipgloss.Color // Status differs from the desired status
+
+	TypeBaremetal lipgloss.Color
+	TypeVM        lipgloss.Color
+	TypeContainer lipgloss.Color
+
+	Literal lipgloss.Color // JSON booleans and null
+}
+
+// Dark is the default theme, for terminals with a dark background
+var Dark = Theme{
+	Name:           "dark",
+	Primary:        lipgloss.Color("#7D56F4"),
+	Accent:         lipgloss.Color("205"),
+	Text:           lipgloss.Color("#C0CAF5"),
+	Muted:          lipgloss.Color("241"),
+	SelectedFg:     lipgloss.Color("229"),
+	SelectedBg:     lipgloss.Color("57"),
+	Success:        lipgloss.Color("#04B575"),
+	Warning:        lipgloss.Color("#FFA500"),
+	Error:          lipgloss.Color("#FF0000"),
+	StatusUp:       lipgloss.Color("#04B575"),
+	StatusDown:     lipgloss.Color("#FF0000"),
+	StatusDegraded: lipgloss.Color("#FFA500"),
+	StatusUnknown:  lipgloss.Color("#626262"),
+	Drift:          lipgloss.Color("#FF6B6B"),
+	TypeBaremetal:  lipgloss.Color("33"),
+	TypeVM:         lipgloss.Color("135"),
+	TypeContainer:  lipgloss.Color("220"),
+	Literal:        lipgloss.Color("#4ECDC4"),
+}
+
+// Light uses darker, saturated colors that stay readable on a white or
+// light gray background
+var Light = Theme{
+	Name:           "light",
+	Primary:        lipgloss.Color("#5A3FC0"),
+	Accent:         lipgloss.Color("#B0287A"),
+	Text:           lipgloss.Color("#1F2335"),
+	Muted:          lipgloss.Color("#5C5F77"),
+	SelectedFg:     lipgloss.Color("#FFFFFF"),
+	SelectedBg:     lipgloss.Color("#5A3FC0"),
+	Success:        lipgloss.Color("#087F4A"),
+	Warning:        lipgloss.Color("#B35900"),
+	Error:          lipgloss.Color("#C4001A"),
+	StatusUp:       lipgloss.Color("#087F4A"),
+	StatusDown:     lipgloss.Color("#C4001A"),
+	StatusDegraded: lipgloss.Color("#B35900"),
+	StatusUnknown:  lipgloss.Color("#5C5F77"),
+	Drift:          lipgloss.Color("#C2185B"),
+	TypeBaremetal:  lipgloss.Color("#1F5FBF"),
+	TypeVM:         lipgloss.Color("#7B3FB5"),
+	TypeContainer:  lipgloss.Color("#8A5A00"),
+	Literal:        lipgloss.Color("#00727A"),
+}
+
+// All lists the built-in themes in the order the theme key cycles them
+var All = []Theme{Dark, Light}
+
+// ByName returns the built-in theme called name. An empty name is Dark.
+func ByName(name string) (Theme, error) {
+	if name == "" {
+		return Dark, nil
+	}
+	names := make([]string, len(All))
+	for i, t := range All {
+		if strings.EqualFold(t.Name, name) {
+			return t, nil
+		}
+		names[i] = t.Name
+	}
+	return Theme{}, fmt.Errorf("unknown theme %q (want %s)", name, strings.Join(names, " or "))
+}
+
+// Next returns the theme after t in All, wrapping around
+func Next(t Theme) Theme {
+	for i, candidate := range All {
+		if candidate.Name == t.Name {
+			return All[(i+1)%len(All)]
+		}
+	}
+	return All[0]
+}
+
+// StatusColor returns the color for a node status name
+func (t Theme) StatusColor(status string) lipgloss.Color {
+	switch status {
+	case "UP":
+		return t.StatusUp
+	case "DOWN":
+		return t.StatusDown
+	case "DEGRADED":
+		return t.StatusDegraded
+	default:
+		return t.StatusUnknown
+	}
+}
+
+// StatusStyle returns the bold style for a node status name
+func (t Theme) StatusStyle(status string) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(t.StatusColor(status)).Bold(true)
+}
+
+// TypeColor returns the color for a node type name
+func (t Theme) TypeColor(nodeType string) lipgloss.Color {
+	switch nodeType {
+	case "BAREMETAL":
+		return t.TypeBaremetal
+	case "VM":
+		return t.TypeVM
+	case "CONTAINER":
+		return t.TypeContainer
+	default:
+		return t.Muted
+	}
+}
\ No newline at end of file
@@ -0,0 +1,159 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/melkior/nodestatus/internal/data"
+	"github.com/melkior/nodestatus/internal/tui/theme"
+)
+
+// BatchResultsView is a modal panel listing per-node outcomes of a batch operation
+type BatchResultsView struct {
+	result  *data.BatchResult
+	visible bool
+	width   int
+	height  int
+	offset  int
+	theme   theme.Theme
+}
+
+// NewBatchResultsView creates a new, hidden batch results panel
+func NewBatchResultsView() *BatchResultsView {
+	return &BatchResultsView{theme: theme.Dark}
+}
+
+// SetTheme switches the colors of the panel
+func (v *BatchResultsView) SetTheme(t theme.Theme) {
+	v.theme = t
+}
+
+// SetResult shows the panel with a new batch result
+func (v *BatchResultsView) SetResult(result *data.BatchResult) {
+	v.result = result
+	v.visible = result != nil
+	v.offset = 0
+}
+
+// Visible reports whether the panel is open
+func (v *BatchResultsView) Visible() bool {
+	return v.visible
+}
+
+// Update handles messages
+func (v *BatchResultsView) Update(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.width = msg.Width
+		v.height = msg.Height
+
+	case tea.KeyMsg:
+		if !v.visible {
+			return nil
+		}
+		switch msg.String() {
+		case "esc", "enter", "q":
+			v.visible = false
+		case "up", "k":
+			if v.offset > 0 {
+				v.offset--
+			}
+		case "down", "j":
+			v.offset++
+		case "pgup":
+			v.offset -= 10
+			if v.offset < 0 {
+				v.offset = 0
+			}
+		case "pgdown":
+			v.offset += 10
+		}
+	}
+
+	return nil
+}
+
+// View renders the panel
+func (v *BatchResultsView) View() string {
+	if !v.visible || v.result == nil {
+		return ""
+	}
+
+	succeeded := v.result.Succeeded()
+	failed := v.result.Failed()
+
+	headerStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(v.theme.Primary)
+	okStyle := lipgloss.NewStyle().Foreground(v.theme.Success)
+	failStyle := lipgloss.NewStyle().Foreground(v.theme.Error)
+
+	var lines []string
+	// Failures first: they are what the operator has to act on
+	if len(failed) > 0 {
+		lines = append(lines, headerStyle.Render(fmt.Sprintf("Failed (%d)", len(failed))))
+		for _, item := range failed {
+			lines = append(lines, failStyle.Render("✗ "+batchItemName(item)+": "+item.Error))
+		}
+		lines = append(lines, "")
+	}
+	if len(succeeded) > 0 {
+		lines = append(lines, headerStyle.Render(fmt.Sprintf("Succeeded (%d)", len(succeeded))))
+		for _, item := range succeeded {
+			lines = append(lines, okStyle.Render("✓ "+batchItemName(item)))
+		}
+	}
+
+	// Leave room for the title, summary, footer and border
+	visibleLines := len(lines)
+	if v.height > 0 {
+		visibleLines = v.height - 8
+		if visibleLines < 1 {
+			visibleLines = 1
+		}
+	}
+	if v.offset > len(lines)-visibleLines {
+		v.offset = len(lines) - visibleLines
+	}
+	if v.offset < 0 {
+		v.offset = 0
+	}
+	end := v.offset + visibleLines
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	var b strings.Builder
+	b.WriteString(headerStyle.Render(v.result.Operation))
+	b.WriteString("\n")
+
+	summary := fmt.Sprintf("%d succeeded, %d failed", len(succeeded), len(failed))
+	summaryStyle := okStyle
+	if len(failed) > 0 {
+		summaryStyle = failStyle
+	}
+	b.WriteString(summaryStyle.Render(summary))
+	b.WriteString("\n\n")
+
+	b.WriteString(strings.Join(lines[v.offset:end], "\n"))
+	b.WriteString("\n\n")
+	b.WriteString(lipgloss.NewStyle().
+		Foreground(v.theme.Muted).
+		Render("[↑/↓] Scroll  [Enter/Esc] Close"))
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(v.theme.Primary).
+		Padding(0, 1).
+		Render(b.String())
+}
+
+// batchItemName prefers the node name, falling back to its ID
+func batchItemName(item data.BatchItemResult) string {
+	if item.NodeName == "" {
+		return item.NodeID
+	}
+	return fmt.Sprintf("%s (%s)", item.NodeName, truncateID(item.NodeID))
+}
\ No newline at end of file
@@ -0,0 +1,50 @@
+package views
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/melkior/nodestatus/internal/data"
+)
+
+func TestBatchResultsViewListsSuccessesAndFailures(t *testing.T) {
+	v := NewBatchResultsView()
+	v.SetResult(&data.BatchResult{
+		Operation: "Set status DOWN",
+		Items: []data.BatchItemResult{
+			{NodeID: "id-1", NodeName: "web-01"},
+			{NodeID: "id-2", NodeName: "web-02", Error: "node not found"},
+			{NodeID: "id-3", NodeName: "db-01"},
+			{NodeID: "id-4", Error: "permission denied"},
+		},
+	})
+
+	if !v.Visible() {
+		t.Fatal("panel should open when a result is set")
+	}
+
+	out := v.View()
+	for _, want := range []string{
+		"Set status DOWN",
+		"2 succeeded, 2 failed",
+		"Failed (2)",
+		"✗ web-02 (id-2): node not found",
+		"✗ id-4: permission denied",
+		"Succeeded (2)",
+		"✓ web-01 (id-1)",
+		"✓ db-01 (id-3)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("panel is missing %q:\n%s", want, out)
+		}
+	}
+
+	// Failures are listed before successes
+	if strings.Index(out, "Failed (2)") > strings.Index(out, "Succeeded (2)") {
+		t.Errorf("failures should be listed first:\n%s", out)
+	}
+	// Successful nodes must not be reported as failed and vice versa
+	if strings.Contains(out, "✗ web-01") || strings.Contains(out, "✓ web-02") {
+		t.Errorf("outcomes are mixed up:\n%s", out)
+	}
+}
\ No newline at end of file
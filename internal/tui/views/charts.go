@@ -8,6 +8,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
 	"github.com/melkior/nodestatus/internal/data"
+	"github.com/melkior/nodestatus/internal/tui/theme"
 )
 
 // ChartsView displays metrics charts using bubble tea
@@ -16,6 +17,7 @@ type ChartsView struct {
 	height     int
 	aggregator *data.Aggregator
 	snapshot   data.MetricsSnapshot
+	theme      theme.Theme
 }
 
 // NewChartsView creates a new charts view
@@ -25,9 +27,15 @@ func NewChartsView(aggregator *data.Aggregator) *ChartsView {
 		snapshot:   aggregator.Snapshot(),
 		width:      80,  // Default width
 		height:     24,  // Default height
+		theme:      theme.Dark,
 	}
 }
 
+// SetTheme switches the colors of the charts
+func (v *ChartsView) SetTheme(t theme.Theme) {
+	v.theme = t
+}
+
 // Init initializes the charts view
 func (v *ChartsView) Init() tea.Cmd {
 	return nil
@@ -56,7 +64,7 @@ func (v *ChartsView) View() string {
 	// Title
 	titleStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("205")).
+		Foreground(v.theme.Accent).
 		MarginBottom(1)
 
 	b.WriteString(titleStyle.Render("📊 Node Status Charts"))
@@ -76,7 +84,7 @@ func (v *ChartsView) View() string {
 
 	// Help text
 	helpStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241"))
+		Foreground(v.theme.Muted)
 	b.WriteString(helpStyle.Render("Press 'q' or 'ESC' to return to main view"))
 
 	return b.String()
@@ -95,44 +103,21 @@ func (v *ChartsView) renderStatusChart() string {
 		maxWidth = 20
 	}
 
-	totalNodes := v.snapshot.TotalNodes
-	if totalNodes == 0 {
-		totalNodes = 1 // Avoid division by zero
-	}
-
-	statusColors := map[nodev1.NodeStatus]string{
-		nodev1.NodeStatus_UP:       "42",  // Green
-		nodev1.NodeStatus_DOWN:     "196", // Red
-		nodev1.NodeStatus_DEGRADED: "214", // Orange
-		nodev1.NodeStatus_UNKNOWN:  "241", // Gray
-	}
-
-	statusNames := map[nodev1.NodeStatus]string{
-		nodev1.NodeStatus_UP:       "UP      ",
-		nodev1.NodeStatus_DOWN:     "DOWN    ",
-		nodev1.NodeStatus_DEGRADED: "DEGRADED",
-		nodev1.NodeStatus_UNKNOWN:  "UNKNOWN ",
-	}
-
-	for status := nodev1.NodeStatus(0); status <= nodev1.NodeStatus_DEGRADED; status++ {
-		count := v.snapshot.StatusCounts[status]
-		ratio := float64(count) / float64(totalNodes)
-		barWidth := int(ratio * float64(maxWidth))
+	for _, bar := range v.StatusBars() {
+		barWidth := int(bar.Ratio * float64(maxWidth))
 
-		color := statusColors[status]
-		barStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(color))
+		barStyle := lipgloss.NewStyle().Foreground(v.theme.StatusColor(bar.Status.String()))
 
-		name := statusNames[status]
-		bar := strings.Repeat("█", barWidth)
-		if barWidth == 0 && count > 0 {
-			bar = "▏" // Show minimal bar for non-zero counts
+		barStr := strings.Repeat("█", barWidth)
+		if barWidth == 0 && bar.Count > 0 {
+			barStr = "▏" // Show minimal bar for non-zero counts
 		}
 
-		line := fmt.Sprintf("%s │ %s %d (%.1f%%)",
-			name,
-			barStyle.Render(bar),
-			count,
-			ratio*100)
+		line := fmt.Sprintf("%-8s │ %s %d (%.1f%%)",
+			bar.Label,
+			barStyle.Render(barStr),
+			bar.Count,
+			bar.Ratio*100)
 
 		b.WriteString(line)
 		b.WriteString("\n")
@@ -149,22 +134,15 @@ func (v *ChartsView) renderEventRateChart() string {
 	b.WriteString(headerStyle.Render("Event Rate (last 60 seconds)"))
 	b.WriteString("\n\n")
 
-	// Get event time series
-	allEvents := make([]int, 0)
-	for _, buffer := range v.snapshot.StatusTimeSeries {
-		if len(buffer) > len(allEvents) {
-			allEvents = buffer
-			break
-		}
-	}
+	points := v.EventRatePoints()
 
-	if len(allEvents) == 0 {
+	if len(points) == 0 {
 		return b.String() + "No event data available\n"
 	}
 
 	// Find max value for scaling
 	maxVal := 1
-	for _, val := range allEvents {
+	for _, val := range points {
 		if val > maxVal {
 			maxVal = val
 		}
@@ -172,10 +150,7 @@ func (v *ChartsView) renderEventRateChart() string {
 
 	// Chart dimensions
 	chartHeight := 10
-	chartWidth := v.width - 10
-	if chartWidth > len(allEvents) {
-		chartWidth = len(allEvents)
-	}
+	chartWidth := len(points)
 
 	// Create the chart
 	for row := chartHeight; row > 0; row-- {
@@ -191,13 +166,7 @@ func (v *ChartsView) renderEventRateChart() string {
 		}
 
 		// Plot points
-		start := len(allEvents) - chartWidth
-		if start < 0 {
-			start = 0
-		}
-
-		for i := start; i < len(allEvents); i++ {
-			val := allEvents[i]
+		for _, val := range points {
 			if float64(val) >= threshold {
 				b.WriteString("▄")
 			} else {
@@ -234,35 +203,18 @@ func (v *ChartsView) renderTypeDistribution() string {
 	b.WriteString(headerStyle.Render("Node Type Distribution"))
 	b.WriteString("\n\n")
 
-	typeNames := map[nodev1.NodeType]string{
-		nodev1.NodeType_BAREMETAL: "Bare Metal",
-		nodev1.NodeType_VM:        "Virtual Machine",
-		nodev1.NodeType_CONTAINER: "Container",
-	}
-
-	typeColors := map[nodev1.NodeType]string{
-		nodev1.NodeType_BAREMETAL: "33",  // Blue
-		nodev1.NodeType_VM:        "135", // Purple
-		nodev1.NodeType_CONTAINER: "220", // Yellow
-	}
-
-	for nodeType := nodev1.NodeType(1); nodeType <= nodev1.NodeType_CONTAINER; nodeType++ {
-		count := v.snapshot.TypeCounts[nodeType]
-		ratio := v.snapshot.TypeRatios[nodeType]
-
-		name := typeNames[nodeType]
-		color := typeColors[nodeType]
-		style := lipgloss.NewStyle().Foreground(lipgloss.Color(color))
+	for _, bar := range v.TypeBars() {
+		style := lipgloss.NewStyle().Foreground(v.theme.TypeColor(bar.Type.String()))
 
 		// Simple pie chart representation using unicode
-		blocks := int(ratio * 10)
-		bar := strings.Repeat("●", blocks)
+		blocks := int(bar.Ratio * 10)
+		barStr := strings.Repeat("●", blocks)
 
 		line := fmt.Sprintf("%-15s: %s %d (%.1f%%)",
-			name,
-			style.Render(bar),
-			count,
-			ratio*100)
+			bar.Label,
+			style.Render(barStr),
+			bar.Count,
+			bar.Ratio*100)
 
 		b.WriteString(line)
 		b.WriteString("\n")
@@ -270,12 +222,14 @@ func (v *ChartsView) renderTypeDistribution() string {
 
 	// Summary stats
 	b.WriteString("\n")
-	summaryStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	summaryStyle := lipgloss.NewStyle().Foreground(v.theme.Muted)
 	b.WriteString(summaryStyle.Render(fmt.Sprintf(
-		"Total Nodes: %d | Events/sec: %.1f | Mutations/sec: %.1f",
+		"Total Nodes: %d | Events/sec: %.1f (p95 %.1f) | Mutations/sec: %.1f | Watchers: %d",
 		v.snapshot.TotalNodes,
 		v.snapshot.EventsPerSecond,
+		v.snapshot.EventsPerSecondP95,
 		v.snapshot.MutationRate,
+		v.snapshot.ConnectedWatchers,
 	)))
 
 	return b.String()
@@ -284,4 +238,77 @@ func (v *ChartsView) renderTypeDistribution() string {
 // SetSnapshot updates the metrics snapshot
 func (v *ChartsView) SetSnapshot(snapshot data.MetricsSnapshot) {
 	v.snapshot = snapshot
-}
\ No newline at end of file
+}
+
+// ChartBar is one bar of a distribution chart
+type ChartBar struct {
+	Label  string
+	Status nodev1.NodeStatus // Set for status bars
+	Type   nodev1.NodeType   // Set for type bars
+	Count  int
+	Ratio  float64 // Share of all nodes, 0 to 1
+}
+
+// StatusBars returns the status distribution bars in display order
+func (v *ChartsView) StatusBars() []ChartBar {
+	total := v.snapshot.TotalNodes
+	if total == 0 {
+		total = 1 // Avoid division by zero
+	}
+
+	order := []struct {
+		status nodev1.NodeStatus
+		label  string
+	}{
+		{nodev1.NodeStatus_UP, "UP"},
+		{nodev1.NodeStatus_DOWN, "DOWN"},
+		{nodev1.NodeStatus_DEGRADED, "DEGRADED"},
+		{nodev1.NodeStatus_UNKNOWN, "UNKNOWN"},
+	}
+
+	bars := make([]ChartBar, len(order))
+	for i, o := range order {
+		count := v.snapshot.StatusCounts[o.status]
+		bars[i] = ChartBar{
+			Label:  o.label,
+			Status: o.status,
+			Count:  count,
+			Ratio:  float64(count) / float64(total),
+		}
+	}
+	return bars
+}
+
+// TypeBars returns the node type distribution bars in display order
+func (v *ChartsView) TypeBars() []ChartBar {
+	order := []struct {
+		nodeType nodev1.NodeType
+		label    string
+	}{
+		{nodev1.NodeType_BAREMETAL, "Bare Metal"},
+		{nodev1.NodeType_VM, "Virtual Machine"},
+		{nodev1.NodeType_CONTAINER, "Container"},
+	}
+
+	bars := make([]ChartBar, len(order))
+	for i, o := range order {
+		bars[i] = ChartBar{
+			Label: o.label,
+			Type:  o.nodeType,
+			Count: v.snapshot.TypeCounts[o.nodeType],
+			Ratio: v.snapshot.TypeRatios[o.nodeType],
+		}
+	}
+	return bars
+}
+
+// EventRatePoints returns the events received per sample interval that the
+// event rate chart plots, oldest first, trimmed to the most recent samples
+// that fit the view width
+func (v *ChartsView) EventRatePoints() []int {
+	series := v.snapshot.EventRateSeries
+	if width := v.width - 10; width >= 0 && len(series) > width {
+		series = series[len(series)-width:]
+	}
+	return series
+}
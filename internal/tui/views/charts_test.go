@@ -0,0 +1,52 @@
+package views
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/melkior/nodestatus/internal/data"
+)
+
+func TestChartsViewMapsSnapshot(t *testing.T) {
+	v := NewChartsView(data.NewAggregator(60))
+	v.Update(tea.WindowSizeMsg{Width: 15, Height: 40})
+	v.Update(data.MetricsSnapshot{
+		TotalNodes: 4,
+		StatusCounts: map[nodev1.NodeStatus]int{
+			nodev1.NodeStatus_UP:      3,
+			nodev1.NodeStatus_UNKNOWN: 1,
+		},
+		TypeCounts: map[nodev1.NodeType]int{nodev1.NodeType_VM: 4},
+		TypeRatios: map[nodev1.NodeType]float64{nodev1.NodeType_VM: 1},
+		// More samples than the 5 columns left at this width
+		EventRateSeries:    []int{9, 9, 1, 2, 3, 4, 5},
+		MutationRateSeries: []int{7, 7, 7, 7, 7, 7, 7},
+	})
+
+	status := v.StatusBars()
+	var labels []string
+	for _, bar := range status {
+		labels = append(labels, bar.Label)
+	}
+	if got := strings.Join(labels, ","); got != "UP,DOWN,DEGRADED,UNKNOWN" {
+		t.Errorf("status bars = %s, want UP,DOWN,DEGRADED,UNKNOWN", got)
+	}
+	if status[0].Count != 3 || status[0].Ratio != 0.75 {
+		t.Errorf("UP bar = %+v, want 3 nodes at 0.75", status[0])
+	}
+	if status[3].Count != 1 || status[3].Status != nodev1.NodeStatus_UNKNOWN {
+		t.Errorf("UNKNOWN bar = %+v, want 1 node", status[3])
+	}
+
+	types := v.TypeBars()
+	if len(types) != 3 || types[1].Label != "Virtual Machine" || types[1].Count != 4 || types[1].Ratio != 1 {
+		t.Errorf("type bars = %+v, want all 4 nodes on Virtual Machine", types)
+	}
+
+	// The event chart plots received events, not mutations, newest last
+	if got := v.EventRatePoints(); len(got) != 5 || got[0] != 1 || got[4] != 5 {
+		t.Errorf("event rate points = %v, want [1 2 3 4 5]", got)
+	}
+}
\ No newline at end of file
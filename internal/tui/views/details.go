@@ -1,26 +1,40 @@
 package views
 
 import (
-	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
 	"github.com/melkior/nodestatus/internal/data"
+	"github.com/melkior/nodestatus/internal/tui/theme"
 )
 
 // DetailsView displays detailed information about a selected node
 type DetailsView struct {
-	node   *data.Node
-	width  int
-	height int
-	offset int // For scrolling
+	node      *data.Node
+	width     int
+	height    int
+	offset    int             // For scrolling
+	cursor    int             // Highlighted line
+	collapsed map[string]bool // Collapsed metadata JSON paths
+	removed   bool            // The node is no longer reported; node is the last known version
+	theme     theme.Theme
 }
 
 // NewDetailsView creates a new details view
 func NewDetailsView() *DetailsView {
-	return &DetailsView{}
+	return &DetailsView{
+		collapsed: make(map[string]bool),
+		theme:     theme.Dark,
+	}
+}
+
+// SetTheme switches the colors of the view
+func (v *DetailsView) SetTheme(t theme.Theme) {
+	v.theme = t
 }
 
 // Update handles messages
@@ -33,78 +47,75 @@ func (v *DetailsView) Update(msg tea.Msg) tea.Cmd {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "up", "k":
-			if v.offset > 0 {
-				v.offset--
+			if v.cursor > 0 {
+				v.cursor--
 			}
 		case "down", "j":
-			v.offset++
+			v.cursor++
 		case "pgup":
-			v.offset -= 10
-			if v.offset < 0 {
-				v.offset = 0
+			v.cursor -= 10
+			if v.cursor < 0 {
+				v.cursor = 0
 			}
 		case "pgdown":
-			v.offset += 10
+			v.cursor += 10
+		case "enter":
+			v.toggleCollapsed()
 		}
 	}
 
 	return nil
 }
 
+// toggleCollapsed folds or unfolds the JSON object/array on the highlighted line
+func (v *DetailsView) toggleCollapsed() {
+	if v.node == nil {
+		return
+	}
+
+	lines := v.buildLines()
+	if v.cursor < 0 || v.cursor >= len(lines) {
+		return
+	}
+
+	if path := lines[v.cursor].path; path != "" {
+		if v.collapsed[path] {
+			delete(v.collapsed, path)
+		} else {
+			v.collapsed[path] = true
+		}
+	}
+}
+
 // View renders the details view
 func (v *DetailsView) View() string {
 	if v.node == nil {
 		return lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
+			Foreground(v.theme.Muted).
 			Render("No node selected")
 	}
 
-	// Build content
-	var lines []string
-
-	// Header
-	headerStyle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("#7D56F4"))
-
-	lines = append(lines, headerStyle.Render("Node Details"))
-	lines = append(lines, "")
-
-	// Basic info
-	lines = append(lines, v.renderField("ID", v.node.ID))
-	lines = append(lines, v.renderField("Name", v.node.Name))
-	lines = append(lines, v.renderField("Type", v.node.Type.String()))
-	lines = append(lines, v.renderField("Status", v.node.Status.String()))
-	lines = append(lines, v.renderField("Last Seen", v.node.LastSeen.Format("2006-01-02 15:04:05")))
-	lines = append(lines, "")
+	lines := v.buildLines()
 
-	// Labels
-	if len(v.node.Labels) > 0 {
-		lines = append(lines, headerStyle.Render("Labels"))
-		for k, val := range v.node.Labels {
-			lines = append(lines, v.renderField("  "+k, val))
-		}
-		lines = append(lines, "")
+	// Keep the cursor within the rendered (possibly collapsed) line set
+	if v.cursor >= len(lines) {
+		v.cursor = len(lines) - 1
 	}
-
-	// Metadata
-	if v.node.Metadata != "" {
-		lines = append(lines, headerStyle.Render("Metadata"))
-
-		// Try to parse as JSON for pretty printing
-		var jsonData interface{}
-		if err := json.Unmarshal([]byte(v.node.Metadata), &jsonData); err == nil {
-			prettyJSON, _ := json.MarshalIndent(jsonData, "", "  ")
-			metadataLines := strings.Split(string(prettyJSON), "\n")
-			lines = append(lines, metadataLines...)
-		} else {
-			// Fallback to raw string
-			lines = append(lines, v.node.Metadata)
-		}
+	if v.cursor < 0 {
+		v.cursor = 0
 	}
 
-	// Apply scrolling
+	// Apply scrolling, following the cursor
 	visibleLines := v.height - 2
+	if visibleLines < 1 {
+		visibleLines = 1
+	}
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+visibleLines {
+		v.offset = v.cursor - visibleLines + 1
+	}
 	if v.offset > len(lines)-visibleLines {
 		v.offset = len(lines) - visibleLines
 	}
@@ -118,13 +129,24 @@ func (v *DetailsView) View() string {
 	}
 
 	// Render visible lines
-	content := strings.Join(lines[v.offset:endIdx], "\n")
+	visible := make([]string, 0, endIdx-v.offset)
+	for i := v.offset; i < endIdx; i++ {
+		marker := "  "
+		if i == v.cursor {
+			marker = lipgloss.NewStyle().
+				Foreground(v.theme.Primary).
+				Bold(true).
+				Render("> ")
+		}
+		visible = append(visible, marker+lines[i].text)
+	}
+	content := strings.Join(visible, "\n")
 
 	// Add scroll indicator
 	if len(lines) > visibleLines {
 		scrollInfo := fmt.Sprintf("[%d-%d/%d]", v.offset+1, endIdx, len(lines))
 		content += "\n" + lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
+			Foreground(v.theme.Muted).
 			Render(scrollInfo)
 	}
 
@@ -133,7 +155,7 @@ func (v *DetailsView) View() string {
 		Width(v.width).
 		Height(v.height).
 		BorderStyle(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("241")).
+		BorderForeground(v.theme.Muted).
 		Padding(1, 2).
 		Render(content)
 }
@@ -142,39 +164,123 @@ func (v *DetailsView) View() string {
 func (v *DetailsView) SetNode(node *data.Node) {
 	v.node = node
 	v.offset = 0
+	v.cursor = 0
+	v.collapsed = make(map[string]bool)
+	v.removed = false
+}
+
+// NodeID returns the ID of the displayed node, empty when none is shown
+func (v *DetailsView) NodeID() string {
+	if v.node == nil {
+		return ""
+	}
+	return v.node.ID
+}
+
+// RefreshNode replaces the displayed node with its latest version, keeping the
+// scroll position and folded metadata. A nil node marks the shown node as
+// removed while still displaying its last known state.
+func (v *DetailsView) RefreshNode(node *data.Node) {
+	if v.node == nil {
+		return
+	}
+	if node == nil {
+		v.removed = true
+		return
+	}
+	if node.ID != v.node.ID {
+		return
+	}
+	v.node = node
+	v.removed = false
+}
+
+// buildLines renders the node into display lines
+func (v *DetailsView) buildLines() []renderedLine {
+	var lines []renderedLine
+	add := func(text string) {
+		lines = append(lines, renderedLine{text: text})
+	}
+
+	// Header
+	headerStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(v.theme.Primary)
+
+	title := "Node Details"
+	if v.removed {
+		title += " (removed)"
+	}
+	add(headerStyle.Render(title))
+	add("")
+
+	// Basic info
+	add(v.renderField("ID", v.node.ID))
+	add(v.renderField("Name", v.node.Name))
+	add(v.renderField("Type", v.node.Type.String()))
+	add(v.renderField("Status", v.node.Status.String()))
+	if v.node.Reason != "" {
+		add(v.renderField("Reason", v.node.Reason))
+	}
+	if v.node.Desired != nodev1.NodeStatus_NODE_STATUS_UNSPECIFIED {
+		desired := v.node.Desired.String()
+		if v.node.Drifted() {
+			desired += " (drift)"
+		}
+		add(v.renderField("Desired", desired))
+	}
+	add(v.renderField("Last Seen", v.node.LastSeen.Format("2006-01-02 15:04:05")))
+	add("")
+
+	// Labels
+	if len(v.node.Labels) > 0 {
+		add(headerStyle.Render("Labels"))
+		// Sorted so line positions stay stable across renders
+		keys := make([]string, 0, len(v.node.Labels))
+		for k := range v.node.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			add(v.renderField("  "+k, v.node.Labels[k]))
+		}
+		add("")
+	}
+
+	// Metadata
+	if v.node.Metadata != "" {
+		add(headerStyle.Render("Metadata"))
+
+		// Try to parse as JSON for highlighting and folding
+		if root, err := parseJSON(v.node.Metadata); err == nil {
+			lines = append(lines, renderJSON(root, v.collapsed, v.theme)...)
+		} else {
+			// Fallback to raw string
+			add(v.node.Metadata)
+		}
+	}
+
+	return lines
 }
 
 // renderField renders a field with label and value
 func (v *DetailsView) renderField(label, value string) string {
 	labelStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#7D56F4")).
+		Foreground(v.theme.Primary).
 		Bold(true)
 
 	valueStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#C0CAF5"))
+		Foreground(v.theme.Text)
 
 	// Handle status coloring
 	if label == "Status" {
-		valueStyle = GetStatusStyle(value)
+		valueStyle = v.theme.StatusStyle(value)
+	}
+	if label == "Desired" && strings.HasSuffix(value, "(drift)") {
+		valueStyle = driftStyle(v.theme)
 	}
 
 	return fmt.Sprintf("%s: %s",
 		labelStyle.Render(label),
 		valueStyle.Render(value))
-}
-
-// GetStatusStyle returns the style for a status value
-func GetStatusStyle(status string) lipgloss.Style {
-	var color lipgloss.Color
-	switch status {
-	case "UP":
-		color = lipgloss.Color("#04B575")
-	case "DOWN":
-		color = lipgloss.Color("#FF0000")
-	case "DEGRADED":
-		color = lipgloss.Color("#FFA500")
-	default:
-		color = lipgloss.Color("#626262")
-	}
-	return lipgloss.NewStyle().Foreground(color).Bold(true)
 }
\ No newline at end of file
@@ -0,0 +1,63 @@
+package views
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/melkior/nodestatus/internal/data"
+)
+
+func TestDetailsViewRefreshKeepsPosition(t *testing.T) {
+	v := NewDetailsView()
+	v.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	v.SetNode(&data.Node{ID: "id-1", Name: "web-01", Status: nodev1.NodeStatus_UP})
+
+	v.Update(tea.KeyMsg{Type: tea.KeyDown})
+	v.Update(tea.KeyMsg{Type: tea.KeyDown})
+	v.View()
+
+	v.RefreshNode(&data.Node{ID: "id-1", Name: "web-01", Status: nodev1.NodeStatus_DOWN})
+	out := v.View()
+	if !strings.Contains(out, "DOWN") {
+		t.Errorf("expected the refreshed status, got:\n%s", out)
+	}
+	if v.cursor != 2 {
+		t.Errorf("expected the cursor to stay at 2, got %d", v.cursor)
+	}
+
+	// Another node's update must not replace the inspected one
+	v.RefreshNode(&data.Node{ID: "id-2", Name: "db-01"})
+	if v.NodeID() != "id-1" {
+		t.Errorf("expected id-1 to stay displayed, got %s", v.NodeID())
+	}
+
+	v.RefreshNode(nil)
+	out = v.View()
+	if !strings.Contains(out, "(removed)") || !strings.Contains(out, "web-01") {
+		t.Errorf("expected the last known node marked removed, got:\n%s", out)
+	}
+}
+
+func TestDetailsAndLogsShowStatusReason(t *testing.T) {
+	node := &data.Node{ID: "id-1", Name: "db-01", Status: nodev1.NodeStatus_DOWN, Reason: "disk full"}
+
+	details := NewDetailsView()
+	details.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	details.SetNode(node)
+	if out := details.View(); !strings.Contains(out, "Reason: disk full") {
+		t.Errorf("expected the reason in the details, got:\n%s", out)
+	}
+
+	logs := NewLogsView(10)
+	logs.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	logs.AddEvent(&data.Event{
+		Type:    nodev1.EventType_UPDATED,
+		Node:    node,
+		Changes: []data.FieldChange{{Field: "status", Old: "UP", New: "DOWN"}},
+	})
+	if out := logs.View(); !strings.Contains(out, "[UP→DOWN: disk full]") {
+		t.Errorf("expected the reason after the status change, got:\n%s", out)
+	}
+}
\ No newline at end of file
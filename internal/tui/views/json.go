@@ -0,0 +1,178 @@
+package views
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/melkior/nodestatus/internal/tui/theme"
+)
+
+// jsonStyles are the JSON syntax colors of a theme
+type jsonStyles struct {
+	key         lipgloss.Style
+	str         lipgloss.Style
+	number      lipgloss.Style
+	literal     lipgloss.Style
+	punctuation lipgloss.Style
+}
+
+func newJSONStyles(t theme.Theme) jsonStyles {
+	return jsonStyles{
+		key:         lipgloss.NewStyle().Foreground(t.Primary).Bold(true),
+		str:         lipgloss.NewStyle().Foreground(t.Success),
+		number:      lipgloss.NewStyle().Foreground(t.Warning),
+		literal:     lipgloss.NewStyle().Foreground(t.Literal),
+		punctuation: lipgloss.NewStyle().Foreground(t.StatusUnknown),
+	}
+}
+
+// jsonNode is a parsed JSON value that keeps object keys in document order
+type jsonNode struct {
+	key      string
+	hasKey   bool
+	kind     byte // '{', '[' or 0 for scalars
+	scalar   interface{}
+	children []*jsonNode
+}
+
+// renderedLine is a single line of the details view. Lines that open a JSON
+// object or array carry the path used to collapse them.
+type renderedLine struct {
+	text string
+	path string
+}
+
+// parseJSON parses a JSON document into an order-preserving tree
+func parseJSON(raw string) (*jsonNode, error) {
+	dec := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	dec.UseNumber()
+
+	root, err := parseJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected trailing data")
+	}
+	return root, nil
+}
+
+// parseJSONValue reads the next value from the decoder
+func parseJSONValue(dec *json.Decoder) (*jsonNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return &jsonNode{scalar: tok}, nil
+	}
+
+	node := &jsonNode{kind: byte(delim)}
+	for dec.More() {
+		var key string
+		if node.kind == '{' {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ = keyTok.(string)
+		}
+
+		child, err := parseJSONValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		if node.kind == '{' {
+			child.key = key
+			child.hasKey = true
+		}
+		node.children = append(node.children, child)
+	}
+
+	// Consume the closing delimiter
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// renderJSON renders the tree as highlighted lines, folding any container
+// whose path is present in collapsed
+func renderJSON(root *jsonNode, collapsed map[string]bool, t theme.Theme) []renderedLine {
+	var lines []renderedLine
+	newJSONStyles(t).renderNode(root, "$", 0, true, collapsed, &lines)
+	return lines
+}
+
+// renderNode appends the lines for a single node
+func (s jsonStyles) renderNode(node *jsonNode, path string, depth int, last bool, collapsed map[string]bool, lines *[]renderedLine) {
+	indent := strings.Repeat("  ", depth)
+
+	prefix := indent
+	if node.hasKey {
+		prefix += s.key.Render(fmt.Sprintf("%q", node.key)) + s.punctuation.Render(": ")
+	}
+
+	comma := ""
+	if !last {
+		comma = s.punctuation.Render(",")
+	}
+
+	if node.kind == 0 {
+		*lines = append(*lines, renderedLine{text: "  " + prefix + s.renderScalar(node.scalar) + comma})
+		return
+	}
+
+	open, closing := "{", "}"
+	if node.kind == '[' {
+		open, closing = "[", "]"
+	}
+
+	if len(node.children) == 0 {
+		*lines = append(*lines, renderedLine{text: "  " + prefix + s.punctuation.Render(open+closing) + comma})
+		return
+	}
+
+	if collapsed[path] {
+		summary := fmt.Sprintf("%d items", len(node.children))
+		if node.kind == '{' {
+			summary = fmt.Sprintf("%d keys", len(node.children))
+		}
+		text := prefix +
+			s.punctuation.Render(open+" … "+closing) + comma + " " +
+			s.punctuation.Italic(true).Render(summary)
+		*lines = append(*lines, renderedLine{text: "▸ " + text, path: path})
+		return
+	}
+
+	*lines = append(*lines, renderedLine{text: "▾ " + prefix + s.punctuation.Render(open), path: path})
+	for i, child := range node.children {
+		childPath := fmt.Sprintf("%s[%d]", path, i)
+		if child.hasKey {
+			childPath = path + "." + child.key
+		}
+		s.renderNode(child, childPath, depth+1, i == len(node.children)-1, collapsed, lines)
+	}
+	*lines = append(*lines, renderedLine{text: "  " + indent + s.punctuation.Render(closing) + comma})
+}
+
+// renderScalar colors a scalar value by its JSON type
+func (s jsonStyles) renderScalar(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return s.str.Render(fmt.Sprintf("%q", v))
+	case json.Number:
+		return s.number.Render(v.String())
+	case bool:
+		return s.literal.Render(fmt.Sprintf("%t", v))
+	case nil:
+		return s.literal.Render("null")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
\ No newline at end of file
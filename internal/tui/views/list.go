@@ -5,62 +5,78 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/melkior/nodestatus/internal/data"
 	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/melkior/nodestatus/internal/data"
+	"github.com/melkior/nodestatus/internal/tui/theme"
 )
 
-// ListView displays a table of nodes
+// ListView displays a table of nodes. Only the visible page of rows is
+// handed to the table, so large fleets don't slow every refresh down.
 type ListView struct {
-	table        table.Model
-	nodes        []*data.Node
+	table         table.Model
+	nodes         []*data.Node
 	filteredNodes []*data.Node
-	typeFilter   nodev1.NodeType
-	statusFilter nodev1.NodeStatus
-	showFilters  bool
-	width        int
-	height       int
-	focused      bool
+	cursor        int // Index of the selected node in filteredNodes
+	offset        int // Index in filteredNodes of the first visible row
+	typeFilter    nodev1.NodeType
+	statusFilter  nodev1.NodeStatus
+	labelFilter   labelSelector
+	driftOnly     bool
+	labelKey      string // Label shown in the extra column, empty when hidden
+	showFilters   bool
+	editing       bool
+	input         textinput.Model
+	width         int
+	height        int
+	focused       bool
+	theme         theme.Theme
 }
 
 // NewListView creates a new list view
 func NewListView() *ListView {
-	columns := []table.Column{
-		{Title: "ID", Width: 20},
-		{Title: "Name", Width: 25},
-		{Title: "Type", Width: 12},
-		{Title: "Status", Width: 10},
-		{Title: "Last Seen", Width: 20},
-	}
-
 	t := table.New(
-		table.WithColumns(columns),
+		table.WithColumns(baseColumns()),
 		table.WithFocused(true),
 		table.WithHeight(10),
 	)
 
-	// Set styles
+	input := textinput.New()
+	input.Prompt = "Label selector: "
+	input.Placeholder = "env=prod,service!=db"
+
+	v := &ListView{
+		table:         t,
+		input:         input,
+		nodes:         []*data.Node{},
+		filteredNodes: []*data.Node{},
+		showFilters:   false,
+		focused:       true,
+	}
+	v.SetTheme(theme.Dark)
+	return v
+}
+
+// SetTheme switches the colors of the table and its rows
+func (v *ListView) SetTheme(t theme.Theme) {
+	v.theme = t
+
 	s := table.DefaultStyles()
 	s.Header = s.Header.
 		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("240")).
+		BorderForeground(t.Muted).
 		BorderBottom(true).
 		Bold(false)
 	s.Selected = s.Selected.
-		Foreground(lipgloss.Color("229")).
-		Background(lipgloss.Color("57")).
+		Foreground(t.SelectedFg).
+		Background(t.SelectedBg).
 		Bold(false)
-	t.SetStyles(s)
-
-	return &ListView{
-		table:        t,
-		nodes:        []*data.Node{},
-		filteredNodes: []*data.Node{},
-		showFilters:  false,
-		focused:      true,
-	}
+	v.table.SetStyles(s)
+	v.updateTable()
 }
 
 // Update handles messages
@@ -72,8 +88,12 @@ func (v *ListView) Update(msg tea.Msg) tea.Cmd {
 		v.width = msg.Width
 		v.height = msg.Height
 		v.table.SetHeight(msg.Height - 4) // Leave room for header and footer
+		v.updateColumns()
 
 	case tea.KeyMsg:
+		if v.editing {
+			return v.updateInput(msg)
+		}
 		if v.focused {
 			switch msg.String() {
 			case "f":
@@ -82,6 +102,23 @@ func (v *ListView) Update(msg tea.Msg) tea.Cmd {
 			case "r":
 				v.resetFilters()
 				return nil
+			case "d":
+				v.driftOnly = !v.driftOnly
+				v.applyFilters()
+				v.updateTable()
+				return nil
+			case "L":
+				v.cycleLabelKey()
+				return nil
+			case "/":
+				v.editing = true
+				v.showFilters = true
+				v.input.SetValue(v.labelFilter.String())
+				v.input.CursorEnd()
+				return v.input.Focus()
+			}
+			if v.handleNavigation(msg) {
+				return nil
 			}
 		}
 	}
@@ -107,13 +144,24 @@ func (v *ListView) View() string {
 		if v.statusFilter != 0 {
 			filterText += fmt.Sprintf("Status=%s ", v.statusFilter.String())
 		}
-		if v.typeFilter == 0 && v.statusFilter == 0 {
+		if len(v.labelFilter) > 0 {
+			filterText += fmt.Sprintf("Labels=%s ", v.labelFilter.String())
+		}
+		if v.driftOnly {
+			filterText += "Drift "
+		}
+		if v.typeFilter == 0 && v.statusFilter == 0 && len(v.labelFilter) == 0 && !v.driftOnly {
 			filterText += "None"
 		}
 		b.WriteString(lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
+			Foreground(v.theme.Muted).
 			Render(filterText))
-		b.WriteString("\n\n")
+		b.WriteString("\n")
+		if v.editing {
+			b.WriteString(v.input.View())
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
 	}
 
 	// Table
@@ -123,15 +171,16 @@ func (v *ListView) View() string {
 	// Footer with counts
 	statusCounts := v.getStatusCounts()
 	footer := fmt.Sprintf(
-		"Total: %d | UP: %d | DOWN: %d | DEGRADED: %d | UNKNOWN: %d",
+		"Total: %d | UP: %d | DOWN: %d | DEGRADED: %d | UNKNOWN: %d | DRIFT: %d",
 		len(v.filteredNodes),
 		statusCounts[nodev1.NodeStatus_UP],
 		statusCounts[nodev1.NodeStatus_DOWN],
 		statusCounts[nodev1.NodeStatus_DEGRADED],
 		statusCounts[nodev1.NodeStatus_UNKNOWN],
+		v.getDriftCount(),
 	)
 	b.WriteString(lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241")).
+		Foreground(v.theme.Muted).
 		Render(footer))
 
 	return b.String()
@@ -158,10 +207,95 @@ func (v *ListView) SetStatusFilter(status nodev1.NodeStatus) {
 	v.updateTable()
 }
 
+// SetLabelFilter parses and applies a label selector such as "env=prod,service!=db"
+func (v *ListView) SetLabelFilter(selector string) error {
+	parsed, err := parseLabelSelector(selector)
+	if err != nil {
+		return err
+	}
+	v.labelFilter = parsed
+	v.applyFilters()
+	v.updateTable()
+	return nil
+}
+
+// SetLabelColumn sets which label key is shown as an extra column (empty hides it)
+func (v *ListView) SetLabelColumn(key string) {
+	v.labelKey = key
+	v.updateColumns()
+}
+
+// Editing reports whether the view is capturing keystrokes for text input
+func (v *ListView) Editing() bool {
+	return v.editing
+}
+
+// updateInput handles keys while the label selector input is active
+func (v *ListView) updateInput(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "enter":
+		if err := v.SetLabelFilter(v.input.Value()); err != nil {
+			v.input.Placeholder = err.Error()
+			v.input.SetValue("")
+			return nil
+		}
+		v.editing = false
+		v.input.Blur()
+		return nil
+	case "esc":
+		v.editing = false
+		v.input.Blur()
+		return nil
+	}
+
+	var cmd tea.Cmd
+	v.input, cmd = v.input.Update(msg)
+	return cmd
+}
+
+// cycleLabelKey advances the label column through the known label keys, then hides it
+func (v *ListView) cycleLabelKey() {
+	keys := v.labelKeys()
+	next := ""
+	if len(keys) > 0 {
+		if v.labelKey == "" {
+			next = keys[0]
+		} else {
+			idx := sort.SearchStrings(keys, v.labelKey)
+			if idx < len(keys) && keys[idx] == v.labelKey {
+				idx++
+			}
+			if idx < len(keys) {
+				next = keys[idx]
+			}
+		}
+	}
+	v.SetLabelColumn(next)
+}
+
+// labelKeys returns the sorted set of label keys across all nodes
+func (v *ListView) labelKeys() []string {
+	seen := make(map[string]struct{})
+	for _, node := range v.nodes {
+		for k := range node.Labels {
+			seen[k] = struct{}{}
+		}
+	}
+
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // resetFilters clears all filters
 func (v *ListView) resetFilters() {
 	v.typeFilter = 0
 	v.statusFilter = 0
+	v.labelFilter = nil
+	v.driftOnly = false
 	v.applyFilters()
 	v.updateTable()
 }
@@ -181,6 +315,16 @@ func (v *ListView) applyFilters() {
 			continue
 		}
 
+		// Apply label selector
+		if !v.labelFilter.Matches(node.Labels) {
+			continue
+		}
+
+		// Apply drift filter
+		if v.driftOnly && !node.Drifted() {
+			continue
+		}
+
 		v.filteredNodes = append(v.filteredNodes, node)
 	}
 
@@ -190,21 +334,160 @@ func (v *ListView) applyFilters() {
 	})
 }
 
-// updateTable updates the table with filtered nodes
-func (v *ListView) updateTable() {
-	rows := make([]table.Row, 0, len(v.filteredNodes))
+// handleNavigation moves the cursor over the whole filtered list for the
+// table's movement keys; the table itself only holds the visible page
+func (v *ListView) handleNavigation(msg tea.KeyMsg) bool {
+	km := v.table.KeyMap
+	page := v.pageSize()
+
+	switch {
+	case key.Matches(msg, km.LineUp):
+		v.moveCursor(v.cursor - 1)
+	case key.Matches(msg, km.LineDown):
+		v.moveCursor(v.cursor + 1)
+	case key.Matches(msg, km.PageUp):
+		v.moveCursor(v.cursor - page)
+	case key.Matches(msg, km.PageDown):
+		v.moveCursor(v.cursor + page)
+	case key.Matches(msg, km.HalfPageUp):
+		v.moveCursor(v.cursor - page/2)
+	case key.Matches(msg, km.HalfPageDown):
+		v.moveCursor(v.cursor + page/2)
+	case key.Matches(msg, km.GotoTop):
+		v.moveCursor(0)
+	case key.Matches(msg, km.GotoBottom):
+		v.moveCursor(len(v.filteredNodes) - 1)
+	default:
+		return false
+	}
+	return true
+}
 
-	for _, node := range v.filteredNodes {
-		rows = append(rows, table.Row{
+// moveCursor selects filteredNodes[i], rebuilding the rows only when the
+// page scrolls
+func (v *ListView) moveCursor(i int) {
+	v.cursor = clampIndex(i, len(v.filteredNodes))
+	if v.cursor < v.offset || v.cursor >= v.offset+v.pageSize() {
+		v.updateTable()
+		return
+	}
+	v.table.SetCursor(v.cursor - v.offset)
+}
+
+// pageSize returns the number of visible rows
+func (v *ListView) pageSize() int {
+	if h := v.table.Height(); h > 0 {
+		return h
+	}
+	return 1
+}
+
+// updateTable loads the rows of the page holding the cursor, scrolling no
+// more than needed to keep it visible
+func (v *ListView) updateTable() {
+	total := len(v.filteredNodes)
+	page := v.pageSize()
+	v.cursor = clampIndex(v.cursor, total)
+
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	} else if v.cursor >= v.offset+page {
+		v.offset = v.cursor - page + 1
+	}
+	// Don't leave blank rows below the last node after the list shrinks
+	v.offset = min(v.offset, max(total-page, 0))
+	end := min(v.offset+page, total)
+
+	rows := make([]table.Row, 0, end-v.offset)
+	for _, node := range v.filteredNodes[v.offset:end] {
+		status := colorizeStatus(v.theme, node.Status.String())
+		if node.Drifted() {
+			status += driftStyle(v.theme).Render(" !")
+		}
+		row := table.Row{
 			truncateID(node.ID),
 			node.Name,
 			node.Type.String(),
-			colorizeStatus(node.Status.String()),
+			status,
 			node.LastSeen.Format("2006-01-02 15:04:05"),
-		})
+		}
+		if v.labelKey != "" {
+			row = append(row, node.Labels[v.labelKey])
+		}
+		rows = append(rows, row)
 	}
 
 	v.table.SetRows(rows)
+	v.table.SetCursor(v.cursor - v.offset)
+}
+
+// clampIndex limits i to the indexes of a list of length n, or 0 when empty
+func clampIndex(i, n int) int {
+	if i >= n {
+		i = n - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return i
+}
+
+// updateColumns rebuilds the table columns for the current label column and width
+func (v *ListView) updateColumns() {
+	columns := baseColumns()
+
+	if v.labelKey != "" {
+		columns = append(columns, table.Column{Title: v.labelKey, Width: 16})
+
+		// Squeeze Name then ID so the extra column still fits the terminal
+		if v.width > 0 {
+			total := 0
+			for _, c := range columns {
+				total += c.Width + 2 // cell padding
+			}
+			overflow := total - v.width
+			for _, idx := range []int{1, 0} {
+				if overflow <= 0 {
+					break
+				}
+				shrink := columns[idx].Width - 10
+				if shrink > overflow {
+					shrink = overflow
+				}
+				if shrink > 0 {
+					columns[idx].Width -= shrink
+					overflow -= shrink
+				}
+			}
+		}
+	}
+
+	// Rows must never have more cells than columns
+	v.table.SetRows(nil)
+	v.table.SetColumns(columns)
+	v.updateTable()
+}
+
+// baseColumns returns the always-present table columns
+func baseColumns() []table.Column {
+	return []table.Column{
+		{Title: "ID", Width: 20},
+		{Title: "Name", Width: 25},
+		{Title: "Type", Width: 12},
+		{Title: "Status", Width: 10},
+		{Title: "Last Seen", Width: 20},
+	}
+}
+
+// getDriftCount returns how many filtered nodes differ from their desired status
+func (v *ListView) getDriftCount() int {
+	count := 0
+	for _, node := range v.filteredNodes {
+		if node.Drifted() {
+			count++
+		}
+	}
+	return count
 }
 
 // getStatusCounts returns counts by status
@@ -219,7 +502,15 @@ func (v *ListView) getStatusCounts() map[nodev1.NodeStatus]int {
 // SetFocused sets the focus state
 func (v *ListView) SetFocused(focused bool) {
 	v.focused = focused
-	v.table.SetCursor(0)
+	v.cursor = 0
+	v.updateTable()
+}
+
+// FilteredNodes returns the nodes currently shown, in display order
+func (v *ListView) FilteredNodes() []*data.Node {
+	nodes := make([]*data.Node, len(v.filteredNodes))
+	copy(nodes, v.filteredNodes)
+	return nodes
 }
 
 // GetSelectedNode returns the currently selected node
@@ -228,9 +519,8 @@ func (v *ListView) GetSelectedNode() *data.Node {
 		return nil
 	}
 
-	cursor := v.table.Cursor()
-	if cursor >= 0 && cursor < len(v.filteredNodes) {
-		return v.filteredNodes[cursor]
+	if v.cursor >= 0 && v.cursor < len(v.filteredNodes) {
+		return v.filteredNodes[v.cursor]
 	}
 
 	return nil
@@ -244,17 +534,11 @@ func truncateID(id string) string {
 	return id
 }
 
-func colorizeStatus(status string) string {
-	var color lipgloss.Color
-	switch status {
-	case "UP":
-		color = lipgloss.Color("#04B575")
-	case "DOWN":
-		color = lipgloss.Color("#FF0000")
-	case "DEGRADED":
-		color = lipgloss.Color("#FFA500")
-	default:
-		color = lipgloss.Color("#626262")
-	}
-	return lipgloss.NewStyle().Foreground(color).Render(status)
+// driftStyle marks nodes whose status differs from their desired status
+func driftStyle(t theme.Theme) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(t.Drift).Bold(true)
+}
+
+func colorizeStatus(t theme.Theme, status string) string {
+	return lipgloss.NewStyle().Foreground(t.StatusColor(status)).Render(status)
 }
\ No newline at end of file
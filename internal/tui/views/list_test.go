@@ -0,0 +1,87 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/melkior/nodestatus/internal/data"
+)
+
+func largeFleet(n int) []*data.Node {
+	nodes := make([]*data.Node, n)
+	for i := range nodes {
+		nodes[i] = &data.Node{
+			ID:     fmt.Sprintf("id-%05d", i),
+			Name:   fmt.Sprintf("node-%05d", i),
+			Type:   nodev1.NodeType_VM,
+			Status: nodev1.NodeStatus_UP,
+		}
+	}
+	return nodes
+}
+
+func TestListViewOnlyLoadsVisibleRows(t *testing.T) {
+	v := NewListView()
+	v.Update(tea.WindowSizeMsg{Width: 120, Height: 24})
+	v.SetNodes(largeFleet(10000))
+
+	if got, page := len(v.table.Rows()), v.pageSize(); got != page {
+		t.Fatalf("expected only the %d visible rows loaded, got %d", page, got)
+	}
+
+	keys := func(k string, times int) {
+		for i := 0; i < times; i++ {
+			v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		}
+	}
+
+	// Scrolling past the loaded rows keeps the true index
+	keys("j", 500)
+	if got := v.GetSelectedNode().Name; got != "node-00500" {
+		t.Errorf("expected node-00500 after 500 moves down, got %s", got)
+	}
+	if !strings.Contains(v.View(), "node-00500") {
+		t.Error("selected node should be rendered")
+	}
+
+	keys("G", 1)
+	if got := v.GetSelectedNode().Name; got != "node-09999" {
+		t.Errorf("expected the last node at the bottom, got %s", got)
+	}
+	if !strings.Contains(v.View(), "node-09999") {
+		t.Error("last node should be rendered")
+	}
+	keys("k", 3)
+	if got := v.GetSelectedNode().Name; got != "node-09996" {
+		t.Errorf("expected node-09996, got %s", got)
+	}
+
+	// A refresh keeps the selection
+	v.SetNodes(largeFleet(10000))
+	if got := v.GetSelectedNode().Name; got != "node-09996" {
+		t.Errorf("refresh moved the selection to %s", got)
+	}
+
+	keys("g", 1)
+	if got := v.GetSelectedNode().Name; got != "node-00000" {
+		t.Errorf("expected the first node at the top, got %s", got)
+	}
+}
+
+func TestListViewClampsCursorWhenFilterShrinksList(t *testing.T) {
+	v := NewListView()
+	v.Update(tea.WindowSizeMsg{Width: 120, Height: 24})
+	nodes := largeFleet(1000)
+	nodes[3].Status = nodev1.NodeStatus_DOWN
+	v.SetNodes(nodes)
+
+	v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("G")})
+	v.SetStatusFilter(nodev1.NodeStatus_DOWN)
+
+	if got := v.GetSelectedNode(); got == nil || got.Name != "node-00003" {
+		t.Errorf("expected the only DOWN node selected, got %+v", got)
+	}
+}
\ No newline at end of file
@@ -6,18 +6,32 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/melkior/nodestatus/internal/data"
 	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/melkior/nodestatus/internal/data"
+	"github.com/melkior/nodestatus/internal/tui/theme"
 )
 
-// LogsView displays a scrollable event log
+// LogsView displays a scrollable event log. It is not safe for concurrent
+// use: events and the recorder are only changed from the UI goroutine.
 type LogsView struct {
-	events      []*data.Event
-	maxEvents   int
-	width       int
-	height      int
-	offset      int
-	autoScroll  bool
+	events     []*data.Event
+	maxEvents  int
+	width      int
+	height     int
+	offset     int
+	autoScroll bool
+	sampleRate float64       // Fraction of events the log receives, shown in the header
+	recorder   EventRecorder // Receives every added event when set, regardless of maxEvents
+	theme      theme.Theme
+
+	// Filters applied when rendering; events keeps the full history
+	typeFilter nodev1.EventType // Unspecified shows every type
+	nodeFilter *data.Node       // Only events for this node when set
+}
+
+// EventRecorder persists events added to the log
+type EventRecorder interface {
+	Record(event *data.Event)
 }
 
 // NewLogsView creates a new logs view
@@ -26,9 +40,15 @@ func NewLogsView(maxEvents int) *LogsView {
 		events:     make([]*data.Event, 0, maxEvents),
 		maxEvents:  maxEvents,
 		autoScroll: true,
+		theme:      theme.Dark,
 	}
 }
 
+// SetTheme switches the colors of the view
+func (v *LogsView) SetTheme(t theme.Theme) {
+	v.theme = t
+}
+
 // Update handles messages
 func (v *LogsView) Update(msg tea.Msg) tea.Cmd {
 	switch msg := msg.(type) {
@@ -45,7 +65,7 @@ func (v *LogsView) Update(msg tea.Msg) tea.Cmd {
 			}
 		case "down", "j":
 			v.offset++
-			if v.offset >= len(v.events)-v.height+2 {
+			if v.offset >= len(v.visibleEvents())-v.height+2 {
 				v.autoScroll = true
 			}
 		case "pgup":
@@ -60,10 +80,15 @@ func (v *LogsView) Update(msg tea.Msg) tea.Cmd {
 			v.offset = 0
 			v.autoScroll = false
 		case "end":
-			v.offset = len(v.events) - v.height + 2
+			v.offset = len(v.visibleEvents()) - v.height + 2
 			v.autoScroll = true
 		case "a":
 			v.autoScroll = !v.autoScroll
+		case "t":
+			v.cycleTypeFilter()
+		case "r":
+			v.SetTypeFilter(nodev1.EventType_EVENT_TYPE_UNSPECIFIED)
+			v.SetNodeFilter(nil)
 		}
 	}
 
@@ -77,12 +102,22 @@ func (v *LogsView) View() string {
 	// Header
 	headerStyle := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("#7D56F4"))
+		Foreground(v.theme.Primary)
 
 	header := "Event Log"
 	if v.autoScroll {
 		header += " [AUTO-SCROLL]"
 	}
+	if v.recorder != nil {
+		header += " [REC]"
+	}
+	if v.sampleRate > 0 && v.sampleRate < 1 {
+		header += fmt.Sprintf(" [SAMPLED %g%%]", v.sampleRate*100)
+	}
+	events := v.visibleEvents()
+	if filter := v.filterString(); filter != "" {
+		header += fmt.Sprintf(" [FILTER %s, %d hidden]", filter, len(v.events)-len(events))
+	}
 	b.WriteString(headerStyle.Render(header))
 	b.WriteString("\n\n")
 
@@ -93,13 +128,13 @@ func (v *LogsView) View() string {
 	}
 
 	// Apply auto-scroll
-	if v.autoScroll && len(v.events) > visibleLines {
-		v.offset = len(v.events) - visibleLines
+	if v.autoScroll && len(events) > visibleLines {
+		v.offset = len(events) - visibleLines
 	}
 
 	// Ensure offset is valid
-	if v.offset > len(v.events)-visibleLines {
-		v.offset = len(v.events) - visibleLines
+	if v.offset > len(events)-visibleLines {
+		v.offset = len(events) - visibleLines
 	}
 	if v.offset < 0 {
 		v.offset = 0
@@ -108,18 +143,18 @@ func (v *LogsView) View() string {
 	// Get visible events
 	startIdx := v.offset
 	endIdx := startIdx + visibleLines
-	if endIdx > len(v.events) {
-		endIdx = len(v.events)
+	if endIdx > len(events) {
+		endIdx = len(events)
 	}
 
 	// Render events
-	if len(v.events) == 0 {
+	if len(events) == 0 {
 		b.WriteString(lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
-			Render("No events yet..."))
+			Foreground(v.theme.Muted).
+			Render(v.emptyMessage()))
 	} else {
 		for i := startIdx; i < endIdx; i++ {
-			b.WriteString(v.formatEvent(v.events[i]))
+			b.WriteString(v.formatEvent(events[i]))
 			if i < endIdx-1 {
 				b.WriteString("\n")
 			}
@@ -127,10 +162,10 @@ func (v *LogsView) View() string {
 	}
 
 	// Add scroll indicator
-	if len(v.events) > visibleLines {
-		scrollInfo := fmt.Sprintf("\n[%d-%d/%d]", startIdx+1, endIdx, len(v.events))
+	if len(events) > visibleLines {
+		scrollInfo := fmt.Sprintf("\n[%d-%d/%d]", startIdx+1, endIdx, len(events))
 		b.WriteString(lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
+			Foreground(v.theme.Muted).
 			Render(scrollInfo))
 	}
 
@@ -144,6 +179,10 @@ func (v *LogsView) AddEvent(event *data.Event) {
 		return
 	}
 
+	if v.recorder != nil {
+		v.recorder.Record(event)
+	}
+
 	v.events = append(v.events, event)
 
 	// Trim to max size
@@ -152,6 +191,93 @@ func (v *LogsView) AddEvent(event *data.Event) {
 	}
 }
 
+// SetRecorder tees every added event to r; nil stops recording. The in-memory
+// log keeps its own cap either way. Since AddEvent runs on the same
+// goroutine, no event is recorded after SetRecorder(nil) returns.
+func (v *LogsView) SetRecorder(r EventRecorder) {
+	v.recorder = r
+}
+
+// Recording reports whether added events are being persisted
+func (v *LogsView) Recording() bool {
+	return v.recorder != nil
+}
+
+// SetTypeFilter limits the log to one event type; unspecified shows all
+func (v *LogsView) SetTypeFilter(eventType nodev1.EventType) {
+	v.typeFilter = eventType
+	v.offset = 0
+}
+
+// SetNodeFilter limits the log to events for node; nil shows all nodes
+func (v *LogsView) SetNodeFilter(node *data.Node) {
+	v.nodeFilter = node
+	v.offset = 0
+}
+
+// NodeFilter returns the node the log is limited to, or nil
+func (v *LogsView) NodeFilter() *data.Node {
+	return v.nodeFilter
+}
+
+// cycleTypeFilter steps the type filter through all, CREATED, UPDATED and
+// DELETED
+func (v *LogsView) cycleTypeFilter() {
+	next := v.typeFilter + 1
+	if next > nodev1.EventType_DELETED {
+		next = nodev1.EventType_EVENT_TYPE_UNSPECIFIED
+	}
+	v.SetTypeFilter(next)
+}
+
+// visibleEvents returns the events passing the active filters
+func (v *LogsView) visibleEvents() []*data.Event {
+	if v.typeFilter == nodev1.EventType_EVENT_TYPE_UNSPECIFIED && v.nodeFilter == nil {
+		return v.events
+	}
+
+	events := make([]*data.Event, 0, len(v.events))
+	for _, event := range v.events {
+		if v.typeFilter != nodev1.EventType_EVENT_TYPE_UNSPECIFIED && event.Type != v.typeFilter {
+			continue
+		}
+		if v.nodeFilter != nil && event.Node.ID != v.nodeFilter.ID {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// filterString describes the active filters, empty when there are none
+func (v *LogsView) filterString() string {
+	var parts []string
+	if v.typeFilter != nodev1.EventType_EVENT_TYPE_UNSPECIFIED {
+		parts = append(parts, "type="+v.getEventTypeName(v.typeFilter))
+	}
+	if v.nodeFilter != nil {
+		parts = append(parts, "node="+v.nodeFilter.Name)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (v *LogsView) emptyMessage() string {
+	if len(v.events) > 0 {
+		return "No events match the filter (r to reset)"
+	}
+	return "No events yet..."
+}
+
+// SetSampleRate records the fraction of events being forwarded to the log
+func (v *LogsView) SetSampleRate(rate float64) {
+	v.sampleRate = rate
+}
+
+// EventCount returns the number of events held by the log
+func (v *LogsView) EventCount() int {
+	return len(v.events)
+}
+
 // Clear clears all events
 func (v *LogsView) Clear() {
 	v.events = v.events[:0]
@@ -162,7 +288,7 @@ func (v *LogsView) Clear() {
 func (v *LogsView) formatEvent(event *data.Event) string {
 	// Timestamp
 	timestampStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("241"))
+		Foreground(v.theme.Muted)
 
 	timestamp := event.Timestamp.Format("15:04:05")
 
@@ -172,13 +298,19 @@ func (v *LogsView) formatEvent(event *data.Event) string {
 
 	// Node info
 	nodeStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#C0CAF5"))
+		Foreground(v.theme.Text)
 
 	nodeInfo := fmt.Sprintf("%s (%s)", event.Node.Name, event.Node.Type.String())
 
-	// Status
-	statusStyle := GetStatusStyle(event.Node.Status.String())
+	// Status, as old→new when the event carries the status change
+	statusStyle := v.theme.StatusStyle(event.Node.Status.String())
 	status := event.Node.Status.String()
+	if change, ok := event.Change("status"); ok {
+		status = change.Old + "→" + change.New
+	}
+	if event.Node.Reason != "" {
+		status += ": " + event.Node.Reason
+	}
 
 	// Build event line
 	line := fmt.Sprintf("%s %s %s [%s]",
@@ -190,7 +322,7 @@ func (v *LogsView) formatEvent(event *data.Event) string {
 	// Add changed fields if present
 	if len(event.ChangedFields) > 0 {
 		changedStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
+			Foreground(v.theme.Muted).
 			Italic(true)
 		line += " " + changedStyle.Render(fmt.Sprintf("(%s)", strings.Join(event.ChangedFields, ", ")))
 	}
@@ -203,13 +335,13 @@ func (v *LogsView) getEventTypeStyle(eventType nodev1.EventType) lipgloss.Style
 	var color lipgloss.Color
 	switch eventType {
 	case nodev1.EventType_CREATED:
-		color = lipgloss.Color("#04B575")
+		color = v.theme.Success
 	case nodev1.EventType_UPDATED:
-		color = lipgloss.Color("#FFA500")
+		color = v.theme.Warning
 	case nodev1.EventType_DELETED:
-		color = lipgloss.Color("#FF0000")
+		color = v.theme.Error
 	default:
-		color = lipgloss.Color("#626262")
+		color = v.theme.StatusUnknown
 	}
 	return lipgloss.NewStyle().Foreground(color).Bold(true)
 }
@@ -0,0 +1,104 @@
+package views
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/melkior/nodestatus/internal/data"
+)
+
+func TestLogsViewFiltersKeepHistory(t *testing.T) {
+	v := NewLogsView(100)
+	v.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+
+	web := &data.Node{ID: "id-web", Name: "web-01"}
+	db := &data.Node{ID: "id-db", Name: "db-01"}
+	for _, e := range []*data.Event{
+		{Type: nodev1.EventType_CREATED, Node: web},
+		{Type: nodev1.EventType_UPDATED, Node: web},
+		{Type: nodev1.EventType_UPDATED, Node: db},
+		{Type: nodev1.EventType_DELETED, Node: db},
+	} {
+		v.AddEvent(e)
+	}
+
+	// t cycles to CREATED, then UPDATED
+	v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	out := v.View()
+	if !strings.Contains(out, "[FILTER type=UPDATED, 2 hidden]") {
+		t.Errorf("expected the UPDATED filter in the header, got:\n%s", out)
+	}
+	if strings.Contains(out, "CREATED") || strings.Contains(out, "DELETED") {
+		t.Errorf("only UPDATED events should be shown, got:\n%s", out)
+	}
+
+	v.SetNodeFilter(db)
+	out = v.View()
+	if !strings.Contains(out, "[FILTER type=UPDATED node=db-01, 3 hidden]") {
+		t.Errorf("expected both filters in the header, got:\n%s", out)
+	}
+	if strings.Contains(out, "web-01") {
+		t.Errorf("events of other nodes should be hidden, got:\n%s", out)
+	}
+
+	v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	out = v.View()
+	if strings.Contains(out, "FILTER") || strings.Count(out, "-01") != 4 {
+		t.Errorf("reset should show all 4 events again, got:\n%s", out)
+	}
+	if v.EventCount() != 4 {
+		t.Errorf("filters must not drop history, got %d events", v.EventCount())
+	}
+}
+
+func TestLogsViewShowsStatusTransition(t *testing.T) {
+	v := NewLogsView(100)
+	v.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+
+	node := &data.Node{ID: "id-web", Name: "web-01", Status: nodev1.NodeStatus_DOWN}
+	v.AddEvent(&data.Event{
+		Type:          nodev1.EventType_UPDATED,
+		Node:          node,
+		ChangedFields: []string{"status"},
+		Changes:       []data.FieldChange{{Field: "status", Old: "UP", New: "DOWN"}},
+	})
+	if out := v.View(); !strings.Contains(out, "UP→DOWN") {
+		t.Errorf("expected the status transition, got:\n%s", out)
+	}
+}
+
+type recordedEvents []*data.Event
+
+func (r *recordedEvents) Record(event *data.Event) {
+	*r = append(*r, event)
+}
+
+func TestLogsViewRecorderSeesEveryEvent(t *testing.T) {
+	v := NewLogsView(2)
+	v.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+
+	var rec recordedEvents
+	v.SetRecorder(&rec)
+	for i := 0; i < 5; i++ {
+		v.AddEvent(&data.Event{Type: nodev1.EventType_UPDATED, Node: &data.Node{ID: "id", Name: "web-01"}})
+	}
+
+	if len(rec) != 5 {
+		t.Errorf("expected the recorder to receive all 5 events, got %d", len(rec))
+	}
+	if len(v.events) != 2 {
+		t.Errorf("expected the display log to stay capped at 2, got %d", len(v.events))
+	}
+	if !strings.Contains(v.View(), "[REC]") {
+		t.Errorf("expected the header to show recording")
+	}
+
+	v.SetRecorder(nil)
+	v.AddEvent(&data.Event{Type: nodev1.EventType_UPDATED, Node: &data.Node{ID: "id"}})
+	if len(rec) != 5 || v.Recording() {
+		t.Errorf("expected recording to stop, recorder has %d events", len(rec))
+	}
+}
\ No newline at end of file
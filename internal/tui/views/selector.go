@@ -0,0 +1,86 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+)
+
+// labelRequirement is a single term of a label selector
+type labelRequirement struct {
+	key     string
+	value   string
+	negate  bool
+	hasOnly bool // Bare key: only requires the label to be present
+}
+
+// labelSelector is a conjunction of label requirements, e.g. "env=prod,service!=db,team"
+type labelSelector []labelRequirement
+
+// parseLabelSelector parses a comma-separated selector; an empty string matches everything
+func parseLabelSelector(raw string) (labelSelector, error) {
+	var sel labelSelector
+
+	for _, term := range strings.Split(raw, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		var req labelRequirement
+		switch {
+		case strings.Contains(term, "!="):
+			parts := strings.SplitN(term, "!=", 2)
+			req = labelRequirement{key: strings.TrimSpace(parts[0]), value: strings.TrimSpace(parts[1]), negate: true}
+		case strings.Contains(term, "="):
+			parts := strings.SplitN(term, "=", 2)
+			req = labelRequirement{key: strings.TrimSpace(parts[0]), value: strings.TrimSpace(parts[1])}
+		default:
+			req = labelRequirement{key: term, hasOnly: true}
+		}
+
+		if req.key == "" {
+			return nil, fmt.Errorf("invalid label selector term %q", term)
+		}
+		sel = append(sel, req)
+	}
+
+	return sel, nil
+}
+
+// Matches reports whether labels satisfy every requirement
+func (s labelSelector) Matches(labels map[string]string) bool {
+	for _, req := range s {
+		value, ok := labels[req.key]
+		switch {
+		case req.hasOnly:
+			if !ok {
+				return false
+			}
+		case req.negate:
+			if ok && value == req.value {
+				return false
+			}
+		default:
+			if !ok || value != req.value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// String renders the selector back to its textual form
+func (s labelSelector) String() string {
+	terms := make([]string, 0, len(s))
+	for _, req := range s {
+		switch {
+		case req.hasOnly:
+			terms = append(terms, req.key)
+		case req.negate:
+			terms = append(terms, req.key+"!="+req.value)
+		default:
+			terms = append(terms, req.key+"="+req.value)
+		}
+	}
+	return strings.Join(terms, ",")
+}
\ No newline at end of file
@@ -0,0 +1,41 @@
+package views
+
+import "testing"
+
+func TestLabelSelectorMatches(t *testing.T) {
+	labels := map[string]string{"env": "prod", "service": "api"}
+
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"", true},
+		{"env=prod", true},
+		{"env=staging", false},
+		{"service!=db", true},
+		{"service!=api", false},
+		{"team!=core", true},
+		{"env", true},
+		{"team", false},
+		{"env=prod, service=api", true},
+		{"env=prod,service=db", false},
+	}
+
+	for _, tt := range tests {
+		sel, err := parseLabelSelector(tt.selector)
+		if err != nil {
+			t.Fatalf("parseLabelSelector(%q): %v", tt.selector, err)
+		}
+		if got := sel.Matches(labels); got != tt.want {
+			t.Errorf("%q.Matches() = %v, want %v", tt.selector, got, tt.want)
+		}
+	}
+}
+
+func TestParseLabelSelectorRejectsEmptyKey(t *testing.T) {
+	for _, raw := range []string{"=prod", "env=prod,!=db"} {
+		if _, err := parseLabelSelector(raw); err == nil {
+			t.Errorf("parseLabelSelector(%q) succeeded, want error", raw)
+		}
+	}
+}
\ No newline at end of file
@@ -3,76 +3,403 @@ package grpcclient
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
 	"github.com/melkior/nodestatus/internal/logging"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 type Client struct {
-	conn   *grpc.ClientConn
-	client nodev1.NodeServiceClient
-	token  string
+	conns   []*grpc.ClientConn
+	clients []nodev1.NodeServiceClient
+	next    atomic.Uint64
+	token   string
+	// authHeader is the metadata key the token is sent under
+	authHeader string
 }
 
-func NewClient(addr, token string) (*Client, error) {
-	logging.Debug("Creating gRPC client for %s", addr)
+// Option configures a Client
+type Option func(*clientOptions)
 
-	logging.Debug("Calling grpc.NewClient...")
-	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		logging.Error("grpc.NewClient failed: %v", err)
-		return nil, fmt.Errorf("failed to connect: %w", err)
+type clientOptions struct {
+	retry            RetryPolicy
+	waitForReady     bool
+	keepaliveTime    time.Duration
+	keepaliveTimeout time.Duration
+	callTimeout      time.Duration
+	authHeader       string
+	readerToken      string
+	metadata         []func(context.Context) metadata.MD
+	dialOptions      []grpc.DialOption
+}
+
+const (
+	// DefaultKeepaliveTime is how long a connection may sit idle before the
+	// client pings the server, so NATs and load balancers keep streams open
+	DefaultKeepaliveTime = 30 * time.Second
+	// DefaultKeepaliveTimeout is how long to wait for a ping ack before
+	// treating the connection as dead
+	DefaultKeepaliveTimeout = 10 * time.Second
+	// DefaultCallTimeout bounds unary calls made without a deadline
+	DefaultCallTimeout = 30 * time.Second
+	// DefaultAuthHeader is the metadata key the bearer token is sent under
+	DefaultAuthHeader = "authorization"
+)
+
+// WithRetryPolicy replaces the default retry policy for unary calls
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *clientOptions) {
+		o.retry = policy
 	}
-	logging.Debug("grpc.NewClient successful, connection created")
+}
 
-	logging.Debug("Creating NodeServiceClient...")
-	client := nodev1.NewNodeServiceClient(conn)
+// WithoutRetry disables automatic retries, for callers that retry themselves
+func WithoutRetry() Option {
+	return func(o *clientOptions) {
+		o.retry.MaxAttempts = 1
+	}
+}
+
+// WithWaitForReady makes calls wait for a ready connection instead of failing
+// fast with Unavailable while the backend is down or restarting. Calls then
+// block until their context ends, so give them a deadline.
+func WithWaitForReady() Option {
+	return func(o *clientOptions) {
+		o.waitForReady = true
+	}
+}
+
+// WithKeepalive sets the keepalive ping interval and ack timeout. A zero
+// interval disables keepalive pings.
+func WithKeepalive(interval, timeout time.Duration) Option {
+	return func(o *clientOptions) {
+		o.keepaliveTime = interval
+		o.keepaliveTimeout = timeout
+	}
+}
+
+// WithCallTimeout sets the timeout applied to unary calls whose context has no
+// deadline, retries included. Zero disables it. Streams are not affected.
+func WithCallTimeout(d time.Duration) Option {
+	return func(o *clientOptions) {
+		o.callTimeout = d
+	}
+}
+
+// WithAuthHeader sends the bearer token under key instead of
+// DefaultAuthHeader, for servers or proxies that expect another header
+func WithAuthHeader(key string) Option {
+	return func(o *clientOptions) {
+		o.authHeader = key
+	}
+}
+
+// WithReaderToken sends token on reads and streams instead of the client's
+// main token, for servers that set REQUIRE_READ_AUTH and READER_TOKEN.
+// Mutations still send the main token.
+func WithReaderToken(token string) Option {
+	return func(o *clientOptions) {
+		o.readerToken = token
+	}
+}
+
+// WithMetadata adds fixed key/value pairs, e.g. a tenant header, to the
+// outgoing metadata of every call, reads and streams included. It panics on
+// an odd number of arguments, like metadata.Pairs.
+func WithMetadata(kv ...string) Option {
+	md := metadata.Pairs(kv...)
+	return WithMetadataFunc(func(context.Context) metadata.MD {
+		return md
+	})
+}
+
+// WithMetadataFunc calls fn for every call and adds the metadata it returns,
+// e.g. a request ID taken from ctx. A nil result adds nothing.
+func WithMetadataFunc(fn func(ctx context.Context) metadata.MD) Option {
+	return func(o *clientOptions) {
+		o.metadata = append(o.metadata, fn)
+	}
+}
+
+// WithDialOptions passes extra options to grpc.NewClient, e.g. tracing or
+// metrics interceptors. They apply after the client's own options, so
+// chained interceptors run inside the metadata, timeout and retry ones and
+// see each retry attempt.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *clientOptions) {
+		o.dialOptions = append(o.dialOptions, opts...)
+	}
+}
+
+// NewClient connects to addr. Unary calls that fail with a transient error are
+// retried with DefaultRetryPolicy unless opts say otherwise.
+func NewClient(addr, token string, opts ...Option) (*Client, error) {
+	return NewPooledClient(addr, token, 1, opts...)
+}
+
+// NewPooledClient opens poolSize connections to addr and spreads calls across
+// them round-robin. A single connection multiplexes all calls over one
+// HTTP/2 transport, which can become the bottleneck under high concurrency.
+// A poolSize below 1 is treated as 1.
+func NewPooledClient(addr, token string, poolSize int, opts ...Option) (*Client, error) {
+	if poolSize < 1 {
+		poolSize = 1
+	}
+	options := clientOptions{
+		retry:            DefaultRetryPolicy(),
+		keepaliveTime:    DefaultKeepaliveTime,
+		keepaliveTimeout: DefaultKeepaliveTimeout,
+		callTimeout:      DefaultCallTimeout,
+		authHeader:       DefaultAuthHeader,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	readToken := token
+	if options.readerToken != "" {
+		readToken = options.readerToken
+	}
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		// The timeout wraps the retries so it bounds the whole call
+		grpc.WithChainUnaryInterceptor(
+			unaryReadAuthInterceptor(options.authHeader, readToken),
+			unaryMetadataInterceptor(options.metadata),
+			unaryTimeoutInterceptor(options.callTimeout),
+			unaryRetryInterceptor(options.retry),
+		),
+		grpc.WithChainStreamInterceptor(
+			streamReadAuthInterceptor(options.authHeader, readToken),
+			streamMetadataInterceptor(options.metadata),
+		),
+	}
+	if options.keepaliveTime > 0 {
+		dialOpts = append(dialOpts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    options.keepaliveTime,
+			Timeout: options.keepaliveTimeout,
+		}))
+	}
+	if options.waitForReady {
+		dialOpts = append(dialOpts, grpc.WithDefaultCallOptions(grpc.WaitForReady(true)))
+	}
+	dialOpts = append(dialOpts, options.dialOptions...)
+	logging.Debug("Creating gRPC client for %s (pool size %d)", addr, poolSize)
+
+	c := &Client{token: token, authHeader: options.authHeader}
+	for i := 0; i < poolSize; i++ {
+		logging.Debug("Calling grpc.NewClient...")
+		conn, err := grpc.NewClient(addr, dialOpts...)
+		if err != nil {
+			logging.Error("grpc.NewClient failed: %v", err)
+			c.Close()
+			return nil, fmt.Errorf("failed to connect: %w", err)
+		}
+		logging.Debug("grpc.NewClient successful, connection created")
+
+		c.conns = append(c.conns, conn)
+		c.clients = append(c.clients, nodev1.NewNodeServiceClient(conn))
+	}
 
 	logging.Debug("gRPC client created successfully for %s", addr)
-	return &Client{
-		conn:   conn,
-		client: client,
-		token:  token,
-	}, nil
+	return c, nil
 }
 
 // Compatibility with new naming
-func New(addr, token string) (*Client, error) {
-	return NewClient(addr, token)
+func New(addr, token string, opts ...Option) (*Client, error) {
+	return NewClient(addr, token, opts...)
 }
 
-// NodeService returns the underlying node service client
+// NodeService returns the underlying node service client. With a pool, each
+// call returns the next connection's client.
 func (c *Client) NodeService() nodev1.NodeServiceClient {
-	return c.client
+	return c.pick()
 }
 
-func (c *Client) Close() error {
-	if c.conn != nil {
-		return c.conn.Close()
+// PoolSize returns the number of pooled connections
+func (c *Client) PoolSize() int {
+	return len(c.conns)
+}
+
+// pick returns the next client in round-robin order
+func (c *Client) pick() nodev1.NodeServiceClient {
+	if len(c.clients) == 1 {
+		return c.clients[0]
+	}
+	n := c.next.Add(1) - 1
+	return c.clients[n%uint64(len(c.clients))]
+}
+
+// unaryTimeoutInterceptor gives calls without a deadline one of timeout
+func unaryTimeoutInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); ok || timeout <= 0 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// outgoingMetadata appends the metadata of every fn to ctx
+func outgoingMetadata(ctx context.Context, fns []func(context.Context) metadata.MD) context.Context {
+	for _, fn := range fns {
+		for key, values := range fn(ctx) {
+			for _, v := range values {
+				ctx = metadata.AppendToOutgoingContext(ctx, key, v)
+			}
+		}
+	}
+	return ctx
+}
+
+// unaryMetadataInterceptor adds the WithMetadata metadata to unary calls
+func unaryMetadataInterceptor(fns []func(context.Context) metadata.MD) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoingMetadata(ctx, fns), method, req, reply, cc, opts...)
+	}
+}
+
+// streamMetadataInterceptor adds the WithMetadata metadata to streams
+func streamMetadataInterceptor(fns []func(context.Context) metadata.MD) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoingMetadata(ctx, fns), desc, cc, method, opts...)
+	}
+}
+
+// readAuthContext adds token to calls that carry no token of their own, i.e.
+// reads, streams and calls made through NodeService, so they keep working on
+// servers that require auth for reads. Mutations already carry theirs.
+func readAuthContext(ctx context.Context, header, token string) context.Context {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(header)) > 0 {
+		return ctx
+	}
+	return withToken(ctx, header, token)
+}
+
+// unaryReadAuthInterceptor applies readAuthContext to unary calls
+func unaryReadAuthInterceptor(header, token string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(readAuthContext(ctx, header, token), method, req, reply, cc, opts...)
+	}
+}
+
+// streamReadAuthInterceptor applies readAuthContext to streams
+func streamReadAuthInterceptor(header, token string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(readAuthContext(ctx, header, token), desc, cc, method, opts...)
+	}
+}
+
+// stateRank orders connection states from least to most usable
+var stateRank = map[connectivity.State]int{
+	connectivity.Shutdown:         0,
+	connectivity.TransientFailure: 1,
+	connectivity.Idle:             2,
+	connectivity.Connecting:       3,
+	connectivity.Ready:            4,
+}
+
+// State returns the connection state, for display. With a pool it is the most
+// usable state among the connections, so Ready means calls can go through.
+func (c *Client) State() connectivity.State {
+	best := connectivity.Shutdown
+	for _, conn := range c.conns {
+		if st := conn.GetState(); stateRank[st] > stateRank[best] {
+			best = st
+		}
+	}
+	return best
+}
+
+// WaitForReady connects every pooled connection and blocks until all of them
+// are ready, or ctx ends. grpc.NewClient connects lazily, so calling this
+// first avoids the first RPC failing while the connection comes up.
+func (c *Client) WaitForReady(ctx context.Context) error {
+	for _, conn := range c.conns {
+		conn.Connect()
+		for {
+			st := conn.GetState()
+			if st == connectivity.Ready {
+				break
+			}
+			if st == connectivity.Shutdown {
+				return fmt.Errorf("connection to %s is closed", conn.Target())
+			}
+			if !conn.WaitForStateChange(ctx, st) {
+				return fmt.Errorf("connection to %s not ready (%s): %w", conn.Target(), st, ctx.Err())
+			}
+		}
 	}
 	return nil
 }
 
+func (c *Client) Close() error {
+	var firstErr error
+	for _, conn := range c.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// authContext adds the bearer token to the outgoing metadata of a mutating call
 func (c *Client) authContext(ctx context.Context) context.Context {
-	if c.token != "" {
-		return metadata.AppendToOutgoingContext(ctx, "authorization", fmt.Sprintf("Bearer %s", c.token))
+	return withToken(ctx, c.authHeader, c.token)
+}
+
+func withToken(ctx context.Context, header, token string) context.Context {
+	if token != "" {
+		return metadata.AppendToOutgoingContext(ctx, header, fmt.Sprintf("Bearer %s", token))
 	}
 	return ctx
 }
 
 func (c *Client) CreateNode(ctx context.Context, node *nodev1.Node) (*nodev1.Node, error) {
-	resp, err := c.client.CreateNode(c.authContext(ctx), &nodev1.CreateNodeRequest{Node: node})
+	resp, err := c.pick().CreateNode(c.authContext(ctx), &nodev1.CreateNodeRequest{Node: node})
 	if err != nil {
 		return nil, err
 	}
 	return resp.Node, nil
 }
 
+// UpsertNode creates node or returns the one already stored under its name and
+// type, copying the named fields onto it. The bool reports whether it was created.
+func (c *Client) UpsertNode(ctx context.Context, node *nodev1.Node, paths ...string) (*nodev1.Node, bool, error) {
+	req := &nodev1.CreateNodeRequest{Node: node, Upsert: true}
+	if len(paths) > 0 {
+		req.UpdateMask = &fieldmaskpb.FieldMask{Paths: paths}
+	}
+	resp, err := c.pick().CreateNode(c.authContext(ctx), req)
+	if err != nil {
+		return nil, false, err
+	}
+	return resp.Node, resp.Created, nil
+}
+
 func (c *Client) UpdateNode(ctx context.Context, node *nodev1.Node) (*nodev1.Node, error) {
-	resp, err := c.client.UpdateNode(c.authContext(ctx), &nodev1.UpdateNodeRequest{Node: node})
+	resp, err := c.pick().UpdateNode(c.authContext(ctx), &nodev1.UpdateNodeRequest{Node: node})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Node, nil
+}
+
+// UpdateNodeFields updates only the named fields of node, leaving the rest of
+// the stored node untouched. See UpdateNodeRequest.update_mask for the paths.
+func (c *Client) UpdateNodeFields(ctx context.Context, node *nodev1.Node, paths ...string) (*nodev1.Node, error) {
+	resp, err := c.pick().UpdateNode(c.authContext(ctx), &nodev1.UpdateNodeRequest{
+		Node:       node,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: paths},
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -80,9 +407,16 @@ func (c *Client) UpdateNode(ctx context.Context, node *nodev1.Node) (*nodev1.Nod
 }
 
 func (c *Client) UpdateStatus(ctx context.Context, id string, status nodev1.NodeStatus) (*nodev1.Node, error) {
-	resp, err := c.client.UpdateStatus(c.authContext(ctx), &nodev1.UpdateStatusRequest{
+	return c.UpdateStatusReason(ctx, id, status, "")
+}
+
+// UpdateStatusReason sets a node's status along with why it is in it, e.g.
+// "disk full". An empty reason clears the stored one when the status changes.
+func (c *Client) UpdateStatusReason(ctx context.Context, id string, status nodev1.NodeStatus, reason string) (*nodev1.Node, error) {
+	resp, err := c.pick().UpdateStatus(c.authContext(ctx), &nodev1.UpdateStatusRequest{
 		Id:     id,
 		Status: status,
+		Reason: reason,
 	})
 	if err != nil {
 		return nil, err
@@ -90,48 +424,173 @@ func (c *Client) UpdateStatus(ctx context.Context, id string, status nodev1.Node
 	return resp.Node, nil
 }
 
+// BatchUpdateStatus sets the status of many nodes in one RPC. Per-node
+// failures are reported in the results rather than as an error.
+func (c *Client) BatchUpdateStatus(ctx context.Context, updates []*nodev1.UpdateStatusRequest) ([]*nodev1.BatchUpdateStatusResult, error) {
+	resp, err := c.pick().BatchUpdateStatus(c.authContext(ctx), &nodev1.BatchUpdateStatusRequest{Updates: updates})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
 func (c *Client) DeleteNode(ctx context.Context, id string) error {
-	_, err := c.client.DeleteNode(c.authContext(ctx), &nodev1.DeleteNodeRequest{Id: id})
+	_, err := c.pick().DeleteNode(c.authContext(ctx), &nodev1.DeleteNodeRequest{Id: id})
 	return err
 }
 
 func (c *Client) GetNode(ctx context.Context, id string) (*nodev1.Node, error) {
-	resp, err := c.client.GetNode(ctx, &nodev1.GetNodeRequest{Id: id})
+	resp, err := c.pick().GetNode(ctx, &nodev1.GetNodeRequest{Id: id})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Node, nil
+}
+
+// GetNodeByName looks a node up by its name, which is unique per type
+func (c *Client) GetNodeByName(ctx context.Context, nodeType nodev1.NodeType, name string) (*nodev1.Node, error) {
+	resp, err := c.pick().GetNode(ctx, &nodev1.GetNodeRequest{Name: name, Type: nodeType})
 	if err != nil {
 		return nil, err
 	}
 	return resp.Node, nil
 }
 
+// GetServerStats returns the backend's watcher, node and event counts and uptime
+func (c *Client) GetServerStats(ctx context.Context) (*nodev1.GetServerStatsResponse, error) {
+	return c.pick().GetServerStats(ctx, &nodev1.GetServerStatsRequest{})
+}
+
+// listPageSize is the page size requested by ListNodes and ListNodesStream
+const listPageSize = 100
+
 func (c *Client) ListNodes(ctx context.Context, typeFilter nodev1.NodeType, statusFilter nodev1.NodeStatus) ([]*nodev1.Node, error) {
 	var allNodes []*nodev1.Node
-	pageToken := ""
+	err := c.listPages(ctx, typeFilter, statusFilter, func(nodes []*nodev1.Node) error {
+		allNodes = append(allNodes, nodes...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allNodes, nil
+}
+
+// ListNodesStream pages through ListNodes in the background and sends nodes as
+// each page arrives, so large fleets needn't be held in memory at once. The
+// node channel is closed when the listing ends; the error channel then yields
+// the error that stopped it, if any, and is closed. Cancelling ctx stops the
+// listing early.
+func (c *Client) ListNodesStream(ctx context.Context, typeFilter nodev1.NodeType, statusFilter nodev1.NodeStatus) (<-chan *nodev1.Node, <-chan error) {
+	out := make(chan *nodev1.Node, listPageSize)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(errc)
+		err := c.listPages(ctx, typeFilter, statusFilter, func(nodes []*nodev1.Node) error {
+			for _, node := range nodes {
+				select {
+				case out <- node:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return nil
+		})
+		close(out)
+		if err != nil {
+			errc <- err
+		}
+	}()
 
+	return out, errc
+}
+
+// ListNodesPage fetches one page of up to listPageSize nodes after pageToken
+// (empty for the first page) and returns the token of the next one, empty on
+// the last page. With includeTotal the server also counts every node matching
+// the filters; the count costs an extra query, so ask for it once rather than
+// on every page. total is 0 otherwise.
+func (c *Client) ListNodesPage(ctx context.Context, typeFilter nodev1.NodeType, statusFilter nodev1.NodeStatus, pageToken string, includeTotal bool) (nodes []*nodev1.Node, nextPageToken string, total int64, err error) {
+	resp, err := c.pick().ListNodes(ctx, &nodev1.ListNodesRequest{
+		PageSize:     listPageSize,
+		PageToken:    pageToken,
+		TypeFilter:   typeFilter,
+		StatusFilter: statusFilter,
+		IncludeTotal: includeTotal,
+	})
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return resp.Nodes, resp.NextPageToken, resp.TotalCount, nil
+}
+
+// listPages calls fn with each page of ListNodes until the last page or an
+// error from either the RPC or fn
+func (c *Client) listPages(ctx context.Context, typeFilter nodev1.NodeType, statusFilter nodev1.NodeStatus, fn func([]*nodev1.Node) error) error {
+	pageToken := ""
 	for {
-		resp, err := c.client.ListNodes(ctx, &nodev1.ListNodesRequest{
-			PageSize:     100,
+		resp, err := c.pick().ListNodes(ctx, &nodev1.ListNodesRequest{
+			PageSize:     listPageSize,
 			PageToken:    pageToken,
 			TypeFilter:   typeFilter,
 			StatusFilter: statusFilter,
 		})
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		allNodes = append(allNodes, resp.Nodes...)
+		if err := fn(resp.Nodes); err != nil {
+			return err
+		}
 
 		if resp.NextPageToken == "" {
-			break
+			return nil
 		}
 		pageToken = resp.NextPageToken
 	}
+}
 
-	return allNodes, nil
+func (c *Client) GetDrift(ctx context.Context) ([]*nodev1.Node, error) {
+	resp, err := c.pick().GetDrift(ctx, &nodev1.GetDriftRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Nodes, nil
 }
 
 func (c *Client) WatchEvents(ctx context.Context) (nodev1.NodeService_WatchEventsClient, error) {
+	return c.WatchEventsFrom(ctx, "")
+}
+
+// WatchEventsWithSnapshot streams the current node set as CREATED events
+// marked snapshot, a snapshot_complete marker, then live events, so the caller
+// needs no separate ListNodes
+func (c *Client) WatchEventsWithSnapshot(ctx context.Context) (nodev1.NodeService_WatchEventsClient, error) {
+	stream, err := c.pick().WatchEvents(ctx, &nodev1.WatchEventsRequest{IncludeSnapshot: true})
+	if err != nil {
+		logging.Error("WatchEvents failed: %v", err)
+		return nil, err
+	}
+	return stream, nil
+}
+
+// WatchEventsRecent streams up to n of the newest events already in the
+// event log, then live events, so a new watcher sees what just happened
+func (c *Client) WatchEventsRecent(ctx context.Context, n int) (nodev1.NodeService_WatchEventsClient, error) {
+	stream, err := c.pick().WatchEvents(ctx, &nodev1.WatchEventsRequest{RecentEvents: int32(n)})
+	if err != nil {
+		logging.Error("WatchEvents failed: %v", err)
+		return nil, err
+	}
+	return stream, nil
+}
+
+// WatchEventsFrom streams events after fromEventID, the event_id of an event
+// received earlier, so a reconnecting caller misses nothing
+func (c *Client) WatchEventsFrom(ctx context.Context, fromEventID string) (nodev1.NodeService_WatchEventsClient, error) {
 	logging.Debug("Calling WatchEvents on gRPC client...")
-	stream, err := c.client.WatchEvents(ctx, &nodev1.WatchEventsRequest{})
+	stream, err := c.pick().WatchEvents(ctx, &nodev1.WatchEventsRequest{FromEventId: fromEventID})
 	if err != nil {
 		logging.Error("WatchEvents failed: %v", err)
 		return nil, err
@@ -0,0 +1,545 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/melkior/nodestatus/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// peerRecorder counts GetNode calls per client connection, identified by the
+// remote address the server sees
+type peerRecorder struct {
+	nodev1.UnimplementedNodeServiceServer
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (p *peerRecorder) GetNode(ctx context.Context, req *nodev1.GetNodeRequest) (*nodev1.GetNodeResponse, error) {
+	pr, _ := peer.FromContext(ctx)
+	p.mu.Lock()
+	p.calls[pr.Addr.String()]++
+	p.mu.Unlock()
+	return &nodev1.GetNodeResponse{Node: &nodev1.Node{Id: req.Id}}, nil
+}
+
+func startRecorder(t *testing.T) (string, *peerRecorder) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	recorder := &peerRecorder{calls: make(map[string]int)}
+	srv := grpc.NewServer()
+	nodev1.RegisterNodeServiceServer(srv, recorder)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String(), recorder
+}
+
+func TestPooledClientDistributesCalls(t *testing.T) {
+	addr, recorder := startRecorder(t)
+
+	client, err := NewPooledClient(addr, "", 4)
+	require.NoError(t, err)
+	defer client.Close()
+	assert.Equal(t, 4, client.PoolSize())
+
+	for i := 0; i < 40; i++ {
+		_, err := client.GetNode(context.Background(), "node")
+		require.NoError(t, err)
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	require.Len(t, recorder.calls, 4, "expected calls on every pooled connection")
+	for addr, n := range recorder.calls {
+		assert.Equal(t, 10, n, "connection %s", addr)
+	}
+}
+
+func TestNewClientUsesSingleConnection(t *testing.T) {
+	addr, recorder := startRecorder(t)
+
+	client, err := NewClient(addr, "")
+	require.NoError(t, err)
+	defer client.Close()
+	assert.Equal(t, 1, client.PoolSize())
+
+	for i := 0; i < 5; i++ {
+		_, err := client.GetNode(context.Background(), "node")
+		require.NoError(t, err)
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	assert.Len(t, recorder.calls, 1)
+}
+
+func TestNewPooledClientClampsSize(t *testing.T) {
+	client, err := NewPooledClient("localhost:0", "", 0)
+	require.NoError(t, err)
+	defer client.Close()
+	assert.Equal(t, 1, client.PoolSize())
+}
+
+func TestWaitForReady(t *testing.T) {
+	addr, _ := startRecorder(t)
+
+	client, err := NewPooledClient(addr, "", 2)
+	require.NoError(t, err)
+	defer client.Close()
+	assert.NotEqual(t, connectivity.Ready, client.State(), "connections are lazy")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, client.WaitForReady(ctx))
+	assert.Equal(t, connectivity.Ready, client.State())
+}
+
+func TestWaitForReadyTimesOutWithoutServer(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+	lis.Close()
+
+	client, err := NewClient(addr, "")
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err = client.WaitForReady(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotEqual(t, connectivity.Ready, client.State())
+}
+
+func TestWithWaitForReadySurvivesLateServer(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+	lis.Close()
+
+	client, err := NewClient(addr, "", WithWaitForReady(), WithoutRetry())
+	require.NoError(t, err)
+	defer client.Close()
+
+	// The backend comes up after the call was made
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			return
+		}
+		srv := grpc.NewServer()
+		nodev1.RegisterNodeServiceServer(srv, &peerRecorder{calls: make(map[string]int)})
+		go srv.Serve(lis)
+		t.Cleanup(srv.Stop)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	node, err := client.GetNode(ctx, "late")
+	require.NoError(t, err)
+	assert.Equal(t, "late", node.Id)
+}
+
+// hangingServer never answers GetNode until the caller gives up
+type hangingServer struct {
+	nodev1.UnimplementedNodeServiceServer
+}
+
+func (hangingServer) GetNode(ctx context.Context, req *nodev1.GetNodeRequest) (*nodev1.GetNodeResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestCallTimeoutBoundsHungCalls(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	nodev1.RegisterNodeServiceServer(srv, hangingServer{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	client, err := NewClient(lis.Addr().String(), "", WithCallTimeout(100*time.Millisecond))
+	require.NoError(t, err)
+	defer client.Close()
+
+	start := time.Now()
+	_, err = client.GetNode(context.Background(), "n1")
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+// pagedLister serves ListNodes in pages of req.PageSize over a fixed node set
+type pagedLister struct {
+	nodev1.UnimplementedNodeServiceServer
+	nodes []*nodev1.Node
+	fail  int // Page index that fails with Unavailable, -1 for none
+}
+
+func (p *pagedLister) ListNodes(ctx context.Context, req *nodev1.ListNodesRequest) (*nodev1.ListNodesResponse, error) {
+	start := 0
+	if req.PageToken != "" {
+		fmt.Sscan(req.PageToken, &start)
+	}
+	if start/int(req.PageSize) == p.fail {
+		return nil, status.Error(codes.Unavailable, "page unavailable")
+	}
+
+	var total int64
+	if req.IncludeTotal {
+		total = int64(len(p.nodes))
+	}
+	end := start + int(req.PageSize)
+	if end >= len(p.nodes) {
+		return &nodev1.ListNodesResponse{Nodes: p.nodes[start:], TotalCount: total}, nil
+	}
+	return &nodev1.ListNodesResponse{Nodes: p.nodes[start:end], NextPageToken: fmt.Sprint(end), TotalCount: total}, nil
+}
+
+func startLister(t *testing.T, lister *pagedLister) *Client {
+	t.Helper()
+	return startClient(t, lister)
+}
+
+// startClient serves impl and returns a client of it without retries
+func startClient(t *testing.T, impl nodev1.NodeServiceServer) *Client {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := grpc.NewServer()
+	nodev1.RegisterNodeServiceServer(srv, impl)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	client, err := NewClient(lis.Addr().String(), "", WithoutRetry())
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestListNodesStream(t *testing.T) {
+	lister := &pagedLister{fail: -1}
+	for i := 0; i < 2*listPageSize+10; i++ {
+		lister.nodes = append(lister.nodes, &nodev1.Node{Id: fmt.Sprintf("node-%d", i)})
+	}
+	client := startLister(t, lister)
+
+	nodes, errc := client.ListNodesStream(context.Background(), 0, 0)
+	var ids []string
+	for node := range nodes {
+		ids = append(ids, node.Id)
+	}
+	require.NoError(t, <-errc)
+	require.Len(t, ids, len(lister.nodes))
+	assert.Equal(t, "node-0", ids[0])
+	assert.Equal(t, fmt.Sprintf("node-%d", len(lister.nodes)-1), ids[len(ids)-1])
+
+	// The eager form returns the same set
+	all, err := client.ListNodes(context.Background(), 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, all, len(lister.nodes))
+}
+
+func TestListNodesPage(t *testing.T) {
+	lister := &pagedLister{fail: -1}
+	for i := 0; i < listPageSize+10; i++ {
+		lister.nodes = append(lister.nodes, &nodev1.Node{Id: fmt.Sprintf("node-%d", i)})
+	}
+	client := startLister(t, lister)
+	ctx := context.Background()
+
+	nodes, next, total, err := client.ListNodesPage(ctx, 0, 0, "", true)
+	require.NoError(t, err)
+	assert.Len(t, nodes, listPageSize)
+	assert.NotEmpty(t, next)
+	assert.Equal(t, int64(len(lister.nodes)), total)
+
+	nodes, next, total, err = client.ListNodesPage(ctx, 0, 0, next, false)
+	require.NoError(t, err)
+	assert.Len(t, nodes, 10)
+	assert.Empty(t, next)
+	assert.Zero(t, total)
+}
+
+func TestListNodesStreamReportsError(t *testing.T) {
+	lister := &pagedLister{fail: 1}
+	for i := 0; i < 2*listPageSize; i++ {
+		lister.nodes = append(lister.nodes, &nodev1.Node{Id: fmt.Sprintf("node-%d", i)})
+	}
+	client := startLister(t, lister)
+
+	nodes, errc := client.ListNodesStream(context.Background(), 0, 0)
+	count := 0
+	for range nodes {
+		count++
+	}
+	assert.Equal(t, listPageSize, count, "nodes from pages before the failure are delivered")
+	assert.Equal(t, codes.Unavailable, status.Code(<-errc))
+}
+
+func TestListNodesStreamStopsOnCancel(t *testing.T) {
+	lister := &pagedLister{fail: -1}
+	for i := 0; i < 3*listPageSize; i++ {
+		lister.nodes = append(lister.nodes, &nodev1.Node{Id: fmt.Sprintf("node-%d", i)})
+	}
+	client := startLister(t, lister)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	nodes, errc := client.ListNodesStream(ctx, 0, 0)
+	<-nodes
+	cancel()
+
+	for range nodes {
+	}
+	// Either the send or the next page RPC sees the cancellation
+	err := <-errc
+	assert.True(t, errors.Is(err, context.Canceled) || status.Code(err) == codes.Canceled, "got %v", err)
+}
+
+// updateRecorder keeps the last UpdateNode request
+type updateRecorder struct {
+	nodev1.UnimplementedNodeServiceServer
+
+	mu   sync.Mutex
+	last *nodev1.UpdateNodeRequest
+}
+
+func (u *updateRecorder) UpdateNode(ctx context.Context, req *nodev1.UpdateNodeRequest) (*nodev1.UpdateNodeResponse, error) {
+	u.mu.Lock()
+	u.last = req
+	u.mu.Unlock()
+	return &nodev1.UpdateNodeResponse{Node: req.Node}, nil
+}
+
+func TestUpdateNodeFieldsSendsMask(t *testing.T) {
+	recorder := &updateRecorder{}
+	client := startClient(t, recorder)
+	ctx := context.Background()
+
+	// A partial node is enough when only its masked fields are sent
+	_, err := client.UpdateNodeFields(ctx, &nodev1.Node{Id: "a", MetadataJson: `{"cpu":2}`}, "metadata_json")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"metadata_json"}, recorder.last.GetUpdateMask().GetPaths())
+
+	paths := []string{"status", "labels.env"}
+	_, err = client.UpdateNodeFields(ctx, &nodev1.Node{Id: "a"}, paths...)
+	require.NoError(t, err)
+	assert.Equal(t, paths, recorder.last.GetUpdateMask().GetPaths())
+
+	// UpdateNode still replaces the whole node
+	_, err = client.UpdateNode(ctx, &nodev1.Node{Id: "a", Name: "full"})
+	require.NoError(t, err)
+	assert.Nil(t, recorder.last.UpdateMask)
+}
+
+// metadataRecorder keeps the incoming metadata of the last call
+type metadataRecorder struct {
+	nodev1.UnimplementedNodeServiceServer
+
+	mu   sync.Mutex
+	last metadata.MD
+}
+
+func (m *metadataRecorder) record(ctx context.Context) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	m.mu.Lock()
+	m.last = md
+	m.mu.Unlock()
+}
+
+func (m *metadataRecorder) GetNode(ctx context.Context, req *nodev1.GetNodeRequest) (*nodev1.GetNodeResponse, error) {
+	m.record(ctx)
+	return &nodev1.GetNodeResponse{Node: &nodev1.Node{Id: req.Id}}, nil
+}
+
+func (m *metadataRecorder) DeleteNode(ctx context.Context, req *nodev1.DeleteNodeRequest) (*nodev1.DeleteNodeResponse, error) {
+	m.record(ctx)
+	return &nodev1.DeleteNodeResponse{}, nil
+}
+
+type requestIDKey struct{}
+
+func TestClientMetadataAndDialOptions(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	recorder := &metadataRecorder{}
+	srv := grpc.NewServer()
+	nodev1.RegisterNodeServiceServer(srv, recorder)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	var intercepted atomic.Int32
+	client, err := NewClient(lis.Addr().String(), "secret",
+		WithAuthHeader("x-api-key"),
+		WithMetadata("x-tenant", "acme"),
+		WithMetadataFunc(func(ctx context.Context) metadata.MD {
+			if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+				return metadata.Pairs("x-request-id", id)
+			}
+			return nil
+		}),
+		WithDialOptions(grpc.WithChainUnaryInterceptor(
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				intercepted.Add(1)
+				return invoker(ctx, method, req, reply, cc, opts...)
+			})),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-1")
+	require.NoError(t, client.DeleteNode(ctx, "a"))
+	assert.Equal(t, []string{"Bearer secret"}, recorder.last.Get("x-api-key"))
+	assert.Empty(t, recorder.last.Get("authorization"))
+	assert.Equal(t, []string{"acme"}, recorder.last.Get("x-tenant"))
+	assert.Equal(t, []string{"req-1"}, recorder.last.Get("x-request-id"))
+
+	// Reads carry the extra metadata and the token too
+	_, err = client.GetNode(context.Background(), "a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme"}, recorder.last.Get("x-tenant"))
+	assert.Empty(t, recorder.last.Get("x-request-id"))
+	assert.Equal(t, []string{"Bearer secret"}, recorder.last.Get("x-api-key"))
+
+	assert.EqualValues(t, 2, intercepted.Load())
+}
+
+// authServer answers reads, deletes and status updates behind the server's
+// auth interceptors
+type authServer struct {
+	nodev1.UnimplementedNodeServiceServer
+}
+
+func (authServer) GetNode(ctx context.Context, req *nodev1.GetNodeRequest) (*nodev1.GetNodeResponse, error) {
+	return &nodev1.GetNodeResponse{Node: &nodev1.Node{Id: req.Id}}, nil
+}
+
+func (authServer) ListNodes(ctx context.Context, req *nodev1.ListNodesRequest) (*nodev1.ListNodesResponse, error) {
+	return &nodev1.ListNodesResponse{Nodes: []*nodev1.Node{{Id: "a"}}}, nil
+}
+
+func (authServer) DeleteNode(ctx context.Context, req *nodev1.DeleteNodeRequest) (*nodev1.DeleteNodeResponse, error) {
+	return &nodev1.DeleteNodeResponse{}, nil
+}
+
+// UpdateStatus knows no node, like the real service for the preflight ID
+func (authServer) UpdateStatus(ctx context.Context, req *nodev1.UpdateStatusRequest) (*nodev1.UpdateStatusResponse, error) {
+	return nil, status.Error(codes.NotFound, "node not found")
+}
+
+func (authServer) GetServerStats(ctx context.Context, req *nodev1.GetServerStatsRequest) (*nodev1.GetServerStatsResponse, error) {
+	return &nodev1.GetServerStatsResponse{}, nil
+}
+
+func (authServer) WatchEvents(req *nodev1.WatchEventsRequest, stream nodev1.NodeService_WatchEventsServer) error {
+	return stream.Send(&nodev1.WatchEventsResponse{EventId: "1"})
+}
+
+// startAuthServer serves authServer with reads requiring a token and returns
+// a constructor for clients of it
+func startAuthServer(t *testing.T) func(token string, opts ...Option) *Client {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	opts := auth.Options{RequireAuthForReads: true, ReaderToken: "reader"}
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(auth.UnaryAuthInterceptorWithOptions("admin", opts)),
+		grpc.StreamInterceptor(auth.StreamAuthInterceptorWithOptions("admin", opts)),
+	)
+	nodev1.RegisterNodeServiceServer(srv, authServer{})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	return func(token string, opts ...Option) *Client {
+		client, err := NewClient(lis.Addr().String(), token, append(opts, WithoutRetry())...)
+		require.NoError(t, err)
+		t.Cleanup(func() { client.Close() })
+		return client
+	}
+}
+
+func TestClientAuthenticatesReads(t *testing.T) {
+	newClient := startAuthServer(t)
+	ctx := context.Background()
+
+	reads := func(t *testing.T, client *Client) {
+		_, err := client.GetNode(ctx, "a")
+		require.NoError(t, err)
+		nodes, err := client.ListNodes(ctx, 0, 0)
+		require.NoError(t, err)
+		assert.Len(t, nodes, 1)
+		_, err = client.NodeService().ListNodes(ctx, &nodev1.ListNodesRequest{})
+		require.NoError(t, err)
+
+		stream, err := client.WatchEvents(ctx)
+		require.NoError(t, err)
+		event, err := stream.Recv()
+		require.NoError(t, err)
+		assert.Equal(t, "1", event.EventId)
+	}
+
+	t.Run("admin token", func(t *testing.T) {
+		client := newClient("admin")
+		reads(t, client)
+		require.NoError(t, client.DeleteNode(ctx, "a"))
+	})
+
+	t.Run("reader token", func(t *testing.T) {
+		client := newClient("admin", WithReaderToken("reader"))
+		reads(t, client)
+		// Mutations still send the admin token
+		require.NoError(t, client.DeleteNode(ctx, "a"))
+
+		readOnly := newClient("", WithReaderToken("reader"))
+		reads(t, readOnly)
+		assert.Equal(t, codes.PermissionDenied, status.Code(readOnly.DeleteNode(ctx, "a")))
+	})
+
+	t.Run("no token", func(t *testing.T) {
+		_, err := newClient("").GetNode(ctx, "a")
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
+
+func TestPreflight(t *testing.T) {
+	newClient := startAuthServer(t)
+	ctx := context.Background()
+
+	assert.NoError(t, newClient("admin").Preflight(ctx, true))
+	assert.NoError(t, newClient("reader").Preflight(ctx, false))
+
+	// The reader token passes the read check but not the write check
+	assert.ErrorIs(t, newClient("reader").Preflight(ctx, true), ErrUnauthorized)
+
+	// A failed read is reported in both modes
+	for _, write := range []bool{false, true} {
+		assert.ErrorIs(t, newClient("").Preflight(ctx, write), ErrUnauthorized, "write=%v", write)
+		assert.ErrorIs(t, newClient("wrong").Preflight(ctx, write), ErrUnauthorized, "write=%v", write)
+	}
+
+	// Any answer from the handler shows the token was accepted
+	client := startClient(t, &metadataRecorder{})
+	assert.NoError(t, client.Preflight(ctx, true))
+}
\ No newline at end of file
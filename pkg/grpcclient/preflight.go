@@ -0,0 +1,61 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrUnreachable is returned by Preflight when the backend does not answer
+	ErrUnreachable = errors.New("backend unreachable")
+	// ErrUnauthorized is returned by Preflight when the backend rejects the
+	// client's token
+	ErrUnauthorized = errors.New("backend rejected the token")
+)
+
+// Preflight checks that the backend answers and accepts the client's token
+// for reads and, with write, for mutating calls, so a misconfigured caller
+// fails once with a clear error instead of on every RPC. Reads are checked
+// with GetServerStats. Writes are checked with a status update of a node ID
+// made up for the check, which no node has: the auth interceptor runs before
+// the handler, so any answer but a rejected token means writes are allowed,
+// and the update itself finds nothing to change.
+func (c *Client) Preflight(ctx context.Context, write bool) error {
+	if _, err := c.GetServerStats(ctx); err != nil {
+		switch status.Code(err) {
+		case codes.Unauthenticated, codes.PermissionDenied:
+			return fmt.Errorf("%w: %v", ErrUnauthorized, err)
+		case codes.Unavailable, codes.DeadlineExceeded:
+			return fmt.Errorf("%w: %v", ErrUnreachable, err)
+		default:
+			// Any other answer, e.g. Unimplemented from an older server,
+			// still shows the backend is up
+		}
+	}
+
+	if !write {
+		return nil
+	}
+
+	_, err := c.UpdateStatus(ctx, preflightNodeID(), nodev1.NodeStatus_UNKNOWN)
+	switch status.Code(err) {
+	case codes.Unauthenticated, codes.PermissionDenied:
+		return fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return fmt.Errorf("%w: %v", ErrUnreachable, err)
+	default:
+		// NotFound from the handler, which only runs for an accepted token
+		return nil
+	}
+}
+
+// preflightNodeID returns a node ID no node has, for the write check
+func preflightNodeID() string {
+	return "preflight-" + uuid.NewString()
+}
\ No newline at end of file
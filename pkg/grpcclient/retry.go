@@ -0,0 +1,101 @@
+package grpcclient
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/melkior/nodestatus/internal/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy controls how unary calls are retried on transient errors
+type RetryPolicy struct {
+	MaxAttempts  int // Including the first call; 1 disables retries
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+	Jitter       float64 // Fraction of the delay, e.g. 0.2 for ±20%
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:  4,
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     2 * time.Second,
+		Multiplier:   2.0,
+		Jitter:       0.2,
+	}
+}
+
+// mutatingMethods lists the calls that change server state
+var mutatingMethods = map[string]bool{
+	"/node.v1.NodeService/CreateNode":        true,
+	"/node.v1.NodeService/UpdateNode":        true,
+	"/node.v1.NodeService/UpdateStatus":      true,
+	"/node.v1.NodeService/BatchUpdateStatus": true,
+	"/node.v1.NodeService/DeleteNode":        true,
+}
+
+// IsRetryable reports whether err is a transient gRPC error worth retrying
+func IsRetryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	default:
+		return false
+	}
+}
+
+// retryableFor is IsRetryable, except that a mutation that hit its deadline
+// may already have been applied, so it isn't sent again
+func retryableFor(method string, err error) bool {
+	if mutatingMethods[method] && status.Code(err) == codes.DeadlineExceeded {
+		return false
+	}
+	return IsRetryable(err)
+}
+
+// unaryRetryInterceptor retries calls that fail with a retryable code, with
+// exponential backoff. It gives up early rather than sleep past the context
+// deadline.
+func unaryRetryInterceptor(policy RetryPolicy) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		delay := policy.InitialDelay
+		for attempt := 1; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || attempt >= policy.MaxAttempts || !retryableFor(method, err) {
+				return err
+			}
+
+			wait := jitter(delay, policy.Jitter)
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+				return err
+			}
+			logging.Debug("Retrying %s after %v (attempt %d/%d): %v", method, wait, attempt+1, policy.MaxAttempts, err)
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+
+			delay = time.Duration(float64(delay) * policy.Multiplier)
+			if delay > policy.MaxDelay {
+				delay = policy.MaxDelay
+			}
+		}
+	}
+}
+
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return d
+	}
+	spread := float64(d) * fraction
+	return time.Duration(float64(d) - spread + rand.Float64()*2*spread)
+}
\ No newline at end of file
@@ -0,0 +1,126 @@
+package grpcclient
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	nodev1 "github.com/melkior/nodestatus/gen/go/api/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// flakyServer fails its first failures calls with code
+type flakyServer struct {
+	nodev1.UnimplementedNodeServiceServer
+
+	code     codes.Code
+	failures int32
+	calls    atomic.Int32
+}
+
+func (f *flakyServer) fail() error {
+	if f.calls.Add(1) <= f.failures {
+		return status.Error(f.code, "transient")
+	}
+	return nil
+}
+
+func (f *flakyServer) GetNode(ctx context.Context, req *nodev1.GetNodeRequest) (*nodev1.GetNodeResponse, error) {
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+	return &nodev1.GetNodeResponse{Node: &nodev1.Node{Id: req.Id}}, nil
+}
+
+func (f *flakyServer) DeleteNode(ctx context.Context, req *nodev1.DeleteNodeRequest) (*nodev1.DeleteNodeResponse, error) {
+	if err := f.fail(); err != nil {
+		return nil, err
+	}
+	return &nodev1.DeleteNodeResponse{}, nil
+}
+
+func startFlaky(t *testing.T, code codes.Code, failures int32) (string, *flakyServer) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	flaky := &flakyServer{code: code, failures: failures}
+	srv := grpc.NewServer()
+	nodev1.RegisterNodeServiceServer(srv, flaky)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String(), flaky
+}
+
+func fastRetry(attempts int) RetryPolicy {
+	return RetryPolicy{MaxAttempts: attempts, InitialDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, Multiplier: 2}
+}
+
+func TestRetryRecoversFromTransientErrors(t *testing.T) {
+	addr, flaky := startFlaky(t, codes.Unavailable, 2)
+
+	client, err := NewClient(addr, "", WithRetryPolicy(fastRetry(3)))
+	require.NoError(t, err)
+	defer client.Close()
+
+	node, err := client.GetNode(context.Background(), "n1")
+	require.NoError(t, err)
+	assert.Equal(t, "n1", node.Id)
+	assert.Equal(t, int32(3), flaky.calls.Load())
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	addr, flaky := startFlaky(t, codes.Unavailable, 5)
+
+	client, err := NewClient(addr, "", WithRetryPolicy(fastRetry(2)))
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.GetNode(context.Background(), "n1")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int32(2), flaky.calls.Load())
+}
+
+func TestRetrySkipsPermanentErrors(t *testing.T) {
+	addr, flaky := startFlaky(t, codes.NotFound, 1)
+
+	client, err := NewClient(addr, "", WithRetryPolicy(fastRetry(3)))
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.GetNode(context.Background(), "n1")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, int32(1), flaky.calls.Load())
+}
+
+func TestRetryDoesNotResendTimedOutMutations(t *testing.T) {
+	addr, flaky := startFlaky(t, codes.DeadlineExceeded, 1)
+
+	client, err := NewClient(addr, "", WithRetryPolicy(fastRetry(3)))
+	require.NoError(t, err)
+	defer client.Close()
+
+	err = client.DeleteNode(context.Background(), "n1")
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.Equal(t, int32(1), flaky.calls.Load())
+}
+
+func TestWithoutRetry(t *testing.T) {
+	addr, flaky := startFlaky(t, codes.Unavailable, 1)
+
+	client, err := NewClient(addr, "", WithoutRetry())
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.GetNode(context.Background(), "n1")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, int32(1), flaky.calls.Load())
+}
\ No newline at end of file